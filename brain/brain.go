@@ -8,6 +8,7 @@ import (
 	"strings"
 	"unicode"
 
+	"unitymind/offline"
 	"unitymind/search"
 )
 
@@ -33,7 +34,7 @@ func Synthesize(query string, results []search.Result, history []HistoryEntry) s
 
 	// ── Step 1: Try built-in knowledge base first ──────────────────────────
 	if answer := builtinAnswer(q, query); answer != "" {
-		return answer
+		return FillFromQuery(answer, query)
 	}
 
 	// ── Step 2: Synthesize from doc content ───────────────────────────────
@@ -53,6 +54,20 @@ func Synthesize(query string, results []search.Result, history []HistoryEntry) s
 func builtinAnswer(q, raw string) string {
 	switch {
 
+	// ── PLATFORM PACKS ───────────────────────────────────────────────────────
+	// Checked first: platform constraints override the generic answers below.
+	case offline.DetectPlatform(q) == "webgl":
+		return webglAnswer(q)
+
+	// ── VERSION CONTROL ──────────────────────────────────────────────────────
+	case isVersionControlQuery(q):
+		return vcsAnswer(q)
+
+	// ── INPUT SYSTEM: REBINDING / SCHEMES / LOCAL MULTIPLAYER ─────────────────
+	// Before the generic INPUT case, which would otherwise catch "input"
+	case isInputSystemTemplateQuery(q):
+		return inputSystemAnswer(q)
+
 	// ── AUDIO ────────────────────────────────────────────────────────────────
 	case matchAny(q, "play sound", "sound effect", "audio", "audiosource", "play music", "sfx", "play clip", "music"):
 		if isCodeRequest(q) {
@@ -979,6 +994,16 @@ func matchAny(q string, patterns ...string) bool {
 	return false
 }
 
+// hasWord reports whether w appears in q as a whole word (so "git" doesn't match "digit")
+func hasWord(q, w string) bool {
+	for _, f := range strings.FieldsFunc(q, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if f == w {
+			return true
+		}
+	}
+	return false
+}
+
 func isCodeRequest(q string) bool {
 	return matchAny(q, "script", "code", "write", "example", "how do i", "how to", "show", "give me", "make", "create")
 }
@@ -1058,26 +1083,45 @@ type docContext struct {
 	MainContent string
 	KeyPoints   []string
 	Methods     []string
+	Code        string // the first code sample in it, fenced
 }
 
+// maxQuotedCode caps the length of a code sample quoted from the docs.
+const maxQuotedCode = 1500
+
+// maxChunksPerPage caps how many chunks of one page feed an answer,
+// so a single long page can't be quoted over and over.
+const maxChunksPerPage = 2
+
 func buildContext(results []search.Result) docContext {
 	ctx := docContext{}
 	allText := ""
-	for i, r := range results {
-		if i >= 3 { break }
+	used := 0
+	perPage := map[string]int{}
+	for _, r := range results {
+		if used >= 3 { break }
+		page := search.PageURL(r.URL)
+		if perPage[page] >= maxChunksPerPage { continue }
+		perPage[page]++
+		used++
 		allText += r.Excerpt + "\n\n"
 	}
 	ctx.MainContent = allText
 	ctx.KeyPoints = extractKeyPoints(allText)
 	ctx.Methods = extractMethods(allText)
+	ctx.Code = extractCodeBlock(allText)
 	return ctx
 }
 
 func extractKeyPoints(text string) []string {
 	var points []string
 	lines := strings.Split(text, "\n")
+	inCode := false
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		// Excerpts are Markdown: a point is prose, not code, a table row or a heading
+		if strings.HasPrefix(line, "```") { inCode = !inCode; continue }
+		if inCode || strings.HasPrefix(line, "|") || strings.HasPrefix(line, "#") { continue }
 		if len(line) > 50 && len(line) < 250 && strings.Contains(line, ".") && !strings.Contains(line, "http") {
 			points = append(points, line)
 			if len(points) >= 5 { break }
@@ -1086,6 +1130,18 @@ func extractKeyPoints(text string) []string {
 	return points
 }
 
+// extractCodeBlock returns the first fenced code block in text, fences
+// included, or "" if there's none short enough to quote.
+func extractCodeBlock(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 { return "" }
+	end := strings.Index(text[start+3:], "\n```")
+	if end < 0 { return "" }
+	block := text[start : start+3+end+4]
+	if len(block) > maxQuotedCode { return "" }
+	return block
+}
+
 func extractMethods(text string) []string {
 	var methods []string
 	seen := map[string]bool{}
@@ -1133,6 +1189,7 @@ func synthesizeFromDocs(intent Intent, q, topic string, ctx docContext, results
 	if written == 0 {
 		// Last resort: take a clean slice of the raw content
 		content := ctx.MainContent
+		if i := strings.Index(content, "```"); i > 0 { content = content[:i] } // prose only, the code follows
 		if len(content) > 600 { content = content[:600] }
 		sb.WriteString(cleanSentence(content))
 		sb.WriteString("\n\n")
@@ -1144,6 +1201,12 @@ func synthesizeFromDocs(intent Intent, q, topic string, ctx docContext, results
 		sb.WriteString("`\n\n")
 	}
 
+	if ctx.Code != "" && (intent == IntentHowTo || intent == IntentWriteCode) {
+		sb.WriteString("**From the docs:**\n\n")
+		sb.WriteString(ctx.Code)
+		sb.WriteString("\n\n")
+	}
+
 	if len(results) > 0 {
 		sb.WriteString("Check the linked docs below for the full details.")
 	}
@@ -0,0 +1,131 @@
+package brain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── API listings ──────────────────────────────────────────────────────────────
+// "List all AudioSource methods" or "what MonoBehaviour messages exist for 2D
+// physics" want an inventory, not the five most relevant pages. They're
+// answered from the API catalog (search.Engine.Members) as a table.
+
+// CatalogQuery is a request to list a class's members.
+type CatalogQuery struct {
+	Class  string
+	Kind   string   // method, property, message, constructor or "" for all
+	Filter []string // words members must mention, e.g. "2d", "physics"
+}
+
+var (
+	// "list all AudioSource methods", "what MonoBehaviour messages exist"
+	reCatalogBefore = regexp.MustCompile(`(?i)\b(?:list|show|what|which|all)\b.*?\b([A-Z][A-Za-z0-9]*|[a-z]+[A-Z][A-Za-z0-9]*)\s+(methods|functions|properties|fields|variables|messages|callbacks|events|members|constructors)\b`)
+	// "methods of AudioSource", "list the properties on Rigidbody2D"
+	reCatalogAfter = regexp.MustCompile(`(?i)\b(methods|functions|properties|fields|variables|messages|callbacks|events|members|constructors)\s+(?:of|on|in|for)\s+(?:the\s+)?([A-Za-z][A-Za-z0-9]*)(?:\s+class)?\b`)
+	// "... for 2D physics", "... related to audio"
+	reCatalogFilter = regexp.MustCompile(`(?i)\b(?:for|about|related to|involving|with)\s+(.+)$`)
+)
+
+var catalogKinds = map[string]string{
+	"methods": "method", "functions": "method",
+	"properties": "property", "fields": "property", "variables": "property",
+	"messages": "message", "callbacks": "message", "events": "message",
+	"constructors": "constructor", "members": "",
+}
+
+// ParseCatalogQuery recognises a request to list a class's members.
+func ParseCatalogQuery(q string) (CatalogQuery, bool) {
+	q = strings.TrimRight(strings.TrimSpace(q), "?.!")
+	var cq CatalogQuery
+	rest := ""
+	if m := reCatalogBefore.FindStringSubmatchIndex(q); m != nil {
+		cq.Class, cq.Kind = q[m[2]:m[3]], catalogKinds[strings.ToLower(q[m[4]:m[5]])]
+		rest = q[m[1]:]
+	} else if m := reCatalogAfter.FindStringSubmatchIndex(q); m != nil {
+		cq.Kind, cq.Class = catalogKinds[strings.ToLower(q[m[2]:m[3]])], q[m[4]:m[5]]
+		rest = q[m[1]:]
+	} else {
+		return cq, false
+	}
+	if m := reCatalogFilter.FindStringSubmatch(rest); m != nil {
+		for _, w := range strings.Fields(strings.ToLower(m[1])) {
+			if !queryFiller[w] && w != "only" {
+				cq.Filter = append(cq.Filter, w)
+			}
+		}
+	}
+	return cq, true
+}
+
+// Select narrows a class's members to the kind and filter words asked for.
+// Members must mention every filter word in their name or summary; if none
+// does, mentioning any of them is enough.
+func (cq CatalogQuery) Select(members []search.Member) []search.Member {
+	var ofKind []search.Member
+	for _, m := range members {
+		if cq.Kind == "" || m.Kind == cq.Kind {
+			ofKind = append(ofKind, m)
+		}
+	}
+	if len(cq.Filter) == 0 {
+		return ofKind
+	}
+	var all, any []search.Member
+	for _, m := range ofKind {
+		text := strings.ToLower(m.Name + " " + m.Summary)
+		hits := 0
+		for _, w := range cq.Filter {
+			if strings.Contains(text, w) {
+				hits++
+			}
+		}
+		if hits == len(cq.Filter) {
+			all = append(all, m)
+		} else if hits > 0 {
+			any = append(any, m)
+		}
+	}
+	if len(all) > 0 {
+		return all
+	}
+	return any
+}
+
+// CatalogAnswer renders members as a Markdown table.
+func CatalogAnswer(cq CatalogQuery, members []search.Member) string {
+	class := cq.Class
+	if len(members) > 0 {
+		class = members[0].Class
+	}
+	what := "members"
+	switch cq.Kind {
+	case "":
+	case "property":
+		what = "properties"
+	default:
+		what = cq.Kind + "s"
+	}
+	title := fmt.Sprintf("**%s %s**", class, what)
+	if len(cq.Filter) > 0 {
+		title += " for *" + strings.Join(cq.Filter, " ") + "*"
+	}
+	if len(members) == 0 {
+		return title + ": none in the indexed Scripting Reference."
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s — %d in the Scripting Reference:\n\n", title, len(members))
+	sb.WriteString("| Name | Kind | Declaration | Description |\n|---|---|---|---|\n")
+	for _, m := range members {
+		sig := ""
+		if m.Signature != "" {
+			sig = "`" + strings.ReplaceAll(m.Signature, "|", `\|`) + "`"
+		}
+		fmt.Fprintf(&sb, "| [%s](%s) | %s | %s | %s |\n", m.Name, m.URL, m.Kind, sig,
+			strings.ReplaceAll(m.Summary, "|", `\|`))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
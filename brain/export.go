@@ -0,0 +1,160 @@
+package brain
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ── .unitypackage export ──────────────────────────────────────────────────────
+// Answers that build a whole system (an inventory, a save manager) spread it
+// over several scripts. Export packs every C# type in an answer into a
+// .unitypackage, so one double-click imports them all into a project.
+//
+// A .unitypackage is a gzipped tar with one folder per asset, named by GUID,
+// holding "asset" (the file), "asset.meta" and "pathname" (its project path).
+
+// DefaultExportFolder is where exported scripts land in the project.
+const DefaultExportFolder = "Assets/UnityMind"
+
+// ScriptFile is one C# script pulled out of an answer.
+type ScriptFile struct {
+	Name string `json:"name"` // file name, e.g. "PlayerController.cs"
+	Code string `json:"code"`
+}
+
+var (
+	reCodeFence = regexp.MustCompile("(?s)```([a-zA-Z#]*)[^\n]*\n(.*?)```")
+	reTypeDecl  = regexp.MustCompile(`(?m)^\s*(?:(?:public|internal|abstract|sealed|static|partial)\s+)*(?:class|struct|interface|enum)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	reBadFolder = regexp.MustCompile(`[^A-Za-z0-9 _\-/]`)
+)
+
+// ScriptFiles returns the C# code blocks of answer that declare a type, one
+// file per block, named after the first type declared. Snippets without a
+// type wouldn't compile on their own and are skipped. When several blocks
+// declare the same type the last one wins, as later blocks refine earlier ones.
+func ScriptFiles(answer string) []ScriptFile {
+	var files []ScriptFile
+	index := map[string]int{}
+	for _, m := range reCodeFence.FindAllStringSubmatch(answer, -1) {
+		switch strings.ToLower(m[1]) {
+		case "", "csharp", "cs", "c#":
+		default:
+			continue
+		}
+		decl := reTypeDecl.FindStringSubmatch(m[2])
+		if decl == nil {
+			continue
+		}
+		f := ScriptFile{Name: decl[1] + ".cs", Code: strings.TrimSpace(m[2]) + "\n"}
+		if i, ok := index[f.Name]; ok {
+			files[i] = f
+			continue
+		}
+		index[f.Name] = len(files)
+		files = append(files, f)
+	}
+	return files
+}
+
+// ExportFolder cleans a user-supplied project folder into "Assets/...".
+// An empty or unusable folder gives DefaultExportFolder.
+func ExportFolder(folder string) string {
+	folder = reBadFolder.ReplaceAllString(strings.ReplaceAll(folder, "\\", "/"), "")
+	var parts []string
+	for _, p := range strings.Split(folder, "/") {
+		if p = strings.TrimSpace(p); p != "" && p != "Assets" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return DefaultExportFolder
+	}
+	return "Assets/" + strings.Join(parts, "/")
+}
+
+// WriteUnityPackage writes files as a .unitypackage placing them in folder
+// (see ExportFolder). GUIDs derive from each asset's path, so exporting the
+// same scripts again updates them on import instead of duplicating them.
+func WriteUnityPackage(w io.Writer, files []ScriptFile, folder string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no C# scripts to export")
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	add := func(name string, data string) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := io.WriteString(tw, data)
+		return err
+	}
+
+	// Every folder on the way down needs its own folder asset
+	path := ""
+	for _, part := range strings.Split(folder, "/")[1:] {
+		path += "/" + part
+		full := "Assets" + path
+		guid := assetGUID(full)
+		if err := add(guid+"/pathname", full); err != nil {
+			return err
+		}
+		if err := add(guid+"/asset.meta", fmt.Sprintf(folderMeta, guid)); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		full := folder + "/" + f.Name
+		guid := assetGUID(full)
+		if err := add(guid+"/asset", f.Code); err != nil {
+			return err
+		}
+		if err := add(guid+"/pathname", full); err != nil {
+			return err
+		}
+		if err := add(guid+"/asset.meta", fmt.Sprintf(scriptMeta, guid)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// assetGUID is a stable 32-hex-digit GUID for a project path.
+func assetGUID(path string) string {
+	sum := md5.Sum([]byte("unitymind:" + path))
+	return hex.EncodeToString(sum[:])
+}
+
+const folderMeta = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const scriptMeta = `fileFormatVersion: 2
+guid: %s
+MonoImporter:
+  externalObjects: {}
+  serializedVersion: 2
+  defaultReferences: []
+  executionOrder: 0
+  icon: {instanceID: 0}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
@@ -0,0 +1,162 @@
+package brain
+
+// ── Input System Templates ────────────────────────────────────────────────────
+// Runtime rebinding, control schemes and local multiplayer all live in the
+// (new) Input System package, not the legacy Input class, so they get their
+// own templates instead of the generic input answer.
+
+func isInputSystemTemplateQuery(q string) bool {
+	return matchAny(q, "rebind", "remap", "key binding", "keybinding", "change controls",
+		"performinteractiverebinding", "control scheme", "controlscheme", "gamepad and keyboard",
+		"keyboard and gamepad", "playerinputmanager", "local multiplayer", "split screen",
+		"splitscreen", "couch co-op", "couch coop", "multiple players", "2 players", "two players")
+}
+
+func inputSystemAnswer(q string) string {
+	switch {
+
+	case matchAny(q, "playerinputmanager", "local multiplayer", "split screen", "splitscreen", "couch co-op", "couch coop", "multiple players", "2 players", "two players"):
+		return `**Local multiplayer with PlayerInputManager** (Input System package):
+
+**Setup:**
+1. Create a player prefab with a **PlayerInput** component (assign your Input Actions asset)
+2. Add an empty GameObject with **PlayerInputManager**
+3. Set *Player Prefab* to your player, *Join Behavior* to **Join Players When Button Is Pressed**
+4. Each new device that presses a button spawns its own player, paired to that device
+
+` + "```csharp" + `
+using UnityEngine;
+using UnityEngine.InputSystem;
+
+public class PlayerJoinHandler : MonoBehaviour
+{
+    public Transform[] spawnPoints;
+
+    void OnEnable()
+    {
+        PlayerInputManager.instance.onPlayerJoined += OnPlayerJoined;
+        PlayerInputManager.instance.onPlayerLeft += OnPlayerLeft;
+    }
+
+    void OnDisable()
+    {
+        PlayerInputManager.instance.onPlayerJoined -= OnPlayerJoined;
+        PlayerInputManager.instance.onPlayerLeft -= OnPlayerLeft;
+    }
+
+    void OnPlayerJoined(PlayerInput player)
+    {
+        int i = player.playerIndex;
+        player.transform.position = spawnPoints[i % spawnPoints.Length].position;
+        Debug.Log($"Player {i + 1} joined with {player.currentControlScheme}");
+    }
+
+    void OnPlayerLeft(PlayerInput player)
+    {
+        Debug.Log($"Player {player.playerIndex + 1} left");
+    }
+}
+` + "```" + `
+
+**Split screen:** tick *Split-Screen* on PlayerInputManager and give the player prefab its own Camera — the manager sets each camera's viewport rect for you.`
+
+	case matchAny(q, "control scheme", "controlscheme", "gamepad and keyboard", "keyboard and gamepad"):
+		return `**Control schemes** let one set of actions work with keyboard/mouse *and* gamepad.
+
+**In the Input Actions asset:**
+1. Top-left dropdown → **Add Control Scheme** → "Keyboard&Mouse" (require Keyboard + Mouse)
+2. Add another → "Gamepad" (require Gamepad)
+3. For each action, add a binding per scheme and tick which scheme it belongs to
+
+**Reacting to scheme switches** (e.g. swap button prompts):
+` + "```csharp" + `
+using UnityEngine;
+using UnityEngine.InputSystem;
+
+[RequireComponent(typeof(PlayerInput))]
+public class ControlSchemeWatcher : MonoBehaviour
+{
+    PlayerInput playerInput;
+
+    void Awake()
+    {
+        playerInput = GetComponent<PlayerInput>();
+        playerInput.onControlsChanged += OnControlsChanged;
+    }
+
+    void OnControlsChanged(PlayerInput input)
+    {
+        bool usingGamepad = input.currentControlScheme == "Gamepad";
+        Debug.Log("Now using: " + input.currentControlScheme);
+        // e.g. ButtonPrompts.Show(usingGamepad ? "Ⓐ" : "Space");
+    }
+}
+` + "```" + `
+
+With *Auto-Switch* enabled on PlayerInput, a single-player game hops between schemes as soon as the other device is used.`
+
+	default:
+		return `**Runtime key rebinding** with the Input System (` + "`PerformInteractiveRebinding`" + `):
+
+` + "```csharp" + `
+using UnityEngine;
+using UnityEngine.InputSystem;
+using UnityEngine.UI;
+
+public class RebindButton : MonoBehaviour
+{
+    public InputActionReference action;   // e.g. Player/Jump
+    public int bindingIndex = 0;          // which binding of the action to change
+    public Text label;
+
+    InputActionRebindingExtensions.RebindingOperation rebind;
+
+    void Start()
+    {
+        // Restore saved bindings
+        string saved = PlayerPrefs.GetString("rebinds", "");
+        if (!string.IsNullOrEmpty(saved))
+            action.action.actionMap.asset.LoadBindingOverridesFromJson(saved);
+        UpdateLabel();
+    }
+
+    // Hook this to the UI Button's onClick
+    public void StartRebind()
+    {
+        action.action.Disable(); // actions must be disabled while rebinding
+        label.text = "Press a key...";
+
+        rebind = action.action.PerformInteractiveRebinding(bindingIndex)
+            .WithControlsExcluding("<Mouse>/position")
+            .WithCancelingThrough("<Keyboard>/escape")
+            .OnMatchWaitForAnother(0.1f)
+            .OnComplete(op => Finish())
+            .OnCancel(op => Finish())
+            .Start();
+    }
+
+    void Finish()
+    {
+        rebind.Dispose();
+        action.action.Enable();
+        PlayerPrefs.SetString("rebinds",
+            action.action.actionMap.asset.SaveBindingOverridesAsJson());
+        UpdateLabel();
+    }
+
+    void UpdateLabel()
+    {
+        label.text = action.action.GetBindingDisplayString(bindingIndex);
+    }
+
+    void OnDestroy() => rebind?.Dispose();
+}
+` + "```" + `
+
+**Notes:**
+- Always ` + "`Dispose()`" + ` the rebinding operation — it allocates native memory
+- ` + "`SaveBindingOverridesAsJson`" + ` only stores the *changes*, so the defaults in your asset stay intact
+- Reset with ` + "`action.action.RemoveBindingOverride(bindingIndex)`" + `
+- The Input System package ships a **Rebinding UI** sample (Package Manager → Input System → Samples) with a ready-made prefab`
+	}
+}
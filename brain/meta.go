@@ -0,0 +1,185 @@
+package brain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ── Meta / Capabilities ───────────────────────────────────────────────────────
+// "What docs do you have?", "What Unity version are these docs?", "What can
+// you do?" are about UnityMind itself, so they're answered from the live
+// system state instead of searching the docs for them.
+
+// IndexState describes one loaded index.
+type IndexState struct {
+	Name    string
+	Docs    int
+	Sources map[string]int // "offline", "live", "starter", ...
+}
+
+// SystemState is the snapshot a meta answer is built from.
+type SystemState struct {
+	AppVersion      string
+	UnityVersion    string // "" = latest docs
+	Indexes         []IndexState
+	LastDocUpdate   string
+	OfflineDocsPath string
+	IndexingDone    bool
+	IndexingPercent int
+	OpenAIEnabled   bool
+	OpenAIModel     string
+}
+
+// IsMetaQuery reports whether q asks about UnityMind rather than about Unity.
+// The whole question must be one of the meta questions below, give or take
+// punctuation and a greeting: "what do you do when OnTriggerEnter is not
+// called" or "docs version of Rigidbody" are Unity questions and are searched.
+func IsMetaQuery(q string) bool {
+	q = normalizeMeta(q)
+	return isVersionMetaQuery(q) || isDocsMetaQuery(q) || isCapabilitiesQuery(q)
+}
+
+var versionQuestions = map[string]bool{
+	"what version am i on": true, "which version am i on": true,
+	"what unity version am i on": true, "which unity version am i on": true,
+	"what unity version are these docs": true, "which unity version are these docs": true,
+	"what unity version are these docs for": true, "which unity version are these docs for": true,
+	"what unity version are you using": true, "which unity version are you using": true,
+	"what unity version are you on": true, "which unity version are you on": true,
+	"what version are these docs": true, "which version are these docs": true,
+	"what version are these docs for": true, "which version are these docs for": true,
+	"what version of unity are these docs": true, "which version of unity are these docs": true,
+	"what version of unity are these docs for": true, "which version of unity are these docs for": true,
+	"what version of unity are you using": true, "which version of unity are you using": true,
+	"what version of unity are you on": true, "which version of unity are you on": true,
+	"what version of the docs is this": true, "which version of the docs is this": true,
+	"what version of the docs do you have": true, "which version of the docs do you have": true,
+	"what is the docs version": true, "whats the docs version": true, "docs version": true,
+}
+
+var docsQuestions = map[string]bool{
+	"what docs do you have": true, "which docs do you have": true,
+	"what documentation do you have": true, "which documentation do you have": true,
+	"how many docs do you have": true, "how many docs are indexed": true, "how many docs have you indexed": true,
+	"how many pages do you have": true, "how many pages are indexed": true, "how many pages have you indexed": true,
+	"what have you indexed": true, "what is indexed": true, "whats indexed": true,
+	"what sources do you have": true, "what sources do you use": true,
+	"where do your answers come from": true, "where do you get your answers": true, "where do you get your answers from": true,
+}
+
+var capabilityQuestions = map[string]bool{
+	"what can you do": true, "what can you help with": true, "what can you help me with": true,
+	"what do you do": true, "who are you": true, "what are you": true,
+	"what are your capabilities": true, "what are your features": true, "what features do you have": true,
+}
+
+func isVersionMetaQuery(q string) bool { return versionQuestions[q] }
+
+func isDocsMetaQuery(q string) bool { return docsQuestions[q] }
+
+func isCapabilitiesQuery(q string) bool { return capabilityQuestions[q] }
+
+// metaFiller are words around a meta question that don't change it
+// ("hey unitymind, what can you do?").
+var metaFiller = map[string]bool{"hey": true, "hi": true, "hello": true, "ok": true, "so": true,
+	"please": true, "unitymind": true, "exactly": true, "now": true}
+
+// normalizeMeta lowercases q, drops apostrophes and punctuation, and trims
+// filler words from both ends.
+func normalizeMeta(q string) string {
+	q = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(q))
+	words := strings.FieldsFunc(q, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for len(words) > 0 && metaFiller[words[0]] {
+		words = words[1:]
+	}
+	for len(words) > 0 && metaFiller[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// MetaAnswer answers a meta query from st.
+func MetaAnswer(q string, st SystemState) string {
+	q = normalizeMeta(q)
+	switch {
+	case isVersionMetaQuery(q):
+		return versionSection(st) + "\n\n" + indexesSection(st)
+	case isDocsMetaQuery(q):
+		return indexesSection(st) + "\n\n" + versionSection(st)
+	default:
+		return capabilitiesSection(st) + "\n\n" + indexesSection(st)
+	}
+}
+
+func versionSection(st SystemState) string {
+	var b strings.Builder
+	if st.UnityVersion != "" {
+		fmt.Fprintf(&b, "**Unity version:** doc links point at **Unity %s**.", st.UnityVersion)
+	} else {
+		b.WriteString("**Unity version:** no version is pinned, so doc links point at the **latest** Unity docs.")
+	}
+	var named []string
+	for _, ix := range st.Indexes {
+		if ix.Name != "default" {
+			named = append(named, "`"+ix.Name+"`")
+		}
+	}
+	if len(named) > 0 {
+		fmt.Fprintf(&b, "\nVersion-specific indexes: %s (pick one with `?index=`).", strings.Join(named, ", "))
+	}
+	b.WriteString("\nChange the pinned version in ⚙️ Settings (`unity_version`).")
+	return b.String()
+}
+
+func indexesSection(st SystemState) string {
+	var b strings.Builder
+	b.WriteString("**What I have indexed:**")
+	for _, ix := range st.Indexes {
+		fmt.Fprintf(&b, "\n- `%s`: %d pages", ix.Name, ix.Docs)
+		if len(ix.Sources) > 0 {
+			srcs := make([]string, 0, len(ix.Sources))
+			for s := range ix.Sources {
+				srcs = append(srcs, s)
+			}
+			sort.Strings(srcs)
+			parts := make([]string, len(srcs))
+			for i, s := range srcs {
+				parts[i] = fmt.Sprintf("%d %s", ix.Sources[s], s)
+			}
+			fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+		}
+		if ix.Sources["starter"] > 0 && ix.Sources["starter"] == ix.Docs {
+			b.WriteString(" — only the bundled starter pages so far")
+		}
+	}
+	if !st.IndexingDone && st.IndexingPercent > 0 {
+		fmt.Fprintf(&b, "\n\nIndexing is in progress: **%d%%** done.", st.IndexingPercent)
+	}
+	if st.LastDocUpdate != "" {
+		fmt.Fprintf(&b, "\n\nLast docs update: %s.", st.LastDocUpdate)
+	}
+	if st.OfflineDocsPath != "" {
+		fmt.Fprintf(&b, "\nOffline docs: `%s`", st.OfflineDocsPath)
+	} else {
+		b.WriteString("\nNo offline docs configured — put UnityDocumentation.zip next to the exe or set the path in ⚙️ Settings.")
+	}
+	return b.String()
+}
+
+func capabilitiesSection(st SystemState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "I'm **UnityMind %s**, a Unity docs assistant that runs on your machine. I can:\n", st.AppVersion)
+	b.WriteString("- Answer Unity questions from the indexed documentation, with links to the pages I used\n")
+	b.WriteString("- Write C# scripts for common tasks (movement, coroutines, audio, saving, UI...)\n")
+	b.WriteString("- Explain concepts and compare APIs (*\"Update vs FixedUpdate\"*)\n")
+	b.WriteString("- Suggest spellings when a query doesn't match anything (*did you mean...*)\n")
+	b.WriteString("- Look pages up on docs.unity3d.com when the local index has nothing")
+	if st.OpenAIEnabled {
+		fmt.Fprintf(&b, "\n- Fall back to OpenAI (`%s`) when the docs don't cover it", st.OpenAIModel)
+	} else {
+		b.WriteString("\n\nOpenAI fallback is **off** — add a key in ⚙️ Settings to enable it.")
+	}
+	return b.String()
+}
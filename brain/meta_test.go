@@ -0,0 +1,51 @@
+package brain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsMetaQuery(t *testing.T) {
+	meta := []string{
+		"What version am I on?",
+		"what unity version are these docs",
+		"Docs version?",
+		"What docs do you have?",
+		"how many pages are indexed",
+		"Where do your answers come from?",
+		"Hey UnityMind, what can you do?",
+		"who are you",
+		"What's indexed?",
+	}
+	for _, q := range meta {
+		if !IsMetaQuery(q) {
+			t.Errorf("IsMetaQuery(%q) = false, want true", q)
+		}
+	}
+
+	// Unity questions that merely contain a meta question's words
+	unity := []string{
+		"what do you do when OnTriggerEnter is not called",
+		"how many pages can a ScrollRect hold",
+		"who are you talking to in Netcode RPC",
+		"docs version of Rigidbody",
+		"what can you do with ScriptableObjects",
+		"how many docs does Addressables have on remote catalogs",
+		"what is indexed color in a Texture2D",
+	}
+	for _, q := range unity {
+		if IsMetaQuery(q) {
+			t.Errorf("IsMetaQuery(%q) = true, want false", q)
+		}
+	}
+}
+
+func TestMetaAnswerPicksSection(t *testing.T) {
+	st := SystemState{AppVersion: "1.1.0", UnityVersion: "2022.3"}
+	if got := MetaAnswer("Which Unity version are you using?", st); !strings.HasPrefix(got, "**Unity version:**") {
+		t.Errorf("version question answered with %.40q", got)
+	}
+	if got := MetaAnswer("What can you do?", st); !strings.HasPrefix(got, "I'm **UnityMind") {
+		t.Errorf("capabilities question answered with %.40q", got)
+	}
+}
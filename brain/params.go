@@ -0,0 +1,303 @@
+package brain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ── Template parameters ───────────────────────────────────────────────────────
+// Templates ship with placeholder values: moveSpeed = 5f, tag "Ground", the
+// Space key. A template's parameters are the values it declares as tunable:
+// its Inspector fields (public or [SerializeField]), the tags it compares
+// against and the keys it reads. Values named in the question ("jump force
+// 20", "tagged Floor", "jump with the W key") are filled in, and the rest are
+// listed so the UI can tweak them before the code is copied.
+
+// Param is one tunable value in an answer's code.
+type Param struct {
+	Name  string `json:"name"`  // field name, or "tag:Ground" / "key:Space"
+	Label string `json:"label"` // "Jump Force", "Ground tag", "Space key"
+	Kind  string `json:"kind"`  // float, int, bool, string, tag or key
+	Value string `json:"value"` // as shown to the user: 5, Ground, Space
+}
+
+var (
+	reParamField = regexp.MustCompile(`(?m)((?:public|\[SerializeField\]\s*(?:private\s+)?)\s*(float|int|bool|string)\s+([A-Za-z_][A-Za-z0-9_]*)\s*=\s*)([^;\n]+)(;)`)
+	reParamTag   = regexp.MustCompile(`(?:CompareTag|FindGameObjectWithTag|FindGameObjectsWithTag)\("([^"\\]+)"\)|\btag\s*==\s*"([^"\\]+)"`)
+	reParamKey   = regexp.MustCompile(`\bKeyCode\.([A-Za-z0-9]+)\b`)
+	reTagValue   = regexp.MustCompile(`^[A-Za-z0-9_ ]+$`)
+	reKeyValue   = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+	// In the question: "tagged as Floor", "tag Floor"; "the W key", "key w"
+	reQueryTag = regexp.MustCompile(`(?i)\btag(?:ged)?\s+(?:as\s+|named\s+|called\s+)?["']?([A-Za-z_][A-Za-z0-9_]*)`)
+	reQueryKey = regexp.MustCompile(`(?i)\b(?:the\s+)?([A-Za-z0-9]+)\s+key\b|\bkey\s+["']?([A-Za-z0-9]+)`)
+)
+
+// Parameters returns the tunable values in answer's code, in order of first
+// appearance.
+func Parameters(answer string) []Param {
+	var params []Param
+	seen := map[string]bool{}
+	add := func(p Param) {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			params = append(params, p)
+		}
+	}
+	for _, code := range codeBlocks(answer) {
+		for _, m := range reParamField.FindAllStringSubmatch(code, -1) {
+			add(Param{Name: m[3], Label: fieldLabel(m[3]), Kind: m[2], Value: showValue(m[2], m[4])})
+		}
+		for _, m := range reParamTag.FindAllStringSubmatch(code, -1) {
+			tag := m[1] + m[2]
+			add(Param{Name: "tag:" + tag, Label: tag + " tag", Kind: "tag", Value: tag})
+		}
+		for _, m := range reParamKey.FindAllStringSubmatch(code, -1) {
+			add(Param{Name: "key:" + m[1], Label: m[1] + " key", Kind: "key", Value: m[1]})
+		}
+	}
+	return params
+}
+
+// ApplyParams sets parameters of answer (by Param.Name) to new values.
+// Values that don't fit a parameter's kind are ignored.
+func ApplyParams(answer string, values map[string]string) string {
+	for _, p := range Parameters(answer) {
+		v, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch p.Kind {
+		case "tag":
+			if v != p.Value && reTagValue.MatchString(v) {
+				// Prose mentions the tag too ("Tag your ground objects as "Ground"")
+				answer = strings.ReplaceAll(answer, `"`+p.Value+`"`, `"`+v+`"`)
+			}
+		case "key":
+			if v = keyName(v); v != p.Value && reKeyValue.MatchString(v) {
+				re := regexp.MustCompile(`\bKeyCode\.` + p.Value + `\b`)
+				answer = re.ReplaceAllLiteralString(answer, "KeyCode."+v)
+			}
+		default:
+			lit, ok := fieldLiteral(p.Kind, v)
+			if !ok {
+				continue
+			}
+			answer = replaceInCode(answer, func(code string) string {
+				return reParamField.ReplaceAllStringFunc(code, func(decl string) string {
+					m := reParamField.FindStringSubmatch(decl)
+					if m[3] != p.Name {
+						return decl
+					}
+					return m[1] + lit + m[5]
+				})
+			})
+		}
+	}
+	return answer
+}
+
+// FillFromQuery applies the parameter values named in the question:
+// "jump force 20", "speed of 7.5", "tagged as Floor", "jump with the W key".
+func FillFromQuery(answer, query string) string {
+	params := Parameters(answer)
+	if len(params) == 0 {
+		return answer
+	}
+	values := map[string]string{}
+	var tags, keys []Param
+	for _, p := range params {
+		switch p.Kind {
+		case "tag":
+			tags = append(tags, p)
+		case "key":
+			keys = append(keys, p)
+		case "float", "int":
+			if v := numberAfter(query, fieldWords(p.Name)); v != "" {
+				values[p.Name] = v
+			}
+		}
+	}
+	// Numbers named by the last word alone ("speed 8" for moveSpeed), when
+	// that word picks out a single field
+	for _, p := range params {
+		words := fieldWords(p.Name)
+		if _, done := values[p.Name]; done || len(words) < 2 || p.Kind != "float" && p.Kind != "int" {
+			continue
+		}
+		last := words[len(words)-1]
+		n := 0
+		for _, o := range params {
+			if w := fieldWords(o.Name); len(w) > 0 && w[len(w)-1] == last {
+				n++
+			}
+		}
+		if n == 1 {
+			if v := numberAfter(query, []string{last}); v != "" {
+				values[p.Name] = v
+			}
+		}
+	}
+	// A tag or key in the question is only unambiguous if the code has one
+	if len(tags) == 1 {
+		if m := reQueryTag.FindStringSubmatch(query); m != nil && !queryFiller[strings.ToLower(m[1])] {
+			values[tags[0].Name] = m[1]
+		}
+	}
+	if len(keys) == 1 {
+		for _, m := range reQueryKey.FindAllStringSubmatch(query, -1) {
+			if k := m[1] + m[2]; !queryFiller[strings.ToLower(k)] {
+				values[keys[0].Name] = k
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		return answer
+	}
+	return ApplyParams(answer, values)
+}
+
+// queryFiller are words the tag and key patterns catch that aren't names.
+var queryFiller = map[string]bool{
+	"a": true, "an": true, "the": true, "my": true, "for": true, "of": true,
+	"to": true, "and": true, "with": true, "on": true, "is": true, "any": true,
+	"same": true, "different": true, "press": true, "input": true,
+	"what": true, "which": true, "this": true, "that": true,
+}
+
+// numberAfter finds a number following words in the query, allowing "of",
+// "to", "=" or ":" between: "jump force 20", "move speed of 7.5".
+func numberAfter(query string, words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	re := regexp.MustCompile(`(?i)\b` + strings.Join(words, `\s*`) + `\s*(?:of|to|=|:|at)?\s*(-?\d+(?:\.\d+)?)\b`)
+	if m := re.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// fieldWords splits a field name into lower-case words: "jumpForce" →
+// ["jump", "force"].
+func fieldWords(name string) []string {
+	var words []string
+	var cur []rune
+	for i, r := range name {
+		if r == '_' {
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+		cur = append(cur, unicode.ToLower(r))
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+	return words
+}
+
+// fieldLabel is how the Inspector shows a field: "jumpForce" → "Jump Force".
+func fieldLabel(name string) string {
+	words := fieldWords(name)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// showValue turns a C# initializer into what the user edits: 5f → 5.
+func showValue(kind, lit string) string {
+	lit = strings.TrimSpace(lit)
+	switch kind {
+	case "float":
+		return strings.TrimSuffix(strings.TrimSuffix(lit, "f"), "F")
+	case "string":
+		if s, err := strconv.Unquote(lit); err == nil {
+			return s
+		}
+	}
+	return lit
+}
+
+// fieldLiteral turns a user's value back into a C# initializer of kind.
+func fieldLiteral(kind, v string) (string, bool) {
+	switch kind {
+	case "float":
+		v = strings.TrimSuffix(strings.TrimSuffix(v, "f"), "F")
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", false
+		}
+		return v + "f", true
+	case "int":
+		if _, err := strconv.Atoi(v); err != nil {
+			return "", false
+		}
+		return v, true
+	case "bool":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return strconv.FormatBool(b), true
+		}
+		return "", false
+	case "string":
+		if strings.ContainsAny(v, "\"\\\n") {
+			return "", false
+		}
+		return `"` + v + `"`, true
+	}
+	return "", false
+}
+
+// keyName turns what a user types into a KeyCode name: "w" → "W",
+// "shift" → "LeftShift", "enter" → "Return".
+func keyName(k string) string {
+	switch strings.ToLower(k) {
+	case "shift":
+		return "LeftShift"
+	case "ctrl", "control":
+		return "LeftControl"
+	case "alt":
+		return "LeftAlt"
+	case "enter", "return":
+		return "Return"
+	case "esc", "escape":
+		return "Escape"
+	case "spacebar", "space":
+		return "Space"
+	}
+	if len(k) == 1 && k[0] >= '0' && k[0] <= '9' {
+		return "Alpha" + k
+	}
+	if k == "" {
+		return k
+	}
+	return strings.ToUpper(k[:1]) + k[1:]
+}
+
+// codeBlocks returns the contents of answer's fenced code blocks.
+func codeBlocks(answer string) []string {
+	var blocks []string
+	for _, m := range reCodeFence.FindAllStringSubmatch(answer, -1) {
+		blocks = append(blocks, m[2])
+	}
+	return blocks
+}
+
+// replaceInCode applies change to each fenced code block of answer, leaving
+// the prose alone.
+func replaceInCode(answer string, change func(code string) string) string {
+	return reCodeFence.ReplaceAllStringFunc(answer, func(block string) string {
+		m := reCodeFence.FindStringSubmatchIndex(block)
+		return block[:m[4]] + change(block[m[4]:m[5]]) + block[m[5]:]
+	})
+}
@@ -0,0 +1,57 @@
+package brain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ── Key points ────────────────────────────────────────────────────────────────
+// Review flashcards show the gist of an old answer, not the whole thing: its
+// list items (steps, rules, the AudioSource-vs-AudioClip bullets) and,
+// failing that, its opening sentences.
+
+const maxKeyPoints = 5
+
+var (
+	reListItem = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+(.+)$`)
+	reMdMarks  = regexp.MustCompile("\\*\\*|\\*|`")
+)
+
+// KeyPoints returns up to five short takeaways from an answer.
+func KeyPoints(answer string) []string {
+	prose := reCodeFence.ReplaceAllString(answer, "")
+	var points, sentences []string
+	for _, line := range strings.Split(prose, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := reListItem.FindStringSubmatch(line); m != nil {
+			if p := plainPoint(m[1]); len(p) >= 12 && len(points) < maxKeyPoints {
+				points = append(points, p)
+			}
+			continue
+		}
+		// Lead-ins like "**Setup steps:**" introduce a list, they aren't points
+		if p := plainPoint(line); !strings.HasSuffix(p, ":") && len(p) >= 20 {
+			sentences = append(sentences, cleanSentence(p))
+		}
+	}
+	// Answers without a list get their opening sentences instead
+	for _, s := range sentences {
+		if len(points) >= 2 {
+			break
+		}
+		points = append(points, s)
+	}
+	return points
+}
+
+// plainPoint strips Markdown emphasis and caps a point's length.
+func plainPoint(s string) string {
+	s = strings.TrimSpace(reMdMarks.ReplaceAllString(s, ""))
+	if len(s) > 200 {
+		s = strings.TrimSpace(s[:200]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,306 @@
+package brain
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ── Answer renderers ──────────────────────────────────────────────────────────
+// Answers are built as Markdown, which is what the web UI renders. Other
+// front-ends want something else: Slack its own mrkdwn, a terminal ANSI
+// colors, an editor plugin plain text. Render walks an answer's blocks
+// (headings, paragraphs, lists, tables, code) and inline marks (bold,
+// italic, code, links) and writes them out in the format asked for.
+
+// DefaultFormat is the format answers are built in.
+const DefaultFormat = "markdown"
+
+// formatTypes maps Accept media types to format names.
+var formatTypes = map[string]string{
+	"text/markdown":        "markdown",
+	"text/x-markdown":      "markdown",
+	"text/plain":           "text",
+	"text/html":            "html",
+	"text/x-ansi":          "ansi",
+	"text/x-slack-mrkdwn":  "slack",
+	"application/x-mrkdwn": "slack",
+}
+
+// Formats lists the formats Render accepts.
+func Formats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatFor picks the format for an Accept-style value: a format name
+// ("ansi") or a list of media types ("text/html, text/plain;q=0.5"), taking
+// the first one known. Returns "" if none is.
+func FormatFor(accept string) string {
+	accept = strings.ToLower(strings.TrimSpace(accept))
+	if _, ok := renderers[accept]; ok {
+		return accept
+	}
+	for _, part := range strings.Split(accept, ",") {
+		media, _, _ := strings.Cut(part, ";")
+		if name, ok := formatTypes[strings.TrimSpace(media)]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Render converts a Markdown answer to format (see Formats).
+func Render(answer, format string) (string, error) {
+	if format == "" || format == DefaultFormat {
+		return answer, nil
+	}
+	r, ok := renderers[format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(Formats(), ", "))
+	}
+	return r.render(answer), nil
+}
+
+// renderer writes each kind of block and inline mark in one format.
+type renderer struct {
+	text      func(s string) string // plain runs of text, e.g. escaped
+	code      func(s string) string
+	bold      func(s string) string
+	italic    func(s string) string
+	link      func(text, url string) string
+	heading   func(level int, text string) string
+	para      func(text string) string
+	list      func(items []string, ordered bool) string
+	table     func(rows [][]string) string
+	codeBlock func(lang, code string) string
+}
+
+var renderers = map[string]*renderer{
+	"markdown": nil, // answers already are; see Render
+	"text": {
+		text:    noChange,
+		code:    noChange,
+		bold:    noChange,
+		italic:  noChange,
+		link:    func(text, url string) string { return text + " (" + url + ")" },
+		heading: func(_ int, text string) string { return text },
+		para:    noChange,
+		list:    listLines("- "),
+		table:   tableLines(" | "),
+		codeBlock: func(_, code string) string {
+			return "    " + strings.ReplaceAll(code, "\n", "\n    ")
+		},
+	},
+	"html": {
+		text:    html.EscapeString,
+		code:    wrap("<code>", "</code>"),
+		bold:    wrap("<strong>", "</strong>"),
+		italic:  wrap("<em>", "</em>"),
+		link:    func(text, url string) string { return `<a href="` + html.EscapeString(url) + `">` + text + "</a>" },
+		heading: func(level int, text string) string { return fmt.Sprintf("<h%d>%s</h%d>", level, text, level) },
+		para:    wrap("<p>", "</p>"),
+		list: func(items []string, ordered bool) string {
+			tag := "ul"
+			if ordered {
+				tag = "ol"
+			}
+			return "<" + tag + ">" + wrapEach(items, "<li>", "</li>") + "</" + tag + ">"
+		},
+		table: func(rows [][]string) string {
+			var b strings.Builder
+			b.WriteString("<table>")
+			for i, row := range rows {
+				cell := "td"
+				if i == 0 {
+					cell = "th"
+				}
+				b.WriteString("<tr>" + wrapEach(row, "<"+cell+">", "</"+cell+">") + "</tr>")
+			}
+			return b.String() + "</table>"
+		},
+		codeBlock: func(lang, code string) string {
+			class := ""
+			if lang != "" {
+				class = ` class="language-` + html.EscapeString(lang) + `"`
+			}
+			return "<pre><code" + class + ">" + html.EscapeString(code) + "</code></pre>"
+		},
+	},
+	"ansi": {
+		text:    noChange,
+		code:    wrap("\x1b[33m", "\x1b[0m"),
+		bold:    wrap("\x1b[1m", "\x1b[0m"),
+		italic:  wrap("\x1b[3m", "\x1b[0m"),
+		link:    func(text, url string) string { return text + " (\x1b[4m" + url + "\x1b[0m)" },
+		heading: func(_ int, text string) string { return "\x1b[1;36m" + text + "\x1b[0m" },
+		para:    noChange,
+		list:    listLines("• "),
+		table:   tableLines(" │ "),
+		codeBlock: func(_, code string) string {
+			return "\x1b[33m    " + strings.ReplaceAll(code, "\n", "\n    ") + "\x1b[0m"
+		},
+	},
+	// Slack mrkdwn: *bold*, _italic_, <url|text>, and &, <, > escaped
+	"slack": {
+		text:    slackEscape,
+		code:    wrap("`", "`"),
+		bold:    wrap("*", "*"),
+		italic:  wrap("_", "_"),
+		link:    func(text, url string) string { return "<" + url + "|" + text + ">" },
+		heading: func(_ int, text string) string { return "*" + text + "*" },
+		para:    noChange,
+		list:    listLines("• "),
+		// No tables in mrkdwn; a code block at least keeps the columns
+		table: func(rows [][]string) string {
+			return "```\n" + tableLines(" | ")(rows) + "\n```"
+		},
+		codeBlock: func(_, code string) string { return "```\n" + slackEscape(code) + "\n```" },
+	},
+}
+
+var (
+	reHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	reFenceLine = regexp.MustCompile("^```\\s*([A-Za-z#+]*)")
+	reOrdered   = regexp.MustCompile(`^\s*\d+[.)]\s+`)
+	reTableSep  = regexp.MustCompile(`^\|?[\s:|-]+\|?$`)
+	reInline    = regexp.MustCompile("`([^`]+)`|\\[([^\\]]+)\\]\\((https?://[^)\\s]+)\\)|\\*\\*(.+?)\\*\\*|\\*([^*\\s][^*]*?)\\*")
+)
+
+// render walks the answer line by line, gathering consecutive list items
+// and table rows into one block each.
+func (r *renderer) render(md string) string {
+	var blocks []string
+	var items []string
+	ordered := false
+	var rows [][]string
+	flush := func() {
+		if len(items) > 0 {
+			blocks = append(blocks, r.list(items, ordered))
+			items = nil
+		}
+		if len(rows) > 0 {
+			blocks = append(blocks, r.table(rows))
+			rows = nil
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if m := reFenceLine.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, r.codeBlock(m[1], strings.Join(code, "\n")))
+			continue
+		}
+		if strings.HasPrefix(trimmed, "|") {
+			if len(items) > 0 {
+				flush()
+			}
+			if !reTableSep.MatchString(trimmed) {
+				cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+				for j, c := range cells {
+					cells[j] = r.inline(strings.TrimSpace(c))
+				}
+				rows = append(rows, cells)
+			}
+			continue
+		}
+		if m := reListItem.FindStringSubmatch(line); m != nil {
+			if len(rows) > 0 {
+				flush()
+			}
+			if len(items) == 0 {
+				ordered = reOrdered.MatchString(line)
+			}
+			items = append(items, r.inline(m[1]))
+			continue
+		}
+		flush()
+		switch m := reHeading.FindStringSubmatch(trimmed); {
+		case trimmed == "":
+		case m != nil:
+			blocks = append(blocks, r.heading(len(m[1]), r.inline(m[2])))
+		default:
+			blocks = append(blocks, r.para(r.inline(trimmed)))
+		}
+	}
+	flush()
+	return strings.Join(blocks, "\n\n")
+}
+
+// inline renders the marks within one line of text.
+func (r *renderer) inline(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range reInline.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(r.text(s[last:m[0]]))
+		switch {
+		case m[2] >= 0:
+			b.WriteString(r.code(r.text(s[m[2]:m[3]])))
+		case m[4] >= 0:
+			b.WriteString(r.link(r.inline(s[m[4]:m[5]]), s[m[6]:m[7]]))
+		case m[8] >= 0:
+			b.WriteString(r.bold(r.inline(s[m[8]:m[9]])))
+		default:
+			b.WriteString(r.italic(r.inline(s[m[10]:m[11]])))
+		}
+		last = m[1]
+	}
+	b.WriteString(r.text(s[last:]))
+	return b.String()
+}
+
+func noChange(s string) string { return s }
+
+func wrap(open, close string) func(string) string {
+	return func(s string) string { return open + s + close }
+}
+
+func wrapEach(items []string, open, close string) string {
+	var b strings.Builder
+	for _, it := range items {
+		b.WriteString(open + it + close)
+	}
+	return b.String()
+}
+
+// listLines writes a list one item per line; ordered ones are numbered.
+func listLines(bullet string) func([]string, bool) string {
+	return func(items []string, ordered bool) string {
+		lines := make([]string, len(items))
+		for i, it := range items {
+			if ordered {
+				lines[i] = fmt.Sprintf("%d. %s", i+1, it)
+			} else {
+				lines[i] = bullet + it
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// tableLines writes a table one row per line, cells joined by sep.
+func tableLines(sep string) func([][]string) string {
+	return func(rows [][]string) string {
+		lines := make([]string, len(rows))
+		for i, row := range rows {
+			lines[i] = strings.Join(row, sep)
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,117 @@
+package brain
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ── Answer length cap ─────────────────────────────────────────────────────────
+// Hover cards and chat integrations want short answers. Truncation happens
+// at section (paragraph) boundaries so markdown never breaks mid-list or
+// mid-fence, and the first code block is kept whole unless dropCode is set.
+
+const truncatedMark = "\n\n…"
+
+// Truncate shortens answer to at most maxChars (0 = no limit). Answers that
+// already fit are returned unchanged; dropCode only matters when cutting.
+func Truncate(answer string, maxChars int, dropCode bool) string {
+	if maxChars <= 0 || len(answer) <= maxChars {
+		return answer
+	}
+	blocks := splitBlocks(answer)
+	budget := maxChars - len(truncatedMark)
+
+	// Reserve room for the code block first so prose can't crowd it out
+	code := -1
+	if !dropCode {
+		for i, b := range blocks {
+			if isFence(b) {
+				if len(b) <= budget {
+					code = i
+					budget -= len(b) + 2
+				}
+				break
+			}
+		}
+	}
+
+	var kept []string
+	cut := false
+	for i, b := range blocks {
+		switch {
+		case i == code:
+			kept = append(kept, b)
+		case isFence(b) && (dropCode || code >= 0 || len(b)+2 > budget):
+			cut = true // only the first code block is kept
+		case len(b)+2 <= budget:
+			kept = append(kept, b)
+			budget -= len(b) + 2
+		default:
+			cut = true
+			if len(kept) == 0 && budget > 0 {
+				kept = append(kept, clipText(b, budget)) // first section alone is too long
+				budget = 0
+			}
+		}
+	}
+	out := strings.Join(kept, "\n\n")
+	if cut {
+		out += truncatedMark
+	}
+	return out
+}
+
+// splitBlocks splits markdown into paragraphs, keeping each fenced code
+// block (and its blank lines) as a single block.
+func splitBlocks(s string) []string {
+	var blocks []string
+	var cur []string
+	inFence := false
+	flush := func() {
+		if b := strings.TrimSpace(strings.Join(cur, "\n")); b != "" {
+			blocks = append(blocks, b)
+		}
+		cur = cur[:0]
+	}
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") && !inFence:
+			flush()
+			inFence = true
+			cur = append(cur, line)
+		case strings.HasPrefix(trimmed, "```") && inFence:
+			cur = append(cur, line)
+			inFence = false
+			flush()
+		case trimmed == "" && !inFence:
+			flush()
+		default:
+			cur = append(cur, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func isFence(b string) bool {
+	return strings.HasPrefix(b, "```")
+}
+
+// clipText cuts s to at most n bytes, preferring a sentence end, then a space.
+func clipText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n-- // don't split a multi-byte character
+	}
+	s = s[:n]
+	if i := strings.LastIndexAny(s, ".!?"); i > n/2 {
+		return s[:i+1]
+	}
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package brain
+
+// ── Version Control Answer Pack ───────────────────────────────────────────────
+// Git + Unity trips people up in the same few places: committing Library/,
+// losing .meta files, and unmergeable scene YAML. These cover each of them.
+
+func isVersionControlQuery(q string) bool {
+	return hasWord(q, "git") || matchAny(q, "gitignore", "github", "gitlab",
+		"version control", "source control", "smart merge", "smartmerge",
+		"unityyamlmerge", "yaml merge", "merge conflict", "meta file", ".meta",
+		"git lfs", "large file storage", "plastic scm", "unity version control")
+}
+
+func vcsAnswer(q string) string {
+	switch {
+
+	case matchAny(q, "meta file", ".meta", "missing reference", "missing script", "guid"):
+		return `**.meta files** — always commit them, always move them together with their asset.
+
+Every asset in ` + "`Assets/`" + ` has a ` + "`.meta`" + ` file holding its **GUID**. Scenes and prefabs reference assets by that GUID, not by path.
+
+**Common pitfalls:**
+- **Not committing .meta files** → teammates get new GUIDs on import and every reference shows *Missing*
+- **Moving/renaming files outside Unity** (Explorer, Finder, ` + "`git mv`" + ` without the .meta) → GUID lost, references break
+- **Deleting a .meta file** → Unity generates a new GUID on next import
+- **Two people adding the same new folder** → conflicting folder .meta files; keep either one, they're identical in meaning
+
+**Rules of thumb:**
+1. Move, rename and delete assets **inside the Unity Editor**
+2. Commit the asset and its ` + "`.meta`" + ` in the same commit
+3. Keep **Edit → Project Settings → Editor → Version Control Mode** on *Visible Meta Files*`
+
+	case matchAny(q, "smart merge", "smartmerge", "unityyamlmerge", "yaml merge", "merge conflict", "merge scene", "merge prefab"):
+		return `**Merging scenes and prefabs with UnityYAMLMerge (Smart Merge)**
+
+First make sure assets are saved as text: **Project Settings → Editor → Asset Serialization Mode = Force Text**.
+
+Then register Unity's merge tool with git. Add to ` + "`.git/config`" + ` (or your global ` + "`~/.gitconfig`" + `):
+` + "```" + `
+[merge]
+    tool = unityyamlmerge
+
+[mergetool "unityyamlmerge"]
+    trustExitCode = false
+    cmd = '<UnityEditorPath>/Data/Tools/UnityYAMLMerge' merge -p "$BASE" "$REMOTE" "$LOCAL" "$MERGED"
+` + "```" + `
+
+On Windows the tool lives at ` + "`C:\\Program Files\\Unity\\Hub\\Editor\\<version>\\Editor\\Data\\Tools\\UnityYAMLMerge.exe`" + `.
+
+When a conflict happens run ` + "`git mergetool`" + ` — UnityYAMLMerge resolves most scene/prefab conflicts semantically instead of line-by-line.
+
+**Avoid conflicts in the first place:** split big scenes into prefabs or additive scenes so people rarely edit the same file.`
+
+	case matchAny(q, "lfs", "large file", "big file", "binary file", "repo size", "too large"):
+		return `**Git LFS for Unity** — keep big binary assets out of the normal git history.
+
+` + "```" + `
+git lfs install
+git lfs track "*.psd" "*.png" "*.jpg" "*.tga" "*.fbx" "*.blend"
+git lfs track "*.wav" "*.mp3" "*.ogg" "*.mp4"
+git lfs track "*.ttf" "*.otf" "*.exr" "*.hdr" "*.asset"
+git add .gitattributes
+` + "```" + `
+
+**Notes:**
+- Commit ` + "`.gitattributes`" + ` **before** adding the binary files, or they'll land in regular history
+- Only track ` + "`*.asset`" + ` if your .asset files are large binary data (terrain, lightmaps) — small text ScriptableObjects merge fine without LFS
+- GitHub's free tier has LFS storage/bandwidth limits — check them before pushing a multi-GB project
+- Use ` + "`git lfs lock`" + ` on scenes/art nobody should edit at the same time`
+
+	default:
+		return `**Setting up git for a Unity project**
+
+**1. Editor settings** (Edit → Project Settings → Editor):
+- *Version Control Mode* → **Visible Meta Files**
+- *Asset Serialization Mode* → **Force Text**
+
+**2. A ` + "`.gitignore`" + ` in the project root** (next to ` + "`Assets/`" + `):
+` + "```" + `
+/[Ll]ibrary/
+/[Tt]emp/
+/[Oo]bj/
+/[Bb]uild/
+/[Bb]uilds/
+/[Ll]ogs/
+/[Uu]ser[Ss]ettings/
+/[Mm]emoryCaptures/
+
+# IDE / generated project files
+.vs/
+.idea/
+*.csproj
+*.sln
+*.suo
+*.user
+*.pidb
+*.booproj
+
+# OS junk
+.DS_Store
+Thumbs.db
+` + "```" + `
+
+**Commit:** ` + "`Assets/`" + ` (with every ` + "`.meta`" + ` file), ` + "`Packages/manifest.json`" + `, ` + "`Packages/packages-lock.json`" + `, ` + "`ProjectSettings/`" + `.
+
+**Never commit:** ` + "`Library/`" + ` — it's a local cache Unity rebuilds on open, and it's huge.
+
+**Next steps:** set up **Git LFS** for large art/audio and **UnityYAMLMerge** for scene merges — ask me about either.`
+	}
+}
@@ -0,0 +1,141 @@
+package brain
+
+// ── WebGL Answer Pack ─────────────────────────────────────────────────────────
+// WebGL builds run inside the browser sandbox, so a lot of "normal" Unity
+// advice (threads, File.IO, autoplaying audio) silently breaks there.
+// Triggered when the NLU detects the webgl platform in the query.
+
+func webglAnswer(q string) string {
+	switch {
+
+	case matchAny(q, "thread", "async", "task", "parallel", "job system", "multithread"):
+		return `**Threads on WebGL:** the browser build is **single-threaded** by default.
+
+- ` + "`System.Threading.Thread`" + ` and ` + "`Task.Run`" + ` won't run in parallel — code that blocks waiting on another thread will **hang the tab**
+- ` + "`async`/`await`" + ` still works, but continuations run on the main thread — use it for waiting, not for heavy work
+- The **C# Job System** and Burst compile, but jobs run on the main thread unless you enable *WebAssembly multithreading* (experimental, needs cross-origin isolation headers)
+
+**What to do instead:**
+` + "```csharp" + `
+using System.Collections;
+using UnityEngine;
+
+public class ChunkedWork : MonoBehaviour
+{
+    // Spread heavy work across frames instead of using a thread
+    IEnumerator ProcessItems(int[] items)
+    {
+        for (int i = 0; i < items.Length; i++)
+        {
+            Process(items[i]);
+            if (i % 100 == 0) yield return null; // give the browser a frame
+        }
+    }
+
+    void Process(int item) { /* ... */ }
+}
+` + "```" + `
+
+Guard platform-specific code with ` + "`#if UNITY_WEBGL && !UNITY_EDITOR`" + `.`
+
+	case matchAny(q, "file", "save", "load", "playerprefs", "persist", "streamingassets", "file io"):
+		return `**File access on WebGL:** there is **no synchronous file system** access to the user's disk.
+
+- ` + "`File.ReadAllText`/`File.WriteAllText`" + ` only touch an in-memory virtual FS — writes to ` + "`Application.persistentDataPath`" + ` are backed by **IndexedDB** and must be flushed
+- ` + "`StreamingAssets`" + ` is just a URL on your web server — read it with ` + "`UnityWebRequest`" + `, not ` + "`File`" + `
+- ` + "`PlayerPrefs`" + ` works (also IndexedDB), but keep it small
+
+**Reading StreamingAssets:**
+` + "```csharp" + `
+using System.Collections;
+using System.IO;
+using UnityEngine;
+using UnityEngine.Networking;
+
+public class WebGLLoad : MonoBehaviour
+{
+    IEnumerator Start()
+    {
+        string url = Path.Combine(Application.streamingAssetsPath, "levels.json");
+        using (UnityWebRequest req = UnityWebRequest.Get(url))
+        {
+            yield return req.SendWebRequest();
+            if (req.result == UnityWebRequest.Result.Success)
+                Debug.Log(req.downloadHandler.text);
+        }
+    }
+}
+` + "```" + `
+
+**Saving:** write to ` + "`persistentDataPath`" + `, then call ` + "`PlayerPrefs.Save()`" + ` (or ` + "`FS.syncfs`" + ` from a .jslib plugin) so the data actually reaches IndexedDB before the tab closes.`
+
+	case matchAny(q, "audio", "sound", "music", "autoplay", "mute", "no sound"):
+		return `**Audio on WebGL:** browsers **block audio until the user interacts** with the page (click, tap or key press).
+
+- Sound started in ` + "`Start()`" + ` may stay silent until the first click — Unity resumes the audio context automatically on that first gesture
+- Only ` + "`AudioSource`" + ` basics are supported: no ` + "`OnAudioFilterRead`" + `, limited ` + "`AudioMixer`" + ` effects, no microphone without a plugin
+- Use **compressed** clips (Vorbis/AAC) and *Load In Background* to keep the download small
+
+**Pattern — start music on first input:**
+` + "```csharp" + `
+using UnityEngine;
+
+public class StartMusicOnClick : MonoBehaviour
+{
+    public AudioSource music;
+    bool started;
+
+    void Update()
+    {
+        if (!started && (Input.anyKeyDown || Input.GetMouseButtonDown(0)))
+        {
+            music.Play();
+            started = true;
+        }
+    }
+}
+` + "```" + `
+
+A "Click to start" title screen is the simplest way to guarantee the audio unlock.`
+
+	case matchAny(q, "memory", "out of memory", "heap", "crash", "memory size"):
+		return `**Memory on WebGL:** the whole game runs inside one WebAssembly heap.
+
+- Set the limits in **Player Settings → WebGL → Publishing Settings** (*Initial Memory Size*, *Maximum Memory Size*, *Memory Growth Mode*)
+- Mobile browsers often cap the heap well below desktop — aim for **< 512 MB** total
+- "Out of memory" errors usually mean the heap couldn't grow — lower texture sizes, use **Addressables** to stream content instead of packing everything in the first scene
+- Garbage is only collected **between frames** — avoid large allocations inside a single long frame
+
+Profile with the **Memory Profiler** against a *Development Build* running in the browser.`
+
+	case matchAny(q, "compression", "gzip", "brotli", "hosting", "server", "header", "content-encoding", "upload", "itch"):
+		return `**Compression and hosting WebGL builds:**
+
+**Player Settings → WebGL → Publishing Settings → Compression Format:**
+- **Brotli** — smallest, but browsers only accept it over **HTTPS**
+- **Gzip** — works everywhere, slightly bigger
+- **Disabled** — for hosts that compress on the fly
+
+**Your server must send the right headers** for the ` + "`.br`/`.gz`" + ` files:
+` + "```" + `
+Content-Encoding: br            (or gzip)
+Content-Type: application/wasm  (for .wasm.br / .wasm.gz)
+` + "```" + `
+
+If you can't configure headers (e.g. some static hosts), enable **Decompression Fallback** — the loader unpacks in JavaScript, at the cost of slower startup.
+
+"Unable to parse Build/xxx.framework.js.br" almost always means the ` + "`Content-Encoding`" + ` header is missing.`
+
+	default:
+		return `**Building for WebGL** — the browser sandbox changes a few rules:
+
+- **No threads:** code runs on the main thread — split heavy work across frames with coroutines
+- **No synchronous file IO:** load ` + "`StreamingAssets`" + ` with ` + "`UnityWebRequest`" + `; saves go to IndexedDB
+- **Audio needs a user gesture:** browsers stay silent until the first click or key press
+- **Memory is one fixed heap:** tune *Initial/Maximum Memory Size* in Publishing Settings
+- **Compression needs server headers:** Brotli/Gzip builds need ` + "`Content-Encoding`" + ` set, or enable *Decompression Fallback*
+- **No raw sockets:** use ` + "`UnityWebRequest`" + ` or WebSockets via a .jslib plugin
+
+Ask about any of these for details and code, e.g. *"WebGL audio not playing"*.`
+	}
+}
@@ -0,0 +1,49 @@
+package docs
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Canonical page URLs ───────────────────────────────────────────────────────
+// docs.unity3d.com redirects old page names, aliases and versions to the
+// page they became, and a route or a link naming the old URL indexed the
+// same page under two URLs. A downloaded page is indexed under where its
+// redirects ended, or the page its <link rel="canonical"> names, and
+// search.CanonicalURL folds the remaining spellings (trailing slashes,
+// /Documentation/, ./ and //) before the index dedups by URL.
+
+var (
+	reCanonicalLink = regexp.MustCompile(`(?is)<link\b[^>]*\brel\s*=\s*["']?canonical\b[^>]*>`)
+	reHrefAttr      = regexp.MustCompile(`(?i)\bhref\s*=\s*["']([^"']+)["']`)
+)
+
+// canonicalPage returns the URL a page downloaded from final (where its
+// redirects ended) is indexed under: the page its canonical link names if
+// that's on the same site and in the same language, at final's docs
+// version, else final.
+func canonicalPage(html, final string) string {
+	tag := reCanonicalLink.FindString(html)
+	m := reHrefAttr.FindStringSubmatch(tag)
+	if m == nil {
+		return final
+	}
+	base, err := url.Parse(final)
+	if err != nil {
+		return final
+	}
+	ref, err := url.Parse(strings.TrimSpace(m[1]))
+	if err != nil {
+		return final
+	}
+	link := base.ResolveReference(ref)
+	if !strings.EqualFold(link.Host, base.Host) || pageLanguage(link.String(), "") != pageLanguage(final, "") {
+		return final
+	}
+	canon, _ := search.CanonicalURL(link.String())
+	_, version := search.CanonicalURL(final)
+	return search.VersionedURL(canon, version)
+}
@@ -0,0 +1,122 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Conditional requests ──────────────────────────────────────────────────────
+// Refreshing the core docs used to download every page again even when none
+// had changed. Each page fetchPage downloads is remembered with the ETag and
+// Last-Modified the site sent and the page as parsed, in cache/validators.json.
+// The next fetch of it asks only for a newer copy (If-None-Match,
+// If-Modified-Since), and a 304 Not Modified is answered from what was
+// remembered: no body is downloaded and nothing is parsed again. A page
+// sent without either is still remembered with when it was fetched, see
+// stale.go.
+
+// validator is what's remembered of a page fetched before.
+type validator struct {
+	ETag         string        `json:"etag,omitempty"`
+	LastModified string        `json:"last_modified,omitempty"`
+	Checked      time.Time     `json:"checked"` // when the site last sent or confirmed it
+	Page         search.Result `json:"page"`
+}
+
+// validators holds a Manager's validators, read from disk on first use.
+type validators struct {
+	once   sync.Once
+	mu     sync.Mutex
+	byURL  map[string]validator
+	dirty  bool
+	hits   int // 304s since the last save
+	loaded bool
+}
+
+func (m *Manager) validatorsPath() string {
+	return filepath.Join(m.cacheDir, "validators.json")
+}
+
+func (m *Manager) loadValidators() {
+	m.valid.once.Do(func() {
+		m.valid.byURL = map[string]validator{}
+		if data, err := os.ReadFile(m.validatorsPath()); err == nil {
+			json.Unmarshal(data, &m.valid.byURL) // a bad file just means full fetches
+		}
+		m.valid.loaded = true
+	})
+}
+
+// condition adds the validators remembered for pageURL to req, returning
+// them; ok=false if the page hasn't been fetched with any.
+func (m *Manager) condition(req *http.Request, pageURL string) (v validator, ok bool) {
+	m.loadValidators()
+	m.valid.mu.Lock()
+	v, ok = m.valid.byURL[pageURL]
+	m.valid.mu.Unlock()
+	if !ok || (v.ETag == "" && v.LastModified == "") {
+		return v, false
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+	return v, true
+}
+
+// remember records page as fetched from pageURL with resp's validators. A
+// response without either can't be asked about, so only when it was fetched
+// is kept of it.
+func (m *Manager) remember(pageURL string, resp *http.Response, page search.Result) {
+	m.loadValidators()
+	v := validator{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), Checked: time.Now()}
+	if v.ETag != "" || v.LastModified != "" {
+		v.Page = page
+	}
+	m.valid.mu.Lock()
+	defer m.valid.mu.Unlock()
+	m.valid.byURL[pageURL] = v
+	m.valid.dirty = true
+}
+
+// confirmed records a 304 for pageURL.
+func (m *Manager) confirmed(pageURL string) {
+	m.valid.mu.Lock()
+	defer m.valid.mu.Unlock()
+	if v, ok := m.valid.byURL[pageURL]; ok {
+		v.Checked = time.Now()
+		m.valid.byURL[pageURL] = v
+		m.valid.dirty = true
+		m.valid.hits++
+	}
+}
+
+// saveValidators writes the validators to disk if they changed, returning
+// how many pages were answered by a 304 since the last save.
+func (m *Manager) saveValidators() int {
+	m.valid.mu.Lock()
+	defer m.valid.mu.Unlock()
+	hits := m.valid.hits
+	m.valid.hits = 0
+	if !m.valid.loaded || !m.valid.dirty {
+		return hits
+	}
+	data, err := json.Marshal(m.valid.byURL)
+	if err != nil {
+		return hits
+	}
+	os.MkdirAll(m.cacheDir, 0755)
+	tmp := m.validatorsPath() + ".tmp"
+	if os.WriteFile(tmp, data, 0644) == nil && os.Rename(tmp, m.validatorsPath()) == nil {
+		m.valid.dirty = false
+	}
+	return hits
+}
@@ -0,0 +1,134 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Crawling a user's URL ─────────────────────────────────────────────────────
+// Any page can be indexed next to the docs: a blog post, a GitHub wiki page,
+// an asset's online manual. Crawl fetches it and, when asked for more than
+// one page, the pages it links to under the same folder (an asset manual's
+// other chapters), breadth first.
+
+const (
+	// MaxCrawlPages caps how many pages one crawl fetches.
+	MaxCrawlPages = 100
+)
+
+var (
+	reHref = regexp.MustCompile(`(?i)<a\b[^>]*\bhref\s*=\s*["']([^"'#]+)`)
+	// Links to files rather than pages aren't followed
+	reNotPage = regexp.MustCompile(`(?i)\.(?:png|jpe?g|gif|svg|webp|ico|zip|gz|tgz|rar|7z|pdf|mp4|mov|webm|mp3|wav|exe|dmg|pkg|unitypackage|css|js|json|xml)$`)
+)
+
+// CrawledPage is a page a crawl fetched, as parse turned it into results.
+type CrawledPage struct {
+	URL     string          `json:"url"`
+	Results []search.Result `json:"-"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Crawl fetches start and, up to maxPages in all, the pages it links to
+// under start's folder, handing each one's HTML to parse. It stops early
+// once ctx is done. The error is for start itself failing, or ctx being
+// done before it was fetched; a linked page that fails is listed with its
+// error.
+func (m *Manager) Crawl(ctx context.Context, start string, maxPages int, parse func(html []byte, pageURL string) []search.Result) ([]CrawledPage, error) {
+	u, err := url.Parse(start)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("not an http(s) URL: %s", start)
+	}
+	u.Fragment = ""
+	maxPages = min(max(maxPages, 1), MaxCrawlPages)
+	scope := u.Host + strings.TrimSuffix(path.Dir(u.Path+"x"), "/") + "/" // the folder start is in
+
+	var pages []CrawledPage
+	queue := []string{u.String()}
+	seen := map[string]bool{u.String(): true}
+	for len(queue) > 0 && len(pages) < maxPages && ctx.Err() == nil {
+		pageURL := queue[0]
+		queue = queue[1:]
+		body, final, err := m.fetchRaw(ctx, pageURL)
+		if err != nil {
+			if len(pages) == 0 {
+				return nil, err
+			}
+			pages = append(pages, CrawledPage{URL: pageURL, Error: err.Error()})
+			continue
+		}
+		page := CrawledPage{URL: final, Results: parse(body, final)}
+		if len(page.Results) == 0 {
+			page.Error = "no text to index"
+		}
+		pages = append(pages, page)
+		if maxPages == 1 {
+			break
+		}
+		for _, link := range crawlLinks(string(body), final, scope) {
+			if !seen[link] {
+				seen[link] = true
+				queue = append(queue, link)
+			}
+		}
+		m.pause(ctx, u.Host, 0) // go easy on the site
+	}
+	if len(pages) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("nothing fetched from %s", start)
+	}
+	return pages, nil
+}
+
+// fetchRaw downloads a page, returning its body and the URL it ended up at
+// after redirects.
+func (m *Manager) fetchRaw(ctx context.Context, pageURL string) ([]byte, string, error) {
+	resp, err := m.get(ctx, pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, pageURL)
+	}
+	if err := checkPage(resp); err != nil {
+		return nil, "", err
+	}
+	body, err := m.readBody(resp)
+	if err != nil {
+		return nil, "", err
+	}
+	m.storeRaw(resp.Request.URL.String(), body)
+	return body, resp.Request.URL.String(), nil
+}
+
+// crawlLinks returns the pages html links to within scope (host + folder),
+// resolved against pageURL and without their fragments.
+func crawlLinks(html, pageURL, scope string) []string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	for _, m := range reHref.FindAllStringSubmatch(html, -1) {
+		ref, err := url.Parse(strings.TrimSpace(strings.ReplaceAll(m[1], "&amp;", "&")))
+		if err != nil {
+			continue
+		}
+		link := base.ResolveReference(ref)
+		link.Fragment = ""
+		if link.Scheme != base.Scheme || !strings.HasPrefix(link.Host+link.Path, scope) || reNotPage.MatchString(link.Path) {
+			continue
+		}
+		links = append(links, link.String())
+	}
+	return links
+}
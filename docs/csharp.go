@@ -0,0 +1,205 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"unitymind/offline"
+	"unitymind/search"
+)
+
+// ── C# language docs ──────────────────────────────────────────────────────────
+// Questions about the language rather than the engine (generics,
+// async/await, LINQ, events) aren't answered anywhere in the Unity docs, so
+// they fell through every step to OpenAI. csharpTopics maps the language's
+// features to their pages in Microsoft's C# guide and reference on
+// learn.microsoft.com, the way the keyword routes map Unity's: a question
+// that names one and nothing of Unity (IsCSharpQuestion) is answered from
+// those pages, which are then indexed like any other live page.
+
+const (
+	// CSharpSource marks results from the C# docs.
+	CSharpSource = "csharp"
+	// csharpHost is where the C# docs are published.
+	csharpHost = "https://learn.microsoft.com/en-us/dotnet/"
+	// maxCSharpPages is how many pages one question reads.
+	maxCSharpPages = 2
+)
+
+// csharpTopic is a C# language feature and the pages that explain it.
+type csharpTopic struct {
+	Keywords []string
+	Pages    []string // under csharpHost
+}
+
+var csharpTopics = []csharpTopic{
+	{[]string{"generic", "generics", "type parameter", "where t :", "constraint"},
+		[]string{"csharp/fundamentals/types/generics", "csharp/programming-guide/generics/constraints-on-type-parameters"}},
+	{[]string{"async", "await", "asynchronous", "task<", "async method"},
+		[]string{"csharp/asynchronous-programming/", "csharp/language-reference/operators/await"}},
+	{[]string{"linq", "query syntax", "select many", "selectmany", "groupby", "orderby", "firstordefault"},
+		[]string{"csharp/linq/", "csharp/linq/standard-query-operators/"}},
+	{[]string{"event", "events", "event handler", "eventhandler", "subscribe", "unsubscribe"},
+		[]string{"csharp/programming-guide/events/", "csharp/programming-guide/events/how-to-subscribe-to-and-unsubscribe-from-events"}},
+	{[]string{"delegate", "delegates", "func<", "action<", "multicast"},
+		[]string{"csharp/programming-guide/delegates/", "csharp/language-reference/builtin-types/reference-types"}},
+	{[]string{"lambda", "lambdas", "=>", "anonymous function", "closure"},
+		[]string{"csharp/language-reference/operators/lambda-expressions"}},
+	{[]string{"interface", "interfaces", "default interface method"},
+		[]string{"csharp/fundamentals/types/interfaces", "csharp/language-reference/keywords/interface"}},
+	{[]string{"struct", "structs", "value type", "reference type", "struct vs class"},
+		[]string{"csharp/language-reference/builtin-types/struct", "csharp/fundamentals/types/classes"}},
+	{[]string{"extension method", "extension methods"},
+		[]string{"csharp/programming-guide/classes-and-structs/extension-methods"}},
+	{[]string{"nullable", "null-conditional", "null coalescing", "??=", "?."},
+		[]string{"csharp/nullable-references", "csharp/language-reference/builtin-types/nullable-value-types"}},
+	{[]string{"pattern matching", "is not null", "switch expression"},
+		[]string{"csharp/fundamentals/functional/pattern-matching", "csharp/language-reference/operators/switch-expression"}},
+	{[]string{"exception", "exceptions", "try catch", "try/catch", "finally", "throw"},
+		[]string{"csharp/fundamentals/exceptions/", "csharp/language-reference/statements/exception-handling-statements"}},
+	{[]string{"property", "properties", "getter", "setter", "auto-property", "auto property"},
+		[]string{"csharp/programming-guide/classes-and-structs/properties"}},
+	{[]string{"records", "record type", "record class", "record struct"},
+		[]string{"csharp/language-reference/builtin-types/record"}},
+	{[]string{"string interpolation", "interpolated string", "$\""},
+		[]string{"csharp/language-reference/tokens/interpolated"}},
+	{[]string{"enum", "enums", "flags enum"},
+		[]string{"csharp/language-reference/builtin-types/enum"}},
+	{[]string{"tuple", "tuples", "deconstruct"},
+		[]string{"csharp/language-reference/builtin-types/value-tuples"}},
+	{[]string{"ref", "out parameter", "in parameter", "pass by reference", "params"},
+		[]string{"csharp/language-reference/keywords/method-parameters"}},
+	{[]string{"access modifier", "access modifiers", "private", "protected", "internal"},
+		[]string{"csharp/programming-guide/classes-and-structs/access-modifiers"}},
+	{[]string{"abstract", "virtual", "override", "inheritance", "base class", "polymorphism"},
+		[]string{"csharp/fundamentals/object-oriented/inheritance", "csharp/fundamentals/object-oriented/polymorphism"}},
+	{[]string{"static class", "static method", "static constructor"},
+		[]string{"csharp/programming-guide/classes-and-structs/static-classes-and-static-class-members"}},
+	{[]string{"iterator", "ienumerable<", "yield break"},
+		[]string{"csharp/iterators", "csharp/language-reference/statements/yield"}},
+	{[]string{"list<", "dictionary<", "hashset<", "collection", "collections"},
+		[]string{"standard/collections/", "api/system.collections.generic.list-1"}},
+	{[]string{"operator overloading", "operator overload", "implicit operator", "explicit operator"},
+		[]string{"csharp/language-reference/operators/operator-overloading", "csharp/language-reference/operators/user-defined-conversion-operators"}},
+}
+
+// unityWords say a question is about Unity even when it also names a
+// language feature: "unsubscribe from a UnityEvent", "async scene loading".
+var unityWords = []string{"unity", "monobehaviour", "gameobject", "prefab", "scene", "inspector", "editor",
+	"coroutine", "component", "unityevent", "scriptableobject", "awaitable", "jobs", "burst", "dots"}
+
+// csharpPages returns the C# docs pages for the language feature query
+// names best, nil if it names none.
+func csharpPages(query string) []string {
+	q := strings.ToLower(query)
+	var best []string
+	bestScore := 0
+	for _, t := range csharpTopics {
+		score := 0
+		for _, kw := range t.Keywords {
+			if strings.Contains(q, kw) && (wholeWords(q, kw) || !isWordByte(kw[0]) || !isWordByte(kw[len(kw)-1])) {
+				score += len(strings.Fields(kw))
+			}
+		}
+		if score > bestScore {
+			best, bestScore = t.Pages, score
+		}
+	}
+	var urls []string
+	for _, p := range best {
+		urls = append(urls, csharpHost+p)
+	}
+	return urls
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '_'
+}
+
+// IsCSharpQuestion reports whether query is about the C# language and not
+// about Unity: it names a language feature, no Unity API or word, and
+// matches no keyword route with any confidence. The APIs the NLU relates
+// to the question ("events" brings UnityEvent) only count if it names them,
+// and its plain words ("delegate") not at all.
+func (m *Manager) IsCSharpQuestion(query string) bool {
+	if len(csharpPages(query)) == 0 {
+		return false
+	}
+	q := strings.ToLower(query)
+	words := unityWords
+	for _, sym := range offline.UnderstandQuery(query).APISymbols {
+		if sym != "" && sym[0] >= 'A' && sym[0] <= 'Z' {
+			words = append(words[:len(words):len(words)], strings.ToLower(sym))
+		}
+	}
+	for _, w := range words {
+		if wholeWords(q, w) {
+			return false
+		}
+	}
+	return m.routeQuery(query).Confidence < minRouteConfidence
+}
+
+// SearchCSharpDocs returns the pages of the C# docs about the language
+// feature query names, at most maxCSharpPages.
+func (m *Manager) SearchCSharpDocs(ctx context.Context, query string) ([]search.Result, error) {
+	pages := csharpPages(query)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no C# docs for: %s", query)
+	}
+	if len(pages) > maxCSharpPages {
+		pages = pages[:maxCSharpPages]
+	}
+	var results []search.Result
+	var lastErr error
+	for _, u := range pages {
+		body, final, err := m.fetchRaw(ctx, u)
+		if err == nil {
+			var r search.Result
+			if r, err = parseCSharpPage(string(body), final); err == nil {
+				results = append(results, r)
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		log.Printf("[docs] C# page not fetched: %v", err)
+		lastErr = err
+	}
+	if len(results) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no C# docs could be fetched: %w", lastErr)
+	}
+	return results, nil
+}
+
+// reArticle is the article of a learn.microsoft.com page, without the
+// site's navigation around it.
+var reArticle = regexp.MustCompile(`(?is)<main\b[^>]*>(.*)</main>`)
+
+// parseCSharpPage turns a downloaded C# docs page into a result.
+func parseCSharpPage(html, pageURL string) (search.Result, error) {
+	title := extractTitle(html)
+	if m := reArticle.FindStringSubmatch(html); m != nil {
+		html = m[1]
+	}
+	r, err := parsePage(html, pageURL)
+	if err != nil {
+		return r, err
+	}
+	r.Title = strings.TrimSuffix(strings.TrimSuffix(title, " | Microsoft Learn"), " - C#")
+	r.Source = CSharpSource
+	r.Label = "C# docs (Microsoft Learn)"
+	return r, nil
+}
+
+// isCSharpPage reports whether u is a page of the C# docs.
+func isCSharpPage(u string) bool {
+	return strings.HasPrefix(u, csharpHost)
+}
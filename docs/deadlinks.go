@@ -0,0 +1,162 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ── Dead links ────────────────────────────────────────────────────────────────
+// Pages drop out of the docs (an API removed in Unity 6, a Manual page
+// merged into another) while the index still holds them, and answers kept
+// linking to a 404. CheckLinks asks the site about indexed pages with HEAD
+// requests and remembers the ones it no longer has, in
+// cache/dead_links.json, with the page that replaces each if there is one.
+// LiveLink tells the UI what to link to instead: the replacement, or
+// nothing. A dead page that comes back is forgotten the next time it's
+// checked.
+
+// DeadLink is an indexed page the site answered 404 or 410 for.
+type DeadLink struct {
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+	Checked     time.Time `json:"checked"`
+	Replacement string    `json:"replacement,omitempty"` // the page to link to instead
+}
+
+// LinkReport is what a CheckLinks run found.
+type LinkReport struct {
+	Checked int        `json:"checked"`
+	Dead    []DeadLink `json:"dead"`
+	Failed  int        `json:"failed"` // couldn't be checked (offline, timeouts)
+}
+
+// deadLinks holds a Manager's dead links, read from disk on first use.
+type deadLinks struct {
+	once  sync.Once
+	mu    sync.Mutex
+	byURL map[string]DeadLink
+}
+
+func (m *Manager) deadLinksPath() string {
+	return filepath.Join(m.cacheDir, "dead_links.json")
+}
+
+func (m *Manager) loadDeadLinks() {
+	m.dead.once.Do(func() {
+		m.dead.byURL = map[string]DeadLink{}
+		if data, err := os.ReadFile(m.deadLinksPath()); err == nil {
+			var links []DeadLink
+			if json.Unmarshal(data, &links) == nil {
+				for _, l := range links {
+					m.dead.byURL[l.URL] = l
+				}
+			}
+		}
+	})
+}
+
+// CheckLinks checks the pages at urls with HEAD requests (GET where the
+// site won't answer HEAD). Those answered 404 or 410 are remembered as dead
+// with the page replace (nil for none) names for them; the rest are
+// forgotten if they were dead before.
+func (m *Manager) CheckLinks(ctx context.Context, urls []string, replace func(deadURL string) string) LinkReport {
+	m.loadDeadLinks()
+	var report LinkReport
+	for _, u := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		status, err := m.linkStatus(ctx, u)
+		if err != nil {
+			report.Failed++
+			continue
+		}
+		report.Checked++
+		m.dead.mu.Lock()
+		if status == http.StatusNotFound || status == http.StatusGone {
+			l := DeadLink{URL: u, Status: status, Checked: time.Now()}
+			if replace != nil {
+				l.Replacement = replace(u)
+			}
+			m.dead.byURL[u] = l
+			report.Dead = append(report.Dead, l)
+		} else {
+			delete(m.dead.byURL, u)
+		}
+		m.dead.mu.Unlock()
+	}
+	m.saveDeadLinks()
+	if len(report.Dead) > 0 {
+		log.Printf("[docs] %d of %d checked pages are gone", len(report.Dead), report.Checked)
+	}
+	return report
+}
+
+// linkStatus is the status the site answers for u, after redirects.
+func (m *Manager) linkStatus(ctx context.Context, u string) (int, error) {
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := m.do(req)
+		if err != nil {
+			return 0, err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		status = resp.StatusCode
+		// Some servers refuse HEAD rather than answer it
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented && status != http.StatusForbidden {
+			break
+		}
+	}
+	return status, nil
+}
+
+// LiveLink returns what to link to for the page at u: u itself, or the page
+// that replaced it if it's dead; ok=false if it's dead with no replacement.
+func (m *Manager) LiveLink(u string) (string, bool) {
+	m.loadDeadLinks()
+	m.dead.mu.Lock()
+	defer m.dead.mu.Unlock()
+	l, dead := m.dead.byURL[u]
+	if !dead {
+		return u, true
+	}
+	return l.Replacement, l.Replacement != ""
+}
+
+// DeadLinks returns the pages known to be dead, by URL.
+func (m *Manager) DeadLinks() []DeadLink {
+	m.loadDeadLinks()
+	m.dead.mu.Lock()
+	defer m.dead.mu.Unlock()
+	links := make([]DeadLink, 0, len(m.dead.byURL))
+	for _, l := range m.dead.byURL {
+		links = append(links, l)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].URL < links[j].URL })
+	return links
+}
+
+func (m *Manager) saveDeadLinks() {
+	data, err := json.Marshal(m.DeadLinks())
+	if err != nil {
+		return
+	}
+	os.MkdirAll(m.cacheDir, 0755)
+	tmp := m.deadLinksPath() + ".tmp"
+	if os.WriteFile(tmp, data, 0644) == nil {
+		os.Rename(tmp, m.deadLinksPath())
+	}
+}
@@ -0,0 +1,146 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Unity Discussions ─────────────────────────────────────────────────────────
+// Many real errors ("NullReferenceException in OnTriggerEnter2D", a build
+// that fails on Android) aren't answered anywhere in the docs, only in a
+// forum thread someone solved. SearchForum looks for solved threads on Unity
+// Discussions (a Discourse forum, so its JSON API) and turns each into a
+// result holding the question and its accepted answer, credited to whoever
+// wrote the answer.
+
+const (
+	// ForumSource marks results from Unity Discussions threads.
+	ForumSource = "forum"
+	// forumHost is where Unity Discussions is.
+	forumHost = "https://discussions.unity.com"
+	// maxForumThreads is how many threads one search reads.
+	maxForumThreads = 3
+	// maxForumBytes caps the size of a forum response read.
+	maxForumBytes = 2 << 20
+)
+
+type forumSearch struct {
+	Topics []struct {
+		ID                int    `json:"id"`
+		Slug              string `json:"slug"`
+		Title             string `json:"title"`
+		HasAcceptedAnswer bool   `json:"has_accepted_answer"`
+	} `json:"topics"`
+}
+
+type forumTopic struct {
+	ID         int    `json:"id"`
+	Slug       string `json:"slug"`
+	Title      string `json:"title"`
+	PostStream struct {
+		Posts []forumPost `json:"posts"`
+	} `json:"post_stream"`
+	AcceptedAnswer *struct {
+		PostNumber int `json:"post_number"`
+	} `json:"accepted_answer"`
+}
+
+type forumPost struct {
+	PostNumber     int    `json:"post_number"`
+	Username       string `json:"username"`
+	Cooked         string `json:"cooked"` // the post as HTML
+	AcceptedAnswer bool   `json:"accepted_answer"`
+}
+
+// SearchForum returns up to three solved Unity Discussions threads matching
+// query, each as the question and its accepted answer. Threads without an
+// accepted answer are skipped; so are ones that fail to load once ctx is
+// done, in which case what was read so far is returned.
+func (m *Manager) SearchForum(ctx context.Context, query string) ([]search.Result, error) {
+	var found forumSearch
+	if err := m.forumJSON(ctx, forumHost+"/search.json?q="+url.QueryEscape(query+" status:solved"), &found); err != nil {
+		return nil, err
+	}
+	var results []search.Result
+	for _, t := range found.Topics {
+		if len(results) >= maxForumThreads || ctx.Err() != nil {
+			break
+		}
+		if !t.HasAcceptedAnswer {
+			continue
+		}
+		var topic forumTopic
+		if err := m.forumJSON(ctx, fmt.Sprintf("%s/t/%d.json", forumHost, t.ID), &topic); err != nil {
+			continue
+		}
+		if r, ok := forumResult(topic); ok {
+			results = append(results, r)
+		}
+	}
+	if len(results) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no solved threads for: %s", query)
+	}
+	return results, nil
+}
+
+// forumJSON fetches a Unity Discussions API URL into v.
+func (m *Manager) forumJSON(ctx context.Context, u string, v any) error {
+	resp, err := m.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, u)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxForumBytes))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// forumResult turns a thread into a result: the question, then the
+// accepted answer with who wrote it. ok=false if the thread has no accepted
+// answer among the posts it came with.
+func forumResult(t forumTopic) (search.Result, bool) {
+	var question, answer *forumPost
+	for i := range t.PostStream.Posts {
+		p := &t.PostStream.Posts[i]
+		switch {
+		case p.PostNumber == 1:
+			question = p
+		case p.AcceptedAnswer || (t.AcceptedAnswer != nil && p.PostNumber == t.AcceptedAnswer.PostNumber):
+			answer = p
+		}
+	}
+	if question == nil || answer == nil {
+		return search.Result{}, false
+	}
+	text := cleanContent(stripHTML(question.Cooked))
+	if len(text) > 2000 {
+		text = text[:2000]
+	}
+	content := "Question: " + text + "\n\nAccepted answer by @" + answer.Username + ": " + cleanContent(stripHTML(answer.Cooked))
+	if len(content) > 10000 {
+		content = content[:10000]
+	}
+	return search.Result{
+		Title:   t.Title,
+		URL:     fmt.Sprintf("%s/t/%s/%d/%d", forumHost, t.Slug, t.ID, answer.PostNumber),
+		Excerpt: content,
+		Score:   1.0,
+		Source:  ForumSource,
+		Code:    strings.TrimSpace(extractCode(answer.Cooked)),
+		Label:   "Unity Discussions, answer by @" + answer.Username,
+	}, true
+}
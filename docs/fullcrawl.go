@@ -0,0 +1,212 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Crawling the online docs ──────────────────────────────────────────────────
+// Without the offline ZIP (a locked-down network, a metered connection that
+// can't take 300 MB at once) the live pages were all there was: the core
+// pages and whatever questions happened to route to. CrawlDocs builds a full
+// online index instead. It starts from the tables of contents of the Manual
+// and the Scripting Reference, every page either lists, and follows their
+// links a few hops further (a class's page links its members), within a
+// page budget and as politely as polite.go has every bulk fetch go.
+
+const (
+	// CrawlSource marks pages from CrawlDocs, so live page pruning leaves
+	// them alone.
+	CrawlSource = "crawl"
+	// DefaultCrawlPages is the page budget when none is set.
+	DefaultCrawlPages = 5000
+	// DefaultCrawlDepth is how many links away from a contents page a crawl
+	// goes when none is set: a member page is one hop from its class.
+	DefaultCrawlDepth = 1
+	// crawlBatch is how many pages are handed on at a time.
+	crawlBatch = 50
+)
+
+// docsHost is where the online docs are.
+const docsHost = "https://docs.unity3d.com"
+
+// tocSections are the parts of the docs a crawl starts from.
+var tocSections = []string{"Manual", "ScriptReference"}
+
+// DocsCrawl is how far CrawlDocs goes; zero fields take the defaults.
+type DocsCrawl struct {
+	MaxPages int           `json:"max_pages"`
+	MaxDepth int           `json:"max_depth"` // links followed from a contents page, -1 for none
+	Delay    time.Duration `json:"-"`         // pause between fetches, 0 for the politeness delay (see polite.go)
+	// Pages, if set, are fetched instead of the contents pages' and no links
+	// are followed from them: a topic from the sitemap, see SitemapPages
+	Pages []string `json:"-"`
+}
+
+// CrawlProgress is how far a crawl has got.
+type CrawlProgress struct {
+	Fetched int    `json:"fetched"` // pages indexed
+	Failed  int    `json:"failed"`  // pages that couldn't be fetched or had no text
+	Queued  int    `json:"queued"`  // pages found and not fetched yet
+	Budget  int    `json:"budget"`
+	Current string `json:"current,omitempty"`
+}
+
+// tocNode is an entry in a docs section's docdata/toc.json.
+type tocNode struct {
+	Link     string    `json:"link"`
+	Title    string    `json:"title"`
+	Children []tocNode `json:"children"`
+}
+
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// CrawlDocs crawls the online Manual and Scripting Reference as opts allow,
+// handing the pages to emit in batches and reporting progress after each
+// fetch. It stops at the page budget or once ctx is done, returning ctx's
+// error in that case; either way what was emitted stays emitted.
+func (m *Manager) CrawlDocs(ctx context.Context, opts DocsCrawl, emit func([]search.Result), progress func(CrawlProgress)) (CrawlProgress, error) {
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultCrawlPages
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultCrawlDepth
+	}
+	p := CrawlProgress{Budget: opts.MaxPages}
+
+	var queue []crawlItem
+	seen := map[string]bool{}
+	enqueue := func(u string, depth int) {
+		if !seen[u] {
+			seen[u] = true
+			queue = append(queue, crawlItem{u, depth})
+		}
+	}
+	for _, u := range opts.Pages {
+		enqueue(u, 0)
+	}
+	if len(opts.Pages) > 0 {
+		opts.MaxDepth = 0
+	}
+	for _, section := range tocSections {
+		if len(opts.Pages) > 0 {
+			break
+		}
+		pages, err := m.tocPages(ctx, section)
+		if err != nil {
+			// No contents to start from: follow the links of the section's front page
+			log.Printf("[docs] No %s contents (%v), crawling from its index page", section, err)
+			enqueue(docsHost+"/"+section+"/index.html", 0)
+			continue
+		}
+		for _, u := range pages {
+			enqueue(u, 0)
+		}
+	}
+	if len(queue) == 0 {
+		return p, fmt.Errorf("nothing to crawl")
+	}
+
+	var batch []search.Result
+	for len(queue) > 0 && p.Fetched+p.Failed < opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+		item := queue[0]
+		queue = queue[1:]
+		p.Current, p.Queued = item.url, len(queue)
+		body, final, err := m.fetchRaw(ctx, m.pinned(item.url))
+		if err == nil {
+			var r search.Result
+			if r, err = parsePage(string(body), canonicalPage(string(body), final)); err == nil {
+				r.Source = CrawlSource
+				batch = append(batch, r)
+				p.Fetched++
+			}
+		}
+		if err != nil {
+			p.Failed++
+		}
+		if err == nil && len(opts.Pages) == 0 && (opts.MaxDepth < 0 || item.depth < opts.MaxDepth) {
+			for _, link := range crawlLinks(string(body), final, strings.TrimPrefix(docsHost, "https://")+"/") {
+				// A versioned link is the same page: /6000.0/Documentation/Manual/X.html
+				if canon, _ := search.CanonicalURL(link); isDocsPage(canon) {
+					enqueue(canon, item.depth+1)
+				}
+			}
+		}
+		if len(batch) >= crawlBatch {
+			emit(batch)
+			batch = nil
+		}
+		p.Queued = len(queue)
+		if progress != nil {
+			progress(p)
+		}
+		m.pause(ctx, "docs.unity3d.com", opts.Delay)
+	}
+	if len(batch) > 0 {
+		emit(batch)
+	}
+	p.Current = ""
+	return p, ctx.Err()
+}
+
+// tocPages returns every page a docs section's table of contents lists.
+func (m *Manager) tocPages(ctx context.Context, section string) ([]string, error) {
+	resp, err := m.get(ctx, m.pinned(docsHost+"/"+section+"/docdata/toc.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := m.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var root tocNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("bad contents: %w", err)
+	}
+	var pages []string
+	var walk func(n tocNode)
+	walk = func(n tocNode) {
+		if link := strings.TrimSuffix(n.Link, ".html"); link != "" && link != "null" {
+			pages = append(pages, docsHost+"/"+section+"/"+link+".html")
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("empty contents")
+	}
+	return pages, nil
+}
+
+// isDocsPage reports whether a link is a Manual or Scripting Reference
+// page, rather than a search, a download or another part of the site.
+func isDocsPage(link string) bool {
+	rest := strings.TrimPrefix(link, docsHost+"/")
+	if rest == link || strings.Contains(rest, "?") {
+		return false
+	}
+	for _, section := range tocSections {
+		if page, ok := strings.CutPrefix(rest, section+"/"); ok {
+			return strings.HasSuffix(page, ".html") && !strings.Contains(page, "/")
+		}
+	}
+	return false
+}
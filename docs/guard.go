@@ -0,0 +1,90 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ── Download guards ───────────────────────────────────────────────────────────
+// A route, a link or a redirect that led to an installer or a video had
+// fetchPage read all of it into memory, and the chat waited while it did.
+// Every response body is now read through readBody, which gives up on one
+// bigger than the size limit (as soon as its Content-Length says so, or
+// once that many bytes have come), pages are only read when they say
+// they're HTML or text (checkPage), and every request has its own timeout,
+// from sending it to its last byte, however long its caller would wait.
+
+var (
+	// ErrTooLarge is returned for a response bigger than Limits.MaxBytes.
+	ErrTooLarge = errors.New("response too large")
+	// ErrNotPage is returned for a page that turned out not to be one:
+	// an image, an archive, a binary.
+	ErrNotPage = errors.New("not a web page")
+)
+
+// Limits bound what one request may cost; zero fields take the defaults.
+type Limits struct {
+	MaxBytes int64         `json:"max_bytes"` // most bytes read from one response
+	Timeout  time.Duration `json:"-"`         // longest one request may take, body included
+}
+
+// Limits defaults.
+const (
+	DefaultMaxBytes     = 5 << 20
+	DefaultFetchTimeout = 12 * time.Second
+)
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = DefaultMaxBytes
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultFetchTimeout
+	}
+	return l
+}
+
+// SetLimits replaces the download limits. Requests already sent keep the
+// timeout they started with.
+func (m *Manager) SetLimits(l Limits) {
+	l = l.withDefaults()
+	m.limits.Store(&l)
+}
+
+// Limits returns the download limits in use.
+func (m *Manager) Limits() Limits {
+	if l := m.limits.Load(); l != nil {
+		return *l
+	}
+	return Limits{}.withDefaults()
+}
+
+// readBody reads resp's body, failing with ErrTooLarge past MaxBytes.
+func (m *Manager) readBody(resp *http.Response) ([]byte, error) {
+	limit := m.Limits().MaxBytes
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w (%d MB): %s", ErrTooLarge, resp.ContentLength>>20, resp.Request.URL)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w (over %d MB): %s", ErrTooLarge, limit>>20, resp.Request.URL)
+	}
+	return data, nil
+}
+
+// checkPage fails with ErrNotPage unless resp says it's HTML or text (or
+// says nothing).
+func checkPage(resp *http.Response) error {
+	ct := resp.Header.Get("Content-Type")
+	if ct != "" && !strings.Contains(ct, "html") && !strings.HasPrefix(ct, "text/") {
+		return fmt.Errorf("%w (%s): %s", ErrNotPage, ct, resp.Request.URL)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package docs
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// ── Request headers ───────────────────────────────────────────────────────────
+// Go's default User-Agent ("Go-http-client/1.1") is turned away by some
+// corporate gateways, and a mirror of the docs behind one may want an auth
+// header. Every request the Manager sends (pages, robots.txt, the forum and
+// Stack Exchange APIs, proxied pages) goes through headerTransport, which
+// sets the configured User-Agent and extra headers on it; an extra header
+// can be kept to one host so a token for a mirror isn't sent anywhere else.
+
+// DefaultUserAgent is sent when no User-Agent is set. It starts with
+// robotsAgent, so robots.txt rules for unitymind are the ones that apply.
+const DefaultUserAgent = robotsAgent + "/1.0 (Unity docs assistant)"
+
+// Header is an extra header sent with requests, to Host only if it's set.
+type Header struct {
+	Host  string `json:"host,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseHeader reads a header from a settings line: "Name: value" for every
+// host, or "host Name: value" for requests to host only.
+func ParseHeader(line string) (Header, error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return Header{}, fmt.Errorf("header %q has no ':'", line)
+	}
+	var h Header
+	if f := strings.Fields(name); len(f) == 2 {
+		h.Host, h.Name = strings.ToLower(f[0]), f[1]
+	} else if len(f) == 1 {
+		h.Name = f[0]
+	} else {
+		return Header{}, fmt.Errorf("header %q: want \"Name: value\" or \"host Name: value\"", line)
+	}
+	if !validHeaderName(h.Name) {
+		return Header{}, fmt.Errorf("header %q: bad name %q", line, h.Name)
+	}
+	h.Name, h.Value = textproto.CanonicalMIMEHeaderKey(h.Name), strings.TrimSpace(value)
+	return h, nil
+}
+
+// String is h as ParseHeader reads it.
+func (h Header) String() string {
+	if h.Host != "" {
+		return h.Host + " " + h.Name + ": " + h.Value
+	}
+	return h.Name + ": " + h.Value
+}
+
+func validHeaderName(name string) bool {
+	for _, c := range name {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// requestHeaders are the headers set on every request.
+type requestHeaders struct {
+	userAgent string
+	extra     []Header
+}
+
+// SetHeaders sets the User-Agent sent with every request ("" for
+// DefaultUserAgent) and the extra headers.
+func (m *Manager) SetHeaders(userAgent string, extra []Header) {
+	if strings.TrimSpace(userAgent) == "" {
+		userAgent = DefaultUserAgent
+	}
+	m.headers.Store(&requestHeaders{userAgent: strings.TrimSpace(userAgent), extra: append([]Header(nil), extra...)})
+}
+
+// UserAgent returns the User-Agent sent with requests.
+func (m *Manager) UserAgent() string {
+	if h := m.headers.Load(); h != nil {
+		return h.userAgent
+	}
+	return DefaultUserAgent
+}
+
+// headerTransport sets a Manager's headers, and the Accept-Language of
+// its language (see language.go), on each request, redirects included,
+// before base sends it.
+type headerTransport struct {
+	base http.RoundTripper
+	m    *Manager
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := t.m.headers.Load()
+	req = req.Clone(req.Context()) // a RoundTripper mustn't change the request it's given
+	if accept, ok := acceptLanguages[t.m.Language()]; ok {
+		req.Header.Set("Accept-Language", accept)
+	}
+	if h == nil {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	} else {
+		req.Header.Set("User-Agent", h.userAgent)
+		for _, x := range h.extra {
+			if x.Host == "" || strings.EqualFold(x.Host, req.URL.Hostname()) {
+				req.Header.Set(x.Name, x.Value)
+			}
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,68 @@
+package docs
+
+import (
+	"strings"
+
+	"unitymind/offline"
+)
+
+// ── Documentation language ────────────────────────────────────────────────────
+// Unity translates the Manual and Scripting Reference into Japanese, Korean,
+// Chinese and Spanish (docs.unity3d.com/ja/current/Manual/..., or
+// /ja/2022.3/Manual/... for a version), but live pages were always fetched
+// in English, so a user who asked for Japanese docs got Japanese offline
+// pages and English live ones. With a language set, fetchPage tries the
+// page's translation first and falls back to English for a page that isn't
+// translated, and every request says which language is preferred.
+
+// acceptLanguages is the Accept-Language header sent for each language.
+var acceptLanguages = map[string]string{
+	"ja": "ja,en;q=0.5",
+	"ko": "ko,en;q=0.5",
+	"zh": "zh-CN,zh;q=0.9,en;q=0.5",
+	"es": "es,en;q=0.5",
+}
+
+// SetLanguage makes live pages be fetched in lang (see offline.Languages)
+// where they're translated; "" or "en" fetches them in English.
+func (m *Manager) SetLanguage(lang string) {
+	if _, ok := acceptLanguages[lang]; !ok {
+		lang = ""
+	}
+	m.language.Store(lang)
+}
+
+// Language is the language live pages are fetched in, "" for English.
+func (m *Manager) Language() string {
+	lang, _ := m.language.Load().(string)
+	return lang
+}
+
+// localized points a docs.unity3d.com Manual or Scripting Reference URL at
+// its translation into the language set, at the pinned version if there is
+// one; any other URL, or any URL with no language set, is returned as is.
+func (m *Manager) localized(u string) string {
+	lang := m.Language()
+	rest, ok := strings.CutPrefix(u, docsHost+"/")
+	if lang == "" || !ok || (!strings.HasPrefix(rest, "Manual/") && !strings.HasPrefix(rest, "ScriptReference/")) {
+		return u
+	}
+	loc := offline.LocalizedURL(u, lang)
+	if v := m.Version(); v != "" {
+		loc = strings.Replace(loc, "/current/", "/"+v+"/", 1)
+	}
+	return loc
+}
+
+// pageLanguage is the language of a page downloaded from pageURL, "" for
+// English, as the index tags it (search.Result.Lang).
+func pageLanguage(pageURL, html string) string {
+	path := pageURL
+	if _, rest, ok := strings.Cut(pageURL, "://"); ok {
+		_, path, _ = strings.Cut(rest, "/")
+	}
+	if lang := offline.DetectLanguage(path, html); lang != "en" {
+		return lang
+	}
+	return ""
+}
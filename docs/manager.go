@@ -1,51 +1,127 @@
 package docs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
+	"sync/atomic"
 
+	"unitymind/offline"
 	"unitymind/search"
 )
 
-// DocLink is a title+URL pair returned to the UI
+// DocLink is a title+URL pair returned to the UI, with what it needs to
+// group and label the links: "Manual · Physics 2D", "ScriptReference · 2022.3".
 type DocLink struct {
-	Title string `json:"title"`
-	URL   string `json:"url"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Label      string   `json:"label,omitempty"`      // doc source the page came from, see search.Result.Label
+	Topic      string   `json:"topic,omitempty"`      // area of the docs it's filed under, see search.Topic
+	Section    string   `json:"section,omitempty"`    // part of the docs, see Section
+	Breadcrumb []string `json:"breadcrumb,omitempty"` // where the page sits in the docs, see search.Trail
+	Source     string   `json:"source,omitempty"`     // offline, live, forum..., see search.Result.Source
+	Version    string   `json:"version,omitempty"`    // Unity docs version linked to, "" for latest
+	Score      float64  `json:"score,omitempty"`      // relevance of the hit, 0 for related pages
+	Related    bool     `json:"related,omitempty"`    // named as related by a hit rather than a hit itself
+}
+
+// Section is the part of the docs the page at u belongs to: "Manual",
+// "ScriptReference", a package's name ("Packages" for one not in
+// docPackages), or "" for a page of another site.
+func Section(u string) string {
+	canon, _ := search.CanonicalURL(u)
+	if !strings.HasPrefix(canon, docsHost+"/") {
+		return ""
+	}
+	if p, ok := isPackagePage(canon); ok {
+		return p.Name
+	}
+	switch path := canon[len(docsHost):]; {
+	case strings.HasPrefix(path, "/Packages/"):
+		return "Packages"
+	case strings.Contains(path, "/ScriptReference/"):
+		return "ScriptReference"
+	case strings.Contains(path, "/Manual/"):
+		return "Manual"
+	}
+	return ""
 }
 
 // Manager handles fetching Unity documentation
 type Manager struct {
 	cacheDir string
 	client   *http.Client
+	gate     *gate        // robots.txt and rate limits, see polite.go
+	valid    validators   // ETags and Last-Modified dates, see conditional.go
+	version  atomic.Value // Unity docs version pages are fetched for, see SetVersion
+	language atomic.Value // language live pages are fetched in, see language.go
+	routes   routeTable   // keyword routes, see routes.go
+	sitemap  sitemapCache // pages the docs sitemaps list, see sitemap.go
+	recent   recentPages  // live pages read lately, see recent.go
+	symbols  symbolTOC    // Scripting Reference contents, see toc.go
+	packages packageTOCs  // package manual contents, see packages.go
+	dead     deadLinks    // indexed pages the site no longer has, see deadlinks.go
+	// Stack Exchange API backoff deadline (UnixNano), see stackoverflow.go
+	stackBackoff atomic.Int64
+	// User-Agent and extra headers sent with every request, see headers.go
+	headers atomic.Pointer[requestHeaders]
+	// Size and time limits of one request, see guard.go
+	limits atomic.Pointer[Limits]
 }
 
 func NewManager(cacheDir string) *Manager {
-	return &Manager{
+	m := &Manager{
 		cacheDir: cacheDir,
-		client:   &http.Client{Timeout: 12 * time.Second},
+		gate:     newGate(),
 	}
+	// Requests time out on their own, see guard.go
+	m.client = &http.Client{Transport: headerTransport{base: http.DefaultTransport, m: m}}
+	return m
+}
+
+// SetVersion pins the pages fetched from docs.unity3d.com to a Unity
+// version, e.g. "2022.3": routes, core docs, crawls and proxied pages are
+// fetched from docs.unity3d.com/2022.3/Documentation/..., so their text
+// matches the user's editor. "" fetches the latest docs.
+func (m *Manager) SetVersion(version string) {
+	m.version.Store(version)
+}
+
+// Version is the Unity docs version pages are fetched for, "" for latest.
+func (m *Manager) Version() string {
+	v, _ := m.version.Load().(string)
+	return v
+}
+
+// pinned points a docs.unity3d.com URL at the pinned version.
+func (m *Manager) pinned(u string) string {
+	return search.VersionedURL(u, m.Version())
 }
 
 // ── Keyword → specific doc URL mapping ───────────────────────────────────────
 // Instead of trusting Unity's search page (which returns junk),
 // we map keywords directly to the exact doc pages that answer them.
-// This is the "smart routing" layer.
+// This is the "smart routing" layer. The table below is the default; a
+// routes file replaces it (see routes.go).
 
-type docRoute struct {
-	keywords []string // any of these in the query triggers this route
-	urls     []string // fetch these pages (in order)
+// Route sends questions with any of its keywords to its pages.
+type Route struct {
+	Keywords []string `json:"keywords"` // any of these in the query triggers this route
+	URLs     []string `json:"urls"`     // fetch these pages (in order)
 }
 
-var routes = []docRoute{
+// builtinRoutes is the route table when there's no routes file, see routes.go.
+var builtinRoutes = []Route{
 	// Audio
 	{
-		keywords: []string{"sound", "audio", "music", "audiosource", "audioclip", "play sound", "sfx", "sound effect", "background music"},
-		urls: []string{
+		Keywords: []string{"sound", "audio", "music", "audiosource", "audioclip", "play sound", "sfx", "sound effect", "background music"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/AudioOverview.html",
 			"https://docs.unity3d.com/ScriptReference/AudioSource.html",
 			"https://docs.unity3d.com/ScriptReference/AudioSource.PlayOneShot.html",
@@ -53,8 +129,8 @@ var routes = []docRoute{
 	},
 	// Movement / Rigidbody 2D
 	{
-		keywords: []string{"rigidbody2d", "move 2d", "movement 2d", "2d movement", "2d player", "player 2d", "platformer"},
-		urls: []string{
+		Keywords: []string{"rigidbody2d", "move 2d", "movement 2d", "2d movement", "2d player", "player 2d", "platformer"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/RigidbodiesOverview.html",
 			"https://docs.unity3d.com/ScriptReference/Rigidbody2D.html",
 			"https://docs.unity3d.com/ScriptReference/Rigidbody2D.MovePosition.html",
@@ -62,8 +138,8 @@ var routes = []docRoute{
 	},
 	// Movement / Rigidbody 3D
 	{
-		keywords: []string{"rigidbody", "move 3d", "movement 3d", "3d movement", "physics movement", "addforce"},
-		urls: []string{
+		Keywords: []string{"rigidbody", "move 3d", "movement 3d", "3d movement", "physics movement", "addforce"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/RigidbodiesOverview.html",
 			"https://docs.unity3d.com/ScriptReference/Rigidbody.html",
 			"https://docs.unity3d.com/ScriptReference/Rigidbody.AddForce.html",
@@ -71,16 +147,16 @@ var routes = []docRoute{
 	},
 	// Transform movement
 	{
-		keywords: []string{"transform move", "translate", "move gameobject", "move object", "move player"},
-		urls: []string{
+		Keywords: []string{"transform move", "translate", "move gameobject", "move object", "move player"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Transform.html",
 			"https://docs.unity3d.com/ScriptReference/Transform.Translate.html",
 		},
 	},
 	// Collision 2D
 	{
-		keywords: []string{"collision 2d", "collider 2d", "oncollisionenter2d", "ontriggerenter2d", "trigger 2d"},
-		urls: []string{
+		Keywords: []string{"collision 2d", "collider 2d", "oncollisionenter2d", "ontriggerenter2d", "trigger 2d"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/CollidersOverview.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.OnCollisionEnter2D.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.OnTriggerEnter2D.html",
@@ -88,8 +164,8 @@ var routes = []docRoute{
 	},
 	// Collision 3D
 	{
-		keywords: []string{"collision", "collider", "oncollisionenter", "ontriggerenter", "trigger", "detect collision"},
-		urls: []string{
+		Keywords: []string{"collision", "collider", "oncollisionenter", "ontriggerenter", "trigger", "detect collision"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/CollidersOverview.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.OnCollisionEnter.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.OnTriggerEnter.html",
@@ -97,8 +173,8 @@ var routes = []docRoute{
 	},
 	// Coroutines
 	{
-		keywords: []string{"coroutine", "waitforseconds", "ienumerator", "startcoroutine", "delay", "wait seconds"},
-		urls: []string{
+		Keywords: []string{"coroutine", "waitforseconds", "ienumerator", "startcoroutine", "delay", "wait seconds"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Coroutines.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.StartCoroutine.html",
 			"https://docs.unity3d.com/ScriptReference/WaitForSeconds.html",
@@ -106,8 +182,8 @@ var routes = []docRoute{
 	},
 	// Animation
 	{
-		keywords: []string{"animator", "animation", "animat", "state machine", "blend tree", "settrigger", "setbool"},
-		urls: []string{
+		Keywords: []string{"animator", "animation", "animat", "state machine", "blend tree", "settrigger", "setbool"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/AnimatorControllers.html",
 			"https://docs.unity3d.com/ScriptReference/Animator.html",
 			"https://docs.unity3d.com/ScriptReference/Animator.SetTrigger.html",
@@ -115,8 +191,8 @@ var routes = []docRoute{
 	},
 	// Scene loading
 	{
-		keywords: []string{"load scene", "loadscene", "scenemanager", "change scene", "next scene", "scene transition"},
-		urls: []string{
+		Keywords: []string{"load scene", "loadscene", "scenemanager", "change scene", "next scene", "scene transition"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/MultiSceneEditing.html",
 			"https://docs.unity3d.com/ScriptReference/SceneManagement.SceneManager.html",
 			"https://docs.unity3d.com/ScriptReference/SceneManagement.SceneManager.LoadScene.html",
@@ -124,16 +200,34 @@ var routes = []docRoute{
 	},
 	// Prefabs & Instantiate
 	{
-		keywords: []string{"prefab", "instantiate", "spawn", "create object"},
-		urls: []string{
+		Keywords: []string{"prefab", "instantiate", "spawn", "create object"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Prefabs.html",
 			"https://docs.unity3d.com/ScriptReference/Object.Instantiate.html",
 		},
 	},
+	// Input System: rebinding / control schemes / local multiplayer
+	{
+		Keywords: []string{"rebind", "remap", "key binding", "performinteractiverebinding", "control scheme", "playerinputmanager", "local multiplayer", "split screen"},
+		URLs: []string{
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/ActionBindings.html",
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/PlayerInputManager.html",
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/PlayerInput.html",
+		},
+	},
+	// Input System package
+	{
+		Keywords: []string{"input system", "inputsystem", "inputaction", "input action", "playerinput", "new input"},
+		URLs: []string{
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/QuickStartGuide.html",
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/Actions.html",
+			"https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/PlayerInput.html",
+		},
+	},
 	// Input
 	{
-		keywords: []string{"input", "keyboard", "mouse", "getkey", "getaxis", "button press", "input system"},
-		urls: []string{
+		Keywords: []string{"input", "keyboard", "mouse", "getkey", "getaxis", "button press"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Input.html",
 			"https://docs.unity3d.com/ScriptReference/Input.html",
 			"https://docs.unity3d.com/ScriptReference/Input.GetAxis.html",
@@ -141,165 +235,251 @@ var routes = []docRoute{
 	},
 	// UI / Canvas
 	{
-		keywords: []string{"ui", "canvas", "button", "text", "slider", "image", "ugui", "ui element"},
-		urls: []string{
+		Keywords: []string{"ui", "canvas", "button", "text", "slider", "image", "ugui", "ui element"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/UISystem.html",
 			"https://docs.unity3d.com/ScriptReference/UI.Button.html",
 		},
 	},
 	// Camera
 	{
-		keywords: []string{"camera", "main camera", "follow camera", "cinemachine"},
-		urls: []string{
+		Keywords: []string{"camera", "main camera", "follow camera"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/CamerasOverview.html",
 			"https://docs.unity3d.com/ScriptReference/Camera.html",
 		},
 	},
+	// Cinemachine package
+	{
+		Keywords: []string{"cinemachine", "virtual camera", "vcam", "freelook", "free look camera"},
+		URLs: []string{
+			"https://docs.unity3d.com/Packages/com.unity.cinemachine@2.10/manual/CinemachineOverview.html",
+			"https://docs.unity3d.com/Packages/com.unity.cinemachine@2.10/manual/CinemachineVirtualCamera.html",
+			"https://docs.unity3d.com/Packages/com.unity.cinemachine@2.10/manual/CinemachineFreeLook.html",
+		},
+	},
+	// Netcode for GameObjects package
+	{
+		Keywords: []string{"netcode", "networkobject", "networkbehaviour", "networkmanager", "networkvariable", "serverrpc", "clientrpc", "multiplayer", "rpc"},
+		URLs: []string{
+			"https://docs.unity3d.com/Packages/com.unity.netcode.gameobjects@1.8/manual/index.html",
+			"https://docs.unity3d.com/Packages/com.unity.netcode.gameobjects@1.8/manual/basics/networkobject.html",
+			"https://docs.unity3d.com/Packages/com.unity.netcode.gameobjects@1.8/manual/advanced-topics/message-system/rpc.html",
+		},
+	},
+	// Addressables package
+	{
+		Keywords: []string{"addressable", "addressables", "loadassetasync", "assetreference", "remote catalog", "asset bundle", "assetbundle"},
+		URLs: []string{
+			"https://docs.unity3d.com/Packages/com.unity.addressables@1.21/manual/index.html",
+			"https://docs.unity3d.com/Packages/com.unity.addressables@1.21/manual/LoadingAddressableAssets.html",
+			"https://docs.unity3d.com/Packages/com.unity.addressables@1.21/manual/AddressableAssetsAsyncOperationHandle.html",
+		},
+	},
 	// NavMesh / AI
 	{
-		keywords: []string{"navmesh", "pathfinding", "ai", "navmeshagent", "navigation", "enemy follow"},
-		urls: []string{
+		Keywords: []string{"navmesh", "pathfinding", "ai", "navmeshagent", "navigation", "enemy follow"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Navigation.html",
 			"https://docs.unity3d.com/ScriptReference/AI.NavMeshAgent.html",
 		},
 	},
 	// Raycasting
 	{
-		keywords: []string{"raycast", "ray", "linecast", "physics.raycast", "shooting", "hit detection"},
-		urls: []string{
+		Keywords: []string{"raycast", "ray", "linecast", "physics.raycast", "shooting", "hit detection"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Physics.Raycast.html",
 			"https://docs.unity3d.com/ScriptReference/Physics2D.Raycast.html",
 		},
 	},
 	// Saving / PlayerPrefs
 	{
-		keywords: []string{"save", "load", "playerprefs", "persist", "store data", "high score", "settings save"},
-		urls: []string{
+		Keywords: []string{"save", "load", "playerprefs", "persist", "store data", "high score", "settings save"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/PlayerPrefs.html",
 			"https://docs.unity3d.com/ScriptReference/JsonUtility.html",
 		},
 	},
 	// Destroy
 	{
-		keywords: []string{"destroy", "delete object", "remove object", "despawn"},
-		urls: []string{
+		Keywords: []string{"destroy", "delete object", "remove object", "despawn"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Object.Destroy.html",
 		},
 	},
 	// Object pooling
 	{
-		keywords: []string{"object pool", "pooling", "pool"},
-		urls: []string{
+		Keywords: []string{"object pool", "pooling", "pool"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Pool.ObjectPool_1.html",
 		},
 	},
 	// Lighting
 	{
-		keywords: []string{"light", "lighting", "bake", "shadow", "global illumination"},
-		urls: []string{
+		Keywords: []string{"light", "lighting", "bake", "shadow", "global illumination"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/LightingInUnity.html",
 			"https://docs.unity3d.com/ScriptReference/Light.html",
 		},
 	},
 	// Sprites / 2D
 	{
-		keywords: []string{"sprite", "spriterenderer", "sprite sheet", "2d art"},
-		urls: []string{
+		Keywords: []string{"sprite", "spriterenderer", "sprite sheet", "2d art"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Sprites.html",
 			"https://docs.unity3d.com/ScriptReference/SpriteRenderer.html",
 		},
 	},
 	// Tilemap
 	{
-		keywords: []string{"tilemap", "tile", "tilelayer"},
-		urls: []string{
+		Keywords: []string{"tilemap", "tile", "tilelayer"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Tilemap.html",
 			"https://docs.unity3d.com/ScriptReference/Tilemaps.Tilemap.html",
 		},
 	},
 	// ScriptableObject
 	{
-		keywords: []string{"scriptableobject", "scriptable object", "data container", "so asset"},
-		urls: []string{
+		Keywords: []string{"scriptableobject", "scriptable object", "data container", "so asset"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/class-ScriptableObject.html",
 			"https://docs.unity3d.com/ScriptReference/ScriptableObject.html",
 		},
 	},
 	// Time / deltaTime
 	{
-		keywords: []string{"time.deltatime", "deltatime", "framerate", "fps independent", "time scale"},
-		urls: []string{
+		Keywords: []string{"time.deltatime", "deltatime", "framerate", "fps independent", "time scale"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Time.html",
 		},
 	},
 	// Update / FixedUpdate
 	{
-		keywords: []string{"update vs fixedupdate", "fixedupdate", "lateupdate", "monobehaviour lifecycle", "execution order"},
-		urls: []string{
+		Keywords: []string{"update vs fixedupdate", "fixedupdate", "lateupdate", "monobehaviour lifecycle", "execution order"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/ExecutionOrder.html",
 			"https://docs.unity3d.com/ScriptReference/MonoBehaviour.FixedUpdate.html",
 		},
 	},
 	// Tags & Layers
 	{
-		keywords: []string{"tag", "layer", "comparetag", "layermask"},
-		urls: []string{
+		Keywords: []string{"tag", "layer", "comparetag", "layermask"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Tags.html",
 			"https://docs.unity3d.com/ScriptReference/GameObject.CompareTag.html",
 		},
 	},
 	// GetComponent
 	{
-		keywords: []string{"getcomponent", "find component", "access component"},
-		urls: []string{
+		Keywords: []string{"getcomponent", "find component", "access component"},
+		URLs: []string{
 			"https://docs.unity3d.com/ScriptReference/Component.GetComponent.html",
 		},
 	},
 	// Events / Delegates
 	{
-		keywords: []string{"unityevent", "event", "delegate", "action", "callback"},
-		urls: []string{
+		Keywords: []string{"unityevent", "event", "delegate", "action", "callback"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/UnityEvents.html",
 			"https://docs.unity3d.com/ScriptReference/Events.UnityEvent.html",
 		},
 	},
 	// Build
 	{
-		keywords: []string{"build", "publish", "export", "release", "build settings", "platform"},
-		urls: []string{
+		Keywords: []string{"build", "publish", "export", "release", "build settings", "platform"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/BuildSettings.html",
 		},
 	},
+	// Version control / git
+	{
+		Keywords: []string{"git ", "gitignore", "version control", "source control", "smart merge", "unityyamlmerge", "meta file", "git lfs", "merge conflict"},
+		URLs: []string{
+			"https://docs.unity3d.com/Manual/VersionControl.html",
+			"https://docs.unity3d.com/Manual/SmartMerge.html",
+			"https://docs.unity3d.com/Manual/AssetMetadata.html",
+		},
+	},
 	// Shader / Material
 	{
-		keywords: []string{"shader", "material", "shadergraph", "urp shader", "hdrp"},
-		urls: []string{
+		Keywords: []string{"shader", "material", "shadergraph", "urp shader", "hdrp"},
+		URLs: []string{
 			"https://docs.unity3d.com/Manual/Shaders.html",
 			"https://docs.unity3d.com/ScriptReference/Material.html",
 		},
 	},
 }
 
-// routeQuery finds the best matching doc URLs for a query
-func routeQuery(query string) []string {
+// routeMatch is the route a query matched and how sure the match is.
+type routeMatch struct {
+	URLs       []string
+	Confidence float64 // 0 to 1, see routeQuery
+}
+
+// Confidence of the live pages found other ways than the keyword routes,
+// which becomes their results' Score.
+const (
+	symbolConfidence    = 1.0 // an exact API's own page
+	packageConfidence   = 0.8 // a package manual page named like the question
+	searchAPIConfidence = 0.3 // whatever Unity's search page links to
+	// minRouteConfidence is the confidence below which a route match is
+	// only used if neither the NLU's reading of the query nor Unity's
+	// search finds anything better.
+	minRouteConfidence = 0.4
+)
+
+// routeQuery finds the best matching doc URLs for a query, and how
+// confident the match is. A keyword found as whole words counts its word
+// count, one only found inside a longer word ("ui" in "build") half that;
+// the confidence is the score over the score plus one, less for a
+// runner-up route that matched nearly as well. One whole keyword no other
+// route matched gives 0.5, a two-word one 0.67, a tie of one-word ones 0.25.
+func (m *Manager) routeQuery(query string) routeMatch {
 	q := strings.ToLower(query)
-	bestScore := 0
+	var best, second float64
 	var bestURLs []string
 
-	for _, route := range routes {
-		score := 0
-		for _, kw := range route.keywords {
-			if strings.Contains(q, kw) {
-				// Longer keyword match = higher confidence
-				score += len(strings.Fields(kw))
+	for _, route := range m.Routes() {
+		score := 0.0
+		for _, kw := range route.Keywords {
+			if !strings.Contains(q, kw) {
+				continue
+			}
+			// Longer keyword match = higher confidence
+			w := float64(len(strings.Fields(kw)))
+			if !wholeWords(q, kw) {
+				w /= 2
 			}
+			score += w
+		}
+		if score > best {
+			second, best, bestURLs = best, score, route.URLs
+		} else if score > second {
+			second = score
+		}
+	}
+	if best == 0 {
+		return routeMatch{}
+	}
+	return routeMatch{URLs: bestURLs, Confidence: (best - second/2) / (best + 1)}
+}
+
+// wholeWords reports whether kw appears in q not as part of a longer word.
+func wholeWords(q, kw string) bool {
+	isWord := func(b byte) bool {
+		return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '_'
+	}
+	for i := 0; ; {
+		j := strings.Index(q[i:], kw)
+		if j < 0 {
+			return false
 		}
-		if score > bestScore {
-			bestScore = score
-			bestURLs = route.urls
+		start, end := i+j, i+j+len(kw)
+		if (start == 0 || !isWord(q[start-1])) && (end == len(q) || !isWord(q[end]) || !isWord(kw[len(kw)-1])) {
+			return true
 		}
+		i = start + 1
 	}
-	return bestURLs
 }
 
 // ── Core doc list (fallback fetcher) ─────────────────────────────────────────
@@ -346,15 +526,27 @@ var coreDocs = []string{
 	"https://docs.unity3d.com/Manual/MobileOptimizationGraphicsMethods.html",
 }
 
+// CoreDocs returns the pages FetchCoreDocs fetches.
+func CoreDocs() []string {
+	return append([]string(nil), coreDocs...)
+}
+
 func (m *Manager) FetchCoreDocs() ([]search.Result, error) {
 	results := make([]search.Result, 0, len(coreDocs))
-	for _, u := range coreDocs {
-		r, err := m.fetchPage(u)
-		if err != nil {
+	failed := 0
+	for _, f := range m.fetchPages(context.Background(), coreDocs) {
+		if f.Err != nil {
+			log.Printf("[docs] Core page not fetched: %v", f.Err)
+			failed++
 			continue
 		}
-		results = append(results, r)
-		time.Sleep(100 * time.Millisecond)
+		results = append(results, f.Result)
+	}
+	if failed > 0 {
+		log.Printf("[docs] %d of %d core pages could not be fetched", failed, len(coreDocs))
+	}
+	if unchanged := m.saveValidators(); unchanged > 0 {
+		log.Printf("[docs] %d of %d core pages unchanged since the last fetch", unchanged, len(results))
 	}
 	if len(results) == 0 {
 		return nil, fmt.Errorf("could not fetch any docs (offline?)")
@@ -365,12 +557,36 @@ func (m *Manager) FetchCoreDocs() ([]search.Result, error) {
 // SearchLive routes the query to specific known Unity doc pages
 // instead of trusting Unity's search page (which returns generic nav junk).
 func (m *Manager) SearchLive(query string) ([]search.Result, error) {
-	// Step 1: try our keyword router first
-	urls := routeQuery(query)
+	return m.SearchLiveContext(context.Background(), query)
+}
 
-	// Step 2: if no route matched, fall back to Unity's search API
+// SearchLiveContext is SearchLive bounded by ctx: once it's done no more
+// pages are fetched, and the pages fetched so far are returned.
+func (m *Manager) SearchLiveContext(ctx context.Context, query string) ([]search.Result, error) {
+	// Step 1: an exact API goes straight to its page, a package's pages to
+	// the package manual, else try our keyword router
+	confidence := symbolConfidence
+	urls := m.ResolveSymbols(query)
+	if len(urls) == 0 {
+		urls, confidence = m.PackagePages(ctx, query), packageConfidence
+	}
 	if len(urls) == 0 {
-		urls = m.unitySearchAPI(query)
+		route := m.routeQuery(query)
+		if route.Confidence < minRouteConfidence {
+			// A weak match: try the query as the NLU reads it, API names included
+			pq := offline.UnderstandQuery(query)
+			if expanded := m.routeQuery(pq.EnhancedQuery()); expanded.Confidence > route.Confidence {
+				route = expanded
+			}
+		}
+		urls, confidence = route.URLs, route.Confidence
+	}
+
+	// Step 2: if no route matched, or only weakly, fall back to Unity's search API
+	if len(urls) == 0 || confidence < minRouteConfidence {
+		if found := m.unitySearchAPI(ctx, query); len(found) > 0 {
+			urls, confidence = found, searchAPIConfidence
+		}
 	}
 
 	if len(urls) == 0 {
@@ -378,25 +594,32 @@ func (m *Manager) SearchLive(query string) ([]search.Result, error) {
 	}
 
 	// Fetch and parse matched pages
+	if len(urls) > 3 {
+		urls = urls[:3]
+	}
 	results := make([]search.Result, 0, len(urls))
-	for i, u := range urls {
-		if i >= 3 {
-			break
-		}
-		r, err := m.fetchPage(u)
-		if err != nil {
+	for _, f := range m.fetchPages(ctx, urls) {
+		if f.Err != nil {
 			continue
 		}
+		r := f.Result
+		if p, ok := isPackagePage(f.URL); ok {
+			r = packageResult(p, r)
+		}
+		r.Score = confidence
 		results = append(results, r)
-		time.Sleep(100 * time.Millisecond)
+	}
+	m.saveValidators()
+	if len(results) == 0 && ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
 	return results, nil
 }
 
 // unitySearchAPI tries to get specific page links from Unity's search endpoint
-func (m *Manager) unitySearchAPI(query string) []string {
+func (m *Manager) unitySearchAPI(ctx context.Context, query string) []string {
 	searchURL := "https://docs.unity3d.com/search/?q=" + url.QueryEscape(query)
-	resp, err := m.client.Get(searchURL)
+	resp, err := m.get(ctx, searchURL)
 	if err != nil {
 		return nil
 	}
@@ -409,7 +632,7 @@ func (m *Manager) unitySearchAPI(query string) []string {
 	// Filter out generic/homepage links
 	var specific []string
 	for _, l := range links {
-		if strings.Contains(l, "/Manual/") || strings.Contains(l, "/ScriptReference/") {
+		if strings.Contains(l, "/Manual/") || strings.Contains(l, "/ScriptReference/") || strings.Contains(l, "/manual/") {
 			base := l[strings.LastIndex(l, "/")+1:]
 			if base != "index.html" && base != "" && !strings.HasPrefix(base, "Unity-") {
 				specific = append(specific, l)
@@ -419,24 +642,122 @@ func (m *Manager) unitySearchAPI(query string) []string {
 	return specific
 }
 
-// fetchPage downloads a doc page and extracts FULL clean text (not just 400 chars)
-func (m *Manager) fetchPage(pageURL string) (search.Result, error) {
-	resp, err := m.client.Get(pageURL)
+// errNotFound is a page the site answered 404 for.
+var errNotFound = errors.New("HTTP 404")
+
+// fetchPage downloads a doc page and extracts FULL clean text (not just 400 chars).
+// A page fetched before is only downloaded again if it changed (see
+// conditional.go).
+func (m *Manager) fetchPage(ctx context.Context, pageURL string) (search.Result, error) {
+	if loc := m.localized(pageURL); loc != pageURL {
+		// A page that isn't translated is only in English
+		r, err := m.fetchPage(ctx, loc)
+		if err == nil || !errors.Is(err, errNotFound) {
+			return r, err
+		}
+	}
+	if pin := m.pinned(pageURL); pin != pageURL {
+		// A page newer than the pinned version only exists in the latest docs
+		r, err := m.fetchPage(ctx, pin)
+		if err == nil || !errors.Is(err, errNotFound) {
+			return r, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return search.Result{}, err
+	}
+	known, conditional := m.condition(req, pageURL)
+	resp, err := m.do(req)
 	if err != nil {
 		return search.Result{}, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return search.Result{}, fmt.Errorf("%w: %s", errNotFound, pageURL)
+	}
 
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		m.confirmed(pageURL)
+		return known.Page, nil
+	}
 	if resp.StatusCode != 200 {
 		return search.Result{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, pageURL)
 	}
+	if err := checkPage(resp); err != nil {
+		return search.Result{}, err
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := m.readBody(resp)
 	if err != nil {
 		return search.Result{}, err
 	}
+	// Indexed under the page the redirects and its canonical link lead to
+	final := resp.Request.URL.String()
+	m.storeRaw(final, body)
+	page, err := parsePage(string(body), canonicalPage(string(body), final))
+	if err == nil {
+		page.Lang = pageLanguage(final, string(body))
+		m.remember(pageURL, resp, page)
+	}
+	return page, err
+}
+
+// get is client.Get bounded by ctx as well as the fetch timeout (see
+// guard.go), once robots.txt and the rate limits allow it (see polite.go).
+func (m *Manager) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.do(req)
+}
+
+// do sends req once robots.txt and the rate limits allow it, trying again
+// after a transient failure (see retry.go). Each try has the fetch timeout
+// (see guard.go) to send the request and read the response. The last
+// response is returned as it is when retries run out on a transient status.
+func (m *Manager) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retries := m.Politeness().Retries
+	timeout := m.Limits().Timeout
+	for n := 0; ; n++ {
+		admitted, err := m.admit(ctx, req.URL)
+		if err != nil {
+			return nil, err
+		}
+		tryCtx, cancel := context.WithTimeout(ctx, timeout)
+		release := func() { cancel(); admitted() }
+		resp, err := m.client.Do(req.Clone(tryCtx))
+		if err != nil {
+			release()
+			if n < retries && transientError(ctx, err) && sleepCtx(ctx, backoff(n, nil)) {
+				log.Printf("[docs] Retrying %s (%d of %d): %v", req.URL, n+1, retries, err)
+				continue
+			}
+			if n > 0 {
+				return nil, fmt.Errorf("%w (after %d tries)", err, n+1)
+			}
+			return nil, err
+		}
+		if n < retries && transientStatus(resp.StatusCode) {
+			wait := backoff(n, resp)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			release()
+			if sleepCtx(ctx, wait) {
+				log.Printf("[docs] Retrying %s (%d of %d): HTTP %d", req.URL, n+1, retries, resp.StatusCode)
+				continue
+			}
+			return nil, ctx.Err()
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	}
+}
 
-	html := string(body)
+// parsePage turns a downloaded doc page into a result for the index.
+func parsePage(html, pageURL string) (search.Result, error) {
 	title := extractTitle(html)
 	content := stripHTML(html)
 	content = cleanContent(content)
@@ -455,6 +776,8 @@ func (m *Manager) fetchPage(pageURL string) (search.Result, error) {
 		URL:     pageURL,
 		Excerpt: content, // full content, not just 400 chars
 		Score:   1.0,
+		Source:  "live",
+		Code:    extractCode(html),
 	}, nil
 }
 
@@ -469,6 +792,7 @@ var (
 	reFooter  = regexp.MustCompile(`(?is)<footer[^>]*>.*?</footer>`)
 	reComment = regexp.MustCompile(`(?s)<!--.*?-->`)
 	reSpaces  = regexp.MustCompile(`\s{3,}`)
+	rePre     = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
 	reTitle   = regexp.MustCompile(`(?i)<title[^>]*>(.*?)</title>`)
 	reAnchors = regexp.MustCompile(`href="(/[^"]+)"`)
 )
@@ -488,6 +812,24 @@ func extractTitle(html string) string {
 	return "Unity Documentation"
 }
 
+// extractCode returns the text of the page's <pre> blocks (the code samples).
+func extractCode(html string) string {
+	var blocks []string
+	size := 0
+	for _, m := range rePre.FindAllStringSubmatch(html, -1) {
+		block := strings.TrimSpace(reTags.ReplaceAllString(m[1], ""))
+		if block == "" {
+			continue
+		}
+		if size += len(block); size > 6000 {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	r := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'", "&amp;", "&")
+	return r.Replace(strings.Join(blocks, "\n\n"))
+}
+
 func stripHTML(html string) string {
 	html = reScript.ReplaceAllString(html, " ")
 	html = reStyle.ReplaceAllString(html, " ")
@@ -526,7 +868,7 @@ func extractLinks(html, baseURL string) []string {
 			continue
 		}
 		path := m[1]
-		if !strings.Contains(path, "/Manual/") && !strings.Contains(path, "/ScriptReference/") {
+		if !strings.Contains(path, "/Manual/") && !strings.Contains(path, "/ScriptReference/") && !strings.HasPrefix(path, "/Packages/") {
 			continue
 		}
 		full := baseURL + path
@@ -0,0 +1,256 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Package docs ──────────────────────────────────────────────────────────────
+// Packages (Input System, Cinemachine, Netcode, Addressables) document
+// themselves under docs.unity3d.com/Packages/<id>@<version>/, outside the
+// Manual and Scripting Reference that the routes, the contents and Unity's
+// search lead to, so package questions always fell through to OpenAI.
+// docPackages lists the packages asked about most with the words that name
+// them: a question naming one goes to the pages of its manual whose names
+// match the question (PackagePages), and the whole manual can be fetched
+// into the index (FetchPackageDocs).
+
+const (
+	// packageTOCTTL is how long a package manual's contents read is trusted.
+	packageTOCTTL = 24 * time.Hour
+	// maxPackagePages caps how many pages FetchPackageDocs fetches.
+	maxPackagePages = 300
+)
+
+// DocPackage is a package whose manual is published on docs.unity3d.com.
+type DocPackage struct {
+	ID       string   `json:"id"`       // com.unity.inputsystem
+	Name     string   `json:"name"`     // Input System
+	Version  string   `json:"version"`  // docs version, major.minor
+	Keywords []string `json:"keywords"` // any of these in a question names the package
+}
+
+// docPackages are the packages questions are routed to.
+var docPackages = []DocPackage{
+	{ID: "com.unity.inputsystem", Name: "Input System", Version: "1.7",
+		Keywords: []string{"input system", "inputsystem", "inputaction", "input action", "playerinput", "new input"}},
+	{ID: "com.unity.cinemachine", Name: "Cinemachine", Version: "2.10",
+		Keywords: []string{"cinemachine", "virtual camera", "vcam", "freelook", "free look camera"}},
+	{ID: "com.unity.netcode.gameobjects", Name: "Netcode for GameObjects", Version: "1.8",
+		Keywords: []string{"netcode", "networkobject", "networkbehaviour", "networkmanager", "networkvariable", "serverrpc", "clientrpc"}},
+	{ID: "com.unity.addressables", Name: "Addressables", Version: "1.21",
+		Keywords: []string{"addressable", "addressables", "loadassetasync", "assetreference", "remote catalog", "asset bundle", "assetbundle"}},
+}
+
+// Packages returns the packages whose docs questions are routed to.
+func Packages() []DocPackage {
+	return append([]DocPackage(nil), docPackages...)
+}
+
+// Manual is where the package's manual is: its pages are
+// Manual + "Installation.html" and so on.
+func (p DocPackage) Manual() string {
+	return docsHost + "/Packages/" + p.ID + "@" + p.Version + "/manual/"
+}
+
+// packageFor returns the package query names, the one with the most
+// keyword words if it names several.
+func packageFor(query string) (DocPackage, bool) {
+	q := strings.ToLower(query)
+	best, bestScore := DocPackage{}, 0
+	for _, p := range docPackages {
+		score := 0
+		for _, kw := range p.Keywords {
+			if strings.Contains(q, kw) {
+				score += len(strings.Fields(kw))
+			}
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// packageTOCs are the package manual contents read, by package id.
+type packageTOCs struct {
+	mu    sync.Mutex
+	pages map[string]packageTOC
+}
+
+type packageTOC struct {
+	fetched time.Time
+	pages   []string
+}
+
+// packageManual returns the pages of p's manual: those its contents
+// (toc.html) link to, or its front page's links if it has no contents.
+func (m *Manager) packageManual(ctx context.Context, p DocPackage) ([]string, error) {
+	m.packages.mu.Lock()
+	if toc, ok := m.packages.pages[p.ID]; ok && time.Since(toc.fetched) < packageTOCTTL {
+		m.packages.mu.Unlock()
+		return toc.pages, nil
+	}
+	m.packages.mu.Unlock()
+
+	manual := p.Manual()
+	scope := strings.TrimPrefix(manual, "https://")
+	var pages []string
+	var lastErr error
+	seen := map[string]bool{}
+	for _, start := range []string{manual + "toc.html", manual + "index.html"} {
+		body, final, err := m.fetchRaw(ctx, start)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, link := range crawlLinks(string(body), final, scope) {
+			if !strings.HasSuffix(link, ".html") || strings.HasSuffix(link, "/toc.html") || seen[link] {
+				continue
+			}
+			seen[link] = true
+			pages = append(pages, link)
+		}
+		if len(pages) > 0 {
+			break
+		}
+	}
+	if len(pages) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no pages listed")
+		}
+		return nil, fmt.Errorf("no %s manual: %w", p.Name, lastErr)
+	}
+	m.packages.mu.Lock()
+	if m.packages.pages == nil {
+		m.packages.pages = map[string]packageTOC{}
+	}
+	m.packages.pages[p.ID] = packageTOC{fetched: time.Now(), pages: pages}
+	m.packages.mu.Unlock()
+	return pages, nil
+}
+
+// PackagePages returns the pages of the manual of the package query names
+// whose names match the question's words best, at most 3: "split screen
+// with PlayerInputManager in the input system" finds PlayerInputManager.html.
+// None if the query names no package or no page matches it, so the keyword
+// routes get their turn.
+func (m *Manager) PackagePages(ctx context.Context, query string) []string {
+	p, ok := packageFor(query)
+	if !ok {
+		return nil
+	}
+	pages, err := m.packageManual(ctx, p)
+	if err != nil {
+		log.Printf("[docs] %v", err)
+		return nil
+	}
+	words := strings.Fields(strings.ToLower(query))
+	type scored struct {
+		url   string
+		score int
+	}
+	var hits []scored
+	for _, u := range pages {
+		name := strings.ToLower(strings.TrimSuffix(u[strings.LastIndexByte(u, '/')+1:], ".html"))
+		score := 0
+		for _, w := range words {
+			w = strings.Trim(w, `.,;:!?"'()`)
+			if len(w) >= 4 && !isPackageWord(p, w) && strings.Contains(name, w) {
+				score += len(w)
+			}
+		}
+		if score > 0 {
+			hits = append(hits, scored{u, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	var urls []string
+	for i := 0; i < len(hits) && i < 3; i++ {
+		urls = append(urls, hits[i].url)
+	}
+	return urls
+}
+
+// isPackageWord reports whether w only names the package, which every page
+// of its manual is about.
+func isPackageWord(p DocPackage, w string) bool {
+	for _, kw := range p.Keywords {
+		if strings.Contains(kw, w) {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(p.Name), w)
+}
+
+// FetchPackageDocs fetches every page of the manual of the package id
+// (up to maxPackagePages), tagged with its name and id like the package
+// docs indexed from a project.
+func (m *Manager) FetchPackageDocs(ctx context.Context, id string) ([]search.Result, error) {
+	var p DocPackage
+	for _, dp := range docPackages {
+		if dp.ID == id {
+			p = dp
+		}
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("unknown package %q", id)
+	}
+	pages, err := m.packageManual(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > maxPackagePages {
+		pages = pages[:maxPackagePages]
+	}
+	var results []search.Result
+	failed := 0
+	for _, f := range m.fetchPages(ctx, pages) {
+		if f.Err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[docs] Package page not fetched: %v", f.Err)
+				failed++
+			}
+			continue
+		}
+		results = append(results, packageResult(p, f.Result))
+	}
+	m.saveValidators()
+	if failed > 0 {
+		log.Printf("[docs] %d of %d %s pages could not be fetched", failed, len(pages), p.Name)
+	}
+	if len(results) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no %s pages could be fetched", p.Name)
+	}
+	return results, ctx.Err()
+}
+
+// packageResult labels a page of p's manual with the package.
+func packageResult(p DocPackage, r search.Result) search.Result {
+	if !strings.HasPrefix(r.Title, p.Name) {
+		r.Title = p.Name + ": " + r.Title
+	}
+	r.Label = p.Name + " package"
+	r.Tags = append(r.Tags, p.Name, p.ID)
+	return r
+}
+
+// isPackagePage reports whether u is a page of a package manual.
+func isPackagePage(u string) (DocPackage, bool) {
+	for _, p := range docPackages {
+		if strings.HasPrefix(u, docsHost+"/Packages/"+p.ID+"@") {
+			return p, true
+		}
+	}
+	return DocPackage{}, false
+}
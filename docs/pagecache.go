@@ -0,0 +1,133 @@
+package docs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Raw page cache ────────────────────────────────────────────────────────────
+// Once a page is parsed only its text is kept, so trying a change to the
+// parsing, the chunking or the ranking on the pages already read meant
+// downloading them all again. Every page downloaded (fetchPage, fetchRaw,
+// Proxy) is also kept as it came, in cache/pages/<sha256 of its URL>.html,
+// behind a first line naming the URL; RawPages lists them and Reparse
+// parses one again without the network.
+
+// rawHeader starts the first line of a cached page; the URL follows.
+const rawHeader = "<!-- unitymind page: "
+
+func (m *Manager) pagesDir() string {
+	return filepath.Join(m.cacheDir, "pages")
+}
+
+func (m *Manager) rawPath(pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(m.pagesDir(), hex.EncodeToString(sum[:])+".html")
+}
+
+// storeRaw keeps body as downloaded from pageURL; a page that can't be
+// written just isn't kept. It's written to a temporary file of its own and
+// renamed into place, so two downloads of the same page never mix.
+func (m *Manager) storeRaw(pageURL string, body []byte) {
+	if os.MkdirAll(m.pagesDir(), 0755) != nil {
+		return
+	}
+	path := m.rawPath(pageURL)
+	f, err := os.CreateTemp(m.pagesDir(), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = io.WriteString(f, rawHeader+pageURL+" -->\n")
+	if err == nil {
+		_, err = f.Write(body)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// RawPage returns the page last downloaded from pageURL as it came.
+func (m *Manager) RawPage(pageURL string) ([]byte, bool) {
+	data, err := os.ReadFile(m.rawPath(pageURL))
+	if err != nil {
+		return nil, false
+	}
+	_, body, ok := splitRaw(data)
+	return body, ok
+}
+
+// splitRaw splits a cached page into its URL and body.
+func splitRaw(data []byte) (string, []byte, bool) {
+	line, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok || !bytes.HasPrefix(line, []byte(rawHeader)) {
+		return "", nil, false
+	}
+	return strings.TrimSuffix(string(line[len(rawHeader):]), " -->"), body, true
+}
+
+// RawPages returns the URLs of the pages in the cache, sorted. Only each
+// file's first line is read.
+func (m *Manager) RawPages() []string {
+	files, _ := filepath.Glob(filepath.Join(m.pagesDir(), "*.html"))
+	var urls []string
+	for _, f := range files {
+		if u, ok := rawURL(f); ok {
+			urls = append(urls, u)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// rawURL reads the URL from the first line of the cached page at path.
+func rawURL(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	line, err := bufio.NewReaderSize(f, 4096).ReadSlice('\n')
+	if err != nil {
+		return "", false
+	}
+	u, _, ok := splitRaw(line)
+	return u, ok
+}
+
+// Reparse parses the cached copy of the page at pageURL again, as fetchPage
+// would have. Pages are cached under where their redirects ended, so
+// pageURL should be one RawPages returned.
+func (m *Manager) Reparse(pageURL string) (search.Result, error) {
+	body, ok := m.RawPage(pageURL)
+	if !ok {
+		return search.Result{}, fmt.Errorf("not cached: %s", pageURL)
+	}
+	if isCSharpPage(pageURL) {
+		return parseCSharpPage(string(body), pageURL)
+	}
+	r, err := parsePage(string(body), canonicalPage(string(body), pageURL))
+	if err != nil {
+		return r, err
+	}
+	r.Lang = pageLanguage(pageURL, string(body))
+	if p, ok := isPackagePage(pageURL); ok {
+		r = packageResult(p, r)
+	}
+	return r, nil
+}
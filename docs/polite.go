@@ -0,0 +1,330 @@
+package docs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Politeness ────────────────────────────────────────────────────────────────
+// Crawls and bulk fetches can send thousands of requests to one site, so
+// every request goes through the same gate: the site's robots.txt is read
+// first (once a day) and honored, requests start no faster than the rate
+// limit (a token bucket holding as many requests as may run at once) and no
+// more run at once than the concurrency limit, and requests to a site with a
+// robots.txt Crawl-delay start that far apart. Crawls also pause between
+// pages for the politeness delay; fetches of a known list of pages run in
+// parallel instead (see pool.go).
+
+// ErrDisallowed is returned for a page the site's robots.txt disallows.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsAgent is the name robots.txt rules are matched against.
+const robotsAgent = "unitymind"
+
+// robotsTTL is how long a site's robots.txt is trusted before it's read again.
+const robotsTTL = 24 * time.Hour
+
+// Politeness is how gently the live-docs layer treats the sites it fetches
+// from; zero fields take the defaults.
+type Politeness struct {
+	RatePerSec   float64       `json:"rate_per_sec"` // most requests started per second
+	Concurrency  int           `json:"concurrency"`  // most requests running at once
+	Delay        time.Duration `json:"-"`            // pause between pages of a crawl or bulk fetch
+	IgnoreRobots bool          `json:"ignore_robots"`
+	Retries      int           `json:"retries"` // more tries after a transient failure, -1 for none (see retry.go)
+}
+
+// Politeness defaults.
+const (
+	DefaultRatePerSec  = 4.0
+	DefaultConcurrency = 2
+	DefaultDelay       = 500 * time.Millisecond
+)
+
+func (p Politeness) withDefaults() Politeness {
+	if p.RatePerSec <= 0 {
+		p.RatePerSec = DefaultRatePerSec
+	}
+	if p.Concurrency <= 0 {
+		p.Concurrency = DefaultConcurrency
+	}
+	if p.Delay <= 0 {
+		p.Delay = DefaultDelay
+	}
+	if p.Retries == 0 {
+		p.Retries = DefaultRetries
+	} else if p.Retries < 0 {
+		p.Retries = 0
+	}
+	return p
+}
+
+// gate holds the politeness state shared by a Manager's requests.
+type gate struct {
+	mu     sync.Mutex
+	polite Politeness
+	slots  chan struct{} // one per request allowed to run at once
+	next   time.Time     // when the rate limit's bucket is empty again, see admit
+	robots map[string]*robotsRules
+	hosts  map[string]time.Time // earliest the next request to a site with a Crawl-delay may start
+}
+
+func newGate() *gate {
+	p := Politeness{}.withDefaults()
+	return &gate{polite: p, slots: make(chan struct{}, p.Concurrency), robots: map[string]*robotsRules{}, hosts: map[string]time.Time{}}
+}
+
+// SetPoliteness replaces the politeness settings. Requests already waiting
+// keep the concurrency limit they started with.
+func (m *Manager) SetPoliteness(p Politeness) {
+	p = p.withDefaults()
+	m.gate.mu.Lock()
+	defer m.gate.mu.Unlock()
+	if p.Concurrency != m.gate.polite.Concurrency {
+		m.gate.slots = make(chan struct{}, p.Concurrency)
+	}
+	m.gate.polite = p
+}
+
+// Politeness returns the politeness settings in use.
+func (m *Manager) Politeness() Politeness {
+	m.gate.mu.Lock()
+	defer m.gate.mu.Unlock()
+	return m.gate.polite
+}
+
+// admit waits until a request to u may start: robots.txt allows it, a slot
+// is free and the rate limit has room. The returned func frees the slot.
+func (m *Manager) admit(ctx context.Context, u *url.URL) (func(), error) {
+	g := m.gate
+	g.mu.Lock()
+	p, slots := g.polite, g.slots
+	g.mu.Unlock()
+	if !p.IgnoreRobots && !m.robotsFor(ctx, u).allowed(u) {
+		return nil, fmt.Errorf("%w: %s", ErrDisallowed, u)
+	}
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-slots }
+
+	g.mu.Lock()
+	// A token bucket of Concurrency requests refilled at RatePerSec: next
+	// is when it would be empty, so a request may start up to a bucket's
+	// worth of intervals before it.
+	now := time.Now()
+	interval := time.Duration(float64(time.Second) / p.RatePerSec)
+	empty := g.next
+	if empty.Before(now) {
+		empty = now
+	}
+	start := empty.Add(-time.Duration(p.Concurrency-1) * interval)
+	if start.Before(now) {
+		start = now
+	}
+	g.next = empty.Add(interval)
+	if r := g.robots[u.Host]; r != nil && r.delay > 0 && !p.IgnoreRobots {
+		if hostStart := g.hosts[u.Host]; start.Before(hostStart) {
+			start = hostStart
+		}
+		g.hosts[u.Host] = start.Add(r.delay)
+	}
+	g.mu.Unlock()
+	if wait := time.Until(start); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// pause waits between two pages of a bulk fetch from host: delay, 0 for
+// the politeness delay, or the site's robots.txt Crawl-delay if longer.
+// Returns early once ctx is done.
+func (m *Manager) pause(ctx context.Context, host string, delay time.Duration) {
+	m.gate.mu.Lock()
+	if delay <= 0 {
+		delay = m.gate.polite.Delay
+	}
+	if r := m.gate.robots[host]; r != nil && r.delay > delay {
+		delay = r.delay
+	}
+	m.gate.mu.Unlock()
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
+
+// releaseBody frees a request's slot once its body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// ── robots.txt ──
+
+// robotsRules are the rules of a site's robots.txt that apply to us.
+type robotsRules struct {
+	fetched  time.Time
+	rules    []robotsRule
+	delay    time.Duration // Crawl-delay
+	sitemaps []string      // Sitemap lines, whichever group they're in
+}
+
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsFor returns the robots.txt rules of u's site, reading them if they
+// aren't known or are out of date. A site without a readable robots.txt
+// allows everything.
+func (m *Manager) robotsFor(ctx context.Context, u *url.URL) *robotsRules {
+	host := u.Host
+	m.gate.mu.Lock()
+	r := m.gate.robots[host]
+	m.gate.mu.Unlock()
+	if r != nil && time.Since(r.fetched) < robotsTTL {
+		return r
+	}
+	r = &robotsRules{fetched: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, m.Limits().Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Scheme+"://"+host+"/robots.txt", nil)
+	if err == nil {
+		if resp, err := m.client.Do(req); err == nil {
+			if resp.StatusCode == 200 {
+				r = parseRobots(io.LimitReader(resp.Body, 512<<10))
+			}
+			resp.Body.Close()
+		}
+	}
+	m.gate.mu.Lock()
+	m.gate.robots[host] = r
+	m.gate.mu.Unlock()
+	return r
+}
+
+// parseRobots reads the group of a robots.txt that applies to robotsAgent,
+// or failing that the one for every agent.
+func parseRobots(body io.Reader) *robotsRules {
+	ours, all := &robotsRules{}, &robotsRules{}
+	var groups []*robotsRules // the groups the current user-agent lines name
+	inRules := false          // a rule has been seen since the last user-agent line
+	var sitemaps []string
+	sc := bufio.NewScanner(body)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				groups, inRules = nil, false
+			}
+			agent := strings.ToLower(value)
+			if agent == "*" {
+				groups = append(groups, all)
+			} else if agent != "" && (strings.Contains(robotsAgent, agent) || strings.Contains(agent, robotsAgent)) {
+				groups = append(groups, ours)
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // "Disallow:" with nothing allows everything
+			}
+			for _, g := range groups {
+				g.rules = append(g.rules, robotsRule{pattern: value, allow: key == "allow"})
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value) // not part of any group
+			}
+		case "crawl-delay":
+			inRules = true
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				for _, g := range groups {
+					g.delay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+	}
+	r := all
+	if len(ours.rules) > 0 || ours.delay > 0 {
+		r = ours
+	}
+	r.fetched, r.sitemaps = time.Now(), sitemaps
+	return r
+}
+
+// allowed reports whether the rules let u be fetched: the longest matching
+// pattern decides, Allow winning a tie.
+func (r *robotsRules) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a robots.txt path pattern: a prefix, where * matches
+// anything and a final $ anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored && rest != "" {
+		// The last part must end the path: look for a later occurrence
+		last := parts[len(parts)-1]
+		return len(parts) > 1 && strings.HasSuffix(path, last)
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package docs
+
+import (
+	"context"
+	"sync"
+
+	"unitymind/search"
+)
+
+// ── Parallel fetches ──────────────────────────────────────────────────────────
+// The core docs, a question's live pages and a package manual used to be
+// fetched one page at a time with a fixed sleep after each, so refreshing
+// forty core pages took over half a minute on a fast connection. A known
+// list of pages is fetched by a pool of workers, as many as requests may
+// run at once; every request still goes through the gate (polite.go), so
+// the shared rate limit and robots.txt keep the pool as polite as the
+// sequential fetches were.
+
+// pageFetch is the outcome of fetching one page of a list.
+type pageFetch struct {
+	URL    string
+	Result search.Result
+	Err    error
+}
+
+// fetchPages fetches the pages at urls in parallel, returning their
+// outcomes in urls' order. Pages not started once ctx is done fail with
+// its error.
+func (m *Manager) fetchPages(ctx context.Context, urls []string) []pageFetch {
+	out := make([]pageFetch, len(urls))
+	workers := min(m.Politeness().Concurrency, len(urls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i].Result, out[i].Err = m.fetchPage(ctx, urls[i])
+			}
+		}()
+	}
+	for i, u := range urls {
+		out[i].URL = u
+		if err := ctx.Err(); err != nil {
+			out[i].Err = err
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return out
+}
@@ -0,0 +1,169 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Read-through proxy ────────────────────────────────────────────────────────
+// Answer links open docs.unity3d.com pages through the server. Each page
+// fetched is kept in the raw page cache (see pagecache.go) and indexed, so
+// the index grows with what people actually read, and a page read once
+// opens again offline or behind a firewall that blocks Unity's site.
+
+// ProxyPrefix is the route pages are served under:
+// /proxy/docs/ScriptReference/AudioSource.html
+const ProxyPrefix = "/proxy/docs/"
+
+const docsBase = "https://docs.unity3d.com/"
+
+// ProxiedPage is what Proxy serves.
+type ProxiedPage struct {
+	Body        []byte
+	ContentType string
+	// Page is the parsed doc page when it was just downloaded, for the
+	// index; nil for cached copies and for CSS, images and the like
+	Page *search.Result
+	// Cached is set when the page came from the raw page cache, not the
+	// live site
+	Cached bool
+}
+
+// Proxy returns the docs.unity3d.com resource at rel (a path like
+// "Manual/index.html"), fetched live when the site is reachable and from
+// the raw page cache when it isn't. HTML has its links pointed back at the proxy.
+// The fetch ends with ctx, i.e. when the reader goes away.
+func (m *Manager) Proxy(ctx context.Context, rel string) (ProxiedPage, error) {
+	rel, err := cleanProxyPath(rel)
+	if err != nil {
+		return ProxiedPage{}, err
+	}
+	ctype := mime.TypeByExtension(path.Ext(rel))
+	if ctype == "" {
+		ctype = "text/html; charset=utf-8"
+	}
+	isHTML := strings.HasPrefix(ctype, "text/html")
+
+	var out ProxiedPage
+	// The pinned version may not have the page yet; latest does
+	live := m.pinned(docsBase + rel)
+	body, err := m.download(ctx, live)
+	if err == errNotFound && live != docsBase+rel {
+		live = docsBase + rel
+		body, err = m.download(ctx, live)
+	}
+	if err == nil {
+		m.storeRaw(live, body)
+		if isHTML {
+			if page, err := parsePage(string(body), live); err == nil {
+				out.Page = &page
+			}
+		}
+	} else if cached, ok := m.rawProxied(rel); ok {
+		body, out.Cached = cached, true
+	} else {
+		return ProxiedPage{}, fmt.Errorf("%s is not reachable and not cached", rel)
+	}
+	if isHTML {
+		body = []byte(proxyLinks(string(body)))
+	}
+	out.Body, out.ContentType = body, ctype
+	return out, nil
+}
+
+// download fetches url like any other page (see get), failing with
+// errNotFound on 404, on anything else but 200 and on anything bigger or
+// slower than the download limits allow (see guard.go).
+func (m *Manager) download(ctx context.Context, url string) ([]byte, error) {
+	resp, err := m.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, url)
+	}
+	return m.readBody(resp)
+}
+
+// rawProxied returns the cached copy of rel, from the pinned version if it
+// was fetched from there, else from latest.
+func (m *Manager) rawProxied(rel string) ([]byte, bool) {
+	if body, ok := m.RawPage(m.pinned(docsBase + rel)); ok {
+		return body, true
+	}
+	return m.RawPage(docsBase + rel)
+}
+
+// cleanProxyPath rejects paths that would leave the docs site, and maps a
+// folder to its index.html.
+func cleanProxyPath(rel string) (string, error) {
+	rel = strings.TrimPrefix(rel, "/")
+	clean := path.Clean("/" + rel)[1:]
+	if clean == "" || strings.Contains(rel, "..") || strings.ContainsAny(rel, "\\:") {
+		return "", fmt.Errorf("bad docs path %q", rel)
+	}
+	if strings.HasSuffix(rel, "/") || path.Ext(clean) == "" {
+		clean += "/index.html"
+	}
+	return clean, nil
+}
+
+var reRootLink = regexp.MustCompile(`\b(href|src)="/([^/"])`)
+
+// proxyLinks points a page's absolute and root-relative links at the proxy;
+// relative ones already resolve under it.
+func proxyLinks(page string) string {
+	page = reRootLink.ReplaceAllString(page, `$1="`+ProxyPrefix+`$2`)
+	return strings.ReplaceAll(page, `"`+docsBase, `"`+ProxyPrefix)
+}
+
+// OfflinePage renders a page from its indexed text, for when it's neither
+// reachable nor cached but was indexed (e.g. from the offline ZIP).
+// chunks are the page's sections in order, see search.Engine.Page.
+func OfflinePage(chunks []search.Result) []byte {
+	var b strings.Builder
+	title := html.EscapeString(chunks[0].Title)
+	fmt.Fprintf(&b, "<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>%s</title>"+
+		"<style>body{font-family:sans-serif;max-width:800px;margin:2em auto;line-height:1.5}"+
+		"pre{background:#f4f4f4;padding:1em;overflow:auto}.note{color:#888;font-size:90%%}</style></head><body>", title)
+	fmt.Fprintf(&b, "<h1>%s</h1><p class=\"note\">Offline copy from the UnityMind index.</p>", title)
+	for _, c := range chunks {
+		// Offline pages are indexed as Markdown; older indexes hold plain
+		// text with the code kept apart
+		var code []string
+		inCode := false
+		for _, para := range strings.Split(c.Excerpt, "\n") {
+			switch trimmed := strings.TrimSpace(para); {
+			case strings.HasPrefix(trimmed, "```"):
+				if inCode {
+					fmt.Fprintf(&b, "<pre>%s</pre>", html.EscapeString(strings.Join(code, "\n")))
+					code = nil
+				}
+				inCode = !inCode
+			case inCode:
+				code = append(code, para)
+			case strings.HasPrefix(trimmed, "#"):
+				fmt.Fprintf(&b, "<h3>%s</h3>", html.EscapeString(strings.TrimLeft(trimmed, "# ")))
+			case trimmed != "":
+				fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(trimmed))
+			}
+		}
+		if c.Code != "" && !strings.Contains(c.Excerpt, "```") {
+			fmt.Fprintf(&b, "<pre>%s</pre>", html.EscapeString(c.Code))
+		}
+	}
+	b.WriteString("</body></html>")
+	return []byte(b.String())
+}
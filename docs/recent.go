@@ -0,0 +1,85 @@
+package docs
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Recently read live pages ──────────────────────────────────────────────────
+// A scheduled refresh re-fetches the core pages, but the live pages people
+// actually read are the ones routed to by their questions. Touch records
+// when a live page was last used in an answer, and RecentPages gives the
+// ones used lately so they can be refreshed too, cheaply now that unchanged
+// pages come back as 304s (see conditional.go).
+
+// maxRecentPages caps how many pages are remembered as read.
+const maxRecentPages = 1000
+
+type recentPages struct {
+	mu sync.Mutex
+	at map[string]time.Time // canonical page URL → last read
+}
+
+// Touch records that the live pages at urls were just read.
+func (m *Manager) Touch(urls ...string) {
+	now := time.Now()
+	m.recent.mu.Lock()
+	defer m.recent.mu.Unlock()
+	if m.recent.at == nil {
+		m.recent.at = map[string]time.Time{}
+	}
+	for _, u := range urls {
+		canon, _ := search.CanonicalURL(u)
+		m.recent.at[search.PageURL(canon)] = now
+	}
+	if len(m.recent.at) > maxRecentPages {
+		// Forget the oldest
+		pages := m.recentLocked(0)
+		for _, u := range pages[maxRecentPages:] {
+			delete(m.recent.at, u)
+		}
+	}
+}
+
+// RecentPages returns the live pages read within the last within (0 for
+// any time), most recent first.
+func (m *Manager) RecentPages(within time.Duration) []string {
+	m.recent.mu.Lock()
+	defer m.recent.mu.Unlock()
+	return m.recentLocked(within)
+}
+
+func (m *Manager) recentLocked(within time.Duration) []string {
+	var pages []string
+	for u, at := range m.recent.at {
+		if within <= 0 || time.Since(at) <= within {
+			pages = append(pages, u)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return m.recent.at[pages[i]].After(m.recent.at[pages[j]]) })
+	return pages
+}
+
+// RefreshPages fetches the pages at urls again, in parallel, returning the ones
+// that could be fetched and how many couldn't. It stops once ctx is done.
+func (m *Manager) RefreshPages(ctx context.Context, urls []string) ([]search.Result, int) {
+	var results []search.Result
+	failed := 0
+	for _, f := range m.fetchPages(ctx, urls) {
+		if f.Err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[docs] Page not refreshed: %v", f.Err)
+				failed++
+			}
+			continue
+		}
+		results = append(results, f.Result)
+	}
+	m.saveValidators()
+	return results, failed
+}
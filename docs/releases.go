@@ -0,0 +1,226 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Release notes and upgrade guides ──────────────────────────────────────────
+// "What changed in Unity 6 physics" and "why did my Input code break after
+// upgrading" are answered by the release notes and the Manual's upgrade
+// guides, which neither the routes nor a crawl of the contents reach.
+// FetchReleaseDocs reads them for the pinned version: the upgrade guide and
+// what's-new pages the Manual links from its upgrade page, and the release
+// notes of the latest releases from Unity's release API, one result per
+// section so a question finds the part that answers it.
+
+const (
+	// ReleaseSource marks release notes and upgrade guides.
+	ReleaseSource = "release_notes"
+	// DefaultReleases is how many releases' notes are read when none is set.
+	DefaultReleases = 10
+	// releaseAPI lists Unity Editor releases with links to their notes.
+	releaseAPI = "https://services.api.unity.com/unity/editor/release/v1/releases"
+	// releasePage is where a release's notes are read on the web.
+	releasePage = "https://unity.com/releases/editor/whats-new/"
+)
+
+var (
+	reGuideLink   = regexp.MustCompile(`(?i)(UpgradeGuide|WhatsNew)[^/]*\.html$`)
+	reMarkdownHdr = regexp.MustCompile(`^#{1,4}\s+(.+)$`)
+)
+
+// ReleaseReport is what FetchReleaseDocs read.
+type ReleaseReport struct {
+	Version  string   `json:"version"`  // docs version, "" for latest
+	Guides   int      `json:"guides"`   // upgrade guide and what's-new pages
+	Releases []string `json:"releases"` // releases whose notes were read
+	Failed   []string `json:"failed,omitempty"`
+}
+
+type releaseList struct {
+	Results []struct {
+		Version      string `json:"version"`
+		ReleaseNotes struct {
+			URL string `json:"url"`
+		} `json:"releaseNotes"`
+	} `json:"results"`
+}
+
+// FetchReleaseDocs reads the upgrade guides and the notes of the latest
+// releases (up to releases, 0 for DefaultReleases) for the pinned version.
+func (m *Manager) FetchReleaseDocs(ctx context.Context, releases int) ([]search.Result, ReleaseReport, error) {
+	if releases <= 0 {
+		releases = DefaultReleases
+	}
+	report := ReleaseReport{Version: m.Version()}
+	var results []search.Result
+
+	guides, err := m.upgradeGuides(ctx)
+	if err != nil {
+		report.Failed = append(report.Failed, "upgrade guides: "+err.Error())
+	}
+	for _, u := range guides {
+		if ctx.Err() != nil {
+			break
+		}
+		r, err := m.fetchPage(ctx, u)
+		if err != nil {
+			report.Failed = append(report.Failed, err.Error())
+			continue
+		}
+		r.Source, r.Label, r.Tags = ReleaseSource, "Upgrade guide", []string{"upgrade-guide"}
+		results = append(results, r)
+		report.Guides++
+		m.pause(ctx, "docs.unity3d.com", 0)
+	}
+
+	var list releaseList
+	q := url.Values{"limit": {fmt.Sprint(releases)}, "order": {"RELEASE_DATE_DESC"}}
+	if report.Version != "" {
+		q.Set("version", report.Version)
+	}
+	if err := m.fetchJSON(ctx, releaseAPI+"?"+q.Encode(), &list); err != nil {
+		report.Failed = append(report.Failed, "release list: "+err.Error())
+	}
+	for _, rel := range list.Results {
+		if ctx.Err() != nil || len(report.Releases) >= releases {
+			break
+		}
+		if rel.ReleaseNotes.URL == "" {
+			continue
+		}
+		notes, err := m.fetchText(ctx, rel.ReleaseNotes.URL)
+		if err != nil {
+			report.Failed = append(report.Failed, rel.Version+": "+err.Error())
+			continue
+		}
+		results = append(results, releaseSections(rel.Version, notes)...)
+		report.Releases = append(report.Releases, rel.Version)
+		m.pause(ctx, "unity.com", 0)
+	}
+	if len(results) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, report, err
+		}
+		return nil, report, fmt.Errorf("no release notes or upgrade guides could be fetched")
+	}
+	return results, report, ctx.Err()
+}
+
+// upgradeGuides returns the upgrade guide and what's-new pages the Manual's
+// upgrade page links to, and that page itself.
+func (m *Manager) upgradeGuides(ctx context.Context) ([]string, error) {
+	start := docsHost + "/Manual/UpgradeGuides.html"
+	body, final, err := m.fetchRaw(ctx, m.pinned(start))
+	if err != nil {
+		return nil, err
+	}
+	pages := []string{start}
+	seen := map[string]bool{start: true}
+	for _, link := range crawlLinks(string(body), final, strings.TrimPrefix(docsHost, "https://")+"/") {
+		canon, _ := search.CanonicalURL(link)
+		if isDocsPage(canon) && reGuideLink.MatchString(canon) && !seen[canon] {
+			seen[canon] = true
+			pages = append(pages, canon)
+		}
+	}
+	return pages, nil
+}
+
+// releaseSections splits a release's notes (Markdown) at their headings,
+// one result per section ("Known Issues", "Fixes", "Physics: ...").
+func releaseSections(version, notes string) []search.Result {
+	page := releasePage + version
+	docsVersion, _ := search.DocsVersion(version)
+	var out []search.Result
+	title, anchor := "", ""
+	anchors := map[string]int{} // "Physics" is a heading under both Improvements and Fixes
+	var body strings.Builder
+	flush := func() {
+		text := cleanContent(body.String())
+		body.Reset()
+		if len(strings.TrimSpace(text)) < 20 {
+			return
+		}
+		if len(text) > 10000 {
+			text = text[:10000]
+		}
+		t := "Unity " + version + " release notes"
+		u := page
+		if title != "" {
+			t += ": " + title
+			u += "#" + anchor
+		}
+		out = append(out, search.Result{
+			Title:   t,
+			URL:     u,
+			Excerpt: text,
+			Score:   1.0,
+			Source:  ReleaseSource,
+			Version: docsVersion,
+			Label:   "Release notes",
+			Tags:    []string{"release-notes", version},
+		})
+	}
+	for _, line := range strings.Split(notes, "\n") {
+		if m := reMarkdownHdr.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			flush()
+			title = strings.TrimSpace(m[1])
+			anchor = slug(title)
+			if anchors[anchor]++; anchors[anchor] > 1 {
+				anchor += fmt.Sprintf("-%d", anchors[anchor])
+			}
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return out
+}
+
+// slug makes a heading into a URL fragment: "Known Issues" → known-issues.
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// fetchJSON fetches u into v.
+func (m *Manager) fetchJSON(ctx context.Context, u string, v any) error {
+	data, err := m.fetchText(ctx, u)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+// fetchText fetches u as text, whatever its content type.
+func (m *Manager) fetchText(ctx context.Context, u string) (string, error) {
+	resp, err := m.get(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, u)
+	}
+	data, err := m.readBody(resp)
+	return string(data), err
+}
@@ -0,0 +1,79 @@
+package docs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ── Retries ───────────────────────────────────────────────────────────────────
+// On flaky Wi-Fi a fetch that times out once usually works a second later,
+// but giving up on it left whole topics out of the index without a word.
+// Every request is tried again after a transient failure (a timeout, a
+// dropped connection, 429, 500, 502, 503, 504) with exponential backoff and
+// jitter, up to Politeness.Retries more times; a 404 or any other permanent
+// answer isn't retried. A Retry-After from the site is waited out instead of
+// the backoff when it's no longer than maxRetryAfter.
+
+const (
+	// DefaultRetries is how many more times a transient failure is tried.
+	DefaultRetries = 2
+	// retryBase is the backoff before the first retry; it doubles for each
+	// retry after that, give or take half for jitter.
+	retryBase = 500 * time.Millisecond
+	// maxRetryAfter caps how long a Retry-After is honored for.
+	maxRetryAfter = 30 * time.Second
+)
+
+// transientStatus reports whether an HTTP status is worth retrying.
+func transientStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transientError reports whether a failed request is worth retrying: a
+// timeout or a network error, not a cancelled context, robots.txt or a bad URL.
+func transientError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrDisallowed) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError // refused, reset, no route, DNS
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff is how long to wait before retry number n (0 for the first),
+// the site's Retry-After if resp has a usable one.
+func backoff(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			if d := time.Duration(secs) * time.Second; d <= maxRetryAfter {
+				return d
+			}
+		}
+	}
+	d := retryBase << n
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// sleepCtx waits d, returning false if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
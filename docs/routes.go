@@ -0,0 +1,237 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Route table file ──────────────────────────────────────────────────────────
+// The keyword routes used to be compiled in, so the only way to teach
+// UnityMind where a favorite page lives was a rebuild. They're read from a
+// routes file instead (routes.json next to config.json), a JSON list of
+// {"keywords": [...], "urls": [...]} tried in order, and the built-in
+// table is used while there's no file. LoadRoutes polls the file on its
+// own, checking its size and modification time every few seconds (the
+// server asks for 5s), and reloads it when they change; a file that
+// doesn't parse keeps the table as it was and says why. Edits made through
+// the API write the whole table to the file, so it starts as a copy of the
+// built-ins.
+
+// routeTable is a Manager's keyword routes and where they come from.
+type routeTable struct {
+	mu     sync.Mutex
+	path   string
+	routes []Route // nil for the built-ins
+	stamp  string  // size and modification time of the file when read
+	err    error   // why the file last failed to load
+	stop   chan struct{}
+}
+
+// RouteInfo describes the route table in use.
+type RouteInfo struct {
+	Path   string  `json:"path"`
+	Custom bool    `json:"custom"` // read from the file rather than built in
+	Error  string  `json:"error,omitempty"`
+	Routes []Route `json:"routes"`
+}
+
+// Routes returns the keyword routes in use, in the order they're tried.
+func (m *Manager) Routes() []Route {
+	m.routes.mu.Lock()
+	defer m.routes.mu.Unlock()
+	if m.routes.routes == nil {
+		return builtinRoutes
+	}
+	return m.routes.routes
+}
+
+// RouteInfo returns the route table and where it comes from.
+func (m *Manager) RouteInfo() RouteInfo {
+	m.routes.mu.Lock()
+	info := RouteInfo{Path: m.routes.path, Custom: m.routes.routes != nil}
+	if m.routes.err != nil {
+		info.Error = m.routes.err.Error()
+	}
+	m.routes.mu.Unlock()
+	info.Routes = m.Routes()
+	return info
+}
+
+// LoadRoutes reads the route table from path, or goes back to the built-ins
+// if there's no file there, and keeps polling it every interval (0 = never)
+// so edits take effect without a restart.
+func (m *Manager) LoadRoutes(path string, interval time.Duration) error {
+	m.routes.mu.Lock()
+	m.routes.path = path
+	if m.routes.stop != nil {
+		close(m.routes.stop)
+		m.routes.stop = nil
+	}
+	if interval > 0 {
+		m.routes.stop = make(chan struct{})
+		go m.watchRoutes(m.routes.stop, interval)
+	}
+	m.routes.mu.Unlock()
+	return m.reloadRoutes()
+}
+
+func (m *Manager) watchRoutes(stop chan struct{}, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			m.routes.mu.Lock()
+			path := m.routes.path
+			changed := routesStamp(path) != m.routes.stamp
+			m.routes.mu.Unlock()
+			if changed {
+				if err := m.reloadRoutes(); err != nil {
+					log.Printf("[docs] Routes file not reloaded: %v", err)
+				} else {
+					log.Printf("[docs] Reloaded %d routes from %s", len(m.Routes()), path)
+				}
+			}
+		}
+	}
+}
+
+// reloadRoutes reads the routes file. A missing file means the built-ins;
+// one that doesn't parse leaves the table as it was.
+func (m *Manager) reloadRoutes() error {
+	m.routes.mu.Lock()
+	defer m.routes.mu.Unlock()
+	path := m.routes.path
+	stamp := routesStamp(path)
+	m.routes.stamp = stamp
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		m.routes.routes, m.routes.err = nil, nil
+		return nil
+	}
+	if err == nil {
+		var routes []Route
+		if err = json.Unmarshal(data, &routes); err == nil {
+			routes, err = cleanRoutes(routes)
+		}
+		if err == nil {
+			m.routes.routes, m.routes.err = routes, nil
+			return nil
+		}
+	}
+	m.routes.err = fmt.Errorf("%s: %w", path, err)
+	return m.routes.err
+}
+
+// SetRoute adds route at the end of the table (at < 0) or replaces the one
+// at index at, and writes the table to the routes file.
+func (m *Manager) SetRoute(at int, route Route) error {
+	cleaned, err := cleanRoutes([]Route{route})
+	if err != nil {
+		return err
+	}
+	return m.editRoutes(func(routes []Route) ([]Route, error) {
+		if at < 0 {
+			return append(routes, cleaned[0]), nil
+		}
+		if at >= len(routes) {
+			return nil, fmt.Errorf("no route %d (there are %d)", at, len(routes))
+		}
+		routes[at] = cleaned[0]
+		return routes, nil
+	})
+}
+
+// DeleteRoute removes the route at index at and writes the table to the
+// routes file.
+func (m *Manager) DeleteRoute(at int) error {
+	return m.editRoutes(func(routes []Route) ([]Route, error) {
+		if at < 0 || at >= len(routes) {
+			return nil, fmt.Errorf("no route %d (there are %d)", at, len(routes))
+		}
+		return append(routes[:at], routes[at+1:]...), nil
+	})
+}
+
+// ResetRoutes deletes the routes file, going back to the built-ins.
+func (m *Manager) ResetRoutes() error {
+	m.routes.mu.Lock()
+	defer m.routes.mu.Unlock()
+	if err := os.Remove(m.routes.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	m.routes.routes, m.routes.err, m.routes.stamp = nil, nil, ""
+	return nil
+}
+
+// editRoutes applies edit to a copy of the table in use and saves it.
+func (m *Manager) editRoutes(edit func([]Route) ([]Route, error)) error {
+	m.routes.mu.Lock()
+	defer m.routes.mu.Unlock()
+	current := m.routes.routes
+	if current == nil {
+		current = builtinRoutes
+	}
+	routes, err := edit(append([]Route(nil), current...))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.routes.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, m.routes.path); err != nil {
+		return err
+	}
+	m.routes.routes, m.routes.err = routes, nil
+	m.routes.stamp = routesStamp(m.routes.path) // our own write isn't a change to reload
+	return nil
+}
+
+// cleanRoutes checks routes and lower-cases their keywords, as queries are
+// matched lower-cased.
+func cleanRoutes(routes []Route) ([]Route, error) {
+	out := make([]Route, 0, len(routes))
+	for i, r := range routes {
+		var c Route
+		for _, kw := range r.Keywords {
+			if kw = strings.ToLower(kw); strings.TrimSpace(kw) != "" {
+				c.Keywords = append(c.Keywords, kw)
+			}
+		}
+		for _, u := range r.URLs {
+			u = strings.TrimSpace(u)
+			if p, err := url.Parse(u); err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+				return nil, fmt.Errorf("route %d: not an http(s) URL: %q", i, u)
+			}
+			c.URLs = append(c.URLs, u)
+		}
+		if len(c.Keywords) == 0 || len(c.URLs) == 0 {
+			return nil, fmt.Errorf("route %d needs at least one keyword and one URL", i)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// routesStamp is the size and modification time of the routes file, "" if
+// there isn't one.
+func routesStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+}
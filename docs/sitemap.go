@@ -0,0 +1,181 @@
+package docs
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Sitemaps ──────────────────────────────────────────────────────────────────
+// The contents pages list what the docs are organized around, not every
+// page; the site's sitemaps list every page. SitemapPages reads them (the
+// ones robots.txt names, else /sitemap.xml) for the pinned Unity version,
+// so a topic can be fetched whole ("everything under Physics") and an index
+// can be checked for the pages it's missing.
+
+const (
+	// sitemapTTL is how long a sitemap read is trusted.
+	sitemapTTL = 24 * time.Hour
+	// maxSitemaps caps how many sitemap files one read follows.
+	maxSitemaps = 50
+	// maxSitemapBytes caps the size of one sitemap file.
+	maxSitemapBytes = 50 << 20
+)
+
+// sitemapCache is the page list last read, per docs version.
+type sitemapCache struct {
+	mu      sync.Mutex
+	version string
+	fetched time.Time
+	pages   []string
+}
+
+// sitemapXML is a sitemap index (<sitemap> entries) or a URL set (<url>
+// entries); only the locations matter.
+type sitemapXML struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// SitemapPages returns every Manual and Scripting Reference page the docs
+// sitemaps list for the pinned version, as canonical URLs (see
+// search.CanonicalURL), sorted. The list is read again after a day.
+func (m *Manager) SitemapPages(ctx context.Context) ([]string, error) {
+	version := m.Version()
+	m.sitemap.mu.Lock()
+	if m.sitemap.pages != nil && m.sitemap.version == version && time.Since(m.sitemap.fetched) < sitemapTTL {
+		pages := m.sitemap.pages
+		m.sitemap.mu.Unlock()
+		return pages, nil
+	}
+	m.sitemap.mu.Unlock()
+
+	home, _ := url.Parse(docsHost + "/")
+	queue := m.robotsFor(ctx, home).sitemaps
+	// Where the sitemaps usually are, tried if robots.txt names none that list pages
+	fallback := []string{docsHost + "/sitemap.xml"}
+	if version != "" {
+		fallback = append([]string{docsHost + "/" + version + "/Documentation/sitemap.xml"}, fallback...)
+	}
+
+	seenMap, seenPage := map[string]bool{}, map[string]bool{}
+	var pages []string
+	var lastErr error
+	for read := 0; read < maxSitemaps; {
+		if len(queue) == 0 {
+			if len(pages) > 0 || len(fallback) == 0 {
+				break
+			}
+			queue, fallback = fallback, nil
+		}
+		loc := queue[0]
+		queue = queue[1:]
+		if seenMap[loc] || !wantSitemap(loc, version) {
+			continue
+		}
+		seenMap[loc] = true
+		read++
+		sm, err := m.readSitemap(ctx, loc)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		for _, s := range sm.Sitemaps {
+			queue = append(queue, strings.TrimSpace(s.Loc))
+		}
+		for _, u := range sm.URLs {
+			canon, v := search.CanonicalURL(strings.TrimSpace(u.Loc))
+			if (v != "" && v != version) || !isDocsPage(canon) || seenPage[canon] {
+				continue
+			}
+			seenPage[canon] = true
+			pages = append(pages, canon)
+		}
+	}
+	if len(pages) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no doc pages listed")
+		}
+		return nil, fmt.Errorf("no docs sitemap: %w", lastErr)
+	}
+	sort.Strings(pages)
+	m.sitemap.mu.Lock()
+	m.sitemap.version, m.sitemap.fetched, m.sitemap.pages = version, time.Now(), pages
+	m.sitemap.mu.Unlock()
+	return pages, nil
+}
+
+// wantSitemap skips the sitemaps of other Unity versions in an index that
+// lists them all.
+func wantSitemap(loc, version string) bool {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return false
+	}
+	first, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if v, ok := search.DocsVersion(first); ok && v != "" {
+		return v == version
+	}
+	return true
+}
+
+// readSitemap fetches and parses one sitemap file, gzipped or not.
+func (m *Manager) readSitemap(ctx context.Context, loc string) (sitemapXML, error) {
+	var sm sitemapXML
+	resp, err := m.get(ctx, loc)
+	if err != nil {
+		return sm, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return sm, fmt.Errorf("HTTP %d: %s", resp.StatusCode, loc)
+	}
+	var body io.Reader = io.LimitReader(resp.Body, maxSitemapBytes)
+	if strings.HasSuffix(loc, ".gz") {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return sm, fmt.Errorf("%s: %w", loc, err)
+		}
+		defer gz.Close()
+		body = io.LimitReader(gz, maxSitemapBytes)
+	}
+	if err := xml.NewDecoder(body).Decode(&sm); err != nil {
+		return sm, fmt.Errorf("%s: %w", loc, err)
+	}
+	return sm, nil
+}
+
+// TopicPages picks the pages about topic out of pages: those whose name
+// contains it, ignoring case. "Physics" gives Manual/PhysicsOverview.html,
+// ScriptReference/Physics.Raycast.html, ScriptReference/Physics2D.html and
+// the like; "" gives them all.
+func TopicPages(pages []string, topic string) []string {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	if topic == "" {
+		return pages
+	}
+	var out []string
+	for _, p := range pages {
+		name := strings.ToLower(strings.TrimSuffix(p[strings.LastIndexByte(p, '/')+1:], ".html"))
+		if strings.Contains(name, topic) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Stack Overflow ────────────────────────────────────────────────────────────
+// The other place errors get solved is Stack Overflow. SearchStackOverflow
+// asks the Stack Exchange API for questions tagged unity-game-engine (the
+// tag unity3d was merged into) with an accepted answer, and turns each into
+// a result like a forum thread's: the question and its accepted answer,
+// credited to whoever wrote it under the CC BY-SA licence answers are
+// published with. The API asks clients to hold off for a while after it
+// says "backoff", and only allows so many requests a day without a key,
+// so it's only asked once the docs and the forum have nothing.
+
+const (
+	// StackOverflowSource marks results from Stack Overflow.
+	StackOverflowSource = "stackoverflow"
+	// stackAPI is the Stack Exchange API.
+	stackAPI = "https://api.stackexchange.com/2.3"
+	// stackTag is the tag Unity questions are filed under.
+	stackTag = "unity-game-engine"
+	// maxStackQuestions is how many questions one search reads.
+	maxStackQuestions = 3
+)
+
+type stackQuestions struct {
+	Items []struct {
+		QuestionID       int    `json:"question_id"`
+		AcceptedAnswerID int    `json:"accepted_answer_id"`
+		Title            string `json:"title"`
+		Link             string `json:"link"`
+		Body             string `json:"body"`
+	} `json:"items"`
+	Backoff int `json:"backoff"`
+}
+
+type stackAnswers struct {
+	Items []struct {
+		AnswerID int    `json:"answer_id"`
+		Body     string `json:"body"`
+		Owner    struct {
+			DisplayName string `json:"display_name"`
+		} `json:"owner"`
+	} `json:"items"`
+	Backoff int `json:"backoff"`
+}
+
+// SearchStackOverflow returns up to three Stack Overflow questions about
+// Unity matching query, each as the question and its accepted answer.
+func (m *Manager) SearchStackOverflow(ctx context.Context, query string) ([]search.Result, error) {
+	if until := time.Unix(0, m.stackBackoff.Load()); time.Now().Before(until) {
+		return nil, fmt.Errorf("stack exchange asked to wait until %s", until.Format(time.TimeOnly))
+	}
+	q := url.Values{
+		"site": {"stackoverflow"}, "tagged": {stackTag}, "accepted": {"True"},
+		"q": {query}, "order": {"desc"}, "sort": {"relevance"},
+		"pagesize": {fmt.Sprint(maxStackQuestions)}, "filter": {"withbody"},
+	}
+	var found stackQuestions
+	if err := m.stackJSON(ctx, stackAPI+"/search/advanced?"+q.Encode(), &found, &found.Backoff); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, it := range found.Items {
+		if it.AcceptedAnswerID != 0 {
+			ids = append(ids, fmt.Sprint(it.AcceptedAnswerID))
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no answered Stack Overflow questions for: %s", query)
+	}
+
+	var answers stackAnswers
+	q = url.Values{"site": {"stackoverflow"}, "filter": {"withbody"}}
+	if err := m.stackJSON(ctx, stackAPI+"/answers/"+strings.Join(ids, ";")+"?"+q.Encode(), &answers, &answers.Backoff); err != nil {
+		return nil, err
+	}
+	var results []search.Result
+	for _, it := range found.Items {
+		for _, a := range answers.Items {
+			if a.AnswerID != it.AcceptedAnswerID {
+				continue
+			}
+			question := cleanContent(stripHTML(it.Body))
+			if len(question) > 2000 {
+				question = question[:2000]
+			}
+			author := html.UnescapeString(a.Owner.DisplayName)
+			content := "Question: " + question + "\n\nAccepted answer by " + author + ": " + cleanContent(stripHTML(a.Body))
+			if len(content) > 10000 {
+				content = content[:10000]
+			}
+			results = append(results, search.Result{
+				Title:   html.UnescapeString(it.Title),
+				URL:     fmt.Sprintf("https://stackoverflow.com/a/%d", a.AnswerID),
+				Excerpt: content,
+				Score:   1.0,
+				Source:  StackOverflowSource,
+				Code:    strings.TrimSpace(extractCode(a.Body)),
+				Label:   "Stack Overflow, answer by " + author + " (CC BY-SA)",
+			})
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no accepted answers for: %s", query)
+	}
+	return results, nil
+}
+
+// stackJSON fetches a Stack Exchange API URL into v, and holds later
+// requests off for as long as *backoff (decoded into v) says.
+func (m *Manager) stackJSON(ctx context.Context, u string, v any, backoff *int) error {
+	resp, err := m.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, u)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxForumBytes))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if *backoff > 0 {
+		m.stackBackoff.Store(time.Now().Add(time.Duration(*backoff) * time.Second).UnixNano())
+	}
+	return nil
+}
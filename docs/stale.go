@@ -0,0 +1,41 @@
+package docs
+
+import (
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Staleness ─────────────────────────────────────────────────────────────────
+// Refreshing the live docs fetched every core page again even when it had
+// been fetched an hour before. The validators (conditional.go) record when
+// each page was last downloaded or confirmed unchanged, so a refresh can
+// fetch only the pages older than an age and leave the rest alone.
+
+// FetchedAt is when the page at u was last downloaded or confirmed
+// unchanged, in the language and for the pinned version if they're set;
+// zero if never.
+func (m *Manager) FetchedAt(u string) time.Time {
+	m.loadValidators()
+	m.valid.mu.Lock()
+	defer m.valid.mu.Unlock()
+	for _, at := range []string{m.localized(u), m.pinned(u)} {
+		if v, ok := m.valid.byURL[at]; ok {
+			return v.Checked
+		}
+	}
+	return m.valid.byURL[u].Checked
+}
+
+// Stale returns the pages of urls not fetched within maxAge, and those
+// missing from have (nil to skip that check) however recently they were
+// fetched, in urls' order.
+func (m *Manager) Stale(urls []string, maxAge time.Duration, have search.PageSet) []string {
+	var stale []string
+	for _, u := range urls {
+		if time.Since(m.FetchedAt(u)) > maxAge || (have != nil && !have.Has(u) && !have.Has(m.localized(u))) {
+			stale = append(stale, u)
+		}
+	}
+	return stale
+}
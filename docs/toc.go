@@ -0,0 +1,143 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Scripting Reference contents as a symbol table ────────────────────────────
+// Without the offline docs, "Physics.OverlapSphereNonAlloc" went through the
+// keyword router (which knows a few dozen topics) and then Unity's search
+// page, and usually came back with Physics.html at best. The Scripting
+// Reference's table of contents names every page after its API, so it's
+// read once (and kept in cache/scriptref_toc.json for a week) as a map from
+// API name to page; an exact API named in a question goes straight to its
+// page.
+
+// symbolTOCTTL is how long the contents read is trusted.
+const symbolTOCTTL = 7 * 24 * time.Hour
+
+// symbolTOCRetry is how long to wait before trying again after a failed read.
+const symbolTOCRetry = 10 * time.Minute
+
+// symbolTOC is the Scripting Reference contents as API name → page.
+type symbolTOC struct {
+	mu      sync.Mutex
+	pages   map[string]string // search.SymbolKey of the page name → canonical URL
+	version string
+	loading bool
+	tried   time.Time // last read attempt
+}
+
+type symbolTOCFile struct {
+	Version string            `json:"version"`
+	Fetched time.Time         `json:"fetched"`
+	Pages   map[string]string `json:"pages"`
+}
+
+func (m *Manager) symbolTOCPath() string {
+	return filepath.Join(m.cacheDir, "scriptref_toc.json")
+}
+
+// LoadSymbolTOC reads the Scripting Reference contents for the pinned
+// version: from cache/scriptref_toc.json if it's recent, else from the site.
+func (m *Manager) LoadSymbolTOC(ctx context.Context) error {
+	version := m.Version()
+	m.symbols.mu.Lock()
+	if m.symbols.loading {
+		m.symbols.mu.Unlock()
+		return nil
+	}
+	m.symbols.loading, m.symbols.tried = true, time.Now()
+	m.symbols.mu.Unlock()
+	defer func() {
+		m.symbols.mu.Lock()
+		m.symbols.loading = false
+		m.symbols.mu.Unlock()
+	}()
+
+	var file symbolTOCFile
+	if data, err := os.ReadFile(m.symbolTOCPath()); err == nil && json.Unmarshal(data, &file) == nil &&
+		file.Version == version && time.Since(file.Fetched) < symbolTOCTTL && len(file.Pages) > 0 {
+		m.setSymbolTOC(version, file.Pages)
+		return nil
+	}
+	urls, err := m.tocPages(ctx, "ScriptReference")
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]string, len(urls))
+	for _, u := range urls {
+		name := strings.TrimSuffix(u[strings.LastIndexByte(u, '/')+1:], ".html")
+		if key := search.SymbolKey(name); key != "" && key != "index" {
+			pages[key] = u
+		}
+	}
+	m.setSymbolTOC(version, pages)
+	if data, err := json.Marshal(symbolTOCFile{Version: version, Fetched: time.Now(), Pages: pages}); err == nil {
+		os.MkdirAll(m.cacheDir, 0755)
+		os.WriteFile(m.symbolTOCPath(), data, 0644)
+	}
+	log.Printf("[docs] Scripting Reference contents: %d APIs", len(pages))
+	return nil
+}
+
+func (m *Manager) setSymbolTOC(version string, pages map[string]string) {
+	m.symbols.mu.Lock()
+	m.symbols.version, m.symbols.pages = version, pages
+	m.symbols.mu.Unlock()
+}
+
+// ResolveSymbols returns the Scripting Reference pages of the exact APIs
+// query names, like "Physics.OverlapSphereNonAlloc" (a namespace may be
+// left off: "CommandBuffer.Blit"). It never waits on the network: until the
+// contents have been read it returns nil and starts reading them.
+func (m *Manager) ResolveSymbols(query string) []string {
+	refs := search.SymbolRefs(query)
+	if len(refs) == 0 {
+		return nil
+	}
+	m.symbols.mu.Lock()
+	pages, stale := m.symbols.pages, m.symbols.version != m.Version()
+	idle := !m.symbols.loading && time.Since(m.symbols.tried) > symbolTOCRetry
+	m.symbols.mu.Unlock()
+	if (pages == nil || stale) && idle {
+		go func() {
+			if err := m.LoadSymbolTOC(context.Background()); err != nil {
+				log.Printf("[docs] No Scripting Reference contents: %v", err)
+			}
+		}()
+	}
+	if pages == nil || stale {
+		return nil
+	}
+	var urls []string
+	for _, ref := range refs {
+		key := search.SymbolKey(ref)
+		u, ok := pages[key]
+		if !ok {
+			// Without its namespace: Rendering.CommandBuffer.Blit
+			for k, page := range pages {
+				if strings.HasSuffix(k, "."+key) {
+					if ok {
+						u, ok = "", false // ambiguous
+						break
+					}
+					u, ok = page, true
+				}
+			}
+		}
+		if ok {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
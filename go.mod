@@ -1,3 +1,10 @@
 module unitymind
 
 go 1.21
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/text v0.14.0
+)
+
+require golang.org/x/sys v0.5.0 // indirect
@@ -1,17 +1,27 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,7 +29,10 @@ import (
 	"unitymind/docs"
 	"unitymind/offline"
 	"unitymind/openai"
+	"unitymind/review"
 	"unitymind/search"
+	"unitymind/starter"
+	"unitymind/stream"
 )
 
 //go:embed ui/index.html
@@ -32,17 +45,147 @@ type Config struct {
 	AutoUpdate      bool   `json:"auto_update_docs"`
 	LastDocUpdate   string `json:"last_doc_update"`
 	OfflineDocsPath string `json:"offline_docs_path"`
+
+	UpdateIntervalHours int  `json:"update_interval_hours,omitempty"` // default 24, see scheduledUpdates
+	RefreshMaxAgeHours  int  `json:"refresh_max_age_hours,omitempty"` // default 24, see docs.Manager.Stale
+	DeadLinkSample      int  `json:"dead_link_sample,omitempty"`      // default 50, -1 = none; see checkDeadLinks
+	ForumThreads        bool `json:"forum_threads,omitempty"`         // see docs.Manager.SearchForum
+	StackOverflow       bool `json:"stack_overflow,omitempty"`        // see docs.Manager.SearchStackOverflow
+	CSharpDocs          bool `json:"csharp_docs,omitempty"`           // see docs.Manager.SearchCSharpDocs
+	WatchDocs           bool `json:"watch_docs,omitempty"`            // see offline.Watcher
+	ReviewMode          bool `json:"review_mode,omitempty"`           // see review
+
+	IndexInclude      []string `json:"index_include,omitempty"` // see offline.Indexer.SetPatterns
+	IndexExclude      []string `json:"index_exclude,omitempty"`
+	IndexWorkers      int      `json:"index_workers,omitempty"`         // default auto, see offline.Indexer.SetWorkers
+	IndexReadMBPerSec int      `json:"index_read_mb_per_sec,omitempty"` // default no limit
+	IndexMaxFileMB    int      `json:"index_max_file_mb,omitempty"`     // default 10, see offline.Indexer.SetLimits
+	IndexMaxPages     int      `json:"index_max_pages,omitempty"`       // default 200,000
+	IndexKeepBinary   bool     `json:"index_keep_binary,omitempty"`
+
+	CrawlMaxPages    int      `json:"crawl_max_pages,omitempty"`    // default 5,000, see docs.Manager.CrawlDocs
+	CrawlMaxDepth    int      `json:"crawl_max_depth,omitempty"`    // default 1
+	CrawlDelayMs     int      `json:"crawl_delay_ms,omitempty"`     // default 500, see docs.Politeness
+	FetchRatePerSec  float64  `json:"fetch_rate_per_sec,omitempty"` // default 4
+	FetchConcurrency int      `json:"fetch_concurrency,omitempty"`  // default 2
+	IgnoreRobots     bool     `json:"ignore_robots,omitempty"`
+	FetchRetries     int      `json:"fetch_retries,omitempty"`     // default 2, -1 = none; see docs/retry.go
+	FetchUserAgent   string   `json:"fetch_user_agent,omitempty"`  // default docs.DefaultUserAgent
+	FetchHeaders     []string `json:"fetch_headers,omitempty"`     // "Name: value" or "host Name: value"
+	FetchMaxMB       int      `json:"fetch_max_mb,omitempty"`      // default 5, see docs/guard.go
+	FetchTimeoutSec  int      `json:"fetch_timeout_sec,omitempty"` // default 12
+
+	MarkdownPaths    []string    `json:"markdown_paths,omitempty"`     // Markdown and C# XML doc folders
+	DocSources       []DocSource `json:"doc_sources,omitempty"`        // see /api/config/sources
+	UnityProjectPath string      `json:"unity_project_path,omitempty"` // its Library/PackageCache docs are indexed
+	UnityVersion     string      `json:"unity_version"`                // e.g. "2022.3", "" = latest
+	DocLanguage      string      `json:"doc_language,omitempty"`       // "ja", "ko", "zh", "es", "en"; "" = any
+
+	Ranking           search.Ranking            `json:"ranking"`
+	Profiles          map[string]search.Ranking `json:"profiles,omitempty"`           // merged over the built-in profiles
+	ProfileDefaults   map[string]string         `json:"profile_defaults,omitempty"`   // API → profile, e.g. {"chat": "conceptual"}
+	Indexes           map[string]string         `json:"indexes,omitempty"`            // name → offline docs path
+	MaxMemoryMB       int                       `json:"max_memory_mb,omitempty"`      // page text per index, default no limit
+	StopWords         []string                  `json:"stop_words"`                   // default search.DefaultStopWords
+	SnapshotRetention int                       `json:"snapshot_retention,omitempty"` // default 5
+	Namespaces        map[string]Namespace      `json:"namespaces,omitempty"`
+	Timeouts          Timeouts                  `json:"timeouts"`
+}
+
+// Timeouts bounds the stages of /api/chat, in milliseconds (0 = default).
+// A stage that runs out is skipped as if it found nothing, so no question
+// takes much longer than their sum.
+type Timeouts struct {
+	LocalSearchMS int `json:"local_search_ms,omitempty"` // default 2000
+	LiveFetchMS   int `json:"live_fetch_ms,omitempty"`   // default 12000, all live pages together
+	LLMMS         int `json:"llm_ms,omitempty"`          // default 30000
+}
+
+func stageTimeout(ms int, def time.Duration) time.Duration {
+	if ms <= 0 { return def }
+	return time.Duration(ms) * time.Millisecond
+}
+
+func (t Timeouts) LocalSearch() time.Duration { return stageTimeout(t.LocalSearchMS, 2*time.Second) }
+func (t Timeouts) LiveFetch() time.Duration   { return stageTimeout(t.LiveFetchMS, 12*time.Second) }
+func (t Timeouts) LLM() time.Duration         { return stageTimeout(t.LLMMS, openai.DefaultTimeout) }
+
+// DocSource is a folder or archive of docs indexed into the default index
+// next to the offline docs: a team wiki, an asset's manual, another copy of
+// Unity's docs. Label names it in answer links (default: the folder name).
+type DocSource struct {
+	Path  string `json:"path"`
+	Label string `json:"label,omitempty"`
+}
+
+// Namespace is one team or game served by a shared deployment: its own docs,
+// prompt templates and stats, searched together with the common Unity docs.
+// A request picks it with "Authorization: Bearer <token>", or by name in the
+// X-UnityMind-Namespace header if it has no token. It can't change the
+// shared indexes or settings (see sharedOnly); removing, pruning and hiding
+// pages in it only affect its own.
+type Namespace struct {
+	Token string `json:"token,omitempty"`
+	// Shared index it searches, e.g. "2021.3" ("" = the active one)
+	UnityVersion string `json:"unity_version,omitempty"`
+	// Its own Markdown / XML doc folders, seen by nobody else
+	DocsPaths []string `json:"docs_paths,omitempty"`
 }
 
 var cfg Config
-var searcher *search.Engine
+var indexes *search.Registry
+var searcher *search.Engine // the default index
 var docManager *docs.Manager
 var offlineIndexer *offline.Indexer
+var docWatcher *offline.Watcher
+var prompts *openai.PromptStore
+var exclusions *search.ExclusionList
+var reviews *review.Store
+var tenants = map[string]*tenant{} // by namespace name, set up at startup
 var indexingProgress int32
 var indexingDone int32
+var indexRunsMu sync.Mutex
+var indexRuns = map[string]*indexRun{} // offline docs being indexed, by index name
+var indexReportsMu sync.Mutex
+var indexReports = map[string]*offline.IndexReport{} // the last run over each docs path, by path
+var editorDocsMu sync.Mutex
+var editorDocs []offline.EditorDocs // installed Editors' docs, offered on a first run with none configured
+var docCrawlMu sync.Mutex
+var docCrawl *docCrawlRun // the last crawl of the online docs, nil if none
+
+// docCrawlRun is a crawl of the online docs into an index, see handleDocsCrawl.
+type docCrawlRun struct {
+	Index    string             `json:"index"`
+	Status   string             `json:"status"` // running, done, cancelled or error
+	Error    string             `json:"error,omitempty"`
+	Progress docs.CrawlProgress `json:"progress"`
+	Started  time.Time          `json:"started"`
+	Finished time.Time          `json:"finished,omitempty"`
+	cancel   context.CancelFunc
+}
+var compactMu sync.Mutex
+var compaction *compactState // the offer to compact the last docs indexed, or its run; nil if none
+
+// compactState is an index's docs that could be compacted into a corpus
+// (see offline.Compact), and how doing so went.
+type compactState struct {
+	Index  string                 `json:"index"`
+	Path   string                 `json:"path"`
+	Bytes  int64                  `json:"bytes"`
+	Status string                 `json:"status"` // offered, running, done or error
+	Error  string                 `json:"error,omitempty"`
+	Report *offline.CompactReport `json:"report,omitempty"`
+	Freed  int64                  `json:"freed,omitempty"` // bytes freed by deleting the original
+}
+
+// indexRun is one offline indexing in progress, see startIndexing.
+type indexRun struct {
+	cancel     context.CancelFunc
+	superseded atomic.Bool // cancelled by a newer run over the same index
+}
 
 func loadConfig() {
-	cfg = Config{OpenAIKey: "", OpenAIModel: "gpt-4o-mini", Port: 7331, AutoUpdate: true}
+	cfg = Config{OpenAIKey: "", OpenAIModel: "gpt-4o-mini", Port: 7331, AutoUpdate: true, Ranking: search.DefaultRanking()}
 	data, err := os.ReadFile("config.json")
 	if err != nil { saveConfig(); return }
 	json.Unmarshal(data, &cfg)
@@ -74,6 +217,28 @@ func waitForPort(port int) {
 
 type ChatRequest struct {
 	Message string `json:"message"`
+	Index   string `json:"index"`
+	Profile string `json:"profile"`
+	// UseAI=false keeps the answer doc-only even when an OpenAI key is set.
+	// The UI sends it with every message of a conversation; nil means allowed.
+	UseAI   *bool `json:"use_ai"`
+	// MaxChars caps the answer length (0 = no cap), cutting at section
+	// boundaries; the first code block is kept unless DropCode is set.
+	MaxChars int  `json:"max_chars"`
+	DropCode bool `json:"drop_code"`
+	// Format is how the answer is written: "markdown" (default), "text",
+	// "html", "ansi" or "slack", or a media type like "text/html"
+	Format string `json:"format"`
+	// User is any stable id for the asker, used for their excluded pages;
+	// the UI keeps one in localStorage. "" gets only the global exclusions.
+	User string `json:"user"`
+	// Stream sends the answer as server-sent events while it's written,
+	// see stream.EventSink; the last event, "done", is the ChatResponse.
+	Stream bool `json:"stream"`
+	// SlackResponseURL and DiscordMessageURL have the answer written into
+	// a bot's reply as it comes, see stream.SlackSink and stream.DiscordSink
+	SlackResponseURL  string `json:"slack_response_url"`
+	DiscordMessageURL string `json:"discord_message_url"`
 	History []struct {
 		Role    string `json:"role"`
 		Content string `json:"content"`
@@ -86,6 +251,237 @@ type ChatResponse struct {
 	Links      []docs.DocLink `json:"links"`
 	Elapsed    string         `json:"elapsed"`
 	Understood string         `json:"understood"`
+	Index      string         `json:"index,omitempty"`
+	DidYouMean string         `json:"did_you_mean,omitempty"`
+	// More is set when further local hits exist; page through them with
+	// /api/search?q=<SearchQuery>&offset=5
+	More        bool   `json:"more,omitempty"`
+	SearchQuery string `json:"search_query,omitempty"`
+	// PromptVersion is the "template@version" an AI answer was generated with
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Params are the answer code's tunable values; change them with /api/params
+	Params []brain.Param `json:"params,omitempty"`
+	// Format the answer is written in, when not Markdown
+	Format string `json:"format,omitempty"`
+}
+
+// defaultProfiles is which ranking profile each API uses out of the box
+var defaultProfiles = map[string]string{"chat": "conceptual", "hover": "api"}
+
+// rankingProfiles merges config profiles over the built-in ones.
+func rankingProfiles() map[string]search.Ranking {
+	profiles := search.BuiltinProfiles()
+	for name, p := range cfg.Profiles { profiles[name] = p }
+	return profiles
+}
+
+// pickProfile resolves the ranking for a request: ?profile= wins over the body
+// field, then the API's configured default. The profile is layered over the
+// engine's own ranking. Returns ok=false if the name is unknown.
+func pickProfile(r *http.Request, api, fromBody string, engine *search.Engine) (string, search.Ranking, bool) {
+	name := r.URL.Query().Get("profile")
+	if name == "" { name = fromBody }
+	if name == "" { name = cfg.ProfileDefaults[api] }
+	if name == "" { name = defaultProfiles[api] }
+	if name == "" { return "", engine.Ranking().ForLanguage(pickLanguage(r)), true }
+	p, ok := rankingProfiles()[name]
+	return name, p.Over(engine.Ranking()).ForLanguage(pickLanguage(r)), ok
+}
+
+// pickLanguage is the documentation language a request prefers: ?lang=
+// wins over the one chosen in settings.
+func pickLanguage(r *http.Request) string {
+	if lang := strings.ToLower(r.URL.Query().Get("lang")); offline.IsLanguage(lang) { return lang }
+	return cfg.DocLanguage
+}
+
+// pickIndex resolves the index a request wants: ?index= wins over the body field.
+// Returns nil if the name is unknown.
+func pickIndex(r *http.Request, fromBody string) (string, *search.Engine) {
+	name := r.URL.Query().Get("index")
+	if name == "" { name = fromBody }
+	if t, _ := pickNamespace(r); name == "" && t != nil { name = t.ns.UnityVersion }
+	if name == "" { name = activeIndex() }
+	return name, indexes.Get(name)
+}
+
+// activeIndex is where requests go when they don't pick an index: the one
+// named after the Unity version chosen in settings, if it's been indexed.
+func activeIndex() string {
+	if v := cfg.UnityVersion; v != "" && indexes.Get(v) != nil { return v }
+	return search.DefaultIndex
+}
+
+// linkVersion is the docs version a result should link to: the version its
+// page was indexed from, else the one chosen in settings.
+func linkVersion(r search.Result) string {
+	if r.Version != "" { return r.Version }
+	return cfg.UnityVersion
+}
+
+// pickUser resolves who is asking: ?user= wins over the body field. Users of
+// a namespace are kept apart from same-named ones elsewhere.
+func pickUser(r *http.Request, fromBody string) string {
+	u := r.URL.Query().Get("user")
+	if u == "" { u = strings.TrimSpace(fromBody) }
+	if t, _ := pickNamespace(r); t != nil && u != "" { u = t.name + "/" + u }
+	return u
+}
+
+// ── Namespaces ────────────────────────────────────────────────────────────────
+
+// tenant is a namespace's runtime state. Its engine holds only its own docs;
+// the Unity docs stay in the shared indexes, loaded once for everybody.
+type tenant struct {
+	name    string
+	ns      Namespace
+	dir     string // cache/ns/<name>
+	engine  *search.Engine
+	indexer *offline.Indexer // keeps its own manifests, so folders can be shared
+	prompts *openai.PromptStore
+	mu      sync.Mutex
+	answers map[string]int // questions answered, by source
+}
+
+var reNamespace = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// openTenants loads every configured namespace and indexes its docs folders
+// in the background.
+func openTenants() {
+	for name, ns := range cfg.Namespaces {
+		if !reNamespace.MatchString(name) { log.Printf("[ns] Skipping %q: use letters, digits, - and _", name); continue }
+		dir := filepath.Join("cache", "ns", name)
+		t := &tenant{name: name, ns: ns, dir: dir, engine: search.NewEngine(), indexer: offline.NewIndexer(dir),
+			prompts: openai.LoadPrompts(filepath.Join(dir, "prompts.json")), answers: map[string]int{}}
+		t.engine.SetRanking(searcher.Ranking())
+		t.engine.LoadCache(filepath.Join(dir, "docs_index.json"))
+		t.engine.WarmUp()
+		tenants[name] = t
+		log.Printf("[ns] Namespace %q: %d docs from cache.", name, t.engine.DocCount())
+		for _, path := range ns.DocsPaths { go t.indexDocs(path) }
+	}
+}
+
+// indexDocs (re)indexes one of the namespace's folders into its own engine.
+func (t *tenant) indexDocs(root string) {
+	upd, err := t.indexer.IndexMarkdown(context.Background(), root, t.engine.DocCount() > 0, nil)
+	if err != nil { log.Printf("[ns] %s: %v", t.name, err); return }
+	t.engine.AddResults(upd.Results)
+	t.engine.RemoveDocs(upd.Removed)
+	t.engine.SaveCache(filepath.Join(t.dir, "docs_index.json"))
+	log.Printf("[ns] %s: %d pages indexed, %d removed, %d unchanged from %s", t.name, len(upd.Results), len(upd.Removed), upd.Unchanged, root)
+}
+
+// count tallies an answered question for the namespace's stats.
+func (t *tenant) count(source string) {
+	if t == nil { return }
+	t.mu.Lock()
+	t.answers[source]++
+	t.mu.Unlock()
+}
+
+// pickNamespace resolves the namespace a request is made in: nil with ok=true
+// for none, ok=false for a token or name that doesn't match one.
+func pickNamespace(r *http.Request) (*tenant, bool) {
+	if len(tenants) == 0 { return nil, true }
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		for _, t := range tenants {
+			if t.ns.Token != "" && subtle.ConstantTimeCompare([]byte(t.ns.Token), []byte(strings.TrimSpace(token))) == 1 { return t, true }
+		}
+		return nil, false
+	}
+	name := r.Header.Get("X-UnityMind-Namespace")
+	if name == "" { return nil, true }
+	t := tenants[name]
+	if t == nil || t.ns.Token != "" { return nil, false }
+	return t, true
+}
+
+// hiddenFrom is the pages hidden from user in namespace t: everyone's, the
+// namespace's (see handleExclusions) and the user's own.
+func hiddenFrom(t *tenant, user string) search.PageSet {
+	hide := exclusions.For(user)
+	if t != nil {
+		for p := range exclusions.For(t.name + "/") { hide[p] = true }
+	}
+	return hide
+}
+
+// sharedOnly keeps a namespace from using h, which changes what every
+// namespace shares (the shared indexes, settings, fetching): requests made
+// in one, or with a token or namespace that matches none, are answered 403.
+// With reads, a namespace may still GET what h reports.
+func sharedOnly(h http.HandlerFunc, reads bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := pickNamespace(r)
+		if ok && (t == nil || reads && r.Method == http.MethodGet) { h(w, r); return }
+		msg := "Unknown namespace or token."
+		if t != nil { msg = "Namespace " + t.name + " cannot change shared docs or settings." }
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": msg})
+	}
+}
+
+// searchIn searches the shared index and, in a namespace, its own docs too,
+// merged by score.
+func searchIn(t *tenant, engine *search.Engine, query string, offset, limit int, rk search.Ranking, code bool, hide search.PageSet) ([]search.Result, bool) {
+	if t == nil { return engine.SearchPage(query, offset, limit, rk, code, hide) }
+	shared, sharedMore := engine.SearchPage(query, 0, offset+limit, rk, code, hide)
+	own, ownMore := t.engine.SearchPage(query, 0, offset+limit, rk, code, hide)
+	all := append(shared, own...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score > all[j].Score })
+	more := sharedMore || ownMore || len(all) > offset+limit
+	if offset > len(all) { offset = len(all) }
+	if offset+limit < len(all) { all = all[:offset+limit] }
+	return all[offset:], more
+}
+
+// pinSymbol puts the page of the first API a question names in full
+// ("AudioSource.PlayOneShot") first in its results, scored as the best hit
+// and at least the threshold, however the ranking placed it.
+func pinSymbol(t *tenant, engine *search.Engine, raw string, results []search.Result, threshold float64, hide search.PageSet) []search.Result {
+	engines := []*search.Engine{engine}
+	if t != nil { engines = append(engines, t.engine) }
+	for _, e := range engines {
+		for _, sym := range e.SymbolsIn(raw) {
+			if hide.Has(sym.URL) { continue }
+			page := e.Page(sym.URL)
+			if len(page) == 0 { continue }
+			pinned := page[0]
+			pinned.Score = threshold
+			if len(results) > 0 { pinned.Score = max(results[0].Score, threshold) }
+			kept := []search.Result{pinned}
+			for _, r := range results {
+				if r.URL != pinned.URL { kept = append(kept, r) }
+			}
+			return kept
+		}
+	}
+	return results
+}
+
+// pickFormat resolves the answer format: ?format= wins over the body field.
+// Returns ok=false if neither names a format brain.Render knows.
+func pickFormat(r *http.Request, fromBody string) (string, bool) {
+	f := r.URL.Query().Get("format")
+	if f == "" { f = fromBody }
+	if f == "" { return brain.DefaultFormat, true }
+	name := brain.FormatFor(f)
+	return name, name != ""
+}
+
+// allowAI reports whether the request permits the OpenAI fallback:
+// ?use_ai= wins over the body field, and both default to allowed.
+func allowAI(r *http.Request, fromBody *bool) bool {
+	switch r.URL.Query().Get("use_ai") {
+	case "false", "0":
+		return false
+	case "true", "1":
+		return true
+	}
+	return fromBody == nil || *fromBody
 }
 
 func handleChat(w http.ResponseWriter, r *http.Request) {
@@ -97,95 +493,487 @@ func handleChat(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		json.NewEncoder(w).Encode(ChatResponse{Answer: "Invalid request.", Source: "error"}); return
 	}
+	// ?max_chars= wins over the body field, like ?index= and ?profile=
+	maxChars := req.MaxChars
+	if v := r.URL.Query().Get("max_chars"); v != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil { n = -1 }
+		maxChars = n
+	}
+	if maxChars < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ChatResponse{Answer: "max_chars must be a whole number, 0 for no cap.", Source: "error"}); return
+	}
+
+	format, ok := pickFormat(r, req.Format)
+	// send writes a response: as JSON, or as the "done" event of a stream
+	var events *stream.EventSink
+	if req.Stream { events = stream.NewEventSink(w, format) }
+	send := func(resp ChatResponse) {
+		if events != nil { events.Event("done", resp); return }
+		json.NewEncoder(w).Encode(resp)
+	}
+	if !ok {
+		send(ChatResponse{Answer: "Unknown format, want one of: " + strings.Join(brain.Formats(), ", "), Source: "error"}); return
+	}
+
+	t, ok := pickNamespace(r)
+	if !ok {
+		send(ChatResponse{Answer: "Unknown namespace or token.", Source: "error"}); return
+	}
+	indexName, engine := pickIndex(r, req.Index)
+	if engine == nil {
+		send(ChatResponse{Answer: "Unknown index: " + indexName, Source: "error"}); return
+	}
+
+	profileName, rk, ok := pickProfile(r, "chat", req.Profile, engine)
+	if !ok {
+		send(ChatResponse{Answer: "Unknown ranking profile: " + profileName, Source: "error"}); return
+	}
+
+	relays, err := openRelays(r.Context(), req, events)
+	if err != nil {
+		send(ChatResponse{Answer: err.Error(), Source: "error"}); return
+	}
+	// reply sends a response with its answer in the format asked for; the
+	// steps below keep the Markdown for review cards and params. Front-ends
+	// following the answer as it's written are shown the whole of it.
+	reply := func(resp ChatResponse) {
+		for _, rl := range relays {
+			if err := rl.Finish(resp.Answer); err != nil { log.Printf("[stream] Cannot show the answer: %v", err) }
+		}
+		resp.Answer, _ = brain.Render(resp.Answer, format)
+		if format != brain.DefaultFormat { resp.Format = format }
+		send(resp)
+	}
 
 	start := time.Now()
 	raw := strings.TrimSpace(req.Message)
 	if raw == "" {
-		json.NewEncoder(w).Encode(ChatResponse{Answer: "Ask me anything about Unity!", Source: "error"}); return
+		reply(ChatResponse{Answer: "Ask me anything about Unity!", Source: "error"}); return
+	}
+	// An API the docs mark obsolete (rigidbody.velocity in Unity 6): every
+	// answer opens by pointing at its replacement, and the search looks for both
+	renames := engine.ObsoleteIn(raw)
+	note := renameNote(renames)
+	fit := func(answer string) string { return brain.Truncate(note+answer, maxChars, req.DropCode) }
+
+	// Questions about UnityMind itself are answered from live state, not the docs
+	if brain.IsMetaQuery(raw) {
+		reply(ChatResponse{
+			Answer:  fit(brain.MetaAnswer(raw, systemState())),
+			Source:  "system",
+			Elapsed: time.Since(start).Round(time.Millisecond).String(),
+			Index:   indexName,
+		})
+		return
+	}
+
+	// "List all AudioSource methods" wants an inventory from the API catalog,
+	// not the few most relevant pages
+	if cq, ok := brain.ParseCatalogQuery(raw); ok {
+		if members := engine.Members(cq.Class); len(members) > 0 {
+			reply(ChatResponse{
+				Answer:  fit(brain.CatalogAnswer(cq, cq.Select(members))),
+				Source:  "local_docs",
+				Elapsed: time.Since(start).Round(time.Millisecond).String(),
+				Index:   indexName,
+			})
+			return
+		}
 	}
 
 	// Step 0: Understand the query with NLU
 	pq := offline.UnderstandQuery(raw)
 	searchQuery := pq.EnhancedQuery()
 	understood := pq.Summary()
+	for _, rn := range renames { searchQuery += " " + rn.New }
 
 	brainHistory := make([]brain.HistoryEntry, len(req.History))
 	for i, h := range req.History {
 		brainHistory[i] = brain.HistoryEntry{Role: h.Role, Content: h.Content}
 	}
 
-	// Step 1: Local index search (enhanced + raw fallback)
-	results := searcher.Search(searchQuery, 5)
-	if len(results) == 0 || results[0].Score < 0.4 {
-		rawResults := searcher.Search(raw, 5)
-		if len(rawResults) > 0 && (len(results) == 0 || rawResults[0].Score > results[0].Score) {
-			results = rawResults
+	// Step 1: Local index search (enhanced + raw fallback), page-type boosts
+	// leaning toward what the question is after
+	conceptual := pq.IsExplain && !pq.IsCodeReq
+	rk = rk.ForIntent(pq.IsCodeReq || len(pq.APISymbols) > 0, conceptual)
+	threshold := rk.Threshold
+	// Pages the user (or everyone) asked never to see are left out of every step
+	user := pickUser(r, req.User)
+	hide := hiddenFrom(t, user)
+	// Code requests prefer pages with sample code. A search can't be
+	// interrupted, so one that runs out of time finishes unseen.
+	type localHit struct {
+		results []search.Result
+		more    bool
+		query   string
+	}
+	found := make(chan localHit, 1)
+	go func() {
+		results, more := searchIn(t, engine, searchQuery, 0, 5, rk, pq.IsCodeReq, hide)
+		usedQuery := searchQuery
+		if len(results) == 0 || results[0].Score < threshold {
+			rawResults, rawMore := searchIn(t, engine, raw, 0, 5, rk, pq.IsCodeReq, hide)
+			if len(rawResults) > 0 && (len(results) == 0 || rawResults[0].Score > results[0].Score) {
+				results, more, usedQuery = rawResults, rawMore, raw
+			}
 		}
+		results = pinSymbol(t, engine, raw, results, threshold, hide)
+		found <- localHit{results, more, usedQuery}
+	}()
+	var hit localHit
+	select {
+	case hit = <-found:
+	case <-time.After(cfg.Timeouts.LocalSearch()):
+		log.Printf("[chat] Local search gave up after %s: %q", cfg.Timeouts.LocalSearch(), raw)
+	case <-r.Context().Done():
+		return
 	}
+	results, more, usedQuery := hit.results, hit.more, hit.query
 	elapsed := time.Since(start)
 
-	if len(results) > 0 && results[0].Score >= 0.4 {
-		json.NewEncoder(w).Encode(ChatResponse{
-			Answer:     brain.Synthesize(raw, results, brainHistory),
-			Source:     "local_docs",
+	// Nothing solid locally — maybe it's a typo
+	didYouMean := ""
+	if len(results) == 0 || results[0].Score < threshold {
+		didYouMean = engine.DidYouMean(raw)
+	}
+
+	// An exact API the index doesn't have ("Physics.OverlapSphereNonAlloc"
+	// without the offline docs) and nothing local answers well is answered
+	// from its own page, fetched live into the index searched, rather than
+	// from whatever local page ranked best
+	localOK := len(results) > 0 && results[0].Score >= threshold
+	if pages := docManager.ResolveSymbols(raw); !localOK && len(pages) > 0 && len(engine.SymbolsIn(raw)) == 0 && (t == nil || len(t.engine.SymbolsIn(raw)) == 0) {
+		exactCtx, cancelExact := context.WithTimeout(r.Context(), cfg.Timeouts.LiveFetch())
+		exact, _ := docManager.RefreshPages(exactCtx, pages[:min(len(pages), 2)])
+		cancelExact()
+		if len(exact) > 0 {
+			engine.AddResults(exact)
+			go indexes.Save(indexName)
+			exact = hide.Filter(exact)
+		}
+		if len(exact) > 0 {
+			touchLive(exact)
+			answer := fit(brain.Synthesize(raw, exact, brainHistory))
+			remember(user, raw, answer, "live_docs")
+			t.count("live_docs")
+			reply(ChatResponse{
+				Answer:     answer,
+				Source:     "live_docs",
+				Links:      toLinks(exact),
+				Elapsed:    time.Since(start).Round(time.Millisecond).String(),
+				Understood: understood,
+				Index:      indexName,
+				DidYouMean: didYouMean,
+				Params:     brain.Parameters(answer),
+			})
+			return
+		}
+	}
+
+	if localOK {
+		source := "local_docs"
+		switch results[0].Source {
+		case search.StarterSource: source = "starter_docs"
+		case offline.PackageSource: source = "package_docs"
+		case offline.MarkdownSource: source = "markdown_docs"
+		case offline.XMLDocSource: source = "xml_docs"
+		case docs.ForumSource: source = "forum_threads"
+		case docs.StackOverflowSource: source = "stack_overflow"
+		case docs.CSharpSource: source = "csharp_docs"
+		case docs.ReleaseSource: source = "release_notes"
+		}
+		touchLive(results)
+		answer := fit(brain.Synthesize(raw, results, brainHistory))
+		remember(user, raw, answer, source)
+		t.count(source)
+		reply(ChatResponse{
+			Answer:     answer,
+			Source:     source,
 			Links:      toLinks(results),
 			Elapsed:    elapsed.Round(time.Millisecond).String(),
 			Understood: understood,
+			Index:      indexName,
+			More:        more,
+			SearchQuery: usedQuery,
+			Params:      brain.Parameters(answer),
 		})
 		return
 	}
 
-	// Step 2: Live docs
-	liveResults, err := docManager.SearchLive(raw)
+	// Step 2: Solved forum threads, for errors the docs don't answer
+	if pq.IsFix && cfg.ForumThreads {
+		forumCtx, cancelForum := context.WithTimeout(r.Context(), cfg.Timeouts.LiveFetch())
+		threads, err := docManager.SearchForum(forumCtx, raw)
+		cancelForum()
+		if errors.Is(err, context.DeadlineExceeded) { log.Printf("[chat] Forum search gave up after %s: %q", cfg.Timeouts.LiveFetch(), raw) }
+		if err == nil {
+			// Threads are indexed with their credit, so the next asker finds them locally
+			engine.AddResults(threads)
+			go indexes.Save(indexName)
+			threads = hide.Filter(threads)
+		}
+		if len(threads) > 0 {
+			answer := fit(brain.Synthesize(raw, threads, brainHistory))
+			remember(user, raw, answer, "forum_threads")
+			t.count("forum_threads")
+			reply(ChatResponse{
+				Answer:     answer,
+				Source:     "forum_threads",
+				Links:      toLinks(threads),
+				Elapsed:    time.Since(start).Round(time.Millisecond).String(),
+				Understood: understood,
+				Index:      indexName,
+				DidYouMean: didYouMean,
+				Params:     brain.Parameters(answer),
+			})
+			return
+		}
+	}
+
+	// Step 3: Answered Stack Overflow questions, for errors the forum didn't solve either
+	if pq.IsFix && cfg.StackOverflow {
+		soCtx, cancelSO := context.WithTimeout(r.Context(), cfg.Timeouts.LiveFetch())
+		answers, err := docManager.SearchStackOverflow(soCtx, raw)
+		cancelSO()
+		if errors.Is(err, context.DeadlineExceeded) { log.Printf("[chat] Stack Overflow search gave up after %s: %q", cfg.Timeouts.LiveFetch(), raw) }
+		if err == nil {
+			engine.AddResults(answers)
+			go indexes.Save(indexName)
+			answers = hide.Filter(answers)
+		}
+		if len(answers) > 0 {
+			answer := fit(brain.Synthesize(raw, answers, brainHistory))
+			remember(user, raw, answer, "stack_overflow")
+			t.count("stack_overflow")
+			reply(ChatResponse{
+				Answer:     answer,
+				Source:     "stack_overflow",
+				Links:      toLinks(answers),
+				Elapsed:    time.Since(start).Round(time.Millisecond).String(),
+				Understood: understood,
+				Index:      indexName,
+				DidYouMean: didYouMean,
+				Params:     brain.Parameters(answer),
+			})
+			return
+		}
+	}
+
+	// Step 4: The C# docs, for questions about the language the Unity docs
+	// never answer
+	if cfg.CSharpDocs && docManager.IsCSharpQuestion(raw) {
+		csCtx, cancelCS := context.WithTimeout(r.Context(), cfg.Timeouts.LiveFetch())
+		pages, err := docManager.SearchCSharpDocs(csCtx, raw)
+		cancelCS()
+		if errors.Is(err, context.DeadlineExceeded) { log.Printf("[chat] C# docs gave up after %s: %q", cfg.Timeouts.LiveFetch(), raw) }
+		if err == nil {
+			engine.AddResults(pages)
+			go indexes.Save(indexName)
+			pages = hide.Filter(pages)
+		}
+		if len(pages) > 0 {
+			answer := fit(brain.Synthesize(raw, pages, brainHistory))
+			remember(user, raw, answer, "csharp_docs")
+			t.count("csharp_docs")
+			reply(ChatResponse{
+				Answer:     answer,
+				Source:     "csharp_docs",
+				Links:      toLinks(pages),
+				Elapsed:    time.Since(start).Round(time.Millisecond).String(),
+				Understood: understood,
+				Index:      indexName,
+				DidYouMean: didYouMean,
+				Params:     brain.Parameters(answer),
+			})
+			return
+		}
+	}
+
+	// Step 5: Live docs
+	liveCtx, cancelLive := context.WithTimeout(r.Context(), cfg.Timeouts.LiveFetch())
+	liveResults, err := docManager.SearchLiveContext(liveCtx, raw)
+	cancelLive()
+	if errors.Is(err, context.DeadlineExceeded) { log.Printf("[chat] Live docs gave up after %s: %q", cfg.Timeouts.LiveFetch(), raw) }
 	elapsed = time.Since(start)
 	if err == nil && len(liveResults) > 0 {
-		searcher.AddResults(liveResults)
-		go searcher.SaveCache("cache/docs_index.json")
-		json.NewEncoder(w).Encode(ChatResponse{
-			Answer:     brain.Synthesize(raw, liveResults, brainHistory),
+		// Like everything fetched for a question, live pages are cached in
+		// the index searched; each keeps the version it was fetched for.
+		engine.AddResults(liveResults)
+		go indexes.Save(indexName)
+		liveResults = hide.Filter(liveResults)
+	}
+	if len(liveResults) > 0 {
+		touchLive(liveResults)
+		answer := fit(brain.Synthesize(raw, liveResults, brainHistory))
+		remember(user, raw, answer, "live_docs")
+		t.count("live_docs")
+		reply(ChatResponse{
+			Answer:     answer,
 			Source:     "live_docs",
 			Links:      toLinks(liveResults),
 			Elapsed:    elapsed.Round(time.Millisecond).String(),
 			Understood: understood,
+			Index:      indexName,
+			DidYouMean: didYouMean,
+			Params:     brain.Parameters(answer),
 		})
 		return
 	}
 
-	// Step 3: OpenAI fallback
-	if cfg.OpenAIKey != "" {
+	// Step 6: OpenAI fallback
+	if cfg.OpenAIKey != "" && allowAI(r, req.UseAI) {
 		client := openai.NewClient(cfg.OpenAIKey, cfg.OpenAIModel)
+		client.SetMaxChars(maxChars)
 		oaHistory := make([]openai.HistoryEntry, len(req.History))
 		for i, h := range req.History { oaHistory[i] = openai.HistoryEntry{Role: h.Role, Content: h.Content} }
-		aiAnswer, err := client.Ask(raw, oaHistory)
+		store := prompts
+		if t != nil { store = t.prompts }
+		system, promptVersion, err := store.Resolve(openai.SystemPrompt, r.URL.Query().Get("prompt"))
+		if err != nil {
+			reply(ChatResponse{Answer: err.Error(), Source: "error"}); return
+		}
+		aiCtx, cancelAI := context.WithTimeout(r.Context(), cfg.Timeouts.LLM())
+		var aiAnswer string
+		if len(relays) > 0 {
+			for _, rl := range relays { rl.Write(note) }
+			aiAnswer, err = client.AskStream(aiCtx, system, raw, oaHistory, func(piece string) {
+				for _, rl := range relays { rl.Write(piece) }
+			})
+		} else {
+			aiAnswer, err = client.AskContext(aiCtx, system, raw, oaHistory)
+		}
+		cancelAI()
+		if errors.Is(err, context.DeadlineExceeded) { log.Printf("[openai] Gave up after %s: %q", cfg.Timeouts.LLM(), raw) }
 		elapsed = time.Since(start)
 		if err == nil {
-			json.NewEncoder(w).Encode(ChatResponse{
-				Answer: aiAnswer, Source: "openai",
+			log.Printf("[openai] Answered with prompt %s in %s", promptVersion, elapsed.Round(time.Millisecond))
+			remember(user, raw, aiAnswer, "openai")
+			t.count("openai")
+			reply(ChatResponse{
+				Answer: fit(aiAnswer), Source: "openai",
 				Elapsed: elapsed.Round(time.Millisecond).String(), Understood: understood,
+				DidYouMean: didYouMean, PromptVersion: promptVersion,
 			})
 			return
 		}
 	}
 
 	noKey := ""
-	if cfg.OpenAIKey == "" { noKey = " Add an OpenAI key in ⚙️ Settings to enable AI fallback." }
-	json.NewEncoder(w).Encode(ChatResponse{
-		Answer:     "I couldn't find anything about that in the docs." + noKey,
+	if cfg.OpenAIKey == "" { noKey = " Add an OpenAI key in ⚙️ Settings to enable AI fallback." } else if !allowAI(r, req.UseAI) { noKey = " AI fallback is off for this conversation." }
+	t.count("not_found")
+	reply(ChatResponse{
+		Answer:     note + "I couldn't find anything about that in the docs." + noKey,
 		Source:     "not_found",
 		Elapsed:    time.Since(start).Round(time.Millisecond).String(),
 		Understood: understood,
+		DidYouMean: didYouMean,
 	})
 }
 
+// openRelays returns a relay for each front-end that follows a chat answer
+// as it's written: the request's own event stream, and the bot replies it
+// names.
+func openRelays(ctx context.Context, req ChatRequest, events *stream.EventSink) ([]*stream.Relay, error) {
+	var relays []*stream.Relay
+	if events != nil { relays = append(relays, stream.NewRelay(ctx, events)) }
+	if u := strings.TrimSpace(req.SlackResponseURL); u != "" {
+		sink, err := stream.NewSlackSink(u)
+		if err != nil { return nil, fmt.Errorf("Bad slack_response_url: %v", err) }
+		relays = append(relays, stream.NewRelay(ctx, sink))
+	}
+	if u := strings.TrimSpace(req.DiscordMessageURL); u != "" {
+		sink, err := stream.NewDiscordSink(u)
+		if err != nil { return nil, fmt.Errorf("Bad discord_message_url: %v", err) }
+		relays = append(relays, stream.NewRelay(ctx, sink))
+	}
+	return relays, nil
+}
+
+// remember keeps an answered question as a review flashcard, in review mode.
+func remember(user, question, answer, source string) {
+	if !cfg.ReviewMode || user == "" { return }
+	if err := reviews.Record(user, question, brain.KeyPoints(answer), source, time.Now()); err != nil {
+		log.Printf("[review] Cannot save: %v", err)
+	}
+}
+
+// systemState snapshots what's loaded and configured, for meta questions.
+func systemState() brain.SystemState {
+	st := brain.SystemState{
+		AppVersion:      "1.1.0",
+		UnityVersion:    cfg.UnityVersion,
+		LastDocUpdate:   cfg.LastDocUpdate,
+		OfflineDocsPath: cfg.OfflineDocsPath,
+		IndexingDone:    atomic.LoadInt32(&indexingDone) == 1,
+		IndexingPercent: int(atomic.LoadInt32(&indexingProgress)),
+		OpenAIEnabled:   cfg.OpenAIKey != "",
+		OpenAIModel:     cfg.OpenAIModel,
+	}
+	for _, name := range indexes.Names() {
+		stats := indexes.Get(name).Stats(0)
+		ix := brain.IndexState{Name: name, Docs: stats.Docs, Sources: map[string]int{}}
+		for src, ss := range stats.Sources {
+			ix.Sources[src] = ss.Docs
+		}
+		st.Indexes = append(st.Indexes, ix)
+	}
+	return st
+}
+
+// toLinks returns one link per page. Results arrive best-first, so when several
+// chunks of a page matched, the link keeps the anchor of the best one.
 func toLinks(results []search.Result) []docs.DocLink {
 	links := make([]docs.DocLink, 0, len(results))
 	seen := map[string]bool{}
 	for _, r := range results {
-		if !seen[r.URL] { seen[r.URL] = true; links = append(links, docs.DocLink{Title: r.Title, URL: r.URL}) }
+		u, _ := search.CanonicalURL(r.URL)
+		page := search.PageURL(u)
+		if seen[page] { continue }
+		seen[page] = true
+		if link, ok := liveLink(search.VersionedURL(u, linkVersion(r))); ok { links = append(links, docLink(r.Title, link, r, false)) }
+	}
+	// Then the pages the hits themselves point to, best hits first
+	added := 0
+	for _, r := range results {
+		for _, rel := range r.Related {
+			if added == maxRelatedLinks { return links }
+			u, _ := search.CanonicalURL(rel.URL)
+			page := search.PageURL(u)
+			if seen[page] { continue }
+			seen[page] = true
+			link, ok := liveLink(search.VersionedURL(u, linkVersion(r)))
+			if !ok { continue }
+			added++
+			links = append(links, docLink(rel.Title, link, r, true))
+		}
 	}
 	return links
 }
 
+// maxRelatedLinks caps the "See also" pages added to an answer's links.
+const maxRelatedLinks = 3
+
+// docLink is the link to u for hit r: the page r is, or one it names as
+// related, which only shares its label, source and version.
+func docLink(title, u string, r search.Result, related bool) docs.DocLink {
+	link := docs.DocLink{Title: title, URL: u, Label: r.Label, Section: docs.Section(u), Source: r.Source, Related: related}
+	if _, v := search.CanonicalURL(u); v != "" { link.Version = v }
+	if !related {
+		link.Topic, link.Breadcrumb, link.Score = search.Topic(r.Tags), search.Trail(r.Tags), r.Score
+	}
+	return link
+}
+
+// liveLink returns what to link to for u: u, or the page that replaced it
+// if a dead-link check found it gone; ok=false if nothing replaced it.
+func liveLink(u string) (string, bool) {
+	page := search.PageURL(u)
+	if link, ok := docManager.LiveLink(page); !ok || link != page { return link, ok }
+	return u, true
+}
+
 func handleConfig(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -196,7 +984,45 @@ func handleConfig(w http.ResponseWriter, r *http.Request) {
 			"port":              cfg.Port,
 			"last_doc_update":   cfg.LastDocUpdate,
 			"doc_count":         searcher.DocCount(),
+			"starter_docs":      searcher.SourceCount(search.StarterSource),
 			"offline_docs_path": cfg.OfflineDocsPath,
+			"unity_project_path": cfg.UnityProjectPath,
+			"package_docs":      searcher.SourceCount(offline.PackageSource),
+			"watch_docs":        cfg.WatchDocs,
+			"auto_update_docs":  cfg.AutoUpdate,
+			"forum_threads":     cfg.ForumThreads,
+			"stack_overflow":    cfg.StackOverflow,
+			"csharp_docs":       cfg.CSharpDocs,
+			"update_interval_hours": int(updateInterval() / time.Hour),
+			"refresh_max_age_hours": int(refreshMaxAge() / time.Hour),
+			"dead_link_sample":      deadLinkSample(),
+			"review_mode":       cfg.ReviewMode,
+			"markdown_paths":    cfg.MarkdownPaths,
+			"index_include":     cfg.IndexInclude,
+			"index_exclude":     cfg.IndexExclude,
+			"index_workers":     cfg.IndexWorkers,
+			"index_read_mb_per_sec": cfg.IndexReadMBPerSec,
+			"index_max_file_mb": cfg.IndexMaxFileMB,
+			"crawl_max_pages":   cfg.CrawlMaxPages,
+			"crawl_max_depth":   cfg.CrawlMaxDepth,
+			"crawl_delay_ms":    cfg.CrawlDelayMs,
+			"fetch_rate_per_sec": cfg.FetchRatePerSec,
+			"fetch_concurrency": cfg.FetchConcurrency,
+			"ignore_robots":     cfg.IgnoreRobots,
+			"fetch_retries":     cfg.FetchRetries,
+			"fetch_user_agent":  docManager.UserAgent(),
+			"fetch_headers":     cfg.FetchHeaders,
+			"fetch_max_mb":      cfg.FetchMaxMB,
+			"fetch_timeout_sec": cfg.FetchTimeoutSec,
+			"index_max_pages":   cfg.IndexMaxPages,
+			"index_keep_binary": cfg.IndexKeepBinary,
+			"doc_sources":       cfg.DocSources,
+			"markdown_docs":     searcher.SourceCount(offline.MarkdownSource),
+			"xml_docs":          searcher.SourceCount(offline.XMLDocSource),
+			"unity_version":     cfg.UnityVersion,
+			"doc_language":      cfg.DocLanguage,
+			"indexes":           indexes.Names(),
+			"active_index":      activeIndex(),
 			"indexing_progress": atomic.LoadInt32(&indexingProgress),
 			"indexing_done":     atomic.LoadInt32(&indexingDone) == 1,
 		})
@@ -205,136 +1031,2101 @@ func handleConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
 		var update map[string]string
 		json.NewDecoder(r.Body).Decode(&update)
+		// One pattern per line; a change re-indexes the Unity docs
+		include, exclude := cfg.IndexInclude, cfg.IndexExclude
+		if v, ok := update["index_include"]; ok { include = splitLines(v) }
+		if v, ok := update["index_exclude"]; ok { exclude = splitLines(v) }
+		for _, p := range append(append([]string{}, include...), exclude...) {
+			if err := offline.CheckPattern(p); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Bad pattern " + p + ": " + err.Error()})
+				return
+			}
+		}
+		// Hours between scheduled updates; blank is the default
+		interval := cfg.UpdateIntervalHours
+		if v, ok := update["update_interval_hours"]; ok {
+			v = strings.TrimSpace(v)
+			n, err := strconv.Atoi(v)
+			if v == "" { n, err = 0, nil }
+			if err != nil || n < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Update interval must be a whole number of hours: " + v})
+				return
+			}
+			interval = n
+		}
+		ints := map[string]int{}
+		for _, set := range intSettings {
+			v, ok := update[set.key]
+			if !ok { continue }
+			v = strings.TrimSpace(v)
+			n, err := strconv.Atoi(v)
+			if v == "" || set.key == "index_workers" && v == "auto" { n, err = 0, nil }
+			if err != nil || n < set.min || n > set.max {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": fmt.Sprintf("%s must be a whole number from %d to %d: %s", set.key, set.min, set.max, v)})
+				return
+			}
+			ints[set.key] = n
+		}
+		rate := cfg.FetchRatePerSec
+		if v, ok := update["fetch_rate_per_sec"]; ok {
+			v = strings.TrimSpace(v)
+			f, err := strconv.ParseFloat(v, 64)
+			if v == "" { f, err = 0, nil }
+			if err != nil || !(f >= 0 && f <= maxFetchRate) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": fmt.Sprintf("fetch_rate_per_sec must be a number from 0 to %d: %s", maxFetchRate, v)})
+				return
+			}
+			rate = f
+		}
+		version, setVersion := update["unity_version"]
+		if setVersion {
+			var valid bool
+			if version, valid = search.DocsVersion(version); !valid {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Not a Unity version: " + update["unity_version"] + " (e.g. 2022.3 or 6000.0)"})
+				return
+			}
+		}
+		if v, ok := update["doc_language"]; ok && v != "" && !offline.IsLanguage(v) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Not a docs language: " + v + " (one of " + strings.Join(offline.Languages, ", ") + ")"})
+			return
+		}
+		// Everything is valid: from here on the settings change
 		if key, ok := update["openai_key"]; ok { cfg.OpenAIKey = key }
 		if model, ok := update["openai_model"]; ok { cfg.OpenAIModel = model }
+		if setVersion {
+			cfg.UnityVersion = version
+			docManager.SetVersion(version)
+		}
+		if v, ok := update["doc_language"]; ok { cfg.DocLanguage = v; docManager.SetLanguage(v) }
 		if path, ok := update["offline_docs_path"]; ok && path != cfg.OfflineDocsPath {
 			cfg.OfflineDocsPath = path
-			if path != "" { go indexOfflineDocs(path) }
+			if path != "" { go indexOfflineDocs(search.DefaultIndex, path) }
+		}
+		if v, ok := update["watch_docs"]; ok { cfg.WatchDocs = v == "true" }
+		if strings.Join(include, "\n") != strings.Join(cfg.IndexInclude, "\n") || strings.Join(exclude, "\n") != strings.Join(cfg.IndexExclude, "\n") {
+			cfg.IndexInclude, cfg.IndexExclude = include, exclude
+			offlineIndexer.SetPatterns(include, exclude)
+			for name, path := range docPaths() {
+				if path != "" && !strings.HasPrefix(name, markdownWatch) && !strings.HasPrefix(name, sourceWatch) { go indexOfflineDocs(name, path) }
+			}
+			for _, src := range cfg.DocSources {
+				if offline.IsUnityDocs(src.Path) { go indexDocSource(src, true) }
+			}
+		}
+		if v, ok := update["auto_update_docs"]; ok { cfg.AutoUpdate = v == "true" }
+		if v, ok := update["forum_threads"]; ok { cfg.ForumThreads = v == "true" }
+		if v, ok := update["stack_overflow"]; ok { cfg.StackOverflow = v == "true" }
+		if v, ok := update["csharp_docs"]; ok { cfg.CSharpDocs = v == "true" }
+		cfg.UpdateIntervalHours = interval
+		cfg.FetchRatePerSec = rate
+		for _, set := range intSettings {
+			if n, ok := ints[set.key]; ok { *set.field() = n }
+		}
+		if v, ok := update["ignore_robots"]; ok { cfg.IgnoreRobots = v == "true" }
+		if v, ok := update["fetch_user_agent"]; ok {
+			cfg.FetchUserAgent = strings.TrimSpace(v)
+			if cfg.FetchUserAgent == docs.DefaultUserAgent { cfg.FetchUserAgent = "" }
+			applyHeaders()
+		}
+		if v, ok := update["fetch_headers"]; ok { cfg.FetchHeaders = splitLines(v); applyHeaders() }
+		if v, ok := update["index_keep_binary"]; ok { cfg.IndexKeepBinary = v == "true" }
+		applyIndexThrottle()
+		applyPoliteness()
+		applyFetchLimits()
+		if v, ok := update["review_mode"]; ok { cfg.ReviewMode = v == "true" }
+		// One folder per line; new ones are indexed, dropped ones forgotten
+		if v, ok := update["markdown_paths"]; ok { setMarkdownPaths(strings.Split(v, "\n")) }
+		if path, ok := update["unity_project_path"]; ok && path != cfg.UnityProjectPath {
+			cfg.UnityProjectPath = strings.TrimSpace(path)
+			if cfg.UnityProjectPath != "" {
+				go indexPackageDocs(cfg.UnityProjectPath)
+			} else if searcher.DropSource(offline.PackageSource) > 0 {
+				indexes.Save(search.DefaultIndex)
+			}
 		}
 		saveConfig()
+		docWatcher.Set(watchedDocs())
 		json.NewEncoder(w).Encode(map[string]string{"status": "saved"})
 	}
 }
 
-func indexOfflineDocs(path string) {
-	log.Printf("[offline] Indexing: %s", path)
+// intSettings are the whole-number settings /api/config takes and the
+// values each accepts. Blank (or 0) is the default; index_workers also
+// takes "auto".
+var intSettings = []struct {
+	key      string
+	min, max int
+	field    func() *int
+}{
+	{"index_workers", 0, 256, func() *int { return &cfg.IndexWorkers }},
+	{"index_read_mb_per_sec", 0, 100000, func() *int { return &cfg.IndexReadMBPerSec }},
+	{"index_max_file_mb", 0, 4096, func() *int { return &cfg.IndexMaxFileMB }},
+	{"index_max_pages", 0, 10000000, func() *int { return &cfg.IndexMaxPages }},
+	{"refresh_max_age_hours", 0, 24 * 365, func() *int { return &cfg.RefreshMaxAgeHours }},
+	{"dead_link_sample", -1, 100000, func() *int { return &cfg.DeadLinkSample }},
+	{"crawl_max_pages", 0, 1000000, func() *int { return &cfg.CrawlMaxPages }},
+	{"crawl_max_depth", 0, 50, func() *int { return &cfg.CrawlMaxDepth }},
+	{"crawl_delay_ms", 0, 60000, func() *int { return &cfg.CrawlDelayMs }},
+	{"fetch_concurrency", 0, 64, func() *int { return &cfg.FetchConcurrency }},
+	{"fetch_retries", -1, 10, func() *int { return &cfg.FetchRetries }},
+	{"fetch_max_mb", 0, 1024, func() *int { return &cfg.FetchMaxMB }},
+	{"fetch_timeout_sec", 0, 600, func() *int { return &cfg.FetchTimeoutSec }},
+}
+
+// maxFetchRate caps fetch_rate_per_sec.
+const maxFetchRate = 100
+
+// indexSaveEvery is how often a long indexing run saves what it has so far.
+const indexSaveEvery = 30 * time.Second
+
+func indexOfflineDocs(name, path string) {
+	log.Printf("[offline] Indexing into %q: %s", name, path)
+	ctx, run, finish := startIndexing(name)
+	defer finish()
 	atomic.StoreInt32(&indexingDone, 0)
 	atomic.StoreInt32(&indexingProgress, 0)
-	results, err := offlineIndexer.IndexPath(path, func(done, total int) {
+	progress := func(done, total int) {
 		if total > 0 {
 			atomic.StoreInt32(&indexingProgress, int32(float64(done)/float64(total)*100))
 		}
 		if done%200 == 0 { log.Printf("[offline] %d / %d pages indexed...", done, total) }
+	}
+	engine := indexes.Open(name)
+	reportIndexing(name, path, nil, nil)
+	// Tag every page with the Unity version it documents, so its links and
+	// answers stay version-correct whichever index it's searched from
+	version := offline.DetectVersion(path)
+	if version == "" { version = offline.DetectVersion(name) }
+	// Pages go into the engine in batches as they're parsed, the Scripting
+	// API and common Manual pages first, so a first run (minutes) is
+	// searchable within seconds; the index is saved every indexSaveEvery on
+	// the way so a restart mid-run doesn't lose what's done. With the last
+	// run's pages still indexed only changed files are parsed; otherwise
+	// (first run, lost cache) everything is
+	batches, lastSave := 0, time.Now()
+	upd, err := offlineIndexer.IndexStream(ctx, path, engine.SourceCount("offline") > 0, progress, func(batch []search.Result) {
+		for i := range batch { batch[i].Version = version }
+		if batches == 0 { snapshotBefore(name, "reindex") }
+		engine.AddResults(batch)
+		if batches++; batches == 1 { engine.WarmUp(); log.Printf("[offline] %d pages searchable while the rest is indexed", len(batch)) }
+		if time.Since(lastSave) > indexSaveEvery { indexes.Save(name); lastSave = time.Now() }
 	})
+	reportIndexing(name, path, upd.Report, err)
+	if errors.Is(err, context.Canceled) {
+		log.Printf("[offline] Indexing %q cancelled: %s", name, path)
+		// A newer run over the same index is still going and owns the progress
+		if !run.superseded.Load() { atomic.StoreInt32(&indexingDone, 1) }
+		return
+	}
 	if err != nil {
 		log.Printf("[offline] Error: %v", err)
 		atomic.StoreInt32(&indexingDone, 1)
 		return
 	}
-	searcher.AddResults(results)
-	searcher.SaveCache("cache/docs_index.json")
-	cfg.LastDocUpdate = fmt.Sprintf("Offline docs — %d pages", len(results))
-	saveConfig()
+	if batches == 0 && len(upd.Removed) > 0 { snapshotBefore(name, "reindex") }
+	engine.RemoveDocs(upd.Removed)
+	engine.DropSource(search.StarterSource)
+	engine.WarmUp()
+	indexes.Save(name)
+	if name == search.DefaultIndex {
+		cfg.LastDocUpdate = fmt.Sprintf("Offline docs — %d pages", engine.SourceCount("offline"))
+		saveConfig()
+	}
 	atomic.StoreInt32(&indexingProgress, 100)
 	atomic.StoreInt32(&indexingDone, 1)
-	log.Printf("[offline] Done! %d pages indexed from %s", len(results), path)
+	offerCompaction(name, path)
+	log.Printf("[offline] Done! %d pages indexed, %d removed, %d files unchanged from %s (Unity version %q)", upd.Pages, len(upd.Removed), upd.Unchanged, path, version)
 }
 
-func handleDocsUpdate(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	go func() {
-		results, err := docManager.FetchCoreDocs()
-		if err != nil { log.Printf("[docs] Error: %v", err); return }
-		searcher.AddResults(results)
-		searcher.SaveCache("cache/docs_index.json")
-		cfg.LastDocUpdate = time.Now().Format("2006-01-02 15:04")
-		saveConfig()
-		log.Printf("[docs] Refreshed: %d pages", len(results))
-	}()
-	json.NewEncoder(w).Encode(map[string]string{"status": "update_started"})
+// startIndexing registers an offline indexing of index name so it can be
+// cancelled, cancelling any run already going over the same index: the newer
+// path wins. finish unregisters it.
+func startIndexing(name string) (context.Context, *indexRun, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &indexRun{cancel: cancel}
+	indexRunsMu.Lock()
+	if old := indexRuns[name]; old != nil { old.superseded.Store(true); old.cancel() }
+	indexRuns[name] = run
+	indexRunsMu.Unlock()
+	return ctx, run, func() {
+		indexRunsMu.Lock()
+		if indexRuns[name] == run { delete(indexRuns, name) }
+		indexRunsMu.Unlock()
+		cancel()
+	}
 }
 
-func handleIndexOffline(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	var body struct{ Path string `json:"path"` }
-	json.NewDecoder(r.Body).Decode(&body)
-	path := strings.TrimSpace(body.Path)
-	if path == "" { path = cfg.OfflineDocsPath }
-	if path == "" { path = offline.FindDocPath(nil) }
-	if path == "" {
-		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No offline docs path found."})
-		return
+// watchedDocs is what the doc watcher should watch: index name → docs path.
+func watchedDocs() map[string]string {
+	if !cfg.WatchDocs { return map[string]string{} }
+	return docPaths()
+}
+
+// docPaths is every docs path indexed: index name → path, with Markdown
+// folders and doc sources named as below.
+func docPaths() map[string]string {
+	paths := map[string]string{}
+	paths[search.DefaultIndex] = cfg.OfflineDocsPath
+	for name, path := range cfg.Indexes { paths[name] = path }
+	for _, path := range cfg.MarkdownPaths { paths[markdownWatch+path] = path }
+	for _, src := range cfg.DocSources { paths[sourceWatch+src.Path] = src.Path }
+	return paths
+}
+
+// markdownWatch prefixes the watch names of Markdown folders, sourceWatch
+// those of doc sources.
+const markdownWatch = "markdown:"
+const sourceWatch = "source:"
+
+// onDocsChanged re-indexes the files that changed under a watched path.
+// While another indexing run is going it declines, so the watcher retries.
+func onDocsChanged(name, path string) bool {
+	if atomic.LoadInt32(&indexingDone) == 0 { return false }
+	if strings.HasPrefix(name, markdownWatch) {
+		log.Printf("[offline] %s changed — updating notes", path)
+		indexMarkdownDocs(path)
+		return true
 	}
-	cfg.OfflineDocsPath = path
-	saveConfig()
-	go indexOfflineDocs(path)
-	json.NewEncoder(w).Encode(map[string]interface{}{"status": "indexing_started", "path": path})
+	if strings.HasPrefix(name, sourceWatch) {
+		for _, src := range cfg.DocSources {
+			if src.Path == path { log.Printf("[offline] %s changed — updating %q", path, src.Label); indexDocSource(src, true) }
+		}
+		return true
+	}
+	log.Printf("[offline] %s changed — updating index %q", path, name)
+	indexOfflineDocs(name, path)
+	return true
 }
 
-func handleStatus(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":            "ok",
-		"doc_count":         searcher.DocCount(),
-		"version":           "1.1.0",
-		"indexing_progress": atomic.LoadInt32(&indexingProgress),
-		"indexing_done":     atomic.LoadInt32(&indexingDone) == 1,
+// applyIndexThrottle hands the indexing worker count, read limit and size
+// limits to the indexer; a run already going keeps the ones it started with.
+func applyIndexThrottle() {
+	offlineIndexer.SetWorkers(cfg.IndexWorkers)
+	offlineIndexer.SetReadLimit(int64(cfg.IndexReadMBPerSec) << 20)
+	offlineIndexer.SetLimits(int64(cfg.IndexMaxFileMB) << 20, cfg.IndexMaxPages, cfg.IndexKeepBinary)
+}
+
+// applyPoliteness hands the fetch politeness settings to the doc manager.
+func applyPoliteness() {
+	docManager.SetPoliteness(docs.Politeness{
+		RatePerSec:   cfg.FetchRatePerSec,
+		Concurrency:  cfg.FetchConcurrency,
+		Delay:        time.Duration(cfg.CrawlDelayMs) * time.Millisecond,
+		IgnoreRobots: cfg.IgnoreRobots,
+		Retries:      cfg.FetchRetries,
 	})
 }
 
-func main() {
-	log.Println("╔══════════════════════════════════╗")
-	log.Println("║      UnityMind v1.1.0            ║")
-	log.Println("╚══════════════════════════════════╝")
+// applyFetchLimits hands the download size and time limits to the doc manager.
+func applyFetchLimits() {
+	docManager.SetLimits(docs.Limits{
+		MaxBytes: int64(cfg.FetchMaxMB) << 20,
+		Timeout:  time.Duration(cfg.FetchTimeoutSec) * time.Second,
+	})
+}
 
-	loadConfig()
-	searcher = search.NewEngine()
-	docManager = docs.NewManager("cache")
-	offlineIndexer = offline.NewIndexer()
+// applyHeaders hands the User-Agent and extra headers to the doc manager,
+// skipping header lines it can't read.
+func applyHeaders() {
+	var extra []docs.Header
+	for _, line := range cfg.FetchHeaders {
+		h, err := docs.ParseHeader(line)
+		if err != nil { log.Printf("[docs] Skipping fetch header: %v", err); continue }
+		extra = append(extra, h)
+	}
+	docManager.SetHeaders(cfg.FetchUserAgent, extra)
+}
 
-	if err := searcher.LoadCache("cache/docs_index.json"); err != nil {
-		log.Printf("[search] No cache: %v", err)
-	} else {
-		log.Printf("[search] Loaded %d docs from cache.", searcher.DocCount())
+// splitLines returns the non-blank lines of a settings text box, trimmed.
+func splitLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" { lines = append(lines, l) }
 	}
+	return lines
+}
 
-	// ── Offline docs detection & indexing ─────────────────────────────────────
-	log.Println("[offline] Looking for UnityDocumentation.zip or extracted folder...")
+// setMarkdownPaths replaces the Markdown folders: new ones are indexed in the
+// background and the pages of dropped ones removed.
+func setMarkdownPaths(paths []string) {
+	keep := map[string]bool{}
+	var cleaned []string
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" && !keep[p] { keep[p] = true; cleaned = append(cleaned, p) }
+	}
+	was := map[string]bool{}
+	for _, p := range cfg.MarkdownPaths {
+		was[p] = true
+		if !keep[p] && searcher.RemoveDocs(offlineIndexer.ForgetMarkdown(p)) > 0 { indexes.Save(search.DefaultIndex) }
+	}
+	for _, p := range cleaned {
+		if !was[p] { go indexMarkdownDocs(p) }
+	}
+	cfg.MarkdownPaths = cleaned
+}
 
-	if cfg.OfflineDocsPath != "" {
-		log.Printf("[offline] Config path: %s", cfg.OfflineDocsPath)
-		if searcher.DocCount() >= 100 {
-			log.Printf("[offline] Cache already has %d pages — skipping re-index.", searcher.DocCount())
-			atomic.StoreInt32(&indexingDone, 1)
-			atomic.StoreInt32(&indexingProgress, 100)
-		} else {
-			go indexOfflineDocs(cfg.OfflineDocsPath)
+// indexMarkdownDocs (re)indexes a folder of Markdown notes and XML doc files
+// into the default index. Once notes are indexed only changed files are parsed.
+func indexMarkdownDocs(root string) {
+	upd, err := offlineIndexer.IndexMarkdown(context.Background(), root, searcher.SourceCount(offline.MarkdownSource)+searcher.SourceCount(offline.XMLDocSource) > 0, nil)
+	if err != nil { log.Printf("[offline] Markdown: %v", err); return }
+	searcher.AddResults(upd.Results)
+	searcher.RemoveDocs(upd.Removed)
+	indexes.Save(search.DefaultIndex)
+	log.Printf("[offline] Markdown: %d notes and types indexed, %d removed, %d unchanged from %s", len(upd.Results), len(upd.Removed), upd.Unchanged, root)
+}
+
+// setDocSources replaces the doc sources: new ones, and ones given a new
+// label, are indexed in the background; the pages of dropped ones removed.
+func setDocSources(sources []DocSource) {
+	keep := map[string]DocSource{}
+	var cleaned []DocSource
+	for _, src := range sources {
+		src.Path, src.Label = strings.TrimSpace(src.Path), strings.TrimSpace(src.Label)
+		if src.Path == "" { continue }
+		if _, dup := keep[src.Path]; dup { continue }
+		if src.Label == "" { src.Label = filepath.Base(src.Path) }
+		keep[src.Path] = src
+		cleaned = append(cleaned, src)
+	}
+	was := map[string]DocSource{}
+	for _, src := range cfg.DocSources {
+		was[src.Path] = src
+		if now, ok := keep[src.Path]; !ok || now.Label != src.Label {
+			if searcher.RemoveDocs(offlineIndexer.ForgetPath(src.Path)) > 0 { indexes.Save(search.DefaultIndex) }
 		}
-	} else {
-		detected := offline.FindDocPath(nil)
-		if detected != "" {
+	}
+	for _, src := range cleaned {
+		if old, ok := was[src.Path]; !ok || old.Label != src.Label { go indexDocSource(src, false) }
+	}
+	cfg.DocSources = cleaned
+}
+
+// indexDocSource (re)indexes a doc source into the default index: Unity-style
+// HTML docs (an archive, or a folder with Manual/) like the offline docs,
+// anything else as Markdown notes and XML doc files. Every page is labelled
+// with the source. With changedOnly only changed files are parsed.
+func indexDocSource(src DocSource, changedOnly bool) {
+	ctx, _, finish := startIndexing(sourceWatch + src.Path)
+	defer finish()
+	reportIndexing(search.DefaultIndex, src.Path, nil, nil)
+	var upd offline.IndexUpdate
+	var err error
+	switch {
+	case offline.IsUnityDocs(src.Path) && changedOnly:
+		upd, err = offlineIndexer.IndexChanged(ctx, src.Path, nil)
+	case offline.IsUnityDocs(src.Path):
+		upd.Results, err = offlineIndexer.IndexPath(ctx, src.Path, nil)
+	default:
+		upd, err = offlineIndexer.IndexMarkdown(ctx, src.Path, changedOnly, nil)
+	}
+	reportIndexing(search.DefaultIndex, src.Path, upd.Report, err)
+	if err != nil { log.Printf("[offline] Doc source %q: %v", src.Label, err); return }
+	for i := range upd.Results { upd.Results[i].Label = src.Label }
+	searcher.AddResults(upd.Results)
+	searcher.RemoveDocs(upd.Removed)
+	indexes.Save(search.DefaultIndex)
+	log.Printf("[offline] Doc source %q: %d pages indexed, %d removed, %d unchanged from %s", src.Label, len(upd.Results), len(upd.Removed), upd.Unchanged, src.Path)
+}
+
+// handleDocSources lists the doc sources (GET /api/config/sources) or
+// replaces them (POST {"sources": [{"path": "...", "label": "..."}]}).
+func handleDocSources(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodPost {
+		var body struct {
+			Sources []DocSource `json:"sources"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Invalid request: " + err.Error()})
+			return
+		}
+		for _, src := range body.Sources {
+			if _, err := os.Stat(strings.TrimSpace(src.Path)); err != nil && strings.TrimSpace(src.Path) != "" {
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Not found: " + src.Path})
+				return
+			}
+		}
+		setDocSources(body.Sources)
+		saveConfig()
+		docWatcher.Set(watchedDocs())
+	}
+	sources := []DocSource{}
+	if cfg.DocSources != nil { sources = cfg.DocSources }
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "sources": sources})
+}
+
+// indexPackageDocs (re)indexes the docs of the packages installed in a Unity
+// project into the default index, replacing any from an earlier run.
+func indexPackageDocs(projectPath string) {
+	results, err := offlineIndexer.IndexPackages(projectPath, nil)
+	if err != nil { log.Printf("[offline] Package docs: %v", err); return }
+	searcher.DropSource(offline.PackageSource)
+	searcher.AddResults(results)
+	indexes.Save(search.DefaultIndex)
+	log.Printf("[offline] Indexed %d package doc pages from %s", len(results), projectPath)
+}
+
+// coreDocCount is how many default-index docs are Unity's own docs, not the
+// bundled starter pages or package docs.
+func coreDocCount() int {
+	return searcher.DocCount() - searcher.SourceCount(search.StarterSource) - searcher.SourceCount(offline.PackageSource)
+}
+
+// handleDocsUpdate refreshes the core doc pages fetched longer ago than
+// the refresh age, or all of them with {"all": true}.
+func handleDocsUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		All bool `json:"all"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	maxAge := refreshMaxAge()
+	if body.All { maxAge = 0 }
+	go refreshCoreDocs(maxAge)
+	json.NewEncoder(w).Encode(map[string]string{"status": "update_started"})
+}
+
+// refreshCoreDocs fetches the core doc pages fetched longer ago than maxAge,
+// or missing from the default index, from docs.unity3d.com into it.
+func refreshCoreDocs(maxAge time.Duration) {
+	pages := docManager.Stale(docs.CoreDocs(), maxAge, searcher.Pages())
+	if len(pages) == 0 { log.Printf("[docs] Core pages all fetched within %s, nothing to refresh", maxAge); return }
+	results, failed := docManager.RefreshPages(context.Background(), pages)
+	if len(results) == 0 { log.Printf("[docs] Error: could not fetch any of %d stale core pages (offline?)", failed); return }
+	searcher.AddResults(results)
+	searcher.DropSource(search.StarterSource)
+	searcher.SaveCache("cache/docs_index.json")
+	cfg.LastDocUpdate = time.Now().Format("2006-01-02 15:04")
+	saveConfig()
+	log.Printf("[docs] Refreshed: %d of %d core pages (%d failed, the rest fetched within %s)", len(results), len(docs.CoreDocs()), failed, maxAge)
+}
+
+// refreshMaxAge is how old a live page must be for a refresh to fetch it.
+func refreshMaxAge() time.Duration {
+	if cfg.RefreshMaxAgeHours <= 0 { return 24 * time.Hour }
+	return time.Duration(cfg.RefreshMaxAgeHours) * time.Hour
+}
+
+// deadLinkSample is how many pages a scheduled dead-link check looks at.
+func deadLinkSample() int {
+	if cfg.DeadLinkSample == 0 { return 50 }
+	return max(cfg.DeadLinkSample, 0)
+}
+
+// checkDeadLinks asks the web about up to sample pages of the default index,
+// picked at random, as linked for the version each was indexed for. A dead
+// page the deprecation table (search.Engine.Renames) names a replacement for
+// stays indexed and its links go to the replacement; any other dead page is
+// dropped from the index.
+func checkDeadLinks(ctx context.Context, sample int) docs.LinkReport {
+	var pages []string
+	for p := range searcher.Pages() {
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") { pages = append(pages, p) }
+	}
+	rand.Shuffle(len(pages), func(i, j int) { pages[i], pages[j] = pages[j], pages[i] })
+	if len(pages) > sample { pages = pages[:sample] }
+	indexed := map[string]string{} // link checked -> page in the index
+	var urls []string
+	for _, p := range pages {
+		chunks := searcher.Page(p)
+		if len(chunks) == 0 { continue }
+		u := search.VersionedURL(p, linkVersion(chunks[0]))
+		indexed[u] = p
+		urls = append(urls, u)
+	}
+	renamed := map[string]string{}
+	for _, r := range searcher.Renames() { renamed[search.PageURL(r.URL)] = r.New }
+	report := docManager.CheckLinks(ctx, urls, func(dead string) string {
+		if members := searcher.Symbol(renamed[indexed[dead]]); len(members) > 0 {
+			if chunks := searcher.Page(members[0].URL); len(chunks) > 0 { return search.VersionedURL(members[0].URL, linkVersion(chunks[0])) }
+			return members[0].URL
+		}
+		return ""
+	})
+	var drop []string
+	for _, l := range report.Dead {
+		if l.Replacement != "" { continue }
+		for _, c := range searcher.Page(indexed[l.URL]) { drop = append(drop, c.URL) }
+	}
+	if n := searcher.RemoveDocs(drop); n > 0 {
+		searcher.SaveCache("cache/docs_index.json")
+		log.Printf("[docs] Dropped %d sections of dead pages from the index", n)
+	}
+	return report
+}
+
+// updateInterval is how often scheduledUpdates runs.
+func updateInterval() time.Duration {
+	if cfg.UpdateIntervalHours <= 0 { return 24 * time.Hour }
+	return time.Duration(cfg.UpdateIntervalHours) * time.Hour
+}
+
+// updateJitter spreads scheduled updates by up to this fraction of the
+// interval either way, so servers started together don't fetch together.
+const updateJitter = 0.1
+
+// autoUpdateState is what /api/status reports of scheduled updates.
+type autoUpdateState struct {
+	Enabled     bool      `json:"enabled"`
+	LastRun     time.Time `json:"last_run"` // zero until the first run
+	NextRun     time.Time `json:"next_run"` // zero while updates are off
+	Running     bool      `json:"running"`
+	CorePages   int       `json:"core_pages"`   // core pages fetched by the last run
+	RecentPages int       `json:"recent_pages"` // recently read live pages fetched by the last run
+	Failed      int       `json:"failed"`
+	DeadLinks   int       `json:"dead_links"` // dead pages the last run's link check found
+	Error       string    `json:"error,omitempty"`
+}
+
+var (
+	autoUpdateMu sync.Mutex
+	autoUpdate   autoUpdateState
+)
+
+// autoUpdateStatus returns a copy of the scheduled-update state.
+func autoUpdateStatus() autoUpdateState {
+	autoUpdateMu.Lock()
+	defer autoUpdateMu.Unlock()
+	s := autoUpdate
+	s.Enabled = cfg.AutoUpdate
+	if !s.Enabled { s.NextRun = time.Time{} }
+	return s
+}
+
+// scheduledUpdates keeps the docs fresh while auto_update_docs is on: every
+// updateInterval, give or take updateJitter, it re-indexes what changed
+// under every docs path (cheap when nothing did, see offline.IndexChanged)
+// and refreshes the live pages (see refreshLivePages). A path busy with
+// another indexing run is retried a minute later. Settings changes apply at
+// the next check.
+func scheduledUpdates() {
+	last := time.Now() // startup has just indexed
+	jitter := (rand.Float64()*2 - 1) * updateJitter
+	var pending map[string]string
+	for {
+		next := last.Add(time.Duration(float64(updateInterval()) * (1 + jitter)))
+		autoUpdateMu.Lock()
+		autoUpdate.NextRun = next
+		autoUpdateMu.Unlock()
+		time.Sleep(time.Minute)
+		if !cfg.AutoUpdate { pending = nil; continue }
+		if pending == nil && !time.Now().Before(next) {
+			last = time.Now()
+			jitter = (rand.Float64()*2 - 1) * updateJitter
+			pending = docPaths()
+			log.Printf("[docs] Scheduled update of %d docs paths", len(pending))
+			go refreshLivePages()
+		}
+		for name, path := range pending {
+			if path == "" || onDocsChanged(name, path) { delete(pending, name) }
+		}
+		if len(pending) == 0 { pending = nil }
+	}
+}
+
+// refreshLivePages is the live half of a scheduled update: the core pages
+// again if the index has live pages, and every live page read in the last
+// week that isn't one of them, each only if it's older than the refresh age
+// (or half the update interval, so every run finds the pages the last run
+// fetched stale). Unchanged pages cost a 304 each.
+func refreshLivePages() {
+	autoUpdateMu.Lock()
+	if autoUpdate.Running { autoUpdateMu.Unlock(); return }
+	autoUpdate.Running = true
+	autoUpdateMu.Unlock()
+
+	run := autoUpdateState{LastRun: time.Now()}
+	refreshed := map[string]bool{} // indexes changed
+	maxAge := min(refreshMaxAge(), updateInterval()/2)
+	if core := docManager.Stale(docs.CoreDocs(), maxAge, nil); searcher.SourceCount("live") > 0 && len(core) > 0 {
+		results, failed := docManager.RefreshPages(context.Background(), core)
+		if len(results) == 0 {
+			run.Error = fmt.Sprintf("could not fetch any of %d core pages (offline?)", failed)
+		} else {
+			run.CorePages, run.Failed = len(results), failed
+			searcher.AddResults(results)
+			searcher.DropSource(search.StarterSource)
+			refreshed[search.DefaultIndex] = true
+			cfg.LastDocUpdate = time.Now().Format("2006-01-02 15:04")
+			saveConfig()
+		}
+	}
+	core := map[string]bool{}
+	for _, u := range docs.CoreDocs() { core[u] = true }
+	var recent []string
+	for _, u := range docManager.RecentPages(7 * 24 * time.Hour) {
+		if !core[u] { recent = append(recent, u) }
+	}
+	recent = docManager.Stale(recent, maxAge, nil)
+	if len(recent) > 0 {
+		results, failed := docManager.RefreshPages(context.Background(), recent)
+		run.RecentPages, run.Failed = len(results), run.Failed+failed
+		for name := range addLive(results) { refreshed[name] = true }
+	}
+	for name := range refreshed { indexes.Save(name) }
+	log.Printf("[docs] Scheduled refresh: %d core pages, %d recently read pages, %d failed", run.CorePages, run.RecentPages, run.Failed)
+	if n := deadLinkSample(); n > 0 { run.DeadLinks = len(checkDeadLinks(context.Background(), n).Dead) }
+
+	autoUpdateMu.Lock()
+	run.NextRun = autoUpdate.NextRun
+	autoUpdate = run
+	autoUpdateMu.Unlock()
+}
+
+// addLive puts refreshed live pages back in every index that holds them
+// (chat caches them in the index it searched), and in the default index if
+// none does. It returns the names of the indexes changed.
+func addLive(results []search.Result) map[string]bool {
+	byIndex := map[string][]search.Result{}
+	for _, res := range results {
+		placed := false
+		for _, name := range indexes.Names() {
+			if len(indexes.Get(name).Page(search.PageURL(res.URL))) > 0 { byIndex[name] = append(byIndex[name], res); placed = true }
+		}
+		if !placed { byIndex[search.DefaultIndex] = append(byIndex[search.DefaultIndex], res) }
+	}
+	changed := map[string]bool{}
+	for name, list := range byIndex {
+		indexes.Get(name).AddResults(list)
+		changed[name] = true
+	}
+	return changed
+}
+
+// touchLive records the live pages among results as read, so scheduled
+// updates keep them fresh.
+func touchLive(results []search.Result) {
+	for _, r := range results {
+		if r.Source == "live" { docManager.Touch(r.URL) }
+	}
+}
+
+func handleIndexOffline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Path  string `json:"path"`
+		Index string `json:"index"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	path := strings.TrimSpace(body.Path)
+	name := strings.TrimSpace(body.Index)
+	if name != "" && name != search.DefaultIndex {
+		// Named index: remember its path so it's reloaded on restart
+		if path == "" { path = cfg.Indexes[name] }
+		if path == "" {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No path given for index " + name + "."})
+			return
+		}
+		if cfg.Indexes == nil { cfg.Indexes = map[string]string{} }
+		cfg.Indexes[name] = path
+		saveConfig()
+		docWatcher.Set(watchedDocs())
+		go indexOfflineDocs(name, path)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "indexing_started", "path": path, "index": name})
+		return
+	}
+	if path == "" { path = cfg.OfflineDocsPath }
+	if path == "" { path = offline.FindDocPath(nil) }
+	if path == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No offline docs path found."})
+		return
+	}
+	cfg.OfflineDocsPath = path
+	saveConfig()
+	docWatcher.Set(watchedDocs())
+	go indexOfflineDocs(search.DefaultIndex, path)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "indexing_started", "path": path})
+}
+
+// compactOfferMin is how much disk docs must take before compacting them
+// is offered.
+const compactOfferMin = 100 << 20
+
+// offerCompaction offers to compact the docs just indexed into index name
+// if they take enough disk and aren't a corpus already.
+func offerCompaction(name, path string) {
+	if offlineIndexer.IsCorpus(path) || offline.IsDocset(path) { return }
+	size := offline.DocsSize(path)
+	if size < compactOfferMin { return }
+	compactMu.Lock(); defer compactMu.Unlock()
+	if compaction != nil && compaction.Status == "running" { return }
+	compaction = &compactState{Index: name, Path: path, Bytes: size, Status: "offered"}
+}
+
+// handleCompact compacts the docs last indexed (see offerCompaction) into a
+// corpus of just their pages and switches their index over to it: POST
+// {"delete_original": true} also deletes the docs compacted. GET reports
+// the offer or how the run went.
+func handleCompact(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	compactMu.Lock()
+	defer compactMu.Unlock()
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "compact": compaction})
+		return
+	}
+	var body struct {
+		DeleteOriginal bool `json:"delete_original"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case compaction == nil:
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No indexed docs to compact."})
+		return
+	case compaction.Status == "running":
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Already compacting."})
+		return
+	case atomic.LoadInt32(&indexingDone) == 0:
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Wait for indexing to finish."})
+		return
+	}
+	state := *compaction
+	state.Status, state.Error = "running", ""
+	compaction = &state
+	go compactDocs(&state, body.DeleteOriginal)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "compacting", "compact": compaction})
+}
+
+// compactDocs runs the compaction state stands for. The index keeps its
+// pages; only the path it's re-indexed from changes.
+func compactDocs(state *compactState, deleteOriginal bool) {
+	report, err := offlineIndexer.Compact(context.Background(), state.Path)
+	compactMu.Lock()
+	defer compactMu.Unlock()
+	if err != nil {
+		log.Printf("[offline] Cannot compact %s: %v", state.Path, err)
+		state.Status, state.Error = "error", err.Error()
+		return
+	}
+	if state.Index == search.DefaultIndex && cfg.OfflineDocsPath == state.Path {
+		cfg.OfflineDocsPath = report.Path
+	} else if cfg.Indexes[state.Index] == state.Path {
+		cfg.Indexes[state.Index] = report.Path
+	}
+	saveConfig()
+	docWatcher.Set(watchedDocs())
+	offlineIndexer.ForgetPath(state.Path) // its pages now come from the corpus
+	state.Status, state.Report = "done", &report
+	if deleteOriginal {
+		if err := deleteDocs(state.Path); err != nil {
+			state.Error = "Compacted, but the original wasn't deleted: " + err.Error()
+			return
+		}
+		state.Freed = report.SourceBytes
+	}
+}
+
+// deleteDocs deletes docs that were compacted: an archive, or a folder of
+// Unity docs that isn't part of an Editor install.
+func deleteDocs(path string) error {
+	info, err := os.Stat(path)
+	if err != nil { return err }
+	if !info.IsDir() { return os.Remove(path) }
+	if !offline.IsUnityDocs(path) { return fmt.Errorf("%s doesn't look like a Unity docs folder", path) }
+	editorDocsMu.Lock(); defer editorDocsMu.Unlock()
+	for _, d := range editorDocs {
+		if filepath.Clean(d.Path) == filepath.Clean(path) { return fmt.Errorf("%s belongs to a Unity Editor install", path) }
+	}
+	return os.RemoveAll(path)
+}
+
+// handleDocsCrawl crawls the online Manual and Scripting Reference into an
+// index, for when the offline docs can't be had: POST {"index": "...",
+// "max_pages": 5000, "max_depth": 1, "delay_ms": 500} starts a crawl (the
+// limits default to the config's, the delay to the politeness delay), POST {"cancel": true} stops it, GET
+// reports how far it got.
+func handleDocsCrawl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	docCrawlMu.Lock()
+	defer docCrawlMu.Unlock()
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "crawl": docCrawl})
+		return
+	}
+	var body struct {
+		Index    string `json:"index"`
+		MaxPages int    `json:"max_pages"`
+		MaxDepth int    `json:"max_depth"`
+		DelayMs  int    `json:"delay_ms"`
+		Cancel   bool   `json:"cancel"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	running := docCrawl != nil && docCrawl.Status == "running"
+	if body.Cancel {
+		if running { docCrawl.cancel() }
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "cancelled": running})
+		return
+	}
+	if running {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "A crawl is already running."})
+		return
+	}
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	opts := docs.DocsCrawl{MaxPages: cfg.CrawlMaxPages, MaxDepth: cfg.CrawlMaxDepth}
+	if body.MaxPages > 0 { opts.MaxPages = body.MaxPages }
+	if body.MaxDepth != 0 { opts.MaxDepth = body.MaxDepth }
+	if body.DelayMs > 0 { opts.Delay = time.Duration(body.DelayMs) * time.Millisecond }
+	ctx, cancel := context.WithCancel(context.Background())
+	docCrawl = &docCrawlRun{Index: name, Status: "running", Started: time.Now(), cancel: cancel}
+	go crawlDocs(ctx, docCrawl, engine, opts)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "crawl_started", "crawl": docCrawl})
+}
+
+// handleRoutes serves the keyword route table live questions are routed
+// with (see docs/routes.go). GET lists it; POST {"keywords": [...],
+// "urls": [...]} adds a route, with "index": n replaces route n,
+// {"index": n, "delete": true} removes it and {"reset": true} goes back to
+// the built-in table.
+func handleRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "routes": docManager.RouteInfo()})
+		return
+	}
+	var body struct {
+		Index    *int     `json:"index"`
+		Keywords []string `json:"keywords"`
+		URLs     []string `json:"urls"`
+		Delete   bool     `json:"delete"`
+		Reset    bool     `json:"reset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Invalid request."})
+		return
+	}
+	var err error
+	switch {
+	case body.Reset:
+		err = docManager.ResetRoutes()
+	case body.Delete && body.Index == nil:
+		err = fmt.Errorf("which route? delete needs an index")
+	case body.Delete:
+		err = docManager.DeleteRoute(*body.Index)
+	case body.Index != nil:
+		err = docManager.SetRoute(*body.Index, docs.Route{Keywords: body.Keywords, URLs: body.URLs})
+	default:
+		err = docManager.SetRoute(-1, docs.Route{Keywords: body.Keywords, URLs: body.URLs})
+	}
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "routes": docManager.RouteInfo()})
+}
+
+// handleDocsSitemap checks an index against the docs sitemaps (see
+// docs.Manager.SitemapPages) and fetches what it's missing.
+// GET ?topic=Physics&index=&limit=200 counts the topic's pages (every page
+// without a topic), how many are indexed and lists the missing ones; POST
+// {"topic": "Physics", "index": "", "all": false, "max_pages": 0} fetches
+// the topic's missing pages (every one of them with "all") as a crawl,
+// followed at /api/docs/crawl.
+func handleDocsSitemap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Topic    string `json:"topic"`
+		Index    string `json:"index"`
+		All      bool   `json:"all"`
+		MaxPages int    `json:"max_pages"`
+	}
+	if r.Method == http.MethodPost {
+		json.NewDecoder(r.Body).Decode(&body)
+	} else {
+		body.Topic = r.URL.Query().Get("topic")
+	}
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	pages, err := docManager.SitemapPages(ctx)
+	cancel()
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	pages = docs.TopicPages(pages, body.Topic)
+	indexed := engine.Pages()
+	var missing []string
+	for _, p := range pages {
+		if !indexed[p] { missing = append(missing, p) }
+	}
+	if r.Method != http.MethodPost {
+		limit := 200
+		fmt.Sscan(r.URL.Query().Get("limit"), &limit)
+		list := missing
+		if limit >= 0 && len(list) > limit { list = list[:limit] }
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok", "index": name, "topic": body.Topic, "version": docManager.Version(),
+			"pages": len(pages), "indexed": len(pages) - len(missing), "missing": len(missing), "missing_pages": list,
+		})
+		return
+	}
+	fetch := missing
+	if body.All { fetch = pages }
+	if len(fetch) == 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "pages": len(pages), "missing": 0})
+		return
+	}
+	docCrawlMu.Lock()
+	defer docCrawlMu.Unlock()
+	if docCrawl != nil && docCrawl.Status == "running" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "A crawl is already running."})
+		return
+	}
+	opts := docs.DocsCrawl{MaxPages: len(fetch), Pages: fetch}
+	if body.MaxPages > 0 { opts.MaxPages = min(body.MaxPages, len(fetch)) }
+	crawlCtx, crawlCancel := context.WithCancel(context.Background())
+	docCrawl = &docCrawlRun{Index: name, Status: "running", Started: time.Now(), cancel: crawlCancel}
+	go crawlDocs(crawlCtx, docCrawl, engine, opts)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "crawl_started", "pages": len(pages), "missing": len(missing), "crawl": docCrawl})
+}
+
+// crawlDocs runs crawl, adding the pages to engine as they come and saving
+// the index every indexSaveEvery on the way and at the end.
+func crawlDocs(ctx context.Context, crawl *docCrawlRun, engine *search.Engine, opts docs.DocsCrawl) {
+	log.Printf("[docs] Crawling the online docs into %q", crawl.Index)
+	lastSave := time.Now()
+	p, err := docManager.CrawlDocs(ctx, opts, func(batch []search.Result) {
+		engine.AddResults(batch)
+		engine.DropSource(search.StarterSource)
+		if time.Since(lastSave) > indexSaveEvery { indexes.Save(crawl.Index); lastSave = time.Now() }
+	}, func(p docs.CrawlProgress) {
+		docCrawlMu.Lock(); crawl.Progress = p; docCrawlMu.Unlock()
+	})
+	indexes.Save(crawl.Index)
+	docCrawlMu.Lock()
+	defer docCrawlMu.Unlock()
+	crawl.cancel()
+	crawl.Progress, crawl.Finished = p, time.Now()
+	switch {
+	case errors.Is(err, context.Canceled):
+		crawl.Status = "cancelled"
+	case err != nil:
+		crawl.Status, crawl.Error = "error", err.Error()
+	default:
+		crawl.Status = "done"
+	}
+	log.Printf("[docs] Crawl %s: %d pages indexed, %d failed", crawl.Status, p.Fetched, p.Failed)
+}
+
+// crawlTimeout bounds one /api/docs/index-url request.
+const crawlTimeout = 2 * time.Minute
+
+// handleIndexURL fetches a web page and indexes it next to the docs: POST
+// {"url": "https://...", "max_pages": 10, "label": "Asset manual", "index": "..."}.
+// max_pages > 1 also indexes the pages it links to under its folder (see
+// docs.Manager.Crawl); the pages are labelled with label, by default the
+// site's host.
+func handleIndexURL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		URL      string `json:"url"`
+		MaxPages int    `json:"max_pages"`
+		Label    string `json:"label"`
+		Index    string `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Invalid request: " + err.Error()})
+		return
+	}
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No index named " + name + "."})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), crawlTimeout)
+	defer cancel()
+	pages, err := docManager.Crawl(ctx, strings.TrimSpace(body.URL), body.MaxPages, offline.ParseWebPage)
+	if err == nil && len(pages) == 0 { err = fmt.Errorf("nothing fetched from %s", body.URL) }
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	label := strings.TrimSpace(body.Label)
+	if u, err := url.Parse(pages[0].URL); label == "" && err == nil { label = strings.TrimPrefix(u.Host, "www.") }
+	var results []search.Result
+	for _, p := range pages {
+		for _, res := range p.Results {
+			res.Label = label
+			results = append(results, res)
+		}
+	}
+	engine.AddResults(results)
+	if len(results) > 0 { indexes.Save(name) }
+	log.Printf("[docs] Indexed %d pages (%d chunks) from %s into %q", len(pages), len(results), body.URL, name)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "label": label, "chunks": len(results), "pages": pages})
+}
+
+// handleDocsReleases indexes the release notes and upgrade guides for the
+// Unity version chosen in settings (see docs.Manager.FetchReleaseDocs):
+// POST {"releases": 10, "index": "..."}. Re-running replaces what an
+// earlier run indexed.
+func handleDocsReleases(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Releases int    `json:"releases"`
+		Index    string `json:"index"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No index named " + name + "."})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), crawlTimeout)
+	defer cancel()
+	results, report, err := docManager.FetchReleaseDocs(ctx, body.Releases)
+	if len(results) == 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "error", "error": err.Error(), "report": report})
+		return
+	}
+	engine.DropSource(docs.ReleaseSource)
+	engine.AddResults(results)
+	indexes.Save(name)
+	log.Printf("[docs] Indexed %d upgrade guides and the notes of %d releases (%d sections) into %q", report.Guides, len(report.Releases), len(results), name)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "chunks": len(results), "report": report})
+}
+
+// handleDocsPackages lists the packages whose docs questions are routed to
+// (GET) and fetches the whole manual of one of them into an index (POST
+// {"package": "com.unity.inputsystem"}), so its questions are answered
+// locally rather than page by page.
+func handleDocsPackages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"packages": docs.Packages()})
+		return
+	}
+	var body struct {
+		Package string `json:"package"`
+		Index   string `json:"index"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No index named " + name + "."})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), crawlTimeout)
+	defer cancel()
+	results, err := docManager.FetchPackageDocs(ctx, body.Package)
+	if len(results) == 0 {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	engine.AddResults(results)
+	indexes.Save(name)
+	log.Printf("[docs] Indexed %d %s pages into %q", len(results), body.Package, name)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "package": body.Package, "pages": len(results)})
+}
+
+// handleDocsReparse parses the downloaded pages an index holds again from
+// the raw page cache (POST), without the network, so a change to the
+// parsing or chunking can be tried on them; GET says how many are cached.
+func handleDocsReparse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	cached := docManager.RawPages()
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"cached_pages": len(cached)})
+		return
+	}
+	var body struct {
+		Index string `json:"index"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No index named " + name + "."})
+		return
+	}
+	var results []search.Result
+	failed := 0
+	for _, u := range cached {
+		old := engine.Page(u)
+		if len(old) == 0 {
+			continue
+		}
+		page, err := docManager.Reparse(u)
+		if err != nil {
+			failed++
+			continue
+		}
+		// Keep what the page was indexed as
+		page.Source, page.Label, page.Tags, page.Version = old[0].Source, old[0].Label, old[0].Tags, old[0].Version
+		results = append(results, page)
+	}
+	engine.AddResults(results)
+	if len(results) > 0 { indexes.Save(name) }
+	log.Printf("[docs] Re-parsed %d cached pages of %q (%d failed)", len(results), name, failed)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "pages": len(results), "failed": failed, "cached_pages": len(cached)})
+}
+
+// handleDocsDeadLinks lists the pages found dead and what links to them go
+// to instead (GET), or checks sample indexed pages now (POST {"sample":n},
+// 0 for the configured sample, or 50 if scheduled checks are off).
+func handleDocsDeadLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "dead_links": docManager.DeadLinks()})
+		return
+	}
+	var body struct {
+		Sample int `json:"sample"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Sample <= 0 { body.Sample = deadLinkSample() }
+	if body.Sample <= 0 { body.Sample = 50 }
+	ctx, cancel := context.WithTimeout(r.Context(), crawlTimeout)
+	defer cancel()
+	report := checkDeadLinks(ctx, body.Sample)
+	if report.Checked == 0 && report.Failed > 0 {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": fmt.Sprintf("Could not check any of %d pages (offline?).", report.Failed)})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "report": report, "dead_links": docManager.DeadLinks()})
+}
+
+// reportIndexing records the report of a run over path into index: nil
+// while it's running, and for a run that failed before finding any files.
+func reportIndexing(index, path string, rep *offline.IndexReport, err error) {
+	if rep == nil {
+		started := time.Now()
+		indexReportsMu.Lock()
+		if old := indexReports[path]; old != nil && old.Status == "running" { started = old.Started }
+		indexReportsMu.Unlock()
+		rep = offline.NewIndexReport(path)
+		rep.Started = started
+		if err != nil { rep.Status, rep.Error, rep.Finished = "error", err.Error(), time.Now() }
+	}
+	rep.Index = index
+	indexReportsMu.Lock()
+	defer indexReportsMu.Unlock()
+	// A run cancelled by a newer one over the same path doesn't hide it
+	if old := indexReports[path]; old != nil && old.Started.After(rep.Started) { return }
+	if old := indexReports[path]; old != nil && old.Status == "running" { rep.Started = old.Started }
+	indexReports[path] = rep
+}
+
+// handleIndexReport reports what the last run over each docs path found and
+// left out, and why: GET /api/docs/index-report, ?index=2021.3 for one
+// index's, ?path= for one path's.
+func handleIndexReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	index, path := strings.TrimSpace(r.URL.Query().Get("index")), strings.TrimSpace(r.URL.Query().Get("path"))
+	reports := []*offline.IndexReport{}
+	indexReportsMu.Lock()
+	for p, rep := range indexReports {
+		if (index == "" || rep.Index == index) && (path == "" || p == path) { reports = append(reports, rep) }
+	}
+	indexReportsMu.Unlock()
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Started.After(reports[j].Started) })
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "reports": reports})
+}
+
+// handleIndexCancel stops offline indexing: POST {"index": "2021.3"} for one
+// index, {} for all. Pages the cancelled run already streamed into the index
+// stay there, but its manifest isn't saved, so the next run parses those
+// files again.
+func handleIndexCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost { http.Error(w, "POST only", 405); return }
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Index string `json:"index"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	name := strings.TrimSpace(body.Index)
+	cancelled := []string{}
+	indexRunsMu.Lock()
+	for n, run := range indexRuns {
+		if name == "" || n == name { run.cancel(); cancelled = append(cancelled, n) }
+	}
+	indexRunsMu.Unlock()
+	sort.Strings(cancelled)
+	log.Printf("[offline] Cancel requested for %v", cancelled)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "cancelled": cancelled})
+}
+
+// handleDocsRemove drops a single page from the index by URL.
+func handleDocsRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost { http.Error(w, "POST only", 405); return }
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct{ URL string `json:"url"` }
+	json.NewDecoder(r.Body).Decode(&body)
+	t, ok := pickNamespace(r)
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+		return
+	}
+	// A namespace removes pages from its own docs only
+	if t != nil {
+		if !t.engine.RemoveDoc(strings.TrimSpace(body.URL)) {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "URL is not indexed in namespace " + t.name + "."})
+			return
+		}
+		t.engine.SaveCache(filepath.Join(t.dir, "docs_index.json"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "removed", "namespace": t.name, "doc_count": t.engine.DocCount()})
+		return
+	}
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	if !engine.RemoveDoc(strings.TrimSpace(body.URL)) {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "URL is not indexed."})
+		return
+	}
+	indexes.Save(name)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "removed", "doc_count": engine.DocCount()})
+}
+
+// handleExclusions manages pages hidden from results without deleting them.
+// GET lists them; POST /api/docs/exclude {"url", "user"} hides a page and
+// /api/docs/include shows it again. An empty user means everyone.
+func handleExclusions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodPost {
+		var body struct {
+			URL  string `json:"url"`
+			User string `json:"user"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		t, ok := pickNamespace(r)
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+			return
+		}
+		// In a namespace, no user means everyone in it, not everyone
+		url, user := strings.TrimSpace(body.URL), pickUser(r, body.User)
+		if t != nil && user == "" { user = t.name + "/" }
+		if url == "" {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No url given."})
+			return
+		}
+		var changed bool
+		var err error
+		if r.URL.Path == "/api/docs/include" {
+			changed, err = exclusions.Remove(url, user)
+		} else {
+			changed, err = exclusions.Add(url, user)
+		}
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		if changed { log.Printf("[search] Exclusions updated (%s): %s user=%q", r.URL.Path, url, user) }
+	}
+	json.NewEncoder(w).Encode(exclusions.List())
+}
+
+// handleDocsPrune drops pages that haven't been refreshed within max_age_days.
+// Defaults to live-fetched pages older than 90 days; source "" or "all" prunes everything.
+func handleDocsPrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost { http.Error(w, "POST only", 405); return }
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	body := struct {
+		MaxAgeDays int     `json:"max_age_days"`
+		Source     *string `json:"source"`
+	}{}
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.MaxAgeDays <= 0 { body.MaxAgeDays = 90 }
+	source := "live"
+	if body.Source != nil { source = *body.Source }
+	if source == "all" { source = "" }
+	t, ok := pickNamespace(r)
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+		return
+	}
+	// A namespace prunes its own docs only
+	if t != nil {
+		removed := t.engine.Prune(time.Duration(body.MaxAgeDays)*24*time.Hour, source)
+		if removed > 0 { t.engine.SaveCache(filepath.Join(t.dir, "docs_index.json")) }
+		log.Printf("[ns] %s: pruned %d stale docs (source=%q, max age %dd)", t.name, removed, source, body.MaxAgeDays)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "pruned", "namespace": t.name, "removed": removed, "doc_count": t.engine.DocCount()})
+		return
+	}
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	snapshotBefore(name, "prune")
+	removed := engine.Prune(time.Duration(body.MaxAgeDays)*24*time.Hour, source)
+	if removed > 0 { indexes.Save(name) }
+	log.Printf("[search] Pruned %d stale docs from %q (source=%q, max age %dd)", removed, name, source, body.MaxAgeDays)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "pruned", "removed": removed, "doc_count": engine.DocCount()})
+}
+
+// handleIndexes lists the named indexes and their sizes.
+func handleIndexes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	type indexInfo struct {
+		Name     string `json:"name"`
+		DocCount int    `json:"doc_count"`
+		Path     string `json:"path,omitempty"`
+	}
+	list := []indexInfo{}
+	for _, name := range indexes.Names() {
+		path := cfg.Indexes[name]
+		if name == search.DefaultIndex { path = cfg.OfflineDocsPath }
+		list = append(list, indexInfo{Name: name, DocCount: indexes.Get(name).DocCount(), Path: path})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"indexes": list})
+}
+
+// handleSearch pages through raw local hits without synthesizing an answer:
+// GET /api/search?q=...&offset=0&limit=5[&index=][&profile=][&code=1][&user=]
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	t, ok := pickNamespace(r)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+		return
+	}
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	profile, rk, ok := pickProfile(r, "search", "", engine)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown ranking profile: " + profile})
+		return
+	}
+	q := r.URL.Query()
+	offset, limit := 0, 5
+	fmt.Sscan(q.Get("offset"), &offset)
+	fmt.Sscan(q.Get("limit"), &limit)
+	if limit > 50 { limit = 50 }
+	docCount := engine.DocCount()
+	if t != nil { docCount += t.engine.DocCount() }
+	if offset < 0 || offset > docCount || limit <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": fmt.Sprintf("offset must be 0 to %d and limit 1 to 50", docCount)})
+		return
+	}
+
+	type hit struct {
+		Title   string  `json:"title"`
+		URL     string  `json:"url"`
+		Excerpt string  `json:"excerpt"`
+		Score   float64 `json:"score"`
+		Source  string  `json:"source"`
+		Tags    []string `json:"tags,omitempty"`
+		Topic   string  `json:"topic,omitempty"` // to group hits by, see search.Topic
+	}
+	results, more := searchIn(t, engine, q.Get("q"), offset, limit, rk, q.Get("code") == "1", hiddenFrom(t, pickUser(r, "")))
+	hits := make([]hit, len(results))
+	for i, res := range results {
+		u, _ := search.CanonicalURL(res.URL)
+		hits[i] = hit{res.Title, search.VersionedURL(u, linkVersion(res)), res.Excerpt, res.Score, res.Source, res.Tags, search.Topic(res.Tags)}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   q.Get("q"),
+		"index":   name,
+		"offset":  offset,
+		"limit":   limit,
+		"results": hits,
+		"more":    more,
+	})
+}
+
+// handleProxy serves docs.unity3d.com pages through the server, indexing
+// each one it downloads: GET /proxy/docs/ScriptReference/AudioSource.html.
+// Offline it serves the copy in the raw page cache, else the page's indexed
+// text.
+func handleProxy(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, docs.ProxyPrefix)
+	p, err := docManager.Proxy(r.Context(), rel)
+	if err != nil {
+		chunks := searcher.Page("https://docs.unity3d.com/" + rel)
+		if len(chunks) == 0 { http.Error(w, err.Error(), http.StatusBadGateway); return }
+		p = docs.ProxiedPage{Body: docs.OfflinePage(chunks), ContentType: "text/html; charset=utf-8", Cached: true}
+	}
+	if p.Page != nil {
+		searcher.AddResults([]search.Result{*p.Page})
+		go searcher.SaveCache("cache/docs_index.json")
+	}
+	w.Header().Set("Content-Type", p.ContentType)
+	if p.Cached { w.Header().Set("X-UnityMind-Cache", "hit") }
+	w.Write(p.Body)
+}
+
+// handleSuggest powers the chat box's live suggestions: /api/suggest?q=rigid
+func handleSuggest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	json.NewEncoder(w).Encode(engine.Suggest(r.URL.Query().Get("q"), 8))
+}
+
+// handleSnapshot writes (POST /api/index/snapshot) or restores
+// (POST /api/index/restore) a checksummed copy of an index. The copy is
+// picked by name ({"name": "..."}: a snapshot name or automatic snapshot
+// ID, the index's own by default), always under cache/snapshots; names are
+// per index.
+func handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost { http.Error(w, "POST only", 405); return }
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct{ Name string `json:"name"` }
+	json.NewDecoder(r.Body).Decode(&body)
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	path, err := indexes.NamedSnapshotPath(name, body.Name)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		snapshotBefore(name, "restore")
+		if err := engine.Restore(path); err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		indexes.Save(name)
+		log.Printf("[search] Restored %q from %s (%d docs)", name, path, engine.DocCount())
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "restored", "path": path, "doc_count": engine.DocCount()})
+		return
+	}
+	if err := engine.Snapshot(path); err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	log.Printf("[search] Snapshot of %q written to %s", name, path)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "saved", "path": path, "doc_count": engine.DocCount()})
+}
+
+// snapshotBefore takes an automatic snapshot of an index about to be
+// rewritten. Failing to is logged, not fatal: the change still goes ahead.
+func snapshotBefore(name, reason string) {
+	info, ok, err := indexes.AutoSnapshot(name, reason)
+	if err != nil { log.Printf("[search] Snapshot of %q before %s failed: %v", name, reason, err); return }
+	if ok { log.Printf("[search] Snapshot %s of %q (%d KB)", info.ID, name, info.Bytes>>10) }
+}
+
+// dailySnapshots snapshots every index whose newest automatic snapshot is a
+// day old, checking hourly, so a slow drift (a crawl gone wrong over days)
+// can be rolled back too.
+func dailySnapshots() {
+	for {
+		for _, name := range indexes.Names() {
+			list := indexes.Snapshots(name)
+			if len(list) == 0 || time.Since(list[0].Created) >= 24*time.Hour { snapshotBefore(name, "daily") }
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// handleSnapshots lists an index's automatic snapshots (GET
+// /api/index/snapshots?index=) or rolls it back to one (POST {"id": "..."}).
+// A rollback is itself snapshotted first; the response's "undo" is that id.
+func handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Index string `json:"index"`
+		ID    string `json:"id"`
+	}
+	if r.Method == http.MethodPost { json.NewDecoder(r.Body).Decode(&body) }
+	name, engine := pickIndex(r, body.Index)
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	if r.Method != http.MethodPost {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "snapshots": indexes.Snapshots(name)})
+		return
+	}
+	undo, _, err := indexes.Rollback(name, strings.TrimSpace(body.ID))
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	log.Printf("[search] Rolled %q back to snapshot %s (%d docs)", name, body.ID, engine.DocCount())
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "rolled_back", "index": name, "id": body.ID, "undo": undo.ID, "doc_count": engine.DocCount()})
+}
+
+// handleIndexDiff reports what changed in an index between two states:
+// GET /api/index/diff?index=&from=<snapshot id>&to=<snapshot id>&limit=200.
+// By default it's what the last re-index changed: the snapshot taken before
+// it against the index now (see search.Registry.Diff). Each list is cut to
+// limit pages; "counts" has their full lengths.
+func handleIndexDiff(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	q := r.URL.Query()
+	limit := 200
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "limit must be a whole number: " + v})
+			return
+		}
+		limit = n
+	}
+	diff, err := indexes.Diff(name, q.Get("from"), q.Get("to"))
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	counts := map[string]int{"added": len(diff.Added), "removed": len(diff.Removed), "updated": len(diff.Updated), "moved": len(diff.Moved), "unchanged": diff.Unchanged}
+	for _, list := range []*[]search.PageChange{&diff.Added, &diff.Removed, &diff.Updated, &diff.Moved} {
+		if limit >= 0 && len(*list) > limit { *list = (*list)[:limit] }
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "diff": diff, "counts": counts})
+}
+
+// handleIndexStats reports what an index holds: /api/index/stats?index=...&top=20
+func handleIndexStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	top, ok := statsTop(w, r)
+	if !ok { return }
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"index":           name,
+		"stats":           engine.Stats(top),
+		"last_doc_update": cfg.LastDocUpdate,
+	})
+}
+
+// maxStatsTop is the most common terms a stats request may ask for.
+const maxStatsTop = 500
+
+// statsTop reads a stats request's ?top= (default 20), answering 400 itself
+// if it's negative or over maxStatsTop.
+func statsTop(w http.ResponseWriter, r *http.Request) (int, bool) {
+	top := 20
+	fmt.Sscan(r.URL.Query().Get("top"), &top)
+	if top < 0 || top > maxStatsTop {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": fmt.Sprintf("top must be 0 to %d.", maxStatsTop)})
+		return 0, false
+	}
+	return top, true
+}
+
+// handleNamespace reports the caller's namespace: its docs and what its
+// questions were answered from. /api/namespace?top=20
+func handleNamespace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	t, ok := pickNamespace(r)
+	if !ok || t == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+		return
+	}
+	top, ok := statsTop(w, r)
+	if !ok { return }
+	t.mu.Lock()
+	answers := make(map[string]int, len(t.answers))
+	for src, n := range t.answers { answers[src] = n }
+	t.mu.Unlock()
+	shared, _ := pickIndex(r, "")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":       "ok",
+		"namespace":    t.name,
+		"shared_index": shared,
+		"docs_paths":   t.ns.DocsPaths,
+		"stats":        t.engine.Stats(top),
+		"answers":      answers,
+	})
+}
+
+// handleRanking reads (GET) or updates (POST, partial JSON) the relevance knobs.
+// Changes apply to every index immediately and are saved to config.json.
+func handleRanking(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodPost {
+		rk := searcher.Ranking()
+		if err := json.NewDecoder(r.Body).Decode(&rk); err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		indexes.SetRanking(rk)
+		cfg.Ranking = searcher.Ranking()
+		for _, t := range tenants { t.engine.SetRanking(cfg.Ranking) }
+		saveConfig()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ranking":          searcher.Ranking(),
+		"defaults":         search.DefaultRanking(),
+		"profiles":         rankingProfiles(),
+		"profile_defaults": cfg.ProfileDefaults,
+	})
+}
+
+// handlePrompts lists the prompt templates (GET), adds a new version
+// (POST {"name", "text", "note", "activate"}) or switches the active
+// version (POST {"name", "version"}) — which is also how to roll back.
+func handlePrompts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	t, ok := pickNamespace(r)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown namespace or token."})
+		return
+	}
+	store := prompts
+	if t != nil { store = t.prompts }
+	if r.Method == http.MethodPost {
+		var body struct {
+			Name     string `json:"name"`
+			Text     string `json:"text"`
+			Note     string `json:"note"`
+			Activate *bool  `json:"activate"`
+			Version  int    `json:"version"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		if body.Name == "" { body.Name = openai.SystemPrompt }
+		var err error
+		if body.Text != "" {
+			_, err = store.Add(body.Name, body.Text, body.Note, body.Activate == nil || *body.Activate)
+		} else {
+			err = store.Activate(body.Name, body.Version)
+		}
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		log.Printf("[openai] Prompt templates updated: %s", body.Name)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"templates": store.List()})
+}
+
+// handleExport packs the C# scripts of an answer into a .unitypackage:
+// POST /api/export {"answer": "...", "folder": "Assets/Inventory"}
+func handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost { http.Error(w, "POST only", 405); return }
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Answer string `json:"answer"`
+		Folder string `json:"folder"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	files := brain.ScriptFiles(body.Answer)
+	if len(files) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "No C# scripts in this answer."})
+		return
+	}
+	folder := brain.ExportFolder(body.Folder)
+	name := folder[strings.LastIndexByte(folder, '/')+1:]
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.unitypackage"`)
+	if err := brain.WriteUnityPackage(w, files, folder); err != nil {
+		log.Printf("[export] %v", err); return
+	}
+	log.Printf("[export] %d scripts → %s", len(files), folder)
+}
+
+// handleReview serves flashcards of past questions. GET /api/review?user=...
+// [&limit=10] lists the due ones; POST {"user", "id", "grade"} records how well
+// one was remembered (again, good or easy), {"user", "id", "forget": true}
+// deletes it.
+func handleReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodGet {
+		user := pickUser(r, "")
+		if user == "" {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing user."})
+			return
+		}
+		limit := 10
+		fmt.Sscan(r.URL.Query().Get("limit"), &limit)
+		cards, due := reviews.Due(user, time.Now(), limit)
+		if cards == nil { cards = []review.Card{} }
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "enabled": cfg.ReviewMode, "due": due, "cards": cards})
+		return
+	}
+	if r.Method != http.MethodPost { http.Error(w, "GET or POST only", 405); return }
+	var body struct {
+		User   string       `json:"user"`
+		ID     string       `json:"id"`
+		Grade  review.Grade `json:"grade"`
+		Forget bool         `json:"forget"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	user := pickUser(r, body.User)
+	if user == "" || body.ID == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing user or id."})
+		return
+	}
+	if body.Forget {
+		removed, err := reviews.Forget(user, body.ID)
+		if err != nil { json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()}); return }
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "removed": removed})
+		return
+	}
+	card, err := reviews.Grade(user, body.ID, body.Grade, time.Now())
+	if err != nil { json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()}); return }
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "card": card})
+}
+
+// renameNote tells the user the APIs they asked about are obsolete, "" if
+// none are.
+func renameNote(renames []search.Rename) string {
+	var b strings.Builder
+	for _, rn := range renames {
+		fmt.Fprintf(&b, "> ⚠️ `%s` is obsolete in these docs — use `%s` instead.\n", rn.Old, rn.New)
+	}
+	if b.Len() > 0 { b.WriteString("\n") }
+	return b.String()
+}
+
+// handleRenames lists the obsolete APIs of an index and what replaces each,
+// as found in its Scripting Reference pages: /api/renames[?index=...]
+func handleRenames(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	renames := engine.Renames()
+	if renames == nil { renames = []search.Rename{} }
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "index": name, "renames": renames})
+}
+
+// handleMembers lists a class's documented members from the API catalog:
+// /api/members?class=AudioSource[&kind=method][&index=...]
+func handleMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	class := strings.TrimSpace(r.URL.Query().Get("class"))
+	if class == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing class."})
+		return
+	}
+	cq := brain.CatalogQuery{Class: class, Kind: r.URL.Query().Get("kind")}
+	members := cq.Select(engine.Members(class))
+	if members == nil { members = []search.Member{} }
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "class": class, "index": name, "members": members})
+}
+
+// handleSymbols looks an API up in the symbol table by exact name:
+// /api/symbols?q=AudioSource.PlayOneShot[&index=...]. A bare type or member
+// name lists every match.
+func handleSymbols(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing q."})
+		return
+	}
+	symbols := engine.Symbol(q)
+	if symbols == nil { symbols = []search.Member{} }
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "q": q, "index": name, "symbols": symbols, "total": engine.SymbolCount()})
+}
+
+// handleParams sets tunable values in an answer's code and returns the new
+// answer with its parameters: POST {"answer": "...", "values": {"jumpForce": "20"}}
+func handleParams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body struct {
+		Answer string            `json:"answer"`
+		Values map[string]string `json:"values"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Answer == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing answer."})
+		return
+	}
+	answer := brain.ApplyParams(body.Answer, body.Values)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "answer": answer, "params": brain.Parameters(answer)})
+}
+
+// handleRankingCompare runs one query under two ranking profiles and returns both
+// result lists side by side: /api/debug/compare?q=...&a=balanced&b=api[&index=...]
+// Profile "engine" means the engine's current ranking without any profile layered on.
+func handleRankingCompare(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	name, engine := pickIndex(r, "")
+	if engine == nil {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown index: " + name})
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Missing q."})
+		return
+	}
+	topK := 10
+	fmt.Sscan(r.URL.Query().Get("k"), &topK)
+	if topK < 1 || topK > 50 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "k must be 1 to 50."})
+		return
+	}
+
+	type hit struct {
+		Rank  int     `json:"rank"`
+		Title string  `json:"title"`
+		URL   string  `json:"url"`
+		Score float64 `json:"score"`
+		Moved int     `json:"moved"` // rank change vs the other side, 0 = same or absent there
+	}
+	run := func(profile string) ([]hit, search.Ranking, bool) {
+		rk := engine.Ranking()
+		if profile != "engine" {
+			p, ok := rankingProfiles()[profile]
+			if !ok { return nil, rk, false }
+			rk = p.Over(rk)
+		}
+		var hits []hit
+		for i, res := range engine.SearchWith(q, topK, rk) {
+			hits = append(hits, hit{Rank: i + 1, Title: res.Title, URL: res.URL, Score: res.Score})
+		}
+		return hits, rk, true
+	}
+	nameA, nameB := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	if nameA == "" { nameA = "engine" }
+	if nameB == "" { nameB = defaultProfiles["chat"] }
+	a, rkA, okA := run(nameA)
+	b, rkB, okB := run(nameB)
+	if !okA || !okB {
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "Unknown ranking profile."})
+		return
+	}
+	rankIn := func(hits []hit) map[string]int {
+		m := map[string]int{}
+		for _, h := range hits { m[h.URL] = h.Rank }
+		return m
+	}
+	ra, rb := rankIn(a), rankIn(b)
+	for i := range a { if o, ok := rb[a[i].URL]; ok { a[i].Moved = o - a[i].Rank } }
+	for i := range b { if o, ok := ra[b[i].URL]; ok { b[i].Moved = o - b[i].Rank } }
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query": q,
+		"index": name,
+		"a":     map[string]interface{}{"profile": nameA, "ranking": rkA, "results": a},
+		"b":     map[string]interface{}{"profile": nameB, "ranking": rkB, "results": b},
+	})
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":            "ok",
+		"doc_count":         searcher.DocCount(),
+		"starter_docs":      searcher.SourceCount(search.StarterSource),
+		"package_docs":      searcher.SourceCount(offline.PackageSource),
+		"forum_pages":       searcher.SourceCount(docs.ForumSource),
+		"stack_overflow_pages": searcher.SourceCount(docs.StackOverflowSource),
+		"csharp_pages":      searcher.SourceCount(docs.CSharpSource),
+		"version":           "1.1.0",
+		"indexing_progress": atomic.LoadInt32(&indexingProgress),
+		"indexing_done":     atomic.LoadInt32(&indexingDone) == 1,
+		"editor_docs":       offeredEditorDocs(),
+		"compact":           compactStatus(),
+		"auto_update":       autoUpdateStatus(),
+	})
+}
+
+// compactStatus is the compaction on offer or running, for the status API.
+func compactStatus() *compactState {
+	compactMu.Lock(); defer compactMu.Unlock()
+	if compaction == nil { return nil }
+	c := *compaction
+	return &c
+}
+
+// findEditorDocs looks for the docs of an installed Unity Editor, which the
+// status API then offers to index (see offeredEditorDocs).
+func findEditorDocs() {
+	found := offline.FindEditorDocs()
+	for _, d := range found { log.Printf("[offline] Unity %s Editor docs found: %s", d.Version, d.Path) }
+	editorDocsMu.Lock(); editorDocs = found; editorDocsMu.Unlock()
+}
+
+// offeredEditorDocs returns the Editor docs to offer, none once a docs
+// path is set.
+func offeredEditorDocs() []offline.EditorDocs {
+	if cfg.OfflineDocsPath != "" { return nil }
+	editorDocsMu.Lock(); defer editorDocsMu.Unlock()
+	return editorDocs
+}
+
+func main() {
+	log.Println("╔══════════════════════════════════╗")
+	log.Println("║      UnityMind v1.1.0            ║")
+	log.Println("╚══════════════════════════════════╝")
+
+	setup()
+	registerHandlers(http.DefaultServeMux)
+
+	addr := fmt.Sprintf(":%d", cfg.Port)
+	log.Printf("[server] http://localhost%s", addr)
+	go func() {
+		waitForPort(cfg.Port)
+		openBrowser(fmt.Sprintf("http://localhost:%d", cfg.Port))
+	}()
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		log.Fatalf("[server] Failed: %v", err)
+	}
+}
+
+// setup loads config.json and the indexes from the working directory, then
+// starts indexing whatever needs it and the background jobs.
+func setup() {
+	loadConfig()
+	if cfg.StopWords != nil { search.SetStopWords(cfg.StopWords) }
+	indexes = search.NewRegistry("cache")
+	indexes.SetRanking(cfg.Ranking)
+	if err := indexes.SetMemoryBudget(int64(cfg.MaxMemoryMB) << 20); err != nil {
+		log.Printf("[search] Memory budget: %v", err)
+	}
+	indexes.SetSnapshotRetention(cfg.SnapshotRetention)
+	searcher = indexes.Open(search.DefaultIndex)
+	docManager = docs.NewManager("cache")
+	applyPoliteness()
+	applyHeaders()
+	applyFetchLimits()
+	if err := docManager.LoadRoutes("routes.json", 5*time.Second); err != nil { log.Printf("[docs] Using the built-in routes: %v", err) }
+	if v, ok := search.DocsVersion(cfg.UnityVersion); ok { docManager.SetVersion(v) }
+	docManager.SetLanguage(cfg.DocLanguage)
+	go func() {
+		if err := docManager.LoadSymbolTOC(context.Background()); err != nil { log.Printf("[docs] No Scripting Reference contents yet: %v", err) }
+	}()
+	offlineIndexer = offline.NewIndexer("cache")
+	offlineIndexer.SetPatterns(cfg.IndexInclude, cfg.IndexExclude)
+	applyIndexThrottle()
+	prompts = openai.LoadPrompts("cache/prompts.json")
+	exclusions = search.LoadExclusions("cache/excluded.json")
+	reviews = review.Load("cache/review.json")
+	openTenants()
+
+	if searcher.DocCount() == 0 {
+		log.Printf("[search] No cache at %s", indexes.CachePath(search.DefaultIndex))
+		// Answer the basics from the bundled pages until real docs are indexed
+		if n, err := starter.Load(searcher); err != nil {
+			log.Printf("[search] Starter docs: %v", err)
+		} else {
+			log.Printf("[search] Loaded %d bundled starter pages.", n)
+		}
+	} else {
+		log.Printf("[search] Loaded %d docs from cache.", searcher.DocCount())
+	}
+
+	// Named indexes load from their own cache; (re)index any that are near-empty
+	for name, path := range cfg.Indexes {
+		n := indexes.Open(name).DocCount()
+		log.Printf("[search] Index %q: %d docs from cache.", name, n)
+		if n < 100 && path != "" { go indexOfflineDocs(name, path) }
+	}
+
+	// Notes change between runs; only edited files are re-parsed
+	for _, path := range cfg.MarkdownPaths { go indexMarkdownDocs(path) }
+	for _, src := range cfg.DocSources { go indexDocSource(src, searcher.SourceCount(offline.MarkdownSource)+searcher.SourceCount("offline") > 0) }
+
+	// Package docs are small, so re-read them every start to pick up upgrades
+	if cfg.UnityProjectPath != "" { go indexPackageDocs(cfg.UnityProjectPath) }
+
+	// ── Offline docs detection & indexing ─────────────────────────────────────
+	log.Println("[offline] Looking for UnityDocumentation.zip or extracted folder...")
+
+	if cfg.OfflineDocsPath != "" {
+		log.Printf("[offline] Config path: %s", cfg.OfflineDocsPath)
+		if coreDocCount() >= 100 {
+			log.Printf("[offline] Cache already has %d pages — skipping re-index.", coreDocCount())
+			atomic.StoreInt32(&indexingDone, 1)
+			atomic.StoreInt32(&indexingProgress, 100)
+		} else {
+			go indexOfflineDocs(search.DefaultIndex, cfg.OfflineDocsPath)
+		}
+	} else {
+		detected := offline.FindDocPath(nil)
+		if detected != "" {
 			log.Printf("[offline] ✓ Found: %s — starting index...", detected)
 			cfg.OfflineDocsPath = detected
 			saveConfig()
-			go indexOfflineDocs(detected)
+			go indexOfflineDocs(search.DefaultIndex, detected)
 		} else {
 			log.Println("[offline] ✗ No offline docs found next to exe.")
 			log.Println("[offline]   Put UnityDocumentation.zip next to UnityMind.exe, then restart.")
 			log.Println("[offline]   Or set the path in ⚙ Settings inside the app.")
-			if searcher.DocCount() == 0 {
+			go findEditorDocs()
+			if coreDocCount() == 0 {
 				log.Println("[docs] Falling back: fetching core docs from internet...")
-				go func() {
-					results, err := docManager.FetchCoreDocs()
-					if err != nil { log.Printf("[docs] Error: %v", err); return }
-					searcher.AddResults(results)
-					searcher.SaveCache("cache/docs_index.json")
-					cfg.LastDocUpdate = time.Now().Format("2006-01-02 15:04")
-					saveConfig()
-					log.Printf("[docs] Fetched %d pages.", len(results))
-				}()
+				go refreshCoreDocs(refreshMaxAge())
 			} else {
 				log.Printf("[docs] Using cached %d pages.", searcher.DocCount())
 				atomic.StoreInt32(&indexingDone, 1)
@@ -343,21 +3134,59 @@ func main() {
 		}
 	}
 
+	go dailySnapshots()
+	go scheduledUpdates()
+
+	// Watch the docs paths settled above (auto-detection may have set one)
+	docWatcher = offline.NewWatcher(5*time.Second, onDocsChanged)
+	docWatcher.Set(watchedDocs())
+	docWatcher.Start()
+}
+
+// registerHandlers serves the UI and the API on mux.
+// Handlers that change what every namespace shares are sharedOnly.
+func registerHandlers(mux *http.ServeMux) {
 	uiFS, _ := fs.Sub(uiFiles, "ui")
-	http.Handle("/", http.FileServer(http.FS(uiFS)))
-	http.HandleFunc("/api/chat", handleChat)
-	http.HandleFunc("/api/config", handleConfig)
-	http.HandleFunc("/api/docs/update", handleDocsUpdate)
-	http.HandleFunc("/api/docs/index-offline", handleIndexOffline)
-	http.HandleFunc("/api/status", handleStatus)
+	mux.Handle("/", http.FileServer(http.FS(uiFS)))
 
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	log.Printf("[server] http://localhost%s", addr)
-	go func() {
-		waitForPort(cfg.Port)
-		openBrowser(fmt.Sprintf("http://localhost:%d", cfg.Port))
-	}()
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatalf("[server] Failed: %v", err)
-	}
+	mux.HandleFunc(docs.ProxyPrefix, handleProxy)
+	mux.HandleFunc("/api/chat", handleChat)
+	mux.HandleFunc("/api/config", sharedOnly(handleConfig, true))
+	mux.HandleFunc("/api/config/sources", sharedOnly(handleDocSources, true))
+	mux.HandleFunc("/api/docs/update", sharedOnly(handleDocsUpdate, false))
+	mux.HandleFunc("/api/docs/index-offline", sharedOnly(handleIndexOffline, false))
+	mux.HandleFunc("/api/docs/compact", sharedOnly(handleCompact, true))
+	mux.HandleFunc("/api/docs/index-url", sharedOnly(handleIndexURL, false))
+	mux.HandleFunc("/api/docs/crawl", sharedOnly(handleDocsCrawl, true))
+	mux.HandleFunc("/api/docs/sitemap", sharedOnly(handleDocsSitemap, true))
+	mux.HandleFunc("/api/docs/releases", sharedOnly(handleDocsReleases, false))
+	mux.HandleFunc("/api/docs/packages", sharedOnly(handleDocsPackages, true))
+	mux.HandleFunc("/api/docs/reparse", sharedOnly(handleDocsReparse, true))
+	mux.HandleFunc("/api/docs/deadlinks", sharedOnly(handleDocsDeadLinks, true))
+	mux.HandleFunc("/api/routes", sharedOnly(handleRoutes, true))
+	mux.HandleFunc("/api/docs/index-cancel", sharedOnly(handleIndexCancel, true))
+	mux.HandleFunc("/api/docs/index-report", handleIndexReport)
+	mux.HandleFunc("/api/docs/remove", handleDocsRemove)
+	mux.HandleFunc("/api/docs/prune", handleDocsPrune)
+	mux.HandleFunc("/api/docs/exclude", handleExclusions)
+	mux.HandleFunc("/api/docs/include", handleExclusions)
+	mux.HandleFunc("/api/indexes", handleIndexes)
+	mux.HandleFunc("/api/search", handleSearch)
+	mux.HandleFunc("/api/suggest", handleSuggest)
+	mux.HandleFunc("/api/index/snapshot", sharedOnly(handleSnapshot, false))
+	mux.HandleFunc("/api/index/restore", sharedOnly(handleSnapshot, false))
+	mux.HandleFunc("/api/index/stats", handleIndexStats)
+	mux.HandleFunc("/api/index/snapshots", sharedOnly(handleSnapshots, true))
+	mux.HandleFunc("/api/index/diff", handleIndexDiff)
+	mux.HandleFunc("/api/ranking", sharedOnly(handleRanking, true))
+	mux.HandleFunc("/api/prompts", handlePrompts)
+	mux.HandleFunc("/api/export", handleExport)
+	mux.HandleFunc("/api/params", handleParams)
+	mux.HandleFunc("/api/members", handleMembers)
+	mux.HandleFunc("/api/symbols", handleSymbols)
+	mux.HandleFunc("/api/renames", handleRenames)
+	mux.HandleFunc("/api/review", handleReview)
+	mux.HandleFunc("/api/namespace", handleNamespace)
+	mux.HandleFunc("/api/debug/compare", handleRankingCompare)
+	mux.HandleFunc("/api/status", handleStatus)
 }
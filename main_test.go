@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The chat tests run the whole server against a small fake doc corpus and a
+// stub LLM, and check /api/chat from the outside: local docs, live docs and
+// the AI fallback each answer the questions meant for them. testdata/chat/
+// offline is indexed as the offline docs; testdata/chat/live is what
+// docs.unity3d.com serves while they run.
+
+// stubAnswer starts every answer the stub LLM gives.
+const stubAnswer = "Stub LLM answer to: "
+
+// server is the UnityMind under test.
+var server *httptest.Server
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() { log.SetOutput(io.Discard) }
+	os.Exit(runServer(m))
+}
+
+// runServer starts the server in a fresh temporary folder holding the fake
+// offline docs and a config.json pointing at them, with docs.unity3d.com and
+// the OpenAI API answered by local test servers, and runs the tests.
+func runServer(m *testing.M) int {
+	corpus, err := filepath.Abs(filepath.Join("testdata", "chat"))
+	if err != nil { log.Fatal(err) }
+	dir, err := os.MkdirTemp("", "unitymind-test-")
+	if err != nil { log.Fatal(err) }
+	defer os.RemoveAll(dir)
+	docsDir := filepath.Join(dir, "docs")
+	if err := copyDir(docsDir, filepath.Join(corpus, "offline")); err != nil { log.Fatal(err) }
+	config, _ := json.Marshal(map[string]interface{}{
+		"openai_key":        "test-key",
+		"openai_model":      "stub",
+		"auto_update_docs":  false,
+		"offline_docs_path": docsDir,
+		"namespaces":        map[string]interface{}{"team": map[string]string{"token": "team-token"}},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), config, 0644); err != nil { log.Fatal(err) }
+
+	live := httptest.NewServer(liveDocs(os.DirFS(filepath.Join(corpus, "live"))))
+	defer live.Close()
+	llm := httptest.NewServer(http.HandlerFunc(stubLLM))
+	defer llm.Close()
+	// Everything the server fetches goes to the test servers; the tests
+	// themselves talk to it with a client of their own
+	http.DefaultTransport = redirectTransport{base: http.DefaultTransport, hosts: map[string]string{
+		"docs.unity3d.com": live.Listener.Addr().String(),
+		"api.openai.com":   llm.Listener.Addr().String(),
+	}}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil { log.Fatal(err) }
+	setup()
+	mux := http.NewServeMux()
+	registerHandlers(mux)
+	server = httptest.NewServer(mux)
+	defer server.Close()
+	if err := waitIndexed(30 * time.Second); err != nil { log.Fatal(err) }
+	return m.Run()
+}
+
+// copyDir copies the files under src to dst.
+func copyDir(dst, src string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() { return err }
+		rel, _ := filepath.Rel(src, p)
+		data, err := os.ReadFile(p)
+		if err != nil { return err }
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dst, rel)), 0755); err != nil { return err }
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+}
+
+// redirectTransport sends requests for hosts to the test servers standing
+// in for them, and fails any other.
+type redirectTransport struct {
+	base  http.RoundTripper
+	hosts map[string]string
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	addr, ok := t.hosts[r.URL.Host]
+	if !ok { return nil, fmt.Errorf("test: no network access to %s", r.URL.Host) }
+	out := r.Clone(r.Context())
+	out.URL.Scheme, out.URL.Host = "http", addr
+	resp, err := t.base.RoundTrip(out)
+	// The server sees the page at the URL it asked for
+	if resp != nil { resp.Request = r }
+	return resp, err
+}
+
+// liveDocs serves docs.unity3d.com from pages, with no search results.
+func liveDocs(pages fs.FS) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if strings.HasPrefix(r.URL.Path, "/search/") {
+			fmt.Fprint(w, "<html><body>No results.</body></html>")
+			return
+		}
+		data, err := fs.ReadFile(pages, strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil { http.NotFound(w, r); return }
+		w.Write(data)
+	})
+}
+
+// stubLLM answers a chat completion by echoing the question, in two pieces
+// when asked to stream.
+func stubLLM(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		Stream bool `json:"stream"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/json")
+	if len(req.Messages) == 0 || req.Messages[0].Role != "system" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"missing system prompt","type":"invalid_request_error"}}`)
+		return
+	}
+	question := req.Messages[len(req.Messages)-1].Content
+	if !req.Stream {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": stubAnswer + question}}},
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, piece := range []string{stubAnswer, question} {
+		data, _ := json.Marshal(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{"delta": map[string]string{"content": piece}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// waitIndexed polls /api/status until the offline docs are indexed.
+func waitIndexed(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var st struct {
+			Done bool `json:"indexing_done"`
+		}
+		if resp, err := server.Client().Get(server.URL + "/api/status"); err == nil {
+			json.NewDecoder(resp.Body).Decode(&st)
+			resp.Body.Close()
+			if st.Done { return nil }
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("docs not indexed after %s", timeout)
+}
+
+// chatReply is the part of a ChatResponse the tests look at.
+type chatReply struct {
+	Answer string `json:"answer"`
+	Source string `json:"source"`
+	Links  []struct {
+		URL string `json:"url"`
+	} `json:"links"`
+}
+
+func chat(t *testing.T, body map[string]interface{}) chatReply {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := server.Client().Post(server.URL+"/api/chat", "application/json", bytes.NewReader(data))
+	if err != nil { t.Fatal(err) }
+	defer resp.Body.Close()
+	var got chatReply
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil { t.Fatalf("bad response: %v", err) }
+	return got
+}
+
+func TestChat(t *testing.T) {
+	// Cases run in order: the live case caches its pages into the index, so
+	// it must come after anything that expects them not to be there.
+	// Questions for the later stages share no words with the offline docs,
+	// which are few enough for a single common word to clear the threshold.
+	cases := []struct {
+		name    string
+		message string
+		useAI   bool
+		source  string // expected ChatResponse.Source
+		answer  string // substring the answer must contain ("" = any)
+		link    string // a link the answer must carry ("" = any)
+	}{
+		{name: "empty message", message: " ", useAI: true, source: "error"},
+		{name: "local docs", message: "How do I use Rigidbody.AddForce to push an object?", useAI: true,
+			source: "local_docs", answer: "AddForce", link: "https://docs.unity3d.com/ScriptReference/Rigidbody.AddForce.html"},
+		{name: "local docs (manual)", message: "What does the Transform component do?", useAI: true,
+			source: "local_docs", link: "https://docs.unity3d.com/Manual/class-Transform.html"},
+		{name: "live docs", message: "Background music looping", useAI: true,
+			source: "live_docs", link: "https://docs.unity3d.com/Manual/AudioOverview.html"},
+		{name: "AI fallback", message: "Who painted the Mona Lisa?", useAI: true,
+			source: "openai", answer: stubAnswer + "Who painted the Mona Lisa?"},
+		{name: "AI fallback off", message: "Who painted the Mona Lisa?", useAI: false,
+			source: "not_found"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := chat(t, map[string]interface{}{"message": c.message, "use_ai": c.useAI, "user": "test"})
+			if got.Source != c.source {
+				t.Fatalf("source %q, want %q (answer: %.80q)", got.Source, c.source, got.Answer)
+			}
+			if !strings.Contains(got.Answer, c.answer) {
+				t.Fatalf("answer doesn't mention %q: %.120q", c.answer, got.Answer)
+			}
+			if c.link == "" { return }
+			for _, l := range got.Links {
+				if l.URL == c.link { return }
+			}
+			t.Fatalf("no link to %s in %d links", c.link, len(got.Links))
+		})
+	}
+}
+
+func TestChatUnknownIndex(t *testing.T) {
+	got := chat(t, map[string]interface{}{"message": "Rigidbody.AddForce", "index": "no-such-index"})
+	if got.Source != "error" || !strings.Contains(got.Answer, "no-such-index") {
+		t.Fatalf("got %q %q, want an unknown index error", got.Source, got.Answer)
+	}
+}
+
+func TestChatRejectsBadMaxChars(t *testing.T) {
+	for _, query := range []string{"?max_chars=lots", "?max_chars=-5"} {
+		data, _ := json.Marshal(map[string]interface{}{"message": "Rigidbody.AddForce"})
+		resp, err := server.Client().Post(server.URL+"/api/chat"+query, "application/json", bytes.NewReader(data))
+		if err != nil { t.Fatal(err) }
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	data, _ := json.Marshal(map[string]interface{}{"message": "Who painted the Mona Lisa?", "stream": true})
+	resp, err := server.Client().Post(server.URL+"/api/chat", "application/json", bytes.NewReader(data))
+	if err != nil { t.Fatal(err) }
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type %q, want text/event-stream", ct)
+	}
+	var events []string
+	var done chatReply
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(sc.Text(), "event: "); ok { events = append(events, name) }
+		if d, ok := strings.CutPrefix(sc.Text(), "data: "); ok && events[len(events)-1] == "done" {
+			if err := json.Unmarshal([]byte(d), &done); err != nil { t.Fatal(err) }
+		}
+	}
+	if len(events) == 0 || events[len(events)-1] != "done" {
+		t.Fatalf("events %v, want answers then done", events)
+	}
+	if done.Source != "openai" || done.Answer != stubAnswer+"Who painted the Mona Lisa?" {
+		t.Fatalf("done with %q %q", done.Source, done.Answer)
+	}
+}
+
+func TestProxyServesLivePages(t *testing.T) {
+	resp, err := server.Client().Get(server.URL + "/proxy/docs/ScriptReference/AudioSource.html")
+	if err != nil { t.Fatal(err) }
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestNamespaceCannotChangeSharedIndex(t *testing.T) {
+	post := func(path, token, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := server.Client().Do(req)
+		if err != nil { t.Fatal(err) }
+		defer resp.Body.Close()
+		var got map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&got)
+		return resp.StatusCode, got
+	}
+	for _, path := range []string{"/api/ranking", "/api/config", "/api/docs/index-offline", "/api/index/restore", "/api/index/snapshots"} {
+		if code, _ := post(path, "team-token", "{}"); code != http.StatusForbidden {
+			t.Errorf("POST %s in a namespace: %d, want 403", path, code)
+		}
+		if code, _ := post(path, "no-such-token", "{}"); code != http.StatusForbidden {
+			t.Errorf("POST %s with an unknown token: %d, want 403", path, code)
+		}
+	}
+
+	// Removing a shared page in a namespace looks in the namespace's own docs
+	page := "https://docs.unity3d.com/Manual/class-Transform.html"
+	before := searcher.DocCount()
+	if _, got := post("/api/docs/remove", "team-token", `{"url": "`+page+`"}`); got["status"] != "error" {
+		t.Errorf("removed %s from the shared index in a namespace: %v", page, got)
+	}
+	if searcher.DocCount() != before {
+		t.Errorf("shared index went from %d to %d docs", before, searcher.DocCount())
+	}
+}
+
+func TestConfigRejectsBadNumbers(t *testing.T) {
+	before := cfg
+	for _, update := range []string{
+		`{"fetch_retries": "abc"}`,
+		`{"crawl_delay_ms": "-5"}`,
+		`{"index_workers": "1000"}`,
+		`{"fetch_rate_per_sec": "NaN"}`,
+		`{"fetch_timeout_sec": "30", "fetch_max_mb": "lots"}`,
+	} {
+		resp, err := server.Client().Post(server.URL+"/api/config", "application/json", strings.NewReader(update))
+		if err != nil { t.Fatal(err) }
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST %s: %d, want 400", update, resp.StatusCode)
+		}
+	}
+	if cfg.FetchRetries != before.FetchRetries || cfg.CrawlDelayMs != before.CrawlDelayMs || cfg.FetchTimeoutSec != before.FetchTimeoutSec {
+		t.Errorf("a rejected update changed the settings")
+	}
+}
+
+func TestConfigRejectsBadPattern(t *testing.T) {
+	before := cfg.IndexInclude
+	resp, err := server.Client().Post(server.URL+"/api/config", "application/json", strings.NewReader(`{"index_include": "Manual/[a-"}`))
+	if err != nil { t.Fatal(err) }
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", resp.StatusCode)
+	}
+	if strings.Join(cfg.IndexInclude, "\n") != strings.Join(before, "\n") {
+		t.Errorf("a rejected pattern changed index_include to %q", cfg.IndexInclude)
+	}
+}
+
+func TestConfigRejectsUnknownLanguage(t *testing.T) {
+	before := cfg.DocLanguage
+	resp, err := server.Client().Post(server.URL+"/api/config", "application/json", strings.NewReader(`{"doc_language": "xx"}`))
+	if err != nil { t.Fatal(err) }
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", resp.StatusCode)
+	}
+	if cfg.DocLanguage != before {
+		t.Errorf("a rejected language changed doc_language to %q", cfg.DocLanguage)
+	}
+}
+
+func TestIndexDiffUnknownIndex(t *testing.T) {
+	resp, err := server.Client().Get(server.URL + "/api/index/diff?index=no-such-index")
+	if err != nil { t.Fatal(err) }
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", resp.StatusCode)
+	}
+}
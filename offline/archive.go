@@ -0,0 +1,209 @@
+package offline
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ── Archives ──────────────────────────────────────────────────────────────────
+// Unity ships the offline docs as a ZIP, but mirrors repackage them: as a
+// .tar.gz, or as an archive wrapping the documentation ZIP next to a readme
+// or a license. Both are indexed as they are. A wrapped archive is copied
+// out once to cache/archives and reused until the wrapper changes.
+
+// maxArchiveDepth is how many archives deep the docs are looked for.
+const maxArchiveDepth = 2
+
+func isArchive(p string) bool {
+	return isTarGz(p) || strings.HasSuffix(strings.ToLower(p), ".zip")
+}
+
+func isTarGz(p string) bool {
+	lower := strings.ToLower(p)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// indexArchive indexes a ZIP or .tar.gz found depth archives deep.
+// A .tar.gz is read in the order it's stored in, so only a ZIP gives its
+// priority pages first.
+func (ix *Indexer) indexArchive(ctx context.Context, path string, prev manifest, onProgress func(done, total int), depth int, out *pageSink) (IndexUpdate, manifest, error) {
+	if isTarGz(path) {
+		return ix.indexTarGz(ctx, path, prev, onProgress, depth, out)
+	}
+	return ix.indexZip(ctx, path, prev, onProgress, depth, out)
+}
+
+// indexTarGz indexes a .tar.gz in one pass: a tar has no directory to look
+// things up in, so entries are read in the order they're stored. Entries
+// whose size and modification time match the last run are skipped without
+// being decompressed into memory. The total isn't known until the end, so
+// progress is reported without one.
+func (ix *Indexer) indexTarGz(ctx context.Context, tgzPath string, prev manifest, onProgress func(done, total int), depth int, out *pageSink) (IndexUpdate, manifest, error) {
+	var upd IndexUpdate
+	log.Printf("[offline] Opening tar.gz: %s", tgzPath)
+	f, err := os.Open(tgzPath)
+	if err != nil {
+		return upd, nil, fmt.Errorf("cannot open tar.gz: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return upd, nil, fmt.Errorf("cannot open tar.gz: %w", err)
+	}
+	defer gz.Close()
+
+	next := manifest{}
+	nested := "" // the first archive inside, in case there are no docs beside it
+	parsed := 0
+	entries, last := 0, ""
+	limit := ix.readLimit()
+	tr := tar.NewReader(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			return upd, nil, err
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return upd, nil, fmt.Errorf("tar.gz truncated at entry %d (after %s)", entries+1, last)
+		}
+		if err != nil {
+			return upd, nil, fmt.Errorf("cannot read tar.gz at entry %d (after %s): %w", entries+1, last, err)
+		}
+		entries++
+		last = h.Name
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if nested == "" && isArchive(h.Name) && depth < maxArchiveDepth {
+			stamp := fmt.Sprintf("%d-%d", h.Size, h.ModTime.Unix())
+			if nested, err = ix.extractNested(tgzPath, h.Name, stamp, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+				return upd, nil, err
+			}
+			continue
+		}
+		if why := ix.skipReason(h.Name); why != "" {
+			out.skip(h.Name, why, "")
+			continue
+		}
+		if why := ix.tooLarge(h.Size); why != "" {
+			out.skip(h.Name, why, sizeMB(h.Size))
+			continue
+		}
+		entry := manifestEntry{Size: h.Size, MTime: h.ModTime.Unix()}
+		if old, ok := prev[h.Name]; ok && old.Size == entry.Size && old.MTime == entry.MTime {
+			next[h.Name] = old
+			upd.Unchanged++
+			continue
+		}
+		if out.full() {
+			continue // new to the next run
+		}
+		limit.wait(ctx, int(h.Size))
+		data, err := io.ReadAll(tr)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return upd, nil, fmt.Errorf("tar.gz truncated at entry %d (%s)", entries, h.Name)
+		}
+		if err != nil {
+			return upd, nil, fmt.Errorf("cannot read entry %d (%s): %w", entries, h.Name, err)
+		}
+		entry.Hash = fileHash(data)
+		next[h.Name] = entry
+		data, why := ix.checkPage(data)
+		if why != "" {
+			out.skip(h.Name, why, "")
+			continue
+		}
+		results := parseArchivePage(data, h.Name)
+		if len(results) == 0 {
+			out.skip(h.Name, SkipTooShort, "")
+		}
+		for _, r := range results {
+			next.produced(h.Name, r.URL)
+		}
+		out.add(results)
+		if parsed++; parsed%50 == 0 && onProgress != nil {
+			onProgress(parsed, 0)
+		}
+	}
+	log.Printf("[offline] tar.gz has %d indexable HTML files (%d unchanged)", len(next), upd.Unchanged)
+
+	if len(next) == 0 && nested != "" {
+		return ix.indexArchive(ctx, nested, prev, onProgress, depth+1, out)
+	}
+	if onProgress != nil {
+		onProgress(parsed, parsed)
+	}
+	return upd, next, nil
+}
+
+// largestArchive returns the biggest archive among a ZIP's entries, or nil.
+func largestArchive(files []*zip.File) *zip.File {
+	var best *zip.File
+	for _, f := range files {
+		if isArchive(f.Name) && (best == nil || f.UncompressedSize64 > best.UncompressedSize64) {
+			best = f
+		}
+	}
+	return best
+}
+
+// extractNested copies the archive name found inside outer to cache/archives
+// and returns where. stamp identifies its version (a CRC, a size and date):
+// a copy with the same one is reused, older ones are deleted.
+func (ix *Indexer) extractNested(outer, name, stamp string, open func() (io.ReadCloser, error)) (string, error) {
+	abs, err := filepath.Abs(outer)
+	if err != nil {
+		abs = outer
+	}
+	h := fnv.New64a()
+	h.Write([]byte(abs + "!" + name))
+	prefix := fmt.Sprintf("%016x-", h.Sum64())
+	dir := filepath.Join(ix.cacheDir, "archives")
+	file := filepath.Join(dir, prefix+stamp+"-"+path.Base(filepath.ToSlash(name)))
+	if _, err := os.Stat(file); err == nil {
+		log.Printf("[offline] Using %s from %s (extracted before)", name, outer)
+		return file, nil
+	}
+
+	log.Printf("[offline] Extracting %s from %s", name, outer)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	old, _ := filepath.Glob(filepath.Join(dir, prefix+"*"))
+	for _, o := range old {
+		os.Remove(o)
+	}
+	src, err := open()
+	if err != nil {
+		return "", fmt.Errorf("cannot open %s: %w", name, err)
+	}
+	defer src.Close()
+	tmp := file + ".tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("cannot extract %s: %w", name, err)
+	}
+	return file, os.Rename(tmp, file)
+}
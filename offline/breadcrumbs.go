@@ -0,0 +1,53 @@
+package offline
+
+import (
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Breadcrumbs ───────────────────────────────────────────────────────────────
+// Manual pages open with their place in the docs, "Unity User Manual >
+// Physics > 2D > Rigidbody 2D". The trail, without the manual itself, is
+// kept as a tag so results can be grouped by topic (search.Topic) and a
+// question about "2D physics" finds the pages filed under it.
+
+var (
+	reBreadcrumbs = regexp.MustCompile(`(?is)<div[^>]*\bclass\s*=\s*["'][^"']*\bbreadcrumbs\b[^"']*["'][^>]*>(.*?)</div>`)
+	reCrumb       = regexp.MustCompile(`(?is)<li[^>]*>(.*?)</li>`)
+)
+
+// trailRoots start the crumbs that stand for the docs as a whole.
+var trailRoots = []string{"unity user manual", "unity manual", "manual", "scripting api", "unity scripting api"}
+
+// pageTrail returns a page's breadcrumb trail joined by search.TrailSep, or
+// "" if it has less than two steps once the root is dropped.
+func pageTrail(html string) string {
+	m := reBreadcrumbs.FindStringSubmatch(html)
+	if m == nil {
+		return ""
+	}
+	var crumbs []string
+	for _, li := range reCrumb.FindAllStringSubmatch(m[1], -1) {
+		c := strings.Join(strings.Fields(decodeEntities(stripTags(li[1]))), " ")
+		if c == "" || len(crumbs) == 0 && isTrailRoot(c) {
+			continue
+		}
+		crumbs = append(crumbs, c)
+	}
+	if len(crumbs) < 2 {
+		return ""
+	}
+	return strings.Join(crumbs, search.TrailSep)
+}
+
+func isTrailRoot(crumb string) bool {
+	lower := strings.ToLower(crumb)
+	for _, r := range trailRoots {
+		if strings.HasPrefix(lower, r) {
+			return true
+		}
+	}
+	return false
+}
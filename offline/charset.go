@@ -0,0 +1,66 @@
+package offline
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ── Character sets ────────────────────────────────────────────────────────────
+// Current docs are UTF-8, but older and localized archives come in
+// Shift_JIS, GBK, EUC-KR or UTF-16 and index as mojibake if read as UTF-8.
+// Pages are transcoded before anything is extracted: by their byte order
+// mark, else the charset their <meta> tags declare, else, for bytes that
+// aren't UTF-8, the usual legacy charset of their language folder.
+
+var reMetaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([A-Za-z0-9_:.-]+)`)
+
+// legacyCharsets is what undeclared non-UTF-8 pages of each language most
+// likely are.
+var legacyCharsets = map[string]string{"ja": "shift_jis", "zh": "gb18030", "ko": "euc-kr", "en": "windows-1252", "es": "windows-1252"}
+
+// toUTF8 returns the page at path (for its language folder) as UTF-8.
+// Pages it can't make sense of are returned unchanged.
+func toUTF8(page, path string) string {
+	switch {
+	case strings.HasPrefix(page, "\xef\xbb\xbf"):
+		return page[3:]
+	case strings.HasPrefix(page, "\xff\xfe"):
+		return decodeWith(unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), page)
+	case strings.HasPrefix(page, "\xfe\xff"):
+		return decodeWith(unicode.UTF16(unicode.BigEndian, unicode.UseBOM), page)
+	}
+	name := ""
+	if m := reMetaCharset.FindStringSubmatch(page[:min(len(page), 4096)]); m != nil {
+		name = m[1]
+	}
+	if name == "" || isUTF8Name(name) {
+		if utf8.ValidString(page) {
+			return page
+		}
+		// Declared UTF-8 (or nothing) but it isn't: guess from the language
+		name = legacyCharsets[DetectLanguage(path, "")]
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return page
+	}
+	return decodeWith(enc, page)
+}
+
+func isUTF8Name(name string) bool {
+	n := strings.ToLower(name)
+	return n == "utf-8" || n == "utf8"
+}
+
+func decodeWith(enc encoding.Encoding, page string) string {
+	out, err := enc.NewDecoder().String(page)
+	if err != nil {
+		return page
+	}
+	return out
+}
@@ -0,0 +1,240 @@
+package offline
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── Compact corpus ────────────────────────────────────────────────────────────
+// Extracted docs take around 2 GB, most of it images, stylesheets and
+// scripts that are never indexed. Once they're indexed, Compact copies just
+// the pages that were, as they were parsed, into one maximally compressed
+// ZIP in cache/corpus: a few hundred MB. The pages keep their paths, so they
+// give the same URLs, and the manifest carries over, so the index is kept as
+// it is rather than rebuilt and answers stay identical. The original can then
+// be deleted.
+
+// CompactReport is what a Compact run wrote.
+type CompactReport struct {
+	Source      string `json:"source"`
+	Path        string `json:"path"` // the compact corpus
+	Files       int    `json:"files"`
+	SourceBytes int64  `json:"source_bytes"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// CorpusPath is where Compact writes the corpus of the docs at source.
+func (ix *Indexer) CorpusPath(source string) string {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		abs = source
+	}
+	h := fnv.New64a()
+	h.Write([]byte(abs))
+	return filepath.Join(ix.cacheDir, "corpus", fmt.Sprintf("docs-%016x.zip", h.Sum64()))
+}
+
+// IsCorpus reports whether path is a corpus Compact wrote.
+func (ix *Indexer) IsCorpus(path string) bool {
+	dir, err1 := filepath.Abs(filepath.Dir(path))
+	corpus, err2 := filepath.Abs(filepath.Join(ix.cacheDir, "corpus"))
+	return err1 == nil && err2 == nil && dir == corpus
+}
+
+// DocsSize is the disk space the docs at path take: the archive's size, or
+// the total of every file in the folder.
+func DocsSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var total int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// Compact writes the pages of the docs at source (a folder, ZIP or .tar.gz)
+// to a compact corpus at CorpusPath(source) and gives it source's manifest,
+// so indexing the corpus next finds every page unchanged. Cancelling ctx
+// stops it and leaves no corpus behind.
+func (ix *Indexer) Compact(ctx context.Context, source string) (CompactReport, error) {
+	report := CompactReport{Source: source, Path: ix.CorpusPath(source), SourceBytes: DocsSize(source)}
+	info, err := os.Stat(source)
+	if err != nil {
+		return report, err
+	}
+	if !info.IsDir() && !isArchive(source) || IsDocset(source) {
+		return report, fmt.Errorf("only a docs folder, ZIP or .tar.gz can be compacted: %s", source)
+	}
+	log.Printf("[offline] Compacting %s to %s", source, report.Path)
+	if err := os.MkdirAll(filepath.Dir(report.Path), 0755); err != nil {
+		return report, err
+	}
+	tmp := report.Path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return report, err
+	}
+	defer os.Remove(tmp) // no-op once renamed
+	zw := zip.NewWriter(f)
+	zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flate.BestCompression)
+	})
+
+	prev := ix.loadManifest(source)
+	next := manifest{}
+	add := func(key string, modified time.Time, data []byte) error {
+		if ix.skipReason(key) != "" || ix.tooLarge(int64(len(data))) != "" {
+			return nil
+		}
+		data, why := ix.checkPage(data)
+		if why != "" {
+			return nil
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: key, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		// The entry the zip indexer will see, with the pages it gave last time
+		old := prev[key]
+		next[key] = manifestEntry{Size: int64(len(data)), MTime: modified.Unix(), Hash: fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)), URL: old.URL, More: old.More}
+		report.Files++
+		return nil
+	}
+	if info.IsDir() {
+		err = compactFolder(ctx, source, add)
+	} else if isTarGz(source) {
+		err = compactTarGz(ctx, source, add)
+	} else {
+		err = compactZip(ctx, source, add)
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && report.Files == 0 {
+		err = fmt.Errorf("no doc pages to compact in %s", source)
+	}
+	if err != nil {
+		return report, err
+	}
+	if err := os.Rename(tmp, report.Path); err != nil {
+		return report, err
+	}
+	if err := ix.saveManifest(report.Path, next); err != nil {
+		return report, err
+	}
+	report.Bytes = DocsSize(report.Path)
+	log.Printf("[offline] Compacted %d pages: %s → %s", report.Files, sizeMB(report.SourceBytes), sizeMB(report.Bytes))
+	return report, nil
+}
+
+func compactFolder(ctx context.Context, root string, add func(key string, modified time.Time, data []byte) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skipReason(path) != "" {
+			return nil // not read at all
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", path, err)
+		}
+		return add(manifestKey(root, path), info.ModTime(), data)
+	})
+}
+
+func compactZip(ctx context.Context, zipPath string, add func(key string, modified time.Time, data []byte) error) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("cannot open zip: %w", err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.FileInfo().IsDir() || skipReason(f.Name) != "" {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", f.Name, err)
+		}
+		if err := add(f.Name, f.Modified, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compactTarGz(ctx context.Context, tgzPath string, add func(key string, modified time.Time, data []byte) error) error {
+	f, err := os.Open(tgzPath)
+	if err != nil {
+		return fmt.Errorf("cannot open tar.gz: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("cannot open tar.gz: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read tar.gz: %w", err)
+		}
+		if h.Typeflag != tar.TypeReg || skipReason(h.Name) != "" {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", h.Name, err)
+		}
+		if err := add(h.Name, h.ModTime, data); err != nil {
+			return err
+		}
+	}
+}
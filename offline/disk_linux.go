@@ -0,0 +1,32 @@
+package offline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// isRotational reports whether path is on a spinning disk, going by the
+// block device's queue/rotational flag in sysfs. known is false when that
+// can't be told (network and virtual filesystems, containers).
+func isRotational(path string) (rotational, known bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return false, false
+	}
+	d := uint64(st.Dev)
+	major, minor := (d>>8)&0xfff|(d>>32)&0xfffff000, d&0xff|(d>>12)&0xffffff00
+	dev, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return false, false
+	}
+	// A partition's flag is on its disk: .../sda/sda1 → .../sda
+	for _, dir := range []string{dev, filepath.Dir(dev)} {
+		if b, err := os.ReadFile(filepath.Join(dir, "queue", "rotational")); err == nil {
+			return strings.TrimSpace(string(b)) == "1", true
+		}
+	}
+	return false, false
+}
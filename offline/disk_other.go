@@ -0,0 +1,9 @@
+//go:build !linux
+
+package offline
+
+// isRotational can't tell the disk type outside Linux; auto mode then goes
+// by the CPU count alone.
+func isRotational(path string) (rotational, known bool) {
+	return false, false
+}
@@ -0,0 +1,155 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Dash / Zeal docsets ───────────────────────────────────────────────────────
+// Many developers already have the Unity docs as a Dash or Zeal docset: a
+// folder (Unity 3D.docset) of HTML pages under Contents/Resources/Documents
+// and a SQLite index, docSet.dsidx, naming the API and guide entries and the
+// page each is on. Pages the index lists are indexed like extracted docs,
+// tagged with the entries found on them so "AddForce" finds Rigidbody's page.
+
+// SkipNotInDocset is why a docset file the docset's index doesn't list was
+// left out.
+const SkipNotInDocset = "not in the docset's index"
+
+// maxEntryTags caps how many docset entries are tagged on one page.
+const maxEntryTags = 20
+
+var reDashMarkup = regexp.MustCompile(`<[^>]*>`)
+
+// IsDocset reports whether path is a Dash or Zeal docset folder.
+func IsDocset(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "Contents", "Resources", "Documents"))
+	return err == nil && info.IsDir()
+}
+
+// docsetDirs are where Dash and Zeal keep their docsets.
+func docsetDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, "Library", "Application Support", "Dash", "DocSets"),
+			filepath.Join(home, ".local", "share", "Zeal", "Zeal", "docsets"),
+		)
+	}
+	if local := os.Getenv("LOCALAPPDATA"); local != "" {
+		dirs = append(dirs, filepath.Join(local, "Zeal", "Zeal", "docsets"))
+	}
+	return dirs
+}
+
+// findDocset returns an installed Unity docset, or "".
+func findDocset() string {
+	for _, dir := range docsetDirs() {
+		for _, pattern := range []string{"Unity*.docset", filepath.Join("Unity*", "Unity*.docset")} {
+			matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, m := range matches {
+				if IsDocset(m) {
+					return m
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// docsetEntries reads a docset's index: page (relative to Documents) →
+// names of the entries on it. nil if the index can't be read, in which
+// case the pages are picked like extracted docs'.
+func docsetEntries(root string) map[string][]string {
+	rows, err := readSQLiteTable(filepath.Join(root, "Contents", "Resources", "docSet.dsidx"), "searchIndex")
+	if err != nil {
+		log.Printf("[offline] Docset index unreadable, indexing its pages without it: %v", err)
+		return nil
+	}
+	entries := map[string][]string{}
+	for _, row := range rows {
+		p := reDashMarkup.ReplaceAllString(row["path"], "")
+		p, _, _ = strings.Cut(p, "#")
+		if u, err := url.PathUnescape(p); err == nil {
+			p = u
+		}
+		p = strings.TrimPrefix(filepath.ToSlash(p), "./")
+		if p == "" {
+			continue
+		}
+		names := entries[p]
+		if name := strings.TrimSpace(row["name"]); name != "" && len(names) < maxEntryTags && !contains(names, name) {
+			names = append(names, name)
+		}
+		entries[p] = names
+	}
+	return entries
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// indexDocset indexes the pages of the docset at root that differ from prev.
+func (ix *Indexer) indexDocset(ctx context.Context, root string, prev manifest, onProgress func(done, total int), out *pageSink) (IndexUpdate, manifest, error) {
+	log.Printf("[offline] Reading docset: %s", root)
+	docs := filepath.Join(root, "Contents", "Resources", "Documents")
+	entries := docsetEntries(root)
+	skip := ix.skipReason
+	if entries != nil {
+		log.Printf("[offline] Docset index lists %d pages", len(entries))
+		skip = func(p string) string {
+			if _, ok := entries[manifestKey(docs, p)]; !ok {
+				return SkipNotInDocset
+			}
+			if why := skipReason(p); why == SkipNotHTML {
+				return why
+			}
+			return ix.patternSkip(p)
+		}
+	}
+	upd, next, total, err := ix.scanFolder(ctx, docs, prev, skip, func(data []byte, p string) []search.Result {
+		rel := manifestKey(docs, p)
+		results := pageChunks(string(data), docsetURL(rel, p), rel)
+		for i := range results {
+			tags := append([]string(nil), results[i].Tags...)
+			for _, name := range entries[rel] {
+				if !strings.EqualFold(name, results[i].Title) && !contains(tags, name) {
+					tags = append(tags, name)
+				}
+			}
+			results[i].Tags = tags
+		}
+		return results
+	}, onProgress, out)
+	if err == nil && total == 0 {
+		err = fmt.Errorf("no doc pages found in docset %s", root)
+	}
+	return upd, next, err
+}
+
+// docsetURL links a docset page to its docs.unity3d.com page when it is a
+// copy of one (…/Manual/X.html, …/ScriptReference/X.html), else to the file.
+func docsetURL(rel, p string) string {
+	slash := "/" + rel
+	for _, dir := range []string{"/Manual/", "/ScriptReference/"} {
+		if i := strings.Index(slash, dir); i >= 0 {
+			return "https://docs.unity3d.com" + slash[i:]
+		}
+	}
+	abs, _ := filepath.Abs(p)
+	return "file:///" + strings.TrimPrefix(filepath.ToSlash(abs), "/")
+}
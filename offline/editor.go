@@ -0,0 +1,200 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Unity Editor installs ─────────────────────────────────────────────────────
+// An Editor installed with its Documentation module carries the full offline
+// docs: Editor/Data/Documentation on Windows and Linux, Documentation next
+// to Unity.app on macOS. FindEditorDocs finds them, for first runs where
+// nothing was configured or found next to the exe.
+
+// EditorDocs is the docs folder of an installed Unity Editor.
+type EditorDocs struct {
+	Version string `json:"version"` // e.g. "2022.3.10f1", "" if the install path doesn't say
+	Path    string `json:"path"`    // the Documentation folder
+	Running bool   `json:"running"` // that Editor is open right now
+}
+
+// probeTimeout bounds each command asked about installs (reg, mdfind, ps).
+const probeTimeout = 5 * time.Second
+
+var (
+	reEditorVersion = regexp.MustCompile(`(?:20[0-9]{2}|[6-9][0-9]{3})\.[0-9]+\.[0-9]+[abfpx][0-9]+`)
+	reRegLocation   = regexp.MustCompile(`(?m)^\s*Location(?: x64)?\s+REG_SZ\s+(.+?)\s*$`)
+)
+
+// FindEditorDocs returns the docs of the Unity Editors installed on this
+// machine: Editors open right now first, then the newest.
+func FindEditorDocs() []EditorDocs {
+	open := runningEditors()
+	running := map[string]bool{}
+	for _, root := range open {
+		running[filepath.Clean(root)] = true
+	}
+	var found []EditorDocs
+	seen := map[string]bool{}
+	for _, root := range append(open, editorRoots()...) {
+		root = filepath.Clean(root)
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		for _, sub := range []string{"Documentation", filepath.Join("Editor", "Data", "Documentation")} {
+			dir := filepath.Join(root, sub)
+			if hasUnityDocs(dir) {
+				found = append(found, EditorDocs{Version: reEditorVersion.FindString(root), Path: dir, Running: running[root]})
+				break
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Running != found[j].Running {
+			return found[i].Running
+		}
+		return versionLess(found[j].Version, found[i].Version)
+	})
+	return found
+}
+
+// editorRoots are the install folders of the Editors the OS or Unity Hub
+// knows about: the folder holding Unity.app on macOS, the one holding
+// Editor/ elsewhere.
+func editorRoots() []string {
+	var roots, hubs []string
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		// The standalone installer records each install in the registry
+		for _, key := range []string{`HKCU\Software\Unity Technologies\Installer`, `HKLM\SOFTWARE\Unity Technologies\Installer`} {
+			for _, m := range reRegLocation.FindAllStringSubmatch(probe("reg", "query", key, "/s"), -1) {
+				roots = append(roots, m[1])
+			}
+		}
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if dir := os.Getenv(env); dir != "" {
+				hubs = append(hubs, filepath.Join(dir, "Unity", "Hub", "Editor"))
+				roots = append(roots, filepath.Join(dir, "Unity"))
+			}
+		}
+	case "darwin":
+		// Spotlight knows Editors installed anywhere, not just under /Applications
+		for _, line := range strings.Split(probe("mdfind", "kMDItemCFBundleIdentifier == 'com.unity3d.UnityEditor5.x'"), "\n") {
+			if line = strings.TrimSpace(line); strings.HasSuffix(line, ".app") {
+				roots = append(roots, filepath.Dir(line))
+			}
+		}
+		hubs = append(hubs, "/Applications/Unity/Hub/Editor")
+		roots = append(roots, "/Applications/Unity")
+	default:
+		if home != "" {
+			hubs = append(hubs, filepath.Join(home, "Unity", "Hub", "Editor"))
+		}
+		roots = append(roots, "/opt/unity", "/opt/Unity")
+	}
+	// Unity Hub installs elsewhere when the user picked another folder
+	if dir := hubInstallPath(home); dir != "" {
+		hubs = append(hubs, dir)
+	}
+	for _, hub := range hubs {
+		entries, _ := os.ReadDir(hub)
+		for _, e := range entries {
+			if e.IsDir() {
+				roots = append(roots, filepath.Join(hub, e.Name()))
+			}
+		}
+	}
+	return roots
+}
+
+// hubInstallPath returns the install folder chosen in Unity Hub's settings,
+// or "".
+func hubInstallPath(home string) string {
+	var file string
+	switch runtime.GOOS {
+	case "windows":
+		file = filepath.Join(os.Getenv("APPDATA"), "UnityHub", "secondaryInstallPath.json")
+	case "darwin":
+		file = filepath.Join(home, "Library", "Application Support", "UnityHub", "secondaryInstallPath.json")
+	default:
+		file = filepath.Join(home, ".config", "UnityHub", "secondaryInstallPath.json")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	var dir string
+	if json.Unmarshal(data, &dir) != nil {
+		return ""
+	}
+	return dir
+}
+
+// runningEditors returns the install folders of the Editors open right now.
+func runningEditors() []string {
+	var roots []string
+	if runtime.GOOS == "windows" {
+		out := probe("powershell", "-NoProfile", "-Command", "Get-Process Unity -ErrorAction SilentlyContinue | ForEach-Object { $_.Path }")
+		for _, exe := range strings.Split(out, "\n") {
+			// <root>\Editor\Unity.exe
+			if exe = strings.TrimSpace(exe); exe != "" {
+				roots = append(roots, filepath.Dir(filepath.Dir(exe)))
+			}
+		}
+		return roots
+	}
+	for _, cmd := range strings.Split(probe("ps", "-axo", "command="), "\n") {
+		if i := strings.Index(cmd, "/Unity.app/Contents/MacOS/Unity"); i >= 0 {
+			roots = append(roots, filepath.Dir(cmd[:i+len("/Unity.app")]))
+		} else if i := strings.Index(cmd, "/Editor/Unity"); i >= 0 && (len(cmd) == i+len("/Editor/Unity") || cmd[i+len("/Editor/Unity")] == ' ') {
+			roots = append(roots, cmd[:i])
+		}
+	}
+	return roots
+}
+
+// probe runs a command and returns its output, "" if it fails or isn't there.
+func probe(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// versionLess orders Unity versions by their major.minor.patch numbers;
+// unknown versions sort first.
+func versionLess(a, b string) bool {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return false
+}
+
+func versionParts(v string) [3]int {
+	var parts [3]int
+	for i, s := range strings.SplitN(v, ".", 3) {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		parts[i], _ = strconv.Atoi(s[:end])
+	}
+	return parts
+}
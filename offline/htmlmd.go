@@ -0,0 +1,203 @@
+package offline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ── HTML → Markdown ───────────────────────────────────────────────────────────
+// Pages are indexed as Markdown rather than flat text, so what's quoted from
+// them keeps its code blocks, lists and tables: a code sample stays a fenced
+// block instead of a run of lines, and a table of properties keeps its rows.
+// Links keep just their text, and a plain line too short to be content
+// (menus, buttons) is dropped as extractText drops it.
+
+var (
+	reMdTag   = regexp.MustCompile(`(?s)<(/?)([a-zA-Z][a-zA-Z0-9]*)\b([^>]*)>`)
+	reMdTable = regexp.MustCompile(`(?is)<table\b[^>]*>(.*?)</table>`)
+	reMdRow   = regexp.MustCompile(`(?is)<tr\b[^>]*>(.*?)</tr>`)
+	reMdCell  = regexp.MustCompile(`(?is)<t([hd])\b[^>]*>(.*?)</t[hd]>`)
+	reMdClass = regexp.MustCompile(`(?i)\bclass\s*=\s*["']([^"']*)["']`)
+	reMdSpace = regexp.MustCompile(`\s+`)
+	// Blocks set aside while the rest is converted: <mdhold n> in the HTML,
+	// "\x00<n>\x00" on a line of its own in the Markdown
+	reMdHold = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// toMarkdown turns a page's HTML into Markdown, one block per paragraph.
+func toMarkdown(html string) string {
+	html = stripChrome(html)
+	var held []string
+	hold := func(block string) string {
+		held = append(held, block)
+		return "<mdhold " + strconv.Itoa(len(held)-1) + ">"
+	}
+	html = rePre.ReplaceAllStringFunc(html, func(pre string) string {
+		code := strings.Trim(decodeEntities(stripTags(rePre.FindStringSubmatch(pre)[1])), "\r\n")
+		if strings.TrimSpace(code) == "" {
+			return " "
+		}
+		return hold("```" + codeLang(pre, code) + "\n" + code + "\n```")
+	})
+	html = reMdTable.ReplaceAllStringFunc(html, func(table string) string {
+		if md := mdTable(table); md != "" {
+			return hold(md)
+		}
+		return " "
+	})
+
+	var b strings.Builder
+	var lists []int // open lists, innermost last: -1 for <ul>, else <ol>'s item count
+	text := func(s string) {
+		b.WriteString(reMdSpace.ReplaceAllString(decodeEntities(s), " "))
+	}
+	last := 0
+	for _, m := range reMdTag.FindAllStringSubmatchIndex(html, -1) {
+		text(html[last:m[0]])
+		last = m[1]
+		closing := m[3] > m[2]
+		switch tag := strings.ToLower(html[m[4]:m[5]]); tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			if closing {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n\n" + strings.Repeat("#", int(tag[1]-'0')) + " ")
+			}
+		case "p", "div", "section", "article", "blockquote", "dl", "dd", "table", "tr":
+			b.WriteString("\n\n")
+		case "br", "dt":
+			b.WriteString("\n")
+		case "ul", "ol":
+			if closing {
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				b.WriteString("\n\n")
+			} else if tag == "ul" {
+				lists = append(lists, -1)
+			} else {
+				lists = append(lists, 0)
+			}
+		case "li":
+			if closing {
+				continue
+			}
+			indent, marker := "", "-"
+			if n := len(lists); n > 0 {
+				indent = strings.Repeat("  ", n-1)
+				if lists[n-1] >= 0 {
+					lists[n-1]++
+					marker = strconv.Itoa(lists[n-1]) + "."
+				}
+			}
+			b.WriteString("\n" + indent + marker + " ")
+		case "mdhold":
+			b.WriteString("\n\n\x00" + strings.TrimSpace(html[m[6]:m[7]]) + "\x00\n\n")
+		case "code", "kbd", "tt":
+			b.WriteString("`")
+		case "strong", "b":
+			b.WriteString("**")
+		}
+	}
+	text(html[last:])
+
+	// One block per paragraph, list items and table rows on lines of their own
+	var out []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.TrimRight(line, " ")
+		trimmed := strings.TrimSpace(cleanInline(line))
+		switch {
+		case trimmed == "":
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		case reMdHold.MatchString(trimmed), strings.HasPrefix(trimmed, "#"), isListItem(trimmed):
+			if strings.HasPrefix(trimmed, "#") && strings.TrimLeft(trimmed, "# ") == "" {
+				continue // a heading with nothing in it
+			}
+			if isListItem(trimmed) {
+				trimmed = line[:len(line)-len(strings.TrimLeft(line, " "))] + trimmed
+			}
+		case len(trimmed) <= 15:
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	md := strings.TrimSpace(strings.Join(out, "\n"))
+	return reMdHold.ReplaceAllStringFunc(md, func(s string) string {
+		n, _ := strconv.Atoi(reMdHold.FindStringSubmatch(s)[1])
+		return held[n]
+	})
+}
+
+// cleanInline drops bold and code markers left with nothing between them.
+func cleanInline(line string) string {
+	line = strings.ReplaceAll(line, "****", "")
+	return strings.ReplaceAll(line, "``", "")
+}
+
+// isListItem reports whether a line starts like a Markdown list item.
+func isListItem(line string) bool {
+	if strings.HasPrefix(line, "- ") {
+		return true
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	return i > 0 && strings.HasPrefix(line[i:], ". ")
+}
+
+// mdTable turns an HTML table into a Markdown one, its first row the
+// header. "" for a table with no cells.
+func mdTable(table string) string {
+	var rows [][]string
+	width := 0
+	for _, r := range reMdRow.FindAllStringSubmatch(table, -1) {
+		var cells []string
+		for _, c := range reMdCell.FindAllStringSubmatch(r[1], -1) {
+			cell := strings.TrimSpace(reMdSpace.ReplaceAllString(decodeEntities(stripTags(c[2])), " "))
+			cells = append(cells, strings.ReplaceAll(cell, "|", `\|`))
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+			width = max(width, len(cells))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, cells := range rows {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", width) + "|\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// codeLang names a <pre> block's language for its fence, from its class or
+// failing that its code; "" if neither says.
+func codeLang(pre, code string) string {
+	class := ""
+	if m := reMdClass.FindStringSubmatch(pre); m != nil {
+		class = strings.ToLower(m[1])
+	}
+	switch {
+	case strings.Contains(class, "js") || strings.Contains(class, "javascript"):
+		return "js"
+	case strings.Contains(class, "shader") || strings.Contains(class, "hlsl"):
+		return "hlsl"
+	case strings.Contains(class, "cs") || strings.Contains(code, "using UnityEngine") ||
+		strings.Contains(code, "MonoBehaviour") || strings.Contains(code, "void "):
+		return "csharp"
+	}
+	return ""
+}
@@ -1,10 +1,13 @@
 // Package offline handles indexing and searching of the local Unity
 // offline documentation (the ~300MB ZIP from docs.unity3d.com).
-// It supports both an extracted folder and reading directly from the ZIP.
+// It supports both an extracted folder and reading directly from the ZIP
+// (or a .tar.gz, or either nested in another, see archive.go).
 package offline
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -32,10 +35,20 @@ type IndexProgress struct {
 type Indexer struct {
 	mu       sync.Mutex
 	progress IndexProgress
+	cacheDir string // where manifests are kept, see manifest.go
+	// which doc pages to index, see patterns.go
+	include, exclude []string
+	// folder indexing workers (0 = auto) and read rate, see throttle.go
+	workers int
+	limiter *ioLimiter
+	// largest file read, most pages per run, see limits.go
+	maxFile    int64
+	maxPages   int
+	keepBinary bool
 }
 
-func NewIndexer() *Indexer {
-	return &Indexer{}
+func NewIndexer(cacheDir string) *Indexer {
+	return &Indexer{cacheDir: cacheDir}
 }
 
 // FindDocPath auto-detects where the offline docs are.
@@ -58,23 +71,26 @@ func FindDocPath(hints []string) string {
 	for _, h := range hints {
 		if h == "" { continue }
 		if info, err := os.Stat(h); err == nil {
-			if info.IsDir() && hasUnityDocs(h) { return h }
-			if !info.IsDir() && isZip(h) { return h }
+			if info.IsDir() && (hasUnityDocs(h) || IsDocset(h)) { return h }
+			if !info.IsDir() && isArchive(h) { return h }
 		}
 	}
 
-	// ZIP filenames Unity ships (checked first — user said zip is next to exe)
+	// ZIP filenames Unity ships (checked first — user said zip is next to exe),
+	// then the .tar.gz some mirrors repackage them as
 	zipNames := []string{
 		"UnityDocumentation.zip",
 		"Documentation.zip",
 		"unity_docs.zip",
 		"unity_documentation.zip",
+		"UnityDocumentation.tar.gz",
+		"Documentation.tar.gz",
 	}
 	for _, base := range searchDirs {
 		for _, name := range zipNames {
 			full := filepath.Join(base, name)
 			if _, err := os.Stat(full); err == nil {
-				log.Printf("[offline] Auto-detected ZIP: %s", full)
+				log.Printf("[offline] Auto-detected archive: %s", full)
 				return full
 			}
 		}
@@ -97,11 +113,40 @@ func FindDocPath(hints []string) string {
 			}
 		}
 	}
+
+	// A docset Dash or Zeal already downloaded
+	if docset := findDocset(); docset != "" {
+		log.Printf("[offline] Auto-detected docset: %s", docset)
+		return docset
+	}
+	return ""
+}
+
+// reUnityVersion finds a Unity version in a path: "UnityDocumentation_2021.3.zip",
+// "docs/6000.0/", "2022.3.10f1". Only major.minor is captured.
+var reUnityVersion = regexp.MustCompile(`(?:^|[^0-9])((?:20[0-9]{2}|[6-9][0-9]{3})\.[0-9]+)`)
+
+// DetectVersion returns the Unity version a docs path is for, judging by its
+// file and folder names, or "" if none says.
+func DetectVersion(path string) string {
+	// The last match is the most specific: the file name over its folders
+	if ms := reUnityVersion.FindAllStringSubmatch(filepath.ToSlash(path), -1); ms != nil {
+		return ms[len(ms)-1][1]
+	}
 	return ""
 }
 
-func isZip(p string) bool {
-	return strings.HasSuffix(strings.ToLower(p), ".zip")
+// IsUnityDocs reports whether path holds Unity-style HTML docs: an archive,
+// a folder with Manual/ or ScriptReference/ in it, or a docset.
+func IsUnityDocs(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		return hasUnityDocs(path) || IsDocset(path)
+	}
+	return isArchive(path)
 }
 
 func hasUnityDocs(dir string) bool {
@@ -116,46 +161,158 @@ func hasUnityDocs(dir string) bool {
 
 // IndexPath indexes all HTML files from a path (folder or ZIP).
 // Calls onProgress periodically with count of indexed pages.
-// Returns all indexed results.
-func (ix *Indexer) IndexPath(path string, onProgress func(done, total int)) ([]search.Result, error) {
-	if strings.HasSuffix(strings.ToLower(path), ".zip") {
-		return ix.indexZip(path, onProgress)
+// Returns all indexed results. Cancelling ctx stops it within a page or so
+// and returns ctx's error.
+func (ix *Indexer) IndexPath(ctx context.Context, path string, onProgress func(done, total int)) ([]search.Result, error) {
+	upd, err := ix.index(ctx, path, nil, onProgress, ix.sink(path, nil))
+	return upd.Results, err
+}
+
+// IndexStream indexes path like IndexChanged, or like IndexPath without
+// changedOnly, but hands the pages to emit in batches as they're parsed
+// (see stream.go) instead of returning them in upd.Results: the most
+// asked-about pages first (see priority.go), flushed as soon as they're
+// done. A cancelled run still saves no manifest, but what was emitted
+// before it stopped has been emitted.
+func (ix *Indexer) IndexStream(ctx context.Context, path string, changedOnly bool, onProgress func(done, total int), emit func([]search.Result)) (IndexUpdate, error) {
+	var prev manifest
+	if changedOnly {
+		prev = ix.loadManifest(path)
+	}
+	return ix.index(ctx, path, prev, onProgress, ix.sink(path, emit))
+}
+
+// IndexChanged re-indexes path like IndexPath, but only parses files that
+// are new or changed since the last run over it (see manifest.go). Use it
+// when the pages from that run are still in the index.
+func (ix *Indexer) IndexChanged(ctx context.Context, path string, onProgress func(done, total int)) (IndexUpdate, error) {
+	return ix.index(ctx, path, ix.loadManifest(path), onProgress, ix.sink(path, nil))
+}
+
+// index parses the files of path that differ from prev and records what it
+// saw for the next run. A cancelled run saves nothing, so the next one
+// starts over from prev.
+func (ix *Indexer) index(ctx context.Context, path string, prev manifest, onProgress func(done, total int), out *pageSink) (IndexUpdate, error) {
+	var upd IndexUpdate
+	var next manifest
+	var err error
+	if isArchive(path) {
+		upd, next, err = ix.indexArchive(ctx, path, prev, onProgress, 0, out)
+	} else if IsDocset(path) {
+		upd, next, err = ix.indexDocset(ctx, path, prev, onProgress, out)
+	} else {
+		upd, next, err = ix.indexFolder(ctx, path, prev, onProgress, out)
+	}
+	if err != nil {
+		out.finish(&upd, nil, err)
+		return upd, err
+	}
+	out.flush()
+	out.finish(&upd, next, nil)
+	upd.Removed = prev.removed(next)
+	if err := ix.saveManifest(path, next); err != nil {
+		log.Printf("[offline] Cannot save manifest: %v", err)
 	}
-	return ix.indexFolder(path, onProgress)
+	return upd, nil
 }
 
 // ── ZIP Indexing ──────────────────────────────────────────────────────────────
 
-func (ix *Indexer) indexZip(zipPath string, onProgress func(done, total int)) ([]search.Result, error) {
+func (ix *Indexer) indexZip(ctx context.Context, zipPath string, prev manifest, onProgress func(done, total int), depth int, out *pageSink) (IndexUpdate, manifest, error) {
+	var upd IndexUpdate
 	log.Printf("[offline] Opening ZIP: %s", zipPath)
 	r, err := zip.OpenReader(zipPath)
+	if errors.Is(err, zip.ErrFormat) {
+		return upd, nil, fmt.Errorf("cannot open zip: truncated or not a zip file (%w)", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("cannot open zip: %w", err)
+		return upd, nil, fmt.Errorf("cannot open zip: %w", err)
 	}
 	defer r.Close()
 
-	// First pass: find all relevant HTML files
+	// First pass: find all relevant HTML files. The central directory has
+	// every entry's CRC-32, so unchanged ones are skipped without reading them.
 	var targets []*zip.File
+	next := manifest{}
 	for _, f := range r.File {
-		if shouldIndex(f.Name) {
-			targets = append(targets, f)
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if why := ix.skipReason(f.Name); why != "" {
+			out.skip(f.Name, why, "")
+			continue
+		}
+		if why := ix.tooLarge(int64(f.UncompressedSize64)); why != "" {
+			out.skip(f.Name, why, sizeMB(int64(f.UncompressedSize64)))
+			continue
+		}
+		entry := manifestEntry{Size: int64(f.UncompressedSize64), MTime: f.Modified.Unix(), Hash: fmt.Sprintf("%08x", f.CRC32)}
+		if old, ok := prev[f.Name]; ok && old.Size == entry.Size && old.Hash == entry.Hash {
+			next[f.Name] = old
+			upd.Unchanged++
+			continue
+		}
+		next[f.Name] = entry
+		targets = append(targets, f)
+	}
+	log.Printf("[offline] ZIP has %d indexable HTML files (%d unchanged)", len(targets)+upd.Unchanged, upd.Unchanged)
+	// No docs of its own: a mirror's wrapper around the real docs archive
+	if len(next) == 0 {
+		if inner := largestArchive(r.File); inner != nil && depth < maxArchiveDepth {
+			stamp := fmt.Sprintf("%08x-%d", inner.CRC32, inner.UncompressedSize64)
+			nested, err := ix.extractNested(zipPath, inner.Name, stamp, inner.Open)
+			if err != nil {
+				return upd, nil, err
+			}
+			return ix.indexArchive(ctx, nested, prev, onProgress, depth+1, out)
 		}
 	}
-	log.Printf("[offline] ZIP has %d indexable HTML files", len(targets))
 
-	var results []search.Result
-	var mu sync.Mutex
-	var processed int32
+	// A damaged archive fails here, before any of it is indexed
+	if err := ix.verifyZip(ctx, zipPath, r, targets); err != nil {
+		return upd, nil, err
+	}
 
-	// Process files (sequential for ZIP — random access is slow)
-	for _, f := range targets {
-		result, err := parseZipFile(f)
-		if err != nil || result == nil {
+	var processed int32
+	limit := ix.readLimit()
+
+	// Process files (sequential for ZIP — random access is slow), priority
+	// pages first, see priority.go
+	tier := sortByPriority(len(targets), func(i int) string { return targets[i].Name }, func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	for i, f := range targets {
+		if err := ctx.Err(); err != nil {
+			return upd, nil, err
+		}
+		if out.full() {
+			for _, left := range targets[i:] {
+				delete(next, left.Name) // new to the next run
+			}
+			break
+		}
+		if i == tier {
+			out.flush()
+		}
+		limit.wait(ctx, int(f.CompressedSize64))
+		data, err := readZipFile(f)
+		if err != nil {
+			out.skip(f.Name, SkipFailed, err.Error())
+			next.retry(f.Name, prev)
 			continue
 		}
-		mu.Lock()
-		results = append(results, *result)
-		mu.Unlock()
+		data, why := ix.checkPage(data)
+		if why != "" {
+			out.skip(f.Name, why, "")
+			continue
+		}
+		results := parseArchivePage(data, f.Name)
+		if len(results) == 0 {
+			out.skip(f.Name, SkipTooShort, "")
+			continue
+		}
+		for _, r := range results {
+			next.produced(f.Name, r.URL)
+		}
+		out.add(results)
 
 		n := int(atomic.AddInt32(&processed, 1))
 		if n%50 == 0 && onProgress != nil {
@@ -164,127 +321,208 @@ func (ix *Indexer) indexZip(zipPath string, onProgress func(done, total int)) ([
 	}
 
 	if onProgress != nil {
-		onProgress(len(results), len(targets))
+		onProgress(len(targets), len(targets))
 	}
-	return results, nil
+	return upd, next, nil
 }
 
-func parseZipFile(f *zip.File) (*search.Result, error) {
+func readZipFile(f *zip.File) ([]byte, error) {
 	rc, err := f.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer rc.Close()
+	return io.ReadAll(rc)
+}
 
-	data, err := io.ReadAll(rc)
-	if err != nil {
-		return nil, err
-	}
-
-	html := string(data)
-	title := extractTitle(html)
-	content := extractMainContent(html)
-	if len(content) < 80 {
-		return nil, nil // Skip near-empty pages
-	}
-	if len(content) > 12000 {
-		content = content[:12000]
-	}
-
+// parseArchivePage turns an HTML file read from an archive into its
+// page's chunks, or nil if it's near-empty.
+func parseArchivePage(data []byte, name string) []search.Result {
 	// Build a URL from the ZIP path (so links still work if docs are extracted)
-	url := zipPathToURL(f.Name)
-
-	return &search.Result{
-		Title:   title,
-		URL:     url,
-		Excerpt: content,
-		Score:   1.0,
-	}, nil
+	return pageChunks(string(data), zipPathToURL(name), name)
 }
 
 // ── Folder Indexing ───────────────────────────────────────────────────────────
 
-func (ix *Indexer) indexFolder(root string, onProgress func(done, total int)) ([]search.Result, error) {
+func (ix *Indexer) indexFolder(ctx context.Context, root string, prev manifest, onProgress func(done, total int), out *pageSink) (IndexUpdate, manifest, error) {
 	log.Printf("[offline] Scanning folder: %s", root)
+	upd, next, total, err := ix.scanFolder(ctx, root, prev, ix.skipReason, func(data []byte, path string) []search.Result {
+		return parseFolderFile(data, path, root)
+	}, onProgress, out)
+	if err == nil && total == 0 {
+		err = fmt.Errorf("no Unity HTML files found in %s — make sure the path contains Manual/ or ScriptReference/ folders", root)
+	}
+	return upd, next, err
+}
 
-	// Collect all HTML file paths first
+// scanFolder parses the files under root that differ from prev, in
+// parallel, except those skip gives a reason to leave out; parse turns a
+// file into its pages, which go to out (flushed once the priority files are
+// done). Returns what else changed, the
+// manifest for the next run and how many files matched, or ctx's error once
+// it's cancelled.
+func (ix *Indexer) scanFolder(ctx context.Context, root string, prev manifest, skip func(path string) string, parse func(data []byte, path string) []search.Result, onProgress func(done, total int), out *pageSink) (IndexUpdate, manifest, int, error) {
+	var upd IndexUpdate
+
+	// Collect all matching file paths first. Files whose size and
+	// modification time match the last run are skipped without being read.
 	var paths []string
+	next := manifest{}
+	total := 0
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
-		if !info.IsDir() && shouldIndex(path) {
-			paths = append(paths, path)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if info.IsDir() && path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir // .git, .obsidian
+		}
+		if info.IsDir() {
+			return nil
 		}
+		if why := skip(path); why != "" {
+			out.skip(manifestKey(root, path), why, "")
+			return nil
+		}
+		if why := ix.tooLarge(info.Size()); why != "" {
+			out.skip(manifestKey(root, path), why, sizeMB(info.Size()))
+			return nil
+		}
+		total++
+		key := manifestKey(root, path)
+		if old, ok := prev[key]; ok && old.Size == info.Size() && old.MTime == info.ModTime().UnixNano() {
+			next[key] = old
+			upd.Unchanged++
+			return nil
+		}
+		next[key] = manifestEntry{Size: info.Size(), MTime: info.ModTime().UnixNano()}
+		paths = append(paths, path)
 		return nil
 	})
-	if err != nil {
-		return nil, fmt.Errorf("walk error: %w", err)
+	if ctx.Err() != nil {
+		return upd, nil, 0, ctx.Err()
 	}
-	log.Printf("[offline] Found %d HTML files to index", len(paths))
-
-	if len(paths) == 0 {
-		return nil, fmt.Errorf("no Unity HTML files found in %s — make sure the path contains Manual/ or ScriptReference/ folders", root)
+	if err != nil {
+		return upd, nil, 0, fmt.Errorf("walk error: %w", err)
 	}
+	log.Printf("[offline] Found %d files to index (%d unchanged)", total, upd.Unchanged)
 
-	// Process in parallel (folders are fast with random access)
-	results := make([]search.Result, 0, len(paths))
+	// Process in parallel (folders are fast with random access), priority
+	// pages first (see priority.go): the workers take paths in order
+	tier := sortByPriority(len(paths), func(i int) string { return paths[i] }, func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+	tierLeft := int32(tier)
+	limit := ix.readLimit()
 	var mu sync.Mutex
 	var processed int32
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 8) // 8 concurrent workers
-
-	for _, p := range paths {
-		wg.Add(1)
-		go func(path string) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
 
-			result, err := parseFolderFile(path, root)
-			if err != nil || result == nil {
-				atomic.AddInt32(&processed, 1)
-				return
-			}
+	process := func(path string) {
+		if ctx.Err() != nil {
+			return // the whole run is thrown away, nothing to record
+		}
+		if docPriority(path) < priorityRest {
+			defer func() {
+				if atomic.AddInt32(&tierLeft, -1) == 0 && ctx.Err() == nil {
+					out.flush()
+				}
+			}()
+		}
 
+		key := manifestKey(root, path)
+		data, err := os.ReadFile(path)
+		limit.wait(ctx, len(data))
+		if err != nil {
+			out.skip(key, SkipFailed, err.Error())
 			mu.Lock()
-			results = append(results, *result)
+			next.retry(key, prev)
 			mu.Unlock()
+			atomic.AddInt32(&processed, 1)
+			return
+		}
+		// Touched but not edited (re-extracted, copied): same bytes, same page
+		hash := fileHash(data)
+		data, why := ix.checkPage(data)
+		old, seen := prev[key]
+		unchanged := seen && old.Hash == hash
+		mu.Lock()
+		entry := next[key]
+		entry.Hash = hash
+		if unchanged {
+			entry.URL, entry.More = old.URL, old.More
+			upd.Unchanged++
+		}
+		next[key] = entry
+		mu.Unlock()
+		if unchanged {
+			atomic.AddInt32(&processed, 1)
+			return
+		}
+		if why != "" {
+			out.skip(key, why, "")
+			atomic.AddInt32(&processed, 1)
+			return
+		}
+
+		results := parse(data, path)
+		if len(results) == 0 {
+			out.skip(key, SkipTooShort, "")
+			atomic.AddInt32(&processed, 1)
+			return
+		}
+
+		mu.Lock()
+		for _, r := range results {
+			next.produced(key, r.URL)
+		}
+		mu.Unlock()
+		out.add(results)
+
+		n := int(atomic.AddInt32(&processed, 1))
+		if n%100 == 0 && onProgress != nil {
+			onProgress(n, len(paths))
+		}
+	}
 
-			n := int(atomic.AddInt32(&processed, 1))
-			if n%100 == 0 && onProgress != nil {
-				onProgress(n, len(paths))
+	jobs := make(chan string)
+	for w := ix.workerCount(root); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				process(path)
 			}
-		}(p)
+		}()
+	}
+	sent := 0
+	for _, p := range paths {
+		if ctx.Err() != nil || out.full() {
+			break
+		}
+		jobs <- p
+		sent++
+	}
+	close(jobs)
+	// Files left at the page limit are new to the next run
+	for _, p := range paths[sent:] {
+		delete(next, manifestKey(root, p))
 	}
 
 	wg.Wait()
+	if ctx.Err() != nil {
+		return upd, nil, 0, ctx.Err()
+	}
 
 	if onProgress != nil {
-		onProgress(len(results), len(paths))
+		onProgress(len(paths), len(paths))
 	}
 
-	log.Printf("[offline] Indexed %d pages successfully", len(results))
-	return results, nil
+	log.Printf("[offline] Indexed %d pages successfully", out.total())
+	return upd, next, total, nil
 }
 
-func parseFolderFile(path, root string) (*search.Result, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	html := string(data)
-	title := extractTitle(html)
-	content := extractMainContent(html)
-
-	if len(content) < 80 {
-		return nil, nil
-	}
-	if len(content) > 12000 {
-		content = content[:12000]
-	}
-
+func parseFolderFile(data []byte, path, root string) []search.Result {
 	// Build URL: use online docs.unity3d.com URL so links work everywhere.
 	// Fall back to local file:// if we can't determine the online path.
 	absPath, _ := filepath.Abs(path)
@@ -295,48 +533,17 @@ func parseFolderFile(path, root string) (*search.Result, error) {
 		if strings.HasPrefix(onlineURL, "https://") {
 			url = onlineURL
 		}
+	} else {
+		rel = path
 	}
-
-	return &search.Result{
-		Title:   title,
-		URL:     url,
-		Excerpt: content,
-		Score:   1.0,
-	}, nil
+	// The language folder, if any, is inside root: Documentation/ja/Manual
+	return pageChunks(string(data), url, filepath.ToSlash(rel))
 }
 
 // ── File Filtering ────────────────────────────────────────────────────────────
 
 func shouldIndex(path string) bool {
-	lower := strings.ToLower(filepath.ToSlash(path))
-
-	// Must be HTML
-	if !strings.HasSuffix(lower, ".html") && !strings.HasSuffix(lower, ".htm") {
-		return false
-	}
-
-	// Must be in Manual or ScriptReference section
-	// Unity ZIP structure: Documentation/en/Manual/*.html
-	//                  or: Documentation/en/ScriptReference/*.html
-	inManual := strings.Contains(lower, "/manual/") || strings.HasPrefix(lower, "manual/")
-	inScript := strings.Contains(lower, "/scriptreference/") || strings.HasPrefix(lower, "scriptreference/")
-	if !inManual && !inScript {
-		return false
-	}
-
-	// Skip nav/search/index pages
-	base := strings.ToLower(filepath.Base(path))
-	skipNames := []string{
-		"index.html", "search.html", "toc.html", "nav.html",
-		"30_search", "40_search", "docdata", "genindex",
-		"search-results.html", "404.html",
-	}
-	for _, s := range skipNames {
-		if strings.Contains(base, s) {
-			return false
-		}
-	}
-	return true
+	return skipReason(path) == ""
 }
 
 // ── HTML Parsing ──────────────────────────────────────────────────────────────
@@ -354,6 +561,7 @@ var (
 	reEntities   = regexp.MustCompile(`&[a-z]+;|&#[0-9]+;`)
 	reMultiSpace = regexp.MustCompile(`[ \t]{2,}`)
 	reMultiLine  = regexp.MustCompile(`\n{3,}`)
+	rePre        = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
 	reMain       = regexp.MustCompile(`(?is)<(?:main|article|div[^>]*(?:content|main|body)[^>]*)>(.*?)</(?:main|article|div)>`)
 )
 
@@ -374,20 +582,21 @@ func extractTitle(html string) string {
 }
 
 func extractMainContent(html string) string {
-	// Try to extract just the main content area
+	return extractText(mainArea(html))
+}
+
+// mainArea returns just the page's main content area, if it can find one.
+func mainArea(html string) string {
 	m := reMain.FindStringSubmatch(html)
 	if len(m) > 1 && len(m[1]) > 200 {
-		html = m[1]
+		return m[1]
 	}
+	return html
+}
 
-	// Strip non-content elements
-	html = reScript.ReplaceAllString(html, " ")
-	html = reStyle.ReplaceAllString(html, " ")
-	html = reNav.ReplaceAllString(html, " ")
-	html = reHeader.ReplaceAllString(html, " ")
-	html = reFooter.ReplaceAllString(html, " ")
-	html = reSidebar.ReplaceAllString(html, " ")
-	html = reComment.ReplaceAllString(html, " ")
+// extractText turns HTML into clean text, one block per line.
+func extractText(html string) string {
+	html = stripChrome(html)
 
 	// Add newlines around block elements before stripping tags
 	for _, tag := range []string{"p", "li", "h1", "h2", "h3", "h4", "br", "div", "tr", "pre"} {
@@ -413,6 +622,36 @@ func extractMainContent(html string) string {
 	return strings.TrimSpace(text)
 }
 
+// stripChrome removes what isn't page content: scripts, styles, navigation,
+// headers and footers, sidebars and comments.
+func stripChrome(html string) string {
+	html = reScript.ReplaceAllString(html, " ")
+	html = reStyle.ReplaceAllString(html, " ")
+	html = reNav.ReplaceAllString(html, " ")
+	html = reHeader.ReplaceAllString(html, " ")
+	html = reFooter.ReplaceAllString(html, " ")
+	html = reSidebar.ReplaceAllString(html, " ")
+	return reComment.ReplaceAllString(html, " ")
+}
+
+// extractCode returns the text of the page's <pre> blocks (the code samples),
+// one block per paragraph.
+func extractCode(html string) string {
+	var blocks []string
+	size := 0
+	for _, m := range rePre.FindAllStringSubmatch(html, -1) {
+		block := strings.TrimSpace(decodeEntities(stripTags(m[1])))
+		if block == "" {
+			continue
+		}
+		if size += len(block); size > 6000 {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
 func stripTags(html string) string {
 	return reTags.ReplaceAllString(html, "")
 }
@@ -454,12 +693,13 @@ func zipPathToURL(zipPath string) string {
 
 func folderPathToURL(rel string) string {
 	rel = filepath.ToSlash(rel)
-	// Strip leading "en/" or "Documentation/en/"
-	for _, prefix := range []string{"Documentation/en/", "en/", "documentation/en/"} {
-		if strings.HasPrefix(strings.ToLower(rel), strings.ToLower(prefix)) {
-			rel = rel[len(prefix):]
-			break
-		}
+	// Strip leading "en/" or "Documentation/en/" (or another language's
+	// folder, see LocalizedURL)
+	if strings.HasPrefix(strings.ToLower(rel), "documentation/") {
+		rel = rel[len("documentation/"):]
+	}
+	if folder, rest, ok := strings.Cut(rel, "/"); ok && langFolders[strings.ToLower(folder)] != "" {
+		rel = rest
 	}
 	if strings.HasPrefix(rel, "Manual/") || strings.HasPrefix(rel, "ScriptReference/") {
 		return "https://docs.unity3d.com/" + rel
@@ -498,25 +738,10 @@ type ParsedQuery struct {
 	IsCompare   bool     // comparing two things
 	Context2D   bool     // 2D specific
 	Context3D   bool     // 3D specific
+	Platform    string   // target platform mentioned ("webgl", "android", ...), "" if none
 	SearchTerms []string // final terms to search with (expanded)
 }
 
-// stopwords to remove from keyword extraction
-var stopWords = map[string]bool{
-	"the": true, "a": true, "an": true, "is": true, "in": true,
-	"to": true, "of": true, "and": true, "or": true, "for": true,
-	"on": true, "with": true, "this": true, "that": true, "it": true,
-	"be": true, "as": true, "at": true, "by": true, "we": true,
-	"how": true, "do": true, "i": true, "you": true, "can": true,
-	"what": true, "from": true, "are": true, "use": true, "used": true,
-	"my": true, "me": true, "get": true, "make": true, "create": true,
-	"want": true, "need": true, "help": true, "using": true, "does": true,
-	"would": true, "should": true, "could": true, "will": true, "please": true,
-	"just": true, "also": true, "way": true, "some": true, "give": true,
-	"show": true, "tell": true, "write": true, "let": true, "set": true,
-	"put": true, "try": true, "work": true, "works": true,
-}
-
 // Unity API symbol map: lowercase alias → canonical Unity type
 var unitySymbols = map[string][]string{
 	"rigidbody2d":      {"Rigidbody2D", "Physics2D", "MovePosition", "AddForce", "velocity"},
@@ -576,6 +801,39 @@ var unitySymbols = map[string][]string{
 	"coroutines":       {"Coroutine", "StartCoroutine", "IEnumerator", "WaitForSeconds"},
 }
 
+// platformAliases maps query phrases to a canonical build target.
+// Checked in order so "webgl" wins over the generic "browser".
+var platformAliases = []struct {
+	platform string
+	aliases  []string
+}{
+	{"webgl", []string{"webgl", "web gl", "webassembly", "wasm", "browser build", "in the browser", "itch.io", "html5"}},
+	{"android", []string{"android", "apk", "google play"}},
+	{"ios", []string{"ios", "iphone", "ipad", "xcode"}},
+}
+
+// DetectPlatform returns the build target a (lowercased) query is about,
+// or "" if it doesn't mention one.
+func DetectPlatform(normalized string) string {
+	words := map[string]bool{}
+	for _, tok := range tokenize(normalized) {
+		words[tok] = true
+	}
+	for _, p := range platformAliases {
+		for _, alias := range p.aliases {
+			// Single words must match a whole token ("ios" vs "scenarios")
+			if strings.ContainsAny(alias, " .") {
+				if strings.Contains(normalized, alias) {
+					return p.platform
+				}
+			} else if words[alias] {
+				return p.platform
+			}
+		}
+	}
+	return ""
+}
+
 // UnderstandQuery parses a raw user query into a structured ParsedQuery
 func UnderstandQuery(raw string) ParsedQuery {
 	pq := ParsedQuery{Raw: raw}
@@ -594,6 +852,8 @@ func UnderstandQuery(raw string) ParsedQuery {
 		strings.Contains(pq.Normalized, "shader") ||
 		strings.Contains(pq.Normalized, "mesh")
 
+	pq.Platform = DetectPlatform(pq.Normalized)
+
 	// Detect intent flags
 	pq.IsCodeReq = containsAny(pq.Normalized, []string{
 		"write", "script", "code", "example", "how do i", "how to",
@@ -612,11 +872,13 @@ func UnderstandQuery(raw string) ParsedQuery {
 		"when to use", "compared",
 	})
 
-	// Extract keywords (non-stopword tokens)
+	// Extract keywords (non-stopword tokens); identifier-heavy queries keep
+	// every word, "Animator.SetBool not firing" needs all of them
+	keepAll := search.IdentifierHeavy(raw)
 	tokens := tokenize(pq.Normalized)
 	seen := map[string]bool{}
 	for _, tok := range tokens {
-		if !stopWords[tok] && len(tok) >= 2 && !seen[tok] {
+		if (keepAll || !search.IsStopWord(tok)) && len(tok) >= 2 && !seen[tok] {
 			seen[tok] = true
 			pq.Keywords = append(pq.Keywords, tok)
 		}
@@ -679,6 +941,9 @@ func (pq *ParsedQuery) Summary() string {
 	if pq.Context3D {
 		parts = append(parts, "3D")
 	}
+	if pq.Platform != "" {
+		parts = append(parts, "platform: "+pq.Platform)
+	}
 	if len(pq.APISymbols) > 0 {
 		parts = append(parts, "API: "+strings.Join(pq.APISymbols[:min(3, len(pq.APISymbols))], ", "))
 	}
@@ -0,0 +1,68 @@
+package offline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ── Documentation languages ───────────────────────────────────────────────────
+// Unity ships its docs in Japanese, Korean, Chinese and Spanish as well as
+// English, each under a folder named for the language (Documentation/ja/...).
+// Pages are tagged with theirs and linked to the matching docs.unity3d.com
+// site, so they never overwrite the English pages of the same name.
+
+// Languages lists the documentation languages Unity publishes.
+var Languages = []string{"en", "ja", "ko", "zh", "es"}
+
+// langFolders maps the folder names Unity uses for each language, in the
+// docs and on docs.unity3d.com, to the language.
+var langFolders = map[string]string{
+	"en": "en", "ja": "ja", "kr": "ko", "ko": "ko", "cn": "zh", "zh": "zh", "zh-cn": "zh", "es": "es",
+}
+
+// langSites is the docs.unity3d.com folder for each language but English.
+var langSites = map[string]string{"ja": "ja", "ko": "kr", "zh": "cn", "es": "es"}
+
+var reHTMLLang = regexp.MustCompile(`(?i)<html[^>]*\blang\s*=\s*["']?([a-zA-Z-]+)`)
+
+// DetectLanguage returns the language a page is written in: the language
+// folder in its path, else its <html lang>, else "en".
+func DetectLanguage(path, html string) string {
+	for _, seg := range strings.Split(strings.ToLower(path), "/") {
+		if lang, ok := langFolders[seg]; ok {
+			return lang
+		}
+	}
+	if m := reHTMLLang.FindStringSubmatch(html); m != nil {
+		code := strings.ToLower(m[1])
+		if lang, ok := langFolders[code]; ok {
+			return lang
+		}
+		if lang, ok := langFolders[code[:min(2, len(code))]]; ok {
+			return lang
+		}
+	}
+	return "en"
+}
+
+// LocalizedURL points a docs.unity3d.com URL at the site for lang:
+// https://docs.unity3d.com/Manual/X.html → https://docs.unity3d.com/ja/current/Manual/X.html.
+// English, and anything not on docs.unity3d.com, are returned unchanged.
+func LocalizedURL(url, lang string) string {
+	const base = "https://docs.unity3d.com/"
+	site, ok := langSites[lang]
+	if !ok || !strings.HasPrefix(url, base) {
+		return url
+	}
+	return base + site + "/current/" + url[len(base):]
+}
+
+// IsLanguage reports whether lang is one of Languages.
+func IsLanguage(lang string) bool {
+	for _, l := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
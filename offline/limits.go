@@ -0,0 +1,110 @@
+package offline
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"unitymind/search"
+)
+
+// ── Size limits ───────────────────────────────────────────────────────────────
+// Pointing the docs path at the wrong folder (a project's Assets, a
+// Downloads folder) mustn't hang or exhaust memory. A file over the size
+// limit isn't read; one that's mostly binary (a .html that isn't, a page
+// made of inline base64 images) isn't indexed; and a run stops once it has
+// indexed the page limit. Inline images are dropped from the pages that are
+// indexed: they're no use to search and only slow parsing.
+
+// Why a file was left out, see IndexReport.
+const (
+	SkipTooLarge = "larger than the file size limit"
+	SkipBinary   = "mostly binary data"
+)
+
+const (
+	// DefaultMaxFileBytes is the file size limit when none is set. The
+	// largest Unity doc pages are a few hundred KB.
+	DefaultMaxFileBytes = 10 << 20
+	// DefaultMaxPages is the page limit when none is set: the full docs
+	// chunk into around 60,000 pages.
+	DefaultMaxPages = 200000
+	// binarySample is how much of a file is checked for binary bytes.
+	binarySample = 8 << 10
+)
+
+var reInlineData = regexp.MustCompile(`data:[a-zA-Z0-9.+/-]*;base64,[A-Za-z0-9+/=\s]+`)
+
+// SetLimits sets the size of the largest file read and the most pages one
+// run indexes, 0 for the defaults; keepBinary indexes mostly binary pages
+// too.
+func (ix *Indexer) SetLimits(maxFileBytes int64, maxPages int, keepBinary bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.maxFile, ix.maxPages, ix.keepBinary = max(maxFileBytes, 0), max(maxPages, 0), keepBinary
+}
+
+// fileLimit is the size of the largest file read.
+func (ix *Indexer) fileLimit() int64 {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.maxFile == 0 {
+		return DefaultMaxFileBytes
+	}
+	return ix.maxFile
+}
+
+// pageLimit is the most pages one run indexes.
+func (ix *Indexer) pageLimit() int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.maxPages == 0 {
+		return DefaultMaxPages
+	}
+	return ix.maxPages
+}
+
+// sink starts collecting the pages of a run over path, up to the page
+// limit; see pageSink.
+func (ix *Indexer) sink(path string, emit func([]search.Result)) *pageSink {
+	s := newPageSink(path, emit)
+	s.maxPages = ix.pageLimit()
+	return s
+}
+
+// tooLarge says why a file of size bytes isn't read, "" if it may be.
+func (ix *Indexer) tooLarge(size int64) string {
+	if size > ix.fileLimit() {
+		return SkipTooLarge
+	}
+	return ""
+}
+
+// checkPage returns a page's data with its inline images dropped, or why
+// it isn't indexed: it's mostly binary.
+func (ix *Indexer) checkPage(data []byte) ([]byte, string) {
+	ix.mu.Lock()
+	keep := ix.keepBinary
+	ix.mu.Unlock()
+	if !keep && bytes.IndexByte(data[:min(len(data), binarySample)], 0) >= 0 {
+		return nil, SkipBinary
+	}
+	if bytes.Contains(data, []byte(";base64,")) {
+		stripped := reInlineData.ReplaceAll(data, nil)
+		if !keep && len(stripped) < len(data)/4 {
+			return nil, SkipBinary // three quarters images
+		}
+		data = stripped
+	}
+	return data, ""
+}
+
+// sizeMB is a file size for the report.
+func sizeMB(size int64) string {
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
+
+// limitNote says a run stopped at the page limit, for its report.
+func limitNote(pages int) string {
+	return fmt.Sprintf("stopped at the %d-page limit", pages)
+}
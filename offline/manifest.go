@@ -0,0 +1,144 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+
+	"unitymind/search"
+)
+
+// ── Manifests ─────────────────────────────────────────────────────────────────
+// Re-indexing the same docs used to re-parse every page, though between two
+// runs over extracted docs usually only a handful of files change. Each run
+// leaves a manifest of the files it saw, so IndexChanged only parses new and
+// changed ones and reports the pages whose files are gone.
+
+// IndexUpdate is what an IndexChanged run found.
+type IndexUpdate struct {
+	Results   []search.Result // pages from new and changed files
+	Removed   []string        // URLs of pages whose files were deleted or emptied
+	Unchanged int             // files skipped because they match the manifest
+	Pages     int             // pages parsed, including any streamed (see IndexStream)
+	Report    *IndexReport    // the files found and those left out, see report.go
+}
+
+// manifestEntry is what a file looked like when it was last indexed.
+type manifestEntry struct {
+	Size  int64  `json:"size"`
+	MTime int64  `json:"mtime"`
+	Hash  string `json:"hash"`          // CRC-32 for ZIP entries, FNV-64a for files
+	URL   string `json:"url,omitempty"` // the page it gave; "" if it was skipped
+	// further pages, for files that give several (an XML doc file, one per type)
+	More []string `json:"more,omitempty"`
+}
+
+// pages returns every page the file gave.
+func (e manifestEntry) pages() []string {
+	if e.URL == "" {
+		return nil
+	}
+	return append([]string{e.URL}, e.More...)
+}
+
+// manifest maps a file's path inside the docs to its entry.
+type manifest map[string]manifestEntry
+
+// produced records that the file at key gave the page at url.
+func (m manifest) produced(key, url string) {
+	e := m[key]
+	if e.URL == "" {
+		e.URL = url
+	} else {
+		e.More = append(e.More, url)
+	}
+	m[key] = e
+}
+
+// retry keeps the page a file gave last time when it can't be read now, with
+// no hash, so the next run tries it again.
+func (m manifest) retry(key string, prev manifest) {
+	m[key] = manifestEntry{URL: prev[key].URL, More: prev[key].More}
+}
+
+// removed returns the URLs of pages in m that no file gives in next.
+func (m manifest) removed(next manifest) []string {
+	live := make(map[string]bool, len(next))
+	for _, e := range next {
+		for _, u := range e.pages() {
+			live[u] = true
+		}
+	}
+	var urls []string
+	for _, e := range m {
+		for _, u := range e.pages() {
+			if !live[u] {
+				live[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+func manifestKey(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func fileHash(data []byte) string {
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// manifestPath is where the manifest for a docs path is kept: one file per
+// path, named by a hash of its absolute form.
+func (ix *Indexer) manifestPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	h := fnv.New64a()
+	h.Write([]byte(abs))
+	return filepath.Join(ix.cacheDir, "manifests", fmt.Sprintf("%016x.json", h.Sum64()))
+}
+
+// loadManifest reads the manifest for path. A missing or unreadable one is
+// empty, so everything is indexed.
+func (ix *Indexer) loadManifest(path string) manifest {
+	m := manifest{}
+	if data, err := os.ReadFile(ix.manifestPath(path)); err == nil {
+		json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+// ForgetPath drops the manifest of a docs path (folder or archive) that's
+// no longer indexed and returns the URLs of the pages it gave, for removal.
+func (ix *Indexer) ForgetPath(path string) []string {
+	urls := ix.loadManifest(path).removed(nil)
+	os.Remove(ix.manifestPath(path))
+	return urls
+}
+
+func (ix *Indexer) saveManifest(path string, m manifest) error {
+	file := ix.manifestPath(path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
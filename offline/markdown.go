@@ -0,0 +1,169 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Markdown folders ──────────────────────────────────────────────────────────
+// A team's own docs — a wiki checkout, an Obsidian vault, READMEs — answer
+// the questions the Unity docs can't ("how do we name addressables here?").
+// IndexMarkdown makes a folder of .md files searchable next to the Unity docs.
+
+// MarkdownSource marks docs indexed from a Markdown folder.
+const MarkdownSource = "markdown"
+
+var (
+	reFrontMatter = regexp.MustCompile(`(?s)\A---[ \t]*\n(.*?)\n---[ \t]*(?:\n|\z)`)
+	reWikiLink    = regexp.MustCompile(`!?\[\[([^\]|#]*)(?:#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+)
+
+// IndexMarkdown indexes the .md files under root, and any C# XML doc files
+// among them (see xmldoc.go). With changedOnly it only parses files that are
+// new or changed since the last run over root, like IndexChanged; use that
+// when the earlier run's pages are still indexed. Cancelling ctx stops it
+// like IndexPath.
+func (ix *Indexer) IndexMarkdown(ctx context.Context, root string, changedOnly bool, onProgress func(done, total int)) (IndexUpdate, error) {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return IndexUpdate{}, fmt.Errorf("not a folder: %s", root)
+	}
+	var prev manifest
+	if changedOnly {
+		prev = ix.loadManifest(root)
+	}
+	log.Printf("[offline] Scanning Markdown folder: %s", root)
+	skip := func(path string) string {
+		if isMarkdown(path) || isXMLDoc(path) {
+			return ""
+		}
+		return SkipNotNote
+	}
+	out := ix.sink(root, nil)
+	upd, next, _, err := ix.scanFolder(ctx, root, prev, skip, parseNoteFile, onProgress, out)
+	out.finish(&upd, next, err)
+	if err != nil {
+		return upd, err
+	}
+	upd.Removed = prev.removed(next)
+	if err := ix.saveManifest(root, next); err != nil {
+		log.Printf("[offline] Cannot save manifest: %v", err)
+	}
+	return upd, nil
+}
+
+// parseNoteFile gives the pages of a Markdown or XML doc file.
+func parseNoteFile(data []byte, path string) []search.Result {
+	if isXMLDoc(path) {
+		return parseXMLDoc(data, path)
+	}
+	if r := parseMarkdownFile(data, path); r != nil {
+		return []search.Result{*r}
+	}
+	return nil
+}
+
+func isMarkdown(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".markdown")
+}
+
+// parseMarkdownFile turns one note into a page. The title comes from the
+// front matter, else the first heading, else the file name; front-matter
+// tags become the page's tags. Notes are often short, so only empty ones are
+// skipped.
+func parseMarkdownFile(data []byte, path string) *search.Result {
+	md := strings.ReplaceAll(string(data), "\r\n", "\n")
+	fmTitle, tags := "", []string(nil)
+	if m := reFrontMatter.FindStringSubmatch(md); m != nil {
+		fmTitle, tags = parseFrontMatter(m[1])
+		md = md[len(m[0]):]
+	}
+	// Obsidian links: [[Page]] and [[Page|shown text]] read as their text
+	md = reWikiLink.ReplaceAllStringFunc(md, func(link string) string {
+		m := reWikiLink.FindStringSubmatch(link)
+		if m[2] != "" {
+			return m[2]
+		}
+		return m[1]
+	})
+	title, content, code := parseMarkdown(md)
+	if fmTitle != "" {
+		title = fmTitle
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(strings.TrimSpace(content)) < 20 {
+		return nil
+	}
+	if len(content) > 12000 {
+		content = content[:12000]
+	}
+	absPath, _ := filepath.Abs(path)
+	return &search.Result{
+		Title:   title,
+		URL:     "file:///" + strings.TrimPrefix(filepath.ToSlash(absPath), "/"),
+		Excerpt: content,
+		Score:   1.0,
+		Source:  MarkdownSource,
+		Code:    code,
+		Tags:    tags,
+	}
+}
+
+// parseFrontMatter reads the title and tags from YAML front matter. Tags may
+// be an inline list ("tags: [ui, input]"), a block list or a single word.
+func parseFrontMatter(fm string) (title string, tags []string) {
+	inTags := false
+	for _, line := range strings.Split(fm, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inTags {
+			if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+				tags = append(tags, unquote(item))
+				continue
+			}
+			inTags = false
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			title = unquote(value)
+		case "tags", "tag", "aliases":
+			switch {
+			case value == "":
+				inTags = true
+			case strings.HasPrefix(value, "["):
+				for _, t := range strings.Split(strings.Trim(value, "[]"), ",") {
+					if t = unquote(t); t != "" {
+						tags = append(tags, t)
+					}
+				}
+			default:
+				tags = append(tags, unquote(value))
+			}
+		}
+	}
+	return title, tags
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
+
+// ForgetMarkdown drops the manifest of a Markdown folder that's no longer
+// indexed and returns the URLs of the pages it gave, for removal.
+func (ix *Indexer) ForgetMarkdown(root string) []string {
+	return ix.ForgetPath(root)
+}
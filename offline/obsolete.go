@@ -0,0 +1,54 @@
+package offline
+
+import (
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Obsolete notices ──────────────────────────────────────────────────────────
+// An obsolete Scripting Reference page says what replaces it: "Obsolete:
+// Use Rigidbody.linearVelocity instead", "velocity has been deprecated.
+// Use linearVelocity instead", "...has been renamed to linearVelocity". The
+// replacement is tagged on the page (search.ReplacedByTag) so the engine
+// can map old APIs to new ones.
+
+var reReplacedBy = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:obsolete|deprecated)\b[^\n]{0,200}?\buse\s+(?:the\s+)?([A-Za-z_][\w.]*\w)(?:\(\))?\s+(?:instead|property|method)`),
+	regexp.MustCompile(`(?i)\b(?:obsolete|deprecated)\b[^\n]{0,200}?\b(?:renamed|replaced)\s+(?:to|with|by)\s+([A-Za-z_][\w.]*\w)`),
+	regexp.MustCompile(`(?i)\b(?:renamed|replaced)\s+(?:to|with|by)\s+([A-Za-z_][\w.]*\w)[^\n]{0,40}?\b(?:obsolete|deprecated)\b`),
+}
+
+// replacedBy returns the API that replaces the obsolete one documented at
+// url, qualified with its class ("Rigidbody.linearVelocity"), or "" if the
+// page isn't an obsolete Scripting Reference page.
+func replacedBy(text, url string) string {
+	old := search.APIName(url)
+	if old == "" {
+		return ""
+	}
+	// The notice is at the top, before the description and examples
+	if len(text) > 1500 {
+		text = text[:1500]
+	}
+	text = strings.NewReplacer("`", "", "**", "").Replace(text) // the text is Markdown
+	for _, re := range reReplacedBy {
+		m := re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		repl := m[1]
+		if !strings.Contains(repl, ".") {
+			if dot := strings.LastIndexByte(old, '.'); dot > 0 && !isUpper(repl[0]) {
+				repl = old[:dot] + "." + repl // a member of the same class
+			}
+		}
+		if repl != old {
+			return repl
+		}
+	}
+	return ""
+}
+
+func isUpper(b byte) bool { return b >= 'A' && b <= 'Z' }
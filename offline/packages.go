@@ -0,0 +1,225 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Package Docs ──────────────────────────────────────────────────────────────
+// Packages (Input System, Cinemachine, URP, Netcode, ...) ship their manual as
+// Markdown in a Documentation~ folder, which Unity copies into every project's
+// Library/PackageCache. None of it is in the offline docs ZIP, so without
+// these pages package questions fall through to OpenAI.
+
+// PackageSource marks docs indexed from a project's package cache.
+const PackageSource = "package"
+
+// packageManifest is the part of a package's package.json we need.
+type packageManifest struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Version     string `json:"version"`
+}
+
+// FindPackageCache returns the PackageCache folder for path, which may be a
+// Unity project root, its Library folder or the PackageCache itself.
+// Returns "" if there is none.
+func FindPackageCache(path string) string {
+	for _, dir := range []string{
+		filepath.Join(path, "Library", "PackageCache"),
+		filepath.Join(path, "PackageCache"),
+		path,
+	} {
+		if filepath.Base(dir) != "PackageCache" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// IndexPackages indexes the Documentation~ folder of every package installed
+// in the Unity project at projectPath. Pages are tagged with the package's
+// display name and id ("Input System", "com.unity.inputsystem"), so a
+// question naming either finds them.
+func (ix *Indexer) IndexPackages(projectPath string, onProgress func(done, total int)) ([]search.Result, error) {
+	cache := FindPackageCache(projectPath)
+	if cache == "" {
+		return nil, fmt.Errorf("no Library/PackageCache in %s — open the project in Unity once so packages are installed", projectPath)
+	}
+	pkgs, err := os.ReadDir(cache)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read package cache: %w", err)
+	}
+
+	type page struct {
+		path string
+		pkg  packageManifest
+		rel  string // path inside Documentation~
+	}
+	var pages []page
+	for _, p := range pkgs {
+		if !p.IsDir() {
+			continue
+		}
+		root := filepath.Join(cache, p.Name())
+		manifest, err := readManifest(root, p.Name())
+		if err != nil {
+			continue
+		}
+		docRoot := filepath.Join(root, "Documentation~")
+		filepath.Walk(docRoot, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !isPackageDoc(info.Name()) {
+				return nil
+			}
+			rel, _ := filepath.Rel(docRoot, path)
+			pages = append(pages, page{path, manifest, rel})
+			return nil
+		})
+	}
+	log.Printf("[offline] Found %d package doc pages in %s", len(pages), cache)
+
+	var results []search.Result
+	for i, p := range pages {
+		if r, err := parsePackageDoc(p.path, p.rel, p.pkg); err == nil && r != nil {
+			results = append(results, *r)
+		}
+		if n := i + 1; n%50 == 0 && onProgress != nil {
+			onProgress(n, len(pages))
+		}
+	}
+	if onProgress != nil {
+		onProgress(len(pages), len(pages))
+	}
+	return results, nil
+}
+
+// readManifest reads root/package.json; folders without one aren't packages.
+// dirName ("com.unity.inputsystem@1.7.0") fills in a missing name or version.
+func readManifest(root, dirName string) (packageManifest, error) {
+	var m packageManifest
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, err
+	}
+	name, version, _ := strings.Cut(dirName, "@")
+	if m.Name == "" {
+		m.Name = name
+	}
+	if m.Version == "" {
+		m.Version = version
+	}
+	if m.DisplayName == "" {
+		m.DisplayName = m.Name
+	}
+	return m, nil
+}
+
+// isPackageDoc skips the table of contents and anything that isn't Markdown.
+func isPackageDoc(name string) bool {
+	lower := strings.ToLower(name)
+	if !strings.HasSuffix(lower, ".md") {
+		return false
+	}
+	return lower != "tableofcontents.md" && lower != "toc.md"
+}
+
+func parsePackageDoc(path, rel string, pkg packageManifest) (*search.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	md := strings.ReplaceAll(string(data), "\r\n", "\n")
+	title, content, code := parseMarkdown(md)
+	if len(content) < 80 {
+		return nil, nil // Skip near-empty pages
+	}
+	if len(content) > 12000 {
+		content = content[:12000]
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+	}
+	return &search.Result{
+		Title:   pkg.DisplayName + ": " + title,
+		URL:     packageDocURL(pkg, rel),
+		Excerpt: content,
+		Score:   1.0,
+		Source:  PackageSource,
+		Code:    code,
+		Tags:    []string{pkg.DisplayName, pkg.Name},
+	}, nil
+}
+
+// packageDocURL is where docs.unity3d.com publishes a package page:
+// https://docs.unity3d.com/Packages/com.unity.inputsystem@1.7/manual/Actions.html
+func packageDocURL(pkg packageManifest, rel string) string {
+	version := pkg.Version
+	if parts := strings.SplitN(version, ".", 3); len(parts) >= 2 {
+		version = parts[0] + "." + parts[1]
+	}
+	page := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)) + ".html"
+	return "https://docs.unity3d.com/Packages/" + pkg.Name + "@" + version + "/manual/" + page
+}
+
+var (
+	reMdFence   = regexp.MustCompile("(?s)```[^\n]*\n(.*?)```")
+	reMdImage   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	reMdLink    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	reMdHeading = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	reMdEmph    = regexp.MustCompile("\\*{1,3}|`+") // not _, which identifiers use
+	// Only real HTML tags, so generics like GetComponent<Rigidbody> survive
+	reMdHTML = regexp.MustCompile(`(?i)</?(a|br|details|div|img|p|span|sub|summary|sup|table|td|th|tr)\b[^>]*>`)
+)
+
+// parseMarkdown returns a page's first heading, its plain text (code blocks
+// included, like the HTML pages) and its fenced code samples.
+func parseMarkdown(md string) (title, content, code string) {
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			title = strings.TrimSpace(reMdEmph.ReplaceAllString(line[2:], ""))
+			break
+		}
+	}
+
+	var blocks []string
+	size := 0
+	for _, m := range reMdFence.FindAllStringSubmatch(md, -1) {
+		block := strings.TrimSpace(m[1])
+		if block == "" {
+			continue
+		}
+		if size += len(block); size > 6000 {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	code = strings.Join(blocks, "\n\n")
+
+	text := reMdFence.ReplaceAllString(md, "$1")
+	text = reMdImage.ReplaceAllString(text, "$1")
+	text = reMdLink.ReplaceAllString(text, "$1")
+	text = reMdHeading.ReplaceAllString(text, "")
+	text = reMdEmph.ReplaceAllString(text, "")
+	text = reMdHTML.ReplaceAllString(text, "")
+	text = decodeEntities(text)
+	var cleaned []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+	return title, strings.Join(cleaned, "\n"), code
+}
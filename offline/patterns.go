@@ -0,0 +1,98 @@
+package offline
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ── Include / exclude patterns ────────────────────────────────────────────────
+// Users who only ask about gameplay code don't need 4,000 UnityEditor pages
+// indexed. Patterns pick the doc pages to index by their path under the
+// docs, e.g. "ScriptReference/UnityEditor.*" or "Manual/" for a whole
+// folder, case-insensitively. They apply to Unity docs, not to notes.
+
+// SkipPattern is why a doc page left out by the patterns was.
+const SkipPattern = "left out by the include/exclude patterns"
+
+// SetPatterns sets the doc pages to index: those matching any include
+// pattern (all if there are none) and no exclude pattern. A run over
+// already-indexed docs then drops the pages now left out and parses the
+// ones now let in.
+func (ix *Indexer) SetPatterns(include, exclude []string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.include, ix.exclude = cleanPatterns(include), cleanPatterns(exclude)
+}
+
+// CheckPattern reports a malformed pattern, e.g. an unclosed "[".
+func CheckPattern(p string) error {
+	_, err := path.Match(strings.ToLower(strings.TrimSpace(p)), "")
+	return err
+}
+
+func cleanPatterns(patterns []string) []string {
+	var out []string
+	for _, p := range patterns {
+		if p = strings.ToLower(strings.TrimSpace(filepath.ToSlash(p))); p != "" && CheckPattern(p) == nil {
+			out = append(out, strings.TrimPrefix(p, "/"))
+		}
+	}
+	return out
+}
+
+// skipReason is the package's skipReason plus the patterns.
+func (ix *Indexer) skipReason(p string) string {
+	if why := skipReason(p); why != "" {
+		return why
+	}
+	return ix.patternSkip(p)
+}
+
+// patternSkip says why the patterns leave p out, or "" if they don't.
+func (ix *Indexer) patternSkip(p string) string {
+	ix.mu.Lock()
+	include, exclude := ix.include, ix.exclude
+	ix.mu.Unlock()
+	rel := docRelPath(p)
+	if len(include) > 0 && !matchesAny(include, rel) || matchesAny(exclude, rel) {
+		return SkipPattern
+	}
+	return ""
+}
+
+// docRelPath is p from its Manual/ or ScriptReference/ folder on, lowercased.
+func docRelPath(p string) string {
+	lower := strings.ToLower(filepath.ToSlash(p))
+	for _, dir := range []string{"manual/", "scriptreference/"} {
+		if strings.HasPrefix(lower, dir) {
+			return lower
+		}
+		if i := strings.LastIndex(lower, "/"+dir); i >= 0 {
+			return lower[i+1:]
+		}
+	}
+	return lower
+}
+
+// matchesAny reports whether rel matches a pattern: a folder ("manual/")
+// matches everything under it, anything else is a glob over the whole path
+// or, without a "/", over the file name.
+func matchesAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(rel, p) {
+				return true
+			}
+			continue
+		}
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package offline
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ── Indexing order ────────────────────────────────────────────────────────────
+// A first index of the docs takes minutes. The pages asked about most, the
+// Scripting API and the common Manual topics, are parsed first so chat can
+// use them within seconds (see IndexPathEarly); the rest follows.
+
+const (
+	priorityAPI    = iota // ScriptReference
+	priorityCommon        // the Manual pages most questions land on
+	priorityRest
+)
+
+// commonManualPrefixes start the file names of the most asked-about Manual
+// pages (class-Rigidbody.html, PhysicsOverview.html, AnimationOverview.html…)
+var commonManualPrefixes = []string{
+	"class-", "physics", "animation", "ui", "scripting", "input", "audio",
+	"prefabs", "gameobjects", "creatingscenes", "lighting", "materials", "cameras",
+}
+
+// docPriority ranks a doc file by how soon it should be indexed.
+func docPriority(name string) int {
+	lower := strings.ToLower(strings.ReplaceAll(name, "\\", "/"))
+	if strings.Contains(lower, "scriptreference/") {
+		return priorityAPI
+	}
+	if strings.Contains(lower, "manual/") {
+		base := path.Base(lower)
+		for _, p := range commonManualPrefixes {
+			if strings.HasPrefix(base, p) {
+				return priorityCommon
+			}
+		}
+	}
+	return priorityRest
+}
+
+// sortByPriority stably orders n items, named by name, by docPriority and
+// returns how many come before the rest.
+func sortByPriority(n int, name func(i int) string, swap func(i, j int)) int {
+	s := &byPriority{n: n, name: name, swap: swap, rank: make([]int, n)}
+	for i := range s.rank {
+		s.rank[i] = docPriority(name(i))
+	}
+	sort.Stable(s)
+	tier := 0
+	for tier < n && s.rank[tier] < priorityRest {
+		tier++
+	}
+	return tier
+}
+
+type byPriority struct {
+	n    int
+	name func(i int) string
+	swap func(i, j int)
+	rank []int
+}
+
+func (s *byPriority) Len() int           { return s.n }
+func (s *byPriority) Less(i, j int) bool { return s.rank[i] < s.rank[j] }
+func (s *byPriority) Swap(i, j int) {
+	s.swap(i, j)
+	s.rank[i], s.rank[j] = s.rank[j], s.rank[i]
+}
@@ -0,0 +1,64 @@
+package offline
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Related pages ─────────────────────────────────────────────────────────────
+// Manual pages end with "Additional resources" or "See also": the pages the
+// writers thought go with this one. They're kept with the page as
+// search.Links so an answer can offer them next to its search hits.
+
+// maxRelated caps the related pages kept per page.
+const maxRelated = 10
+
+var (
+	reRelatedHeading = regexp.MustCompile(`(?is)<(?:h[1-6]|p|strong|b|dt|div)\b[^>]*>\s*(?:<[^>]+>\s*)*(?:see also|additional resources|related (?:pages|topics|information|links))\b`)
+	reNextHeading    = regexp.MustCompile(`(?i)<h[1-6]\b`)
+	reLink           = regexp.MustCompile(`(?is)<a\b[^>]*\bhref\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+)
+
+// relatedPages returns the pages a page's "See also" section links to,
+// resolved against its URL.
+func relatedPages(html, pageURL string) []search.Link {
+	loc := reRelatedHeading.FindStringIndex(html)
+	if loc == nil {
+		return nil
+	}
+	section := html[loc[1]:]
+	// The heading itself may close after the match; the section ends at the next one
+	if end := reNextHeading.FindStringIndex(section[min(len(section), 8):]); end != nil {
+		section = section[:end[0]+min(len(section), 8)]
+	}
+	if len(section) > 4000 {
+		section = section[:4000]
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	self := search.PageURL(pageURL)
+	var links []search.Link
+	seen := map[string]bool{}
+	for _, m := range reLink.FindAllStringSubmatch(section, -1) {
+		ref, err := url.Parse(strings.TrimSpace(decodeEntities(m[1])))
+		if err != nil || ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https" {
+			continue
+		}
+		u := base.ResolveReference(ref).String()
+		title := strings.Join(strings.Fields(decodeEntities(stripTags(m[2]))), " ")
+		if title == "" || seen[u] || search.PageURL(u) == self {
+			continue
+		}
+		seen[u] = true
+		links = append(links, search.Link{Title: title, URL: u})
+		if len(links) == maxRelated {
+			break
+		}
+	}
+	return links
+}
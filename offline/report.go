@@ -0,0 +1,95 @@
+package offline
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── Index report ──────────────────────────────────────────────────────────────
+// "My docs folder indexed only 200 of 12,000 pages": every run keeps count of
+// the files it left out and why — not a doc page, unreadable, near-empty —
+// with a few examples of each, so the answer is in the report rather than in
+// the log.
+
+// Why a file was left out of an index run.
+const (
+	SkipNotHTML     = "not HTML"
+	SkipOutsideDocs = "outside Manual/ and ScriptReference/"
+	SkipNavigation  = "navigation or search page"
+	SkipNotNote     = "not Markdown or an XML doc file"
+	SkipTooShort    = "too short to index"
+	SkipFailed      = "could not be read"
+)
+
+// maxReportExamples is how many files are listed for each reason.
+const maxReportExamples = 20
+
+// IndexReport is what an index run did with the files it found.
+type IndexReport struct {
+	Path      string    `json:"path"`
+	Index     string    `json:"index,omitempty"`
+	Status    string    `json:"status"` // running, done, cancelled or error
+	Error     string    `json:"error,omitempty"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished,omitempty"`
+	Files     int       `json:"files"`     // doc files found
+	Unchanged int       `json:"unchanged"` // of which unchanged since the last run
+	Indexed   int       `json:"indexed"`   // of which parsed into pages
+	Pages     int       `json:"pages"`
+	Limit     string    `json:"limit,omitempty"` // set if the run stopped at the page limit, see limits.go
+	// Skipped counts the files left out by reason; Examples names a few of
+	// each, with the error for unreadable ones
+	Skipped  map[string]int      `json:"skipped"`
+	Examples map[string][]string `json:"examples"`
+}
+
+// NewIndexReport starts the report of a run over path.
+func NewIndexReport(path string) *IndexReport {
+	return &IndexReport{Path: path, Status: "running", Started: time.Now(), Skipped: map[string]int{}, Examples: map[string][]string{}}
+}
+
+// skip records file as left out for reason; detail, if any, is shown with
+// it. Not safe for concurrent use, see pageSink.
+func (r *IndexReport) skip(file, reason, detail string) {
+	r.Skipped[reason]++
+	if len(r.Examples[reason]) < maxReportExamples {
+		if detail != "" {
+			file += ": " + detail
+		}
+		r.Examples[reason] = append(r.Examples[reason], filepath.ToSlash(file))
+	}
+}
+
+// skipReason says why path isn't a Unity doc page, or "" if it is one.
+func skipReason(path string) string {
+	lower := strings.ToLower(filepath.ToSlash(path))
+
+	// Must be HTML
+	if !strings.HasSuffix(lower, ".html") && !strings.HasSuffix(lower, ".htm") {
+		return SkipNotHTML
+	}
+
+	// Must be in Manual or ScriptReference section
+	// Unity ZIP structure: Documentation/en/Manual/*.html
+	//                  or: Documentation/en/ScriptReference/*.html
+	inManual := strings.Contains(lower, "/manual/") || strings.HasPrefix(lower, "manual/")
+	inScript := strings.Contains(lower, "/scriptreference/") || strings.HasPrefix(lower, "scriptreference/")
+	if !inManual && !inScript {
+		return SkipOutsideDocs
+	}
+
+	// Skip nav/search/index pages
+	base := strings.ToLower(filepath.Base(path))
+	skipNames := []string{
+		"index.html", "search.html", "toc.html", "nav.html",
+		"30_search", "40_search", "docdata", "genindex",
+		"search-results.html", "404.html",
+	}
+	for _, s := range skipNames {
+		if strings.Contains(base, s) {
+			return SkipNavigation
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package offline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── Section chunking ──────────────────────────────────────────────────────────
+// A long page is indexed as one chunk per h2/h3 section rather than as one
+// blob, each titled with its heading and linked to its anchor
+// (Rigidbody2D.html#velocity), so an answer quotes and cites the section
+// that answers it. The text before the first heading keeps the page's own
+// title and URL. Short pages, and sections too short to stand alone, are
+// kept whole.
+
+const (
+	// chunkPageChars is the text length above which a page is chunked
+	chunkPageChars = 1500
+	// minChunkChars is the shortest section kept as a chunk of its own;
+	// shorter ones join the chunk before them
+	minChunkChars = 200
+	// maxChunkChars caps each chunk's text, like whole pages before
+	maxChunkChars = 12000
+)
+
+var (
+	reSectionHeading = regexp.MustCompile(`(?is)<h([23])\b([^>]*)>(.*?)</h[23]>`)
+	reIDAttr         = regexp.MustCompile(`(?i)\b(?:id|name)\s*=\s*["']([^"']+)["']`)
+	// Older pages put the anchor just before the heading: <a name="x"></a><h2>
+	reAnchorBefore = regexp.MustCompile(`(?is)<a\s[^>]*\b(?:id|name)\s*=\s*["']([^"']+)["'][^>]*>\s*(?:</a>)?\s*$`)
+	reSlugJunk     = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// section is a run of a page's HTML under one heading ("" for the intro).
+type section struct {
+	heading, anchor, html string
+}
+
+// pageChunks turns a doc page into its chunks, or nil if it's near-empty.
+// path is where the page was found, for DetectLanguage.
+func pageChunks(html, url, path string) []search.Result {
+	html = toUTF8(html, path)
+	lang := DetectLanguage(path, html)
+	url = LocalizedURL(url, lang)
+	title := extractTitle(html)
+	var tags []string
+	if trail := pageTrail(html); trail != "" {
+		tags = []string{trail}
+	}
+	related := relatedPages(html, url)
+	main := mainArea(html)
+	content := toMarkdown(main)
+	if len(content) < 80 {
+		return nil // Skip near-empty pages
+	}
+	if repl := replacedBy(content, url); repl != "" {
+		tags = append(tags, search.ReplacedByTag+repl)
+	}
+	page := search.Result{Title: title, URL: url, Excerpt: capText(content), Score: 1.0, Source: "offline", Code: extractCode(html), Lang: lang, Tags: tags, Related: related}
+	sections := splitSections(main)
+	if len(content) <= chunkPageChars || len(sections) < 2 {
+		return []search.Result{page}
+	}
+
+	var chunks []search.Result
+	for _, s := range sections {
+		text := toMarkdown(s.html)
+		if len(chunks) > 0 {
+			last := &chunks[len(chunks)-1]
+			if len(text) < minChunkChars || len(last.Excerpt) < minChunkChars {
+				if s.heading != "" {
+					text = "## " + s.heading + "\n\n" + text
+				}
+				last.Excerpt = capText(strings.TrimSpace(last.Excerpt + "\n\n" + text))
+				last.Code = joinCode(last.Code, extractCode(s.html))
+				continue
+			}
+		}
+		c := search.Result{Title: title, URL: url, Excerpt: capText(text), Score: 1.0, Source: "offline", Code: extractCode(s.html), Lang: lang, Tags: tags, Related: related}
+		if s.heading != "" {
+			c.Title = title + " — " + s.heading
+			c.URL = url + "#" + s.anchor
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+// splitSections cuts a page at its h2 and h3 headings. Anchors are the
+// heading's id, the named anchor right before it, or a slug of its text,
+// made unique within the page.
+func splitSections(html string) []section {
+	locs := reSectionHeading.FindAllStringSubmatchIndex(html, -1)
+	sections := []section{{html: html}}
+	if len(locs) == 0 {
+		return sections
+	}
+	sections[0].html = html[:locs[0][0]]
+	seen := map[string]bool{}
+	for i, loc := range locs {
+		end := len(html)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		heading := strings.TrimSpace(decodeEntities(stripTags(html[loc[6]:loc[7]])))
+		if heading == "" {
+			sections[len(sections)-1].html += html[loc[0]:end]
+			continue
+		}
+		anchor := ""
+		if m := reIDAttr.FindStringSubmatch(html[loc[4]:loc[5]]); m != nil {
+			anchor = m[1]
+		} else if m := reAnchorBefore.FindStringSubmatch(html[max(0, loc[0]-200):loc[0]]); m != nil {
+			anchor = m[1]
+		} else if m := reIDAttr.FindStringSubmatch(html[loc[6]:loc[7]]); m != nil {
+			anchor = m[1] // <h2><a name="x">Heading</a></h2>
+		} else {
+			anchor = strings.Trim(reSlugJunk.ReplaceAllString(strings.ToLower(heading), "-"), "-")
+		}
+		if anchor == "" {
+			anchor = "section"
+		}
+		for base, n := anchor, 2; seen[anchor]; n++ {
+			anchor = base + "-" + strconv.Itoa(n)
+		}
+		seen[anchor] = true
+		sections = append(sections, section{heading: heading, anchor: anchor, html: html[loc[1]:end]})
+	}
+	return sections
+}
+
+func capText(s string) string {
+	if len(s) > maxChunkChars {
+		s = s[:maxChunkChars]
+		if strings.Count(s, "```")%2 == 1 {
+			s += "\n```" // cut inside a code block
+		}
+	}
+	return s
+}
+
+func joinCode(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n\n" + b
+}
@@ -0,0 +1,350 @@
+package offline
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ── SQLite ────────────────────────────────────────────────────────────────────
+// Dash and Zeal docsets keep their index in a SQLite file. All a docset
+// import needs is to read one small table, so rather than a database driver
+// this reads the file format directly: the table's b-tree from the schema,
+// its records, and any overflow pages. Read-only, UTF-8 databases only.
+
+var errNotSQLite = errors.New("not a SQLite database")
+
+type sqliteFile struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+// readSQLiteTable returns the rows of table in the SQLite database at path,
+// each as column name → value (as text; NULL is "").
+func readSQLiteTable(path, table string) (rows []map[string]string, err error) {
+	// Every read is bounds-checked; this is for a damaged file finding a
+	// way past them, which should fail the import, not the process
+	defer func() {
+		if r := recover(); r != nil {
+			rows, err = nil, fmt.Errorf("corrupt SQLite database %s: %v", path, r)
+		}
+	}()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 100 || string(data[:16]) != "SQLite format 3\x00" {
+		return nil, errNotSQLite
+	}
+	db := &sqliteFile{data: data, pageSize: int(binary.BigEndian.Uint16(data[16:18]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 {
+		return nil, fmt.Errorf("bad SQLite page size %d", db.pageSize)
+	}
+	db.usable = db.pageSize - int(data[20])
+	if db.usable < 480 {
+		return nil, fmt.Errorf("bad SQLite reserved space %d", data[20])
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, fmt.Errorf("SQLite text encoding %d isn't supported", enc)
+	}
+
+	// The schema is table sqlite_master on page 1: type, name, tbl_name, rootpage, sql
+	root, sql := 0, ""
+	err = db.walk(1, func(rowid int64, rec []interface{}) error {
+		if len(rec) >= 5 && sqlText(rec[0]) == "table" && strings.EqualFold(sqlText(rec[1]), table) {
+			root, sql = int(sqlInt(rec[3])), sqlText(rec[4])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if root == 0 {
+		return nil, fmt.Errorf("no table %s", table)
+	}
+	cols, rowidCol := sqliteColumns(sql)
+	err = db.walk(root, func(rowid int64, rec []interface{}) error {
+		row := make(map[string]string, len(cols))
+		for i, c := range cols {
+			if i < len(rec) {
+				row[c] = sqlText(rec[i])
+			}
+		}
+		if rowidCol != "" {
+			row[rowidCol] = strconv.FormatInt(rowid, 10)
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+func (db *sqliteFile) page(n int) ([]byte, error) {
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("SQLite page %d out of range", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// walk calls fn with every row of the table b-tree rooted at page root.
+func (db *sqliteFile) walk(root int, fn func(rowid int64, rec []interface{}) error) error {
+	return db.walkPage(root, fn, 0)
+}
+
+func (db *sqliteFile) walkPage(n int, fn func(rowid int64, rec []interface{}) error, depth int) error {
+	if depth > 64 {
+		return errors.New("SQLite b-tree too deep")
+	}
+	pg, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = 100 // the file header
+	}
+	if hdr+12 > len(pg) {
+		return fmt.Errorf("SQLite page %d too small", n)
+	}
+	kind := pg[hdr]
+	cells := int(binary.BigEndian.Uint16(pg[hdr+3:]))
+	ptrs := hdr + 8
+	if kind == 0x05 {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(pg) {
+		return fmt.Errorf("SQLite page %d: %d cells don't fit", n, cells)
+	}
+	for i := 0; i < cells; i++ {
+		off := int(binary.BigEndian.Uint16(pg[ptrs+2*i:]))
+		if off >= len(pg) {
+			return errors.New("SQLite cell out of range")
+		}
+		switch kind {
+		case 0x05: // interior table page: left child, key
+			if off+4 > len(pg) {
+				return errors.New("SQLite cell out of range")
+			}
+			if err := db.walkPage(int(binary.BigEndian.Uint32(pg[off:])), fn, depth+1); err != nil {
+				return err
+			}
+		case 0x0d: // leaf table page: payload size, rowid, payload
+			size, k := uvarint(pg[off:])
+			if k == 0 || size > uint64(len(db.data)) {
+				return errors.New("bad SQLite cell")
+			}
+			rowid, k2 := uvarint(pg[off+k:])
+			if k2 == 0 {
+				return errors.New("bad SQLite cell")
+			}
+			payload, err := db.payload(pg, off+k+k2, int(size))
+			if err != nil {
+				return err
+			}
+			rec, err := decodeRecord(payload)
+			if err != nil {
+				return err
+			}
+			if err := fn(int64(rowid), rec); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("SQLite page %d isn't a table page", n)
+		}
+	}
+	if kind == 0x05 {
+		return db.walkPage(int(binary.BigEndian.Uint32(pg[hdr+8:])), fn, depth+1)
+	}
+	return nil
+}
+
+// payload reads a leaf cell's payload of size bytes starting at off,
+// following its overflow pages.
+func (db *sqliteFile) payload(pg []byte, off, size int) ([]byte, error) {
+	u := db.usable
+	local := size
+	if x := u - 35; size > x {
+		m := (u-12)*32/255 - 23
+		local = m + (size-m)%(u-4)
+		if local > x {
+			local = m
+		}
+	}
+	if off+local > len(pg) || (local < size && off+local+4 > len(pg)) {
+		return nil, errors.New("SQLite payload out of range")
+	}
+	out := append(make([]byte, 0, size), pg[off:off+local]...)
+	if local == size {
+		return out, nil
+	}
+	next := int(binary.BigEndian.Uint32(pg[off+local:]))
+	for len(out) < size && next != 0 {
+		ov, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := min(size-len(out), u-4)
+		out = append(out, ov[4:4+n]...)
+		next = int(binary.BigEndian.Uint32(ov))
+	}
+	if len(out) < size {
+		return nil, errors.New("SQLite overflow chain cut short")
+	}
+	return out, nil
+}
+
+// decodeRecord splits a record into its values: nil, int64, float64,
+// string or []byte.
+func decodeRecord(p []byte) ([]interface{}, error) {
+	hsize, k := uvarint(p)
+	if hsize > uint64(len(p)) || k == 0 {
+		return nil, errors.New("bad SQLite record")
+	}
+	var types []uint64
+	for i := k; i < int(hsize); {
+		t, n := uvarint(p[i:])
+		if n == 0 {
+			return nil, errors.New("bad SQLite record")
+		}
+		types = append(types, t)
+		i += n
+	}
+	body := p[hsize:]
+	vals := make([]interface{}, 0, len(types))
+	for _, t := range types {
+		n := serialSize(t)
+		if n < 0 || n > len(body) {
+			return nil, errors.New("bad SQLite record")
+		}
+		v := body[:n]
+		body = body[n:]
+		switch {
+		case t == 0:
+			vals = append(vals, nil)
+		case t >= 1 && t <= 6:
+			var x int64
+			for _, b := range v {
+				x = x<<8 | int64(b)
+			}
+			if v[0]&0x80 != 0 { // sign-extend
+				x -= 1 << (8 * uint(n))
+			}
+			vals = append(vals, x)
+		case t == 7:
+			vals = append(vals, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case t == 8, t == 9:
+			vals = append(vals, int64(t-8))
+		case t >= 12 && t%2 == 0:
+			vals = append(vals, v)
+		case t >= 13:
+			vals = append(vals, string(v))
+		default:
+			vals = append(vals, nil)
+		}
+	}
+	return vals, nil
+}
+
+func serialSize(t uint64) int {
+	switch {
+	case t <= 4:
+		return []int{0, 1, 2, 3, 4}[t]
+	case t == 5:
+		return 6
+	case t == 6, t == 7:
+		return 8
+	case t < 12:
+		return 0
+	}
+	if t > uint64(math.MaxInt32) {
+		return -1 // no page holds it
+	}
+	return int(t-12) / 2
+}
+
+// uvarint reads a SQLite varint: big-endian, 7 bits a byte, the 9th byte whole.
+func uvarint(p []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < 9 && i < len(p); i++ {
+		if i == 8 {
+			return x<<8 | uint64(p[i]), 9
+		}
+		x = x<<7 | uint64(p[i]&0x7f)
+		if p[i]&0x80 == 0 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
+
+var reColumnConstraint = regexp.MustCompile(`(?i)^(?:constraint|primary|unique|check|foreign)\b`)
+
+// sqliteColumns returns the column names of a CREATE TABLE statement and
+// which of them, if any, is the rowid (INTEGER PRIMARY KEY), whose value
+// isn't stored in the record.
+func sqliteColumns(sql string) (cols []string, rowid string) {
+	open, end := strings.IndexByte(sql, '('), strings.LastIndexByte(sql, ')')
+	if open < 0 || end < open {
+		return nil, ""
+	}
+	depth, start := 0, open+1
+	var defs []string
+	for i := open + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[start:end])
+	for _, d := range defs {
+		d = strings.TrimSpace(d)
+		if d == "" || reColumnConstraint.MatchString(d) {
+			continue
+		}
+		f := strings.Fields(d)
+		name := strings.Trim(f[0], "\"`[]'")
+		cols = append(cols, name)
+		if up := strings.ToUpper(d); len(f) > 1 && strings.ToUpper(f[1]) == "INTEGER" && strings.Contains(up, "PRIMARY KEY") {
+			rowid = name
+		}
+	}
+	return cols, rowid
+}
+
+func sqlText(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return ""
+}
+
+func sqlInt(v interface{}) int64 {
+	if x, ok := v.(int64); ok {
+		return x
+	}
+	return 0
+}
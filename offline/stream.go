@@ -0,0 +1,120 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"unitymind/search"
+)
+
+// ── Streaming ─────────────────────────────────────────────────────────────────
+// The full docs are 12,000+ pages. Rather than holding every one until the
+// run ends and then handing them all over, IndexStream passes them on in
+// batches as they're parsed, so the pages already in the engine aren't held
+// a second time and what's parsed is searchable before the rest is done.
+
+// streamBatch is how many pages IndexStream hands over first. Each batch
+// after is twice the last, up to maxStreamBatch: every batch published
+// copies the engine's term maps, so a run shouldn't pay that hundreds of times.
+const (
+	streamBatch    = 500
+	maxStreamBatch = 8000
+)
+
+// pageSink collects the pages a run over path parses, and reports the files
+// it leaves out (see report.go). Without emit the pages pile up in results;
+// with it they go out in batches and aren't kept. Safe for concurrent use.
+type pageSink struct {
+	mu      sync.Mutex
+	emit    func([]search.Result)
+	results []search.Result // every page, when not streaming
+	pending []search.Result // the next batch, when streaming
+	batch   int             // pages the next batch holds, see streamBatch
+	count   int
+	report  *IndexReport
+	// the most pages the run indexes (0 = no limit), see limits.go
+	maxPages int
+}
+
+func newPageSink(path string, emit func([]search.Result)) *pageSink {
+	return &pageSink{emit: emit, report: NewIndexReport(path)}
+}
+
+// add takes the pages parsed from one file.
+func (s *pageSink) add(results []search.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count += len(results)
+	s.report.Indexed++
+	if s.emit == nil {
+		s.results = append(s.results, results...)
+		return
+	}
+	s.pending = append(s.pending, results...)
+	if len(s.pending) >= max(s.batch, streamBatch) {
+		s.flushLocked()
+	}
+}
+
+// flush hands over whatever is pending, e.g. once the priority pages are
+// parsed so they're searchable right away.
+func (s *pageSink) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+func (s *pageSink) flushLocked() {
+	if s.emit == nil || len(s.pending) == 0 {
+		return
+	}
+	s.emit(s.pending)
+	s.pending = nil
+	s.batch = min(max(s.batch, streamBatch)*2, maxStreamBatch)
+}
+
+// skip reports file as left out for reason, see IndexReport.
+func (s *pageSink) skip(file, reason, detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.skip(file, reason, detail)
+}
+
+// finish fills in upd's pages and report once the run is over; next is the
+// manifest it leaves, nil if it failed with err.
+func (s *pageSink) finish(upd *IndexUpdate, next manifest, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upd.Results, upd.Pages, upd.Report = s.results, s.count, s.report
+	r := s.report
+	r.Files, r.Unchanged, r.Pages, r.Finished = len(next), upd.Unchanged, s.count, time.Now()
+	switch {
+	case errors.Is(err, context.Canceled):
+		r.Status = "cancelled"
+	case err != nil:
+		r.Status, r.Error = "error", err.Error()
+	default:
+		r.Status = "done"
+	}
+}
+
+// full reports whether the run has reached its page limit, noting in its
+// report that it stopped there if so.
+func (s *pageSink) full() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxPages == 0 || s.count < s.maxPages {
+		return false
+	}
+	s.report.Limit = limitNote(s.maxPages)
+	return true
+}
+
+// total is how many pages have been added so far.
+func (s *pageSink) total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
@@ -0,0 +1,100 @@
+package offline
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ── Workers and I/O throttling ────────────────────────────────────────────────
+// How hard indexing should push the machine depends on it: a laptop with a
+// spinning disk is slowed to a crawl by 8 readers seeking at once, while a
+// 16-core desktop with an SSD sits half idle. The number of files read and
+// parsed at once is configurable, by default picked from the CPU count and
+// disk, and reading can be capped to a rate that leaves the disk usable.
+
+const (
+	minAutoWorkers = 2
+	maxAutoWorkers = 16
+	// hddWorkers is the auto worker count for a spinning disk, where
+	// parallel reads only add seeks
+	hddWorkers = 2
+)
+
+// SetWorkers sets how many files folder indexing reads and parses at once;
+// 0 picks a number for the CPU and the disk being read (auto).
+func (ix *Indexer) SetWorkers(n int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.workers = max(n, 0)
+}
+
+// SetReadLimit caps how fast indexing reads doc files, in bytes per second
+// over all workers; 0 doesn't.
+func (ix *Indexer) SetReadLimit(bytesPerSec int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if bytesPerSec <= 0 {
+		ix.limiter = nil
+		return
+	}
+	ix.limiter = &ioLimiter{rate: float64(bytesPerSec)}
+}
+
+// workerCount is how many workers indexing root uses.
+func (ix *Indexer) workerCount(root string) int {
+	ix.mu.Lock()
+	n := ix.workers
+	ix.mu.Unlock()
+	if n > 0 {
+		return n
+	}
+	n = min(max(runtime.NumCPU(), minAutoWorkers), maxAutoWorkers)
+	if rotational, known := isRotational(root); known && rotational {
+		n = hddWorkers
+	}
+	log.Printf("[offline] Using %d workers for %s", n, root)
+	return n
+}
+
+// readLimit is the limiter reads go through, nil for no limit.
+func (ix *Indexer) readLimit() *ioLimiter {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.limiter
+}
+
+// ioLimiter paces reads to rate bytes a second: each read books the time
+// its bytes take at that rate, and waits its turn.
+type ioLimiter struct {
+	mu   sync.Mutex
+	rate float64
+	next time.Time
+}
+
+// wait blocks until n more bytes may be read, or ctx is done. A nil
+// limiter never waits.
+func (l *ioLimiter) wait(ctx context.Context, n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,319 @@
+package offline
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ── Watch mode ────────────────────────────────────────────────────────────────
+// Docs folders change under a running server: a newer version is extracted
+// over the old one, a ZIP is replaced. Watcher notices and hands the path back
+// to be re-indexed, which IndexChanged makes cheap, so nobody has to press
+// "re-index" by hand.
+//
+// It subscribes to the OS's file notifications for every folder under each
+// path (for a ZIP, its parent folder), so an unchanged docs tree costs
+// nothing. A path the OS can't watch (one that doesn't exist yet, a network
+// drive, a system out of inotify watches) is polled instead, every
+// PollInterval, by a fingerprint of its files.
+
+// PollInterval is how often a path that can't be watched is fingerprinted.
+const PollInterval = 10 * time.Minute
+
+// Watcher reports the docs paths that changed.
+type Watcher struct {
+	mu       sync.Mutex
+	debounce time.Duration
+	onChange func(name, path string) bool
+	watched  map[string]*watched // by name, e.g. an index name
+	fs       *fsnotify.Watcher   // nil if notifications are unavailable
+	dirs     map[string]bool     // folders subscribed to
+	stop     chan struct{}
+}
+
+type watched struct {
+	path    string
+	polled  bool      // not watchable: fingerprinted every PollInterval
+	changed time.Time // last change not yet reported, zero if none
+	last    string    // polled: fingerprint at the previous poll
+	fired   string    // polled: fingerprint last reported (or seen when first watched)
+}
+
+// NewWatcher returns a watcher. onChange is called from the watcher's
+// goroutine once a path has changed and then held still for debounce, so a
+// half-extracted folder isn't indexed; returning false (e.g. busy indexing
+// something else) asks to be called again after another debounce.
+func NewWatcher(debounce time.Duration, onChange func(name, path string) bool) *Watcher {
+	w := &Watcher{debounce: debounce, onChange: onChange, watched: map[string]*watched{}, dirs: map[string]bool{}}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[offline] File notifications unavailable, polling docs every %s: %v", PollInterval, err)
+	} else {
+		w.fs = fsw
+	}
+	return w
+}
+
+// Set replaces the watched paths with paths (name → path). Paths already
+// watched under the same name keep their state; new ones count as unchanged
+// as they are now.
+func (w *Watcher) Set(paths map[string]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for name, wt := range w.watched {
+		if paths[name] != wt.path {
+			delete(w.watched, name)
+		}
+	}
+	for name, path := range paths {
+		if path == "" || w.watched[name] != nil {
+			continue
+		}
+		wt := &watched{path: path}
+		if err := w.subscribe(path); err != nil {
+			log.Printf("[offline] Polling %s every %s: %v", path, PollInterval, err)
+			wt.polled = true
+			wt.last = fingerprint(path)
+			wt.fired = wt.last
+		}
+		w.watched[name] = wt
+	}
+	w.unsubscribeUnused()
+}
+
+// subscribe watches path: every folder under it, or a file's own folder.
+func (w *Watcher) subscribe(path string) error {
+	if w.fs == nil {
+		return fmt.Errorf("no file notifications")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return w.add(filepath.Dir(path))
+	}
+	return w.addTree(path)
+}
+
+// addTree watches root and every folder under it but hidden ones.
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.add(p)
+	})
+}
+
+func (w *Watcher) add(dir string) error {
+	if w.dirs[dir] {
+		return nil
+	}
+	if err := w.fs.Add(dir); err != nil {
+		return err
+	}
+	w.dirs[dir] = true
+	return nil
+}
+
+// unsubscribeUnused drops the folders no watched path needs any more.
+func (w *Watcher) unsubscribeUnused() {
+	for dir := range w.dirs {
+		used := false
+		for _, wt := range w.watched {
+			if !wt.polled && (under(dir, wt.path) || dir == filepath.Dir(wt.path)) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			w.fs.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+}
+
+// under reports whether p is root or inside it.
+func under(p, root string) bool {
+	return p == root || strings.HasPrefix(p, root+string(filepath.Separator))
+}
+
+// Start begins watching in the background. Calling it twice does nothing.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+// Stop ends watching.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *Watcher) run(stop chan struct{}) {
+	var events chan fsnotify.Event
+	var errs chan error
+	if w.fs != nil {
+		events, errs = w.fs.Events, w.fs.Errors
+	}
+	settle := time.NewTicker(max(w.debounce/2, 100*time.Millisecond))
+	defer settle.Stop()
+	poll := time.NewTicker(PollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case e := <-events:
+			w.event(e)
+		case err := <-errs:
+			log.Printf("[offline] Watching docs: %v", err)
+		case <-settle.C:
+			w.report()
+		case <-poll.C:
+			w.poll()
+		}
+	}
+}
+
+// event marks the watched paths a file event touches as changed, and
+// watches new folders under them.
+func (w *Watcher) event(e fsnotify.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, wt := range w.watched {
+		if wt.polled || !under(e.Name, wt.path) {
+			continue
+		}
+		info, err := os.Stat(e.Name)
+		isDir := err == nil && info.IsDir()
+		if isDir && e.Has(fsnotify.Create) {
+			w.addTree(e.Name)
+		}
+		// A folder created, removed or renamed may hold docs; any other
+		// file only counts if it would be indexed
+		if isDir || err != nil || e.Name == wt.path || shouldIndex(e.Name) || isMarkdown(e.Name) || isXMLDoc(e.Name) {
+			wt.changed = time.Now()
+		}
+	}
+}
+
+// report hands back the paths that changed and have held still for the
+// debounce.
+func (w *Watcher) report() {
+	w.mu.Lock()
+	var due []string
+	for name, wt := range w.watched {
+		if !wt.changed.IsZero() && time.Since(wt.changed) >= w.debounce {
+			due = append(due, name)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, name := range due {
+		w.mu.Lock()
+		wt := w.watched[name]
+		w.mu.Unlock()
+		if wt == nil {
+			continue
+		}
+		ok := w.onChange(name, wt.path)
+		w.mu.Lock()
+		if ok {
+			wt.changed = time.Time{}
+		} else {
+			wt.changed = time.Now()
+		}
+		w.mu.Unlock()
+	}
+}
+
+// poll fingerprints the paths that can't be watched and reports the
+// settled changes; one that has become watchable is watched from then on.
+func (w *Watcher) poll() {
+	w.mu.Lock()
+	names := make([]string, 0, len(w.watched))
+	for name, wt := range w.watched {
+		if wt.polled {
+			names = append(names, name)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, name := range names {
+		w.mu.Lock()
+		wt := w.watched[name]
+		w.mu.Unlock()
+		if wt == nil {
+			continue
+		}
+		fp := fingerprint(wt.path)
+		settled := fp == wt.last && fp != wt.fired
+		wt.last = fp
+		if settled && w.onChange(name, wt.path) {
+			wt.fired = fp
+		}
+		w.mu.Lock()
+		if fp != "" && fp == wt.fired && w.subscribe(wt.path) == nil {
+			wt.polled = false
+		}
+		w.mu.Unlock()
+	}
+}
+
+// fingerprint summarizes what's at path: for a folder, the name, size and
+// modification time of every HTML, Markdown or XML doc file that would be indexed;
+// for a file, its own size and time. A path that doesn't exist gives "".
+func fingerprint(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+	}
+	h := fnv.New64a()
+	n := 0
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && p != path && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !shouldIndex(p) && !isMarkdown(p) && !isXMLDoc(p) {
+			return nil
+		}
+		n++
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", p, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return fmt.Sprintf("%d/%016x", n, h.Sum64())
+}
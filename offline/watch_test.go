@@ -0,0 +1,51 @@
+package offline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherReportsSettledChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "Manual"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan string, 10)
+	w := NewWatcher(200*time.Millisecond, func(name, path string) bool {
+		changed <- name
+		return true
+	})
+	w.Set(map[string]string{"default": root})
+	w.Start()
+	defer w.Stop()
+
+	// A file that isn't a doc changes nothing
+	os.WriteFile(filepath.Join(root, "Manual", "notes.tmp"), []byte("x"), 0644)
+	select {
+	case name := <-changed:
+		t.Fatalf("reported %q for a file that isn't indexed", name)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// Pages written in a new folder are reported once, after they settle
+	os.MkdirAll(filepath.Join(root, "ScriptReference"), 0755)
+	time.Sleep(50 * time.Millisecond)
+	for _, page := range []string{"AudioSource.html", "Rigidbody.html"} {
+		os.WriteFile(filepath.Join(root, "ScriptReference", page), []byte("<html></html>"), 0644)
+	}
+	select {
+	case name := <-changed:
+		if name != "default" {
+			t.Fatalf("reported %q, want default", name)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("change not reported")
+	}
+	select {
+	case <-changed:
+		t.Fatal("change reported twice")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
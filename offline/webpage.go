@@ -0,0 +1,29 @@
+package offline
+
+import (
+	"net/url"
+
+	"unitymind/search"
+)
+
+// ── Web pages ─────────────────────────────────────────────────────────────────
+// A page from anywhere on the web (a blog post, a GitHub wiki page) is parsed
+// like a doc page, into Markdown chunks by section, so a one-off reference
+// answers like the docs do.
+
+// URLSource is the Source of web pages indexed by URL, see ParseWebPage.
+const URLSource = "url"
+
+// ParseWebPage turns a page fetched from pageURL into its chunks, or nil if
+// it's near-empty.
+func ParseWebPage(html []byte, pageURL string) []search.Result {
+	path := pageURL
+	if u, err := url.Parse(pageURL); err == nil {
+		path = u.Path // for the language folder, see DetectLanguage
+	}
+	results := pageChunks(string(html), pageURL, path)
+	for i := range results {
+		results[i].Source = URLSource
+	}
+	return results
+}
@@ -0,0 +1,173 @@
+package offline
+
+import (
+	"encoding/xml"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"unitymind/search"
+)
+
+// ── C# XML docs ───────────────────────────────────────────────────────────────
+// Next to each DLL, Unity installs (Editor/Data/Managed/UnityEngine) and most
+// plugins ship the compiler's XML doc file: the /// summary of every type and
+// member. It covers APIs the HTML docs don't, like a plugin's, and it's exact.
+// Each type becomes one page listing its summary and its members'.
+
+// XMLDocSource marks docs indexed from C# XML doc files.
+const XMLDocSource = "xmldoc"
+
+type xmlDocFile struct {
+	XMLName  xml.Name `xml:"doc"`
+	Assembly string   `xml:"assembly>name"`
+	Members  []struct {
+		Name    string `xml:"name,attr"`
+		Summary struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"summary"`
+		Params []struct {
+			Name  string `xml:"name,attr"`
+			Inner string `xml:",innerxml"`
+		} `xml:"param"`
+		Returns struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"returns"`
+	} `xml:"members>member"`
+}
+
+var (
+	reXMLCref = regexp.MustCompile(`<see(?:also)?\s+(?:cref|langword|href)="(?:[A-Z]:)?([^"]*)"\s*/>`)
+	reXMLRef  = regexp.MustCompile(`<(?:paramref|typeparamref)\s+name="([^"]*)"\s*/>`)
+	reXMLTag  = regexp.MustCompile(`<[^>]+>`)
+)
+
+// isXMLDoc is a cheap guess from the name; parseXMLDoc skips XML files
+// that aren't doc files.
+func isXMLDoc(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".xml")
+}
+
+// xmlType collects one type's summary and member lines.
+type xmlType struct {
+	summary string
+	members []string
+}
+
+// parseXMLDoc gives one page per documented type in an XML doc file.
+func parseXMLDoc(data []byte, path string) []search.Result {
+	var doc xmlDocFile
+	if xml.Unmarshal(data, &doc) != nil || len(doc.Members) == 0 {
+		return nil
+	}
+	types := map[string]*xmlType{}
+	typeOf := func(name string) *xmlType {
+		t := types[name]
+		if t == nil {
+			t = &xmlType{}
+			types[name] = t
+		}
+		return t
+	}
+	for _, m := range doc.Members {
+		kind, id, ok := strings.Cut(m.Name, ":")
+		if !ok {
+			continue
+		}
+		summary := xmlDocText(m.Summary.Inner)
+		if kind == "T" {
+			typeOf(id).summary = summary
+			continue
+		}
+		name, args, _ := strings.Cut(id, "(")
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			continue
+		}
+		owner, member := name[:dot], name[dot+1:]
+		if member == "#ctor" {
+			member = "constructor"
+		}
+		line := member
+		if args != "" {
+			line += "(" + shortTypes(strings.TrimSuffix(args, ")")) + ")"
+		}
+		if summary != "" {
+			line += ": " + summary
+		}
+		for _, p := range m.Params {
+			if text := xmlDocText(p.Inner); text != "" {
+				line += " " + p.Name + ": " + text
+			}
+		}
+		if ret := xmlDocText(m.Returns.Inner); ret != "" {
+			line += " Returns " + ret
+		}
+		t := typeOf(owner)
+		t.members = append(t.members, line)
+	}
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	absPath, _ := filepath.Abs(path)
+	base := "file:///" + strings.TrimPrefix(filepath.ToSlash(absPath), "/")
+	var results []search.Result
+	for _, name := range names {
+		t := types[name]
+		short := name[strings.LastIndexByte(name, '.')+1:]
+		content := name
+		if t.summary != "" {
+			content += ": " + t.summary
+		}
+		if len(t.members) > 0 {
+			content += "\n" + strings.Join(t.members, "\n")
+		}
+		if len(content) < 40 {
+			continue // an undocumented type
+		}
+		if len(content) > 12000 {
+			content = content[:12000]
+		}
+		tags := []string{short}
+		if doc.Assembly != "" {
+			tags = append(tags, doc.Assembly)
+		}
+		results = append(results, search.Result{
+			Title:   name,
+			URL:     base + "#T:" + name,
+			Excerpt: content,
+			Score:   1.0,
+			Source:  XMLDocSource,
+			Tags:    tags,
+		})
+	}
+	return results
+}
+
+// xmlDocText flattens a doc comment's inner XML to plain text: references
+// become the name they point at, other tags are dropped.
+func xmlDocText(inner string) string {
+	s := reXMLCref.ReplaceAllStringFunc(inner, func(tag string) string {
+		ref := reXMLCref.FindStringSubmatch(tag)[1]
+		ref, _, _ = strings.Cut(ref, "(")
+		return ref[strings.LastIndexByte(ref, '.')+1:]
+	})
+	s = reXMLRef.ReplaceAllString(s, "$1")
+	s = reXMLTag.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// shortTypes drops namespaces from a parameter list:
+// "System.String,UnityEngine.Vector3" → "String, Vector3".
+func shortTypes(args string) string {
+	parts := strings.Split(args, ",")
+	for i, p := range parts {
+		parts[i] = p[strings.LastIndexByte(p, '.')+1:]
+	}
+	return strings.Join(parts, ", ")
+}
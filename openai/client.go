@@ -2,6 +2,7 @@ package openai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,11 +13,15 @@ import (
 
 const apiURL = "https://api.openai.com/v1/chat/completions"
 
+// DefaultTimeout bounds a request made without a deadline of its own.
+const DefaultTimeout = 30 * time.Second
+
 // Client is a minimal OpenAI API client (no SDK, pure stdlib)
 type Client struct {
-	apiKey string
-	model  string
-	http   *http.Client
+	apiKey    string
+	model     string
+	maxTokens int
+	http      *http.Client
 }
 
 func NewClient(apiKey, model string) *Client {
@@ -24,9 +29,10 @@ func NewClient(apiKey, model string) *Client {
 		model = "gpt-4o-mini"
 	}
 	return &Client{
-		apiKey: apiKey,
-		model:  model,
-		http:   &http.Client{Timeout: 30 * time.Second},
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: 1024,
+		http:      &http.Client{},
 	}
 }
 
@@ -40,6 +46,7 @@ type chatRequest struct {
 	Messages    []message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens"`
 	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 type chatResponse struct {
@@ -60,13 +67,16 @@ type HistoryEntry struct {
 	Content string `json:"content"`
 }
 
-// Ask sends a question to OpenAI with conversation history
-func (c *Client) Ask(query string, history []HistoryEntry) (string, error) {
-	// Build message array
-	messages := []message{
-		{
-			Role: "system",
-			Content: `You are UnityMind, an expert Unity game development assistant. 
+// SetMaxChars caps answers at roughly n characters by limiting max_tokens
+// (about 4 characters per token). n <= 0 keeps the default of 1024 tokens.
+func (c *Client) SetMaxChars(n int) {
+	if n > 0 {
+		c.maxTokens = n/4 + 16
+	}
+}
+
+// DefaultSystemPrompt is version 1 of the "system" prompt template, see prompts.go.
+const DefaultSystemPrompt = `You are UnityMind, an expert Unity game development assistant. 
 You specialize in Unity 2D and 3D game development, C# scripting, Unity Editor, 
 physics, animation, UI, audio, scene management, performance optimization, 
 and the Unity ecosystem.
@@ -77,44 +87,25 @@ Guidelines:
 - Prefer Unity's built-in solutions before suggesting third-party assets
 - Format code blocks with triple backticks and 'csharp' language tag
 - Be concise but complete
-- If you reference Unity documentation, mention the specific Manual or ScriptReference page`,
-		},
-	}
-
-	// Add conversation history (last 6 messages max to save tokens)
-	start := 0
-	if len(history) > 6 {
-		start = len(history) - 6
-	}
-	for _, h := range history[start:] {
-		messages = append(messages, message{Role: h.Role, Content: h.Content})
-	}
+- If you reference Unity documentation, mention the specific Manual or ScriptReference page`
 
-	// Add the current question
-	messages = append(messages, message{Role: "user", Content: query})
-
-	reqBody := chatRequest{
-		Model:       c.model,
-		Messages:    messages,
-		MaxTokens:   1024,
-		Temperature: 0.3, // Low temp = more accurate, less hallucination
-	}
-
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshal error: %w", err)
-	}
+// Ask sends a question to OpenAI with conversation history, using DefaultSystemPrompt.
+func (c *Client) Ask(query string, history []HistoryEntry) (string, error) {
+	return c.AskWith(DefaultSystemPrompt, query, history)
+}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return "", fmt.Errorf("request error: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+// AskWith is Ask with a specific system prompt, given DefaultTimeout to answer.
+func (c *Client) AskWith(system, query string, history []HistoryEntry) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return c.AskContext(ctx, system, query, history)
+}
 
-	resp, err := c.http.Do(req)
+// AskContext is AskWith bounded by ctx instead of DefaultTimeout.
+func (c *Client) AskContext(ctx context.Context, system, query string, history []HistoryEntry) (string, error) {
+	resp, err := c.send(ctx, c.request(system, query, history, false))
 	if err != nil {
-		return "", fmt.Errorf("network error: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -143,3 +134,51 @@ Guidelines:
 
 	return answer, nil
 }
+
+// request builds the body of a chat completion.
+func (c *Client) request(system, query string, history []HistoryEntry, stream bool) chatRequest {
+	// Build message array
+	messages := []message{{Role: "system", Content: system}}
+
+	// Add conversation history (last 6 messages max to save tokens)
+	history = SanitizeHistory(history)
+	start := 0
+	if len(history) > 6 {
+		start = len(history) - 6
+	}
+	for _, h := range history[start:] {
+		messages = append(messages, message{Role: h.Role, Content: h.Content})
+	}
+
+	// Add the current question
+	messages = append(messages, message{Role: "user", Content: query})
+
+	return chatRequest{
+		Model:       c.model,
+		Messages:    messages,
+		MaxTokens:   c.maxTokens,
+		Temperature: 0.3, // Low temp = more accurate, less hallucination
+		Stream:      stream,
+	}
+}
+
+// send posts a chat completion request.
+func (c *Client) send(ctx context.Context, reqBody chatRequest) (*http.Response, error) {
+	bodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("network error: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ── History sanitation ────────────────────────────────────────────────────────
+// History comes straight from the browser, so it is untrusted: a crafted
+// entry with role "system" would override the system prompt. Only user and
+// assistant turns are forwarded, each capped and stripped of markup.
+
+// maxHistoryChars caps one history message; older answers with long code
+// blocks are still useful context when truncated.
+const maxHistoryChars = 2000
+
+var (
+	reHistBlocks = regexp.MustCompile(`(?is)<(script|style|iframe)[^>]*>.*?</(script|style|iframe)>`)
+	// Only real HTML tags, so C# generics like List<int> survive
+	reHistTags = regexp.MustCompile(`(?i)</?(a|abbr|b|br|button|code|div|em|form|h[1-6]|i|iframe|img|input|li|link|meta|ol|p|pre|script|small|span|strong|style|sub|sup|table|tbody|td|th|thead|tr|u|ul)\b[^>]*>`)
+)
+
+// SanitizeHistory returns history safe to forward to a provider: roles are
+// normalized to "user"/"assistant" (anything else, e.g. "system", is
+// dropped), markup and control characters are stripped, and each message is
+// capped at maxHistoryChars. Empty messages are dropped.
+func SanitizeHistory(history []HistoryEntry) []HistoryEntry {
+	out := make([]HistoryEntry, 0, len(history))
+	for _, h := range history {
+		role, ok := normalizeRole(h.Role)
+		if !ok {
+			continue
+		}
+		content := sanitizeContent(h.Content)
+		if content == "" {
+			continue
+		}
+		out = append(out, HistoryEntry{Role: role, Content: content})
+	}
+	return out
+}
+
+func normalizeRole(role string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "user":
+		return "user", true
+	case "assistant", "bot":
+		return "assistant", true
+	}
+	return "", false
+}
+
+func sanitizeContent(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = reHistBlocks.ReplaceAllString(s, " ")
+	s = reHistTags.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) > maxHistoryChars {
+		s = string([]rune(s)[:maxHistoryChars]) + "…"
+	}
+	return s
+}
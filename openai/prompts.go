@@ -0,0 +1,171 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Prompt templates ──────────────────────────────────────────────────────────
+// System prompts are named templates with numbered versions. Exactly one
+// version of each template is active; older ones are kept so a change can
+// be rolled back, and every answer records the "name@version" it came from.
+
+// SystemPrompt is the template used for chat answers.
+const SystemPrompt = "system"
+
+// PromptVersion is one revision of a template.
+type PromptVersion struct {
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+	Note    string `json:"note,omitempty"`
+	Created int64  `json:"created"` // unix seconds
+}
+
+// PromptTemplate is a named prompt and its history.
+type PromptTemplate struct {
+	Name     string          `json:"name"`
+	Active   int             `json:"active"`
+	Versions []PromptVersion `json:"versions"`
+}
+
+// PromptStore holds the templates and persists them to a JSON file.
+type PromptStore struct {
+	mu        sync.RWMutex
+	path      string
+	templates map[string]*PromptTemplate
+}
+
+// LoadPrompts reads the templates at path. A missing or unreadable file
+// starts from the built-in "system" template (DefaultSystemPrompt as v1).
+func LoadPrompts(path string) *PromptStore {
+	s := &PromptStore{path: path, templates: make(map[string]*PromptTemplate)}
+	if data, err := os.ReadFile(path); err == nil {
+		var list []*PromptTemplate
+		if json.Unmarshal(data, &list) == nil {
+			for _, t := range list {
+				if t.Name != "" && len(t.Versions) > 0 {
+					s.templates[t.Name] = t
+				}
+			}
+		}
+	}
+	if _, ok := s.templates[SystemPrompt]; !ok {
+		s.templates[SystemPrompt] = &PromptTemplate{
+			Name:     SystemPrompt,
+			Active:   1,
+			Versions: []PromptVersion{{Version: 1, Text: DefaultSystemPrompt, Note: "built-in"}},
+		}
+	}
+	return s
+}
+
+// Resolve picks the prompt for a request. ref is "" (the active version of
+// name), "N" (version N of name) or "other@N"; ?prompt= lets a single request
+// try a version without activating it. Returns the text and its "name@version".
+func (s *PromptStore) Resolve(name, ref string) (string, string, error) {
+	version := 0
+	if ref != "" {
+		if at := strings.LastIndexByte(ref, '@'); at >= 0 {
+			name, ref = ref[:at], ref[at+1:]
+		}
+		n, err := strconv.Atoi(ref)
+		if err != nil {
+			return "", "", fmt.Errorf("bad prompt version %q", ref)
+		}
+		version = n
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown prompt template %q", name)
+	}
+	if version == 0 {
+		version = t.Active
+	}
+	for _, v := range t.Versions {
+		if v.Version == version {
+			return v.Text, fmt.Sprintf("%s@%d", name, version), nil
+		}
+	}
+	return "", "", fmt.Errorf("prompt %s has no version %d", name, version)
+}
+
+// Add stores text as the next version of name (creating the template if
+// needed), activating it unless activate is false.
+func (s *PromptStore) Add(name, text, note string, activate bool) (PromptVersion, error) {
+	name, text = strings.TrimSpace(name), strings.TrimSpace(text)
+	if name == "" || text == "" {
+		return PromptVersion{}, fmt.Errorf("name and text are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.templates[name]
+	if !ok {
+		t = &PromptTemplate{Name: name}
+		s.templates[name] = t
+	}
+	v := PromptVersion{Version: len(t.Versions) + 1, Text: text, Note: note, Created: time.Now().Unix()}
+	t.Versions = append(t.Versions, v)
+	if activate || t.Active == 0 {
+		t.Active = v.Version
+	}
+	return v, s.save()
+}
+
+// Activate switches name to an existing version — also how a change is rolled back.
+func (s *PromptStore) Activate(name string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.templates[name]
+	if !ok {
+		return fmt.Errorf("unknown prompt template %q", name)
+	}
+	if version < 1 || version > len(t.Versions) {
+		return fmt.Errorf("prompt %s has no version %d", name, version)
+	}
+	t.Active = version
+	return s.save()
+}
+
+// List returns every template, sorted by name.
+func (s *PromptStore) List() []PromptTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]PromptTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		cp := *t
+		cp.Versions = append([]PromptVersion(nil), t.Versions...)
+		list = append(list, cp)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// save writes the templates; caller must hold the write lock.
+func (s *PromptStore) save() error {
+	list := make([]*PromptTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
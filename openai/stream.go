@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ── Streaming ─────────────────────────────────────────────────────────────────
+// With "stream": true the API sends the answer as server-sent events, one
+// "data: {...}" line per few tokens and "data: [DONE]" at the end, so a
+// front-end can show it while it is being written instead of after the
+// whole of it (often 10s or more).
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// AskStream is AskContext, calling onDelta with each piece of the answer as
+// it arrives. It returns the whole answer, as AskContext would.
+func (c *Client) AskStream(ctx context.Context, system, query string, history []HistoryEntry, onDelta func(string)) (string, error) {
+	resp, err := c.send(ctx, c.request(system, query, history, true))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Errors come back as a plain JSON body, not as events
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		respBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("read error: %w", err)
+		}
+		var chatResp chatResponse
+		if err := json.Unmarshal(respBytes, &chatResp); err != nil {
+			return "", fmt.Errorf("parse error: %w", err)
+		}
+		if chatResp.Error != nil {
+			return "", fmt.Errorf("API error (%s): %s", chatResp.Error.Type, chatResp.Error.Message)
+		}
+		return "", fmt.Errorf("not a stream: %s", resp.Header.Get("Content-Type"))
+	}
+
+	var answer strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("parse error: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		answer.WriteString(chunk.Choices[0].Delta.Content)
+		if onDelta != nil {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", fmt.Errorf("read error: %w", err)
+	}
+
+	text := strings.TrimSpace(answer.String())
+	if text == "" {
+		return "", fmt.Errorf("empty response")
+	}
+	return text, nil
+}
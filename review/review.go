@@ -0,0 +1,204 @@
+// Package review resurfaces questions users asked weeks ago as flashcards,
+// spaced out further each time they're remembered, so what was learned once
+// sticks.
+package review
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FirstReview is how long after a question is asked it first comes back.
+const FirstReview = 14 * 24 * time.Hour
+
+// maxCardsPerUser caps how many questions are kept per user; the oldest
+// asked go first.
+const maxCardsPerUser = 500
+
+// Card is one past question and the key points of its answer.
+type Card struct {
+	ID       string    `json:"id"`
+	User     string    `json:"user"`
+	Question string    `json:"question"`
+	Points   []string  `json:"points"`
+	Source   string    `json:"source,omitempty"` // where the answer came from
+	Asked    time.Time `json:"asked"`
+	Due      time.Time `json:"due"`
+	// Scheduling (a simplified SM-2): days until the next review after a
+	// successful one, how fast that grows, and how many reviews so far
+	Interval float64 `json:"interval"`
+	Ease     float64 `json:"ease"`
+	Reviews  int     `json:"reviews"`
+}
+
+// Grade is how well a card was remembered.
+type Grade string
+
+const (
+	Again Grade = "again" // forgot: see it again tomorrow
+	Good  Grade = "good"
+	Easy  Grade = "easy"
+)
+
+// Store holds every user's cards and persists them to a JSON file.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	cards map[string]*Card // by ID
+}
+
+// Load reads the cards at path. A missing or unreadable file starts empty.
+func Load(path string) *Store {
+	s := &Store{path: path, cards: map[string]*Card{}}
+	if data, err := os.ReadFile(path); err == nil {
+		var list []*Card
+		if json.Unmarshal(data, &list) == nil {
+			for _, c := range list {
+				s.cards[c.ID] = c
+			}
+		}
+	}
+	return s
+}
+
+// cardID identifies a user's question, ignoring case and spacing, so asking
+// the same thing twice updates one card.
+func cardID(user, question string) string {
+	norm := strings.Join(strings.Fields(strings.ToLower(question)), " ")
+	sum := sha1.Sum([]byte(user + "\x00" + norm))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Record remembers that user asked question and the key points of the answer.
+// Asking again refreshes the points; a card already in review keeps its
+// schedule, since asking again is its own kind of review.
+func (s *Store) Record(user, question string, points []string, source string, now time.Time) error {
+	if user == "" || len(points) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := cardID(user, question)
+	if c, ok := s.cards[id]; ok {
+		c.Points, c.Source = points, source
+		return s.save()
+	}
+	s.cards[id] = &Card{
+		ID: id, User: user, Question: strings.TrimSpace(question), Points: points, Source: source,
+		Asked: now, Due: now.Add(FirstReview), Ease: 2.5,
+	}
+	s.trim(user)
+	return s.save()
+}
+
+// Due returns user's cards due by now, most overdue first, at most limit
+// (0 = all), and how many are due in total.
+func (s *Store) Due(user string, now time.Time, limit int) ([]Card, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Card
+	for _, c := range s.cards {
+		if c.User == user && !c.Due.After(now) {
+			due = append(due, *c)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+	total := len(due)
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, total
+}
+
+// Grade schedules user's card id after a review and returns it.
+func (s *Store) Grade(user, id string, g Grade, now time.Time) (Card, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cards[id]
+	if !ok || c.User != user {
+		return Card{}, fmt.Errorf("no card %q", id)
+	}
+	switch g {
+	case Again:
+		c.Interval = 1
+		c.Ease = math.Max(1.3, c.Ease-0.2)
+	case Good:
+		c.Interval = nextInterval(c, 1)
+	case Easy:
+		c.Interval = nextInterval(c, 1.3)
+		c.Ease += 0.15
+	default:
+		return Card{}, fmt.Errorf("unknown grade %q (want again, good or easy)", g)
+	}
+	c.Reviews++
+	c.Due = now.Add(time.Duration(c.Interval * float64(24*time.Hour)))
+	return *c, s.save()
+}
+
+// nextInterval is the gap after a successful review: a week the first
+// time, then the last gap times the card's ease.
+func nextInterval(c *Card, bonus float64) float64 {
+	if c.Interval < 1 {
+		return 7 * bonus
+	}
+	return math.Round(c.Interval*c.Ease*bonus*10) / 10
+}
+
+// Forget deletes user's card id. Returns false if there was none.
+func (s *Store) Forget(user, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.cards[id]; !ok || c.User != user {
+		return false, nil
+	}
+	delete(s.cards, id)
+	return true, s.save()
+}
+
+// trim drops user's oldest cards beyond maxCardsPerUser; caller must hold
+// the lock.
+func (s *Store) trim(user string) {
+	var mine []*Card
+	for _, c := range s.cards {
+		if c.User == user {
+			mine = append(mine, c)
+		}
+	}
+	if len(mine) <= maxCardsPerUser {
+		return
+	}
+	sort.Slice(mine, func(i, j int) bool { return mine[i].Asked.Before(mine[j].Asked) })
+	for _, c := range mine[:len(mine)-maxCardsPerUser] {
+		delete(s.cards, c.ID)
+	}
+}
+
+// save writes every card; caller must hold the lock.
+func (s *Store) save() error {
+	list := make([]*Card, 0, len(s.cards))
+	for _, c := range s.cards {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Asked.Before(list[j].Asked) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
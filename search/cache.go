@@ -0,0 +1,82 @@
+package search
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// ── Query result cache ────────────────────────────────────────────────────────
+// Follow-up and repeated questions ("and in 2D?", the same question asked
+// twice) would otherwise re-score the whole corpus. Results are cached per
+// normalized query + topK + ranking on each view, so a change to the docs
+// starts from an empty cache.
+
+// queryCacheSize is how many distinct searches are remembered per view.
+const queryCacheSize = 256
+
+type queryKey struct {
+	query    string
+	topK     int
+	rk       Ranking
+	wantCode bool
+	hide     string // PageSet.key of the excluded pages
+}
+
+type queryEntry struct {
+	key     queryKey
+	results []Result
+}
+
+// queryCache is a small LRU. It has its own lock because any number of
+// searches may run against a view at once.
+type queryCache struct {
+	mu    sync.Mutex
+	order *list.List // front = most recently used
+	items map[queryKey]*list.Element
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{order: list.New(), items: make(map[queryKey]*list.Element)}
+}
+
+// normalizeQuery folds case and whitespace so "Rigidbody  jump" and
+// "rigidbody jump" share an entry.
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+func (c *queryCache) get(k queryKey) ([]Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[k]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return copyResults(el.Value.(*queryEntry).results), true
+}
+
+func (c *queryCache) put(k queryKey, results []Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		el.Value.(*queryEntry).results = copyResults(results)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[k] = c.order.PushFront(&queryEntry{key: k, results: copyResults(results)})
+	if c.order.Len() > queryCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*queryEntry).key)
+	}
+}
+
+// copyResults keeps callers from mutating cached slices.
+func copyResults(r []Result) []Result {
+	if r == nil {
+		return nil
+	}
+	return append([]Result(nil), r...)
+}
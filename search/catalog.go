@@ -0,0 +1,132 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ── API catalog ───────────────────────────────────────────────────────────────
+// "List all AudioSource methods" isn't a relevance question: the answer is
+// every page under ScriptReference/AudioSource.*. The Scripting Reference
+// names its pages after what they document (AudioSource.Play is a method,
+// AudioSource-clip a property), so the indexed pages double as a signature
+// database: Members reads a class's members, with the declaration and first
+// sentence of each page, from the symbol table built as they're indexed
+// (see symbols.go).
+
+// Member is one documented member of a scripting API class.
+type Member struct {
+	Class     string `json:"class"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`      // method, property, message or constructor
+	Signature string `json:"signature"` // first declaration on the page, if any
+	Summary   string `json:"summary"`
+	URL       string `json:"url"`
+}
+
+// messageClasses are the classes Unity calls back by name: their On*
+// methods and lifecycle methods are messages, not methods to call.
+var messageClasses = map[string]bool{
+	"MonoBehaviour": true, "ScriptableObject": true, "StateMachineBehaviour": true,
+	"Editor": true, "EditorWindow": true, "AssetPostprocessor": true,
+}
+
+var lifecycleMessages = map[string]bool{
+	"Awake": true, "Start": true, "Update": true, "FixedUpdate": true,
+	"LateUpdate": true, "Reset": true,
+}
+
+var (
+	reDeclaration = regexp.MustCompile(`^(?:public|protected)\s[^;{]*(?:\)|;)`)
+	reParamRow    = regexp.MustCompile(`^[a-z_][A-Za-z0-9_]*\s+[A-Z]`)
+)
+
+// Members returns the documented members of class (matched case-insensitively,
+// ignoring its namespace) sorted by kind then name. Returns nil if the index
+// has no Scripting Reference pages for it.
+func (e *Engine) Members(class string) []Member {
+	var members []Member
+	for _, m := range e.cur.Load().symbols {
+		if m.Name != "" && strings.EqualFold(m.Class, class) {
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Kind != members[j].Kind {
+			return members[i].Kind < members[j].Kind
+		}
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// apiPage splits a Scripting Reference URL into the class it belongs to, the
+// member name and its kind: ScriptReference/AudioSource.Play.html is method
+// Play of AudioSource, AudioSource-clip.html property clip. A class's own
+// page, or anything else, gives ok=false.
+func apiPage(url string) (class, member, kind string, ok bool) {
+	i := strings.Index(url, "/ScriptReference/")
+	if i < 0 || !strings.HasSuffix(url, ".html") {
+		return "", "", "", false
+	}
+	page := strings.TrimSuffix(url[i+len("/ScriptReference/"):], ".html")
+	if strings.Contains(page, "/") {
+		return "", "", "", false
+	}
+	if owner, m, dash := strings.Cut(page, "-"); dash {
+		kind = "property"
+		if m == "ctor" {
+			kind = "constructor"
+		}
+		return lastSegment(owner), m, kind, m != ""
+	}
+	dot := strings.LastIndexByte(page, '.')
+	if dot <= 0 || dot == len(page)-1 {
+		return "", "", "", false
+	}
+	return lastSegment(page[:dot]), page[dot+1:], "method", true
+}
+
+// lastSegment drops a namespace: "Rendering.CommandBuffer" → "CommandBuffer".
+func lastSegment(s string) string {
+	return s[strings.LastIndexByte(s, '.')+1:]
+}
+
+func isMessageName(name string) bool {
+	if lifecycleMessages[name] {
+		return true
+	}
+	return len(name) > 2 && strings.HasPrefix(name, "On") && name[2] >= 'A' && name[2] <= 'Z'
+}
+
+// describeMember picks a page's first declaration and the first sentence of
+// its description, skipping the title and parameter rows that precede it.
+func describeMember(content, title string) (sig, summary string) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, title):
+		case reDeclaration.MatchString(line):
+			if sig == "" {
+				sig = line
+			}
+		case summary == "" && !reParamRow.MatchString(line) && len(strings.Fields(line)) >= 4:
+			summary = firstSentence(line)
+		}
+		if sig != "" && summary != "" {
+			break
+		}
+	}
+	return sig, summary
+}
+
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	if len(s) > 200 {
+		return s[:200] + "…"
+	}
+	return s
+}
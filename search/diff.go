@@ -0,0 +1,184 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ── Index diffs ───────────────────────────────────────────────────────────────
+// Re-indexing newer docs (2022.3 → Unity 6) changes pages without saying
+// which. Diff compares an index at two points, a snapshot and now or two
+// snapshots, page by page: the pages added, removed and updated, and the
+// ones that moved, removed from one URL and added at another under the same
+// title.
+
+// CurrentState names the index as it is now in a diff, as opposed to a
+// snapshot ID.
+const CurrentState = "current"
+
+// PageChange is a page that differs between the two states of a diff.
+type PageChange struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	From  string `json:"from,omitempty"` // where a moved page was
+}
+
+// IndexDiff is what changed in an index between two states.
+type IndexDiff struct {
+	Index     string       `json:"index"`
+	From      string       `json:"from"` // snapshot ID
+	To        string       `json:"to"`   // snapshot ID, or CurrentState
+	Added     []PageChange `json:"added"`
+	Removed   []PageChange `json:"removed"`
+	Updated   []PageChange `json:"updated"`
+	Moved     []PageChange `json:"moved"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// pageState is one page of an index, its chunks taken together.
+type pageState struct {
+	title  string
+	hashes []string
+}
+
+// Diff compares the named index between two states: from and to are
+// snapshot IDs (see Snapshots) or CurrentState. An empty from is the state
+// before the last re-index, the newest "reindex" snapshot (or, failing
+// that, the newest); an empty to is CurrentState.
+func (r *Registry) Diff(name, from, to string) (IndexDiff, error) {
+	name = normalizeIndexName(name)
+	if r.Get(name) == nil {
+		return IndexDiff{}, fmt.Errorf("unknown index %q", name)
+	}
+	if from == "" {
+		list := r.Snapshots(name)
+		if len(list) == 0 {
+			return IndexDiff{}, fmt.Errorf("index %q has no snapshots to compare with yet", name)
+		}
+		from = list[0].ID
+		for _, s := range list {
+			if s.Reason == "reindex" {
+				from = s.ID
+				break
+			}
+		}
+	}
+	if to == "" {
+		to = CurrentState
+	}
+	before, err := r.pageStates(name, from)
+	if err != nil {
+		return IndexDiff{}, err
+	}
+	after, err := r.pageStates(name, to)
+	if err != nil {
+		return IndexDiff{}, err
+	}
+
+	diff := IndexDiff{Index: name, From: from, To: to, Added: []PageChange{}, Removed: []PageChange{}, Updated: []PageChange{}, Moved: []PageChange{}}
+	for url, page := range after {
+		old, ok := before[url]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, PageChange{Title: page.title, URL: url})
+		case strings.Join(old.hashes, " ") != strings.Join(page.hashes, " "):
+			diff.Updated = append(diff.Updated, PageChange{Title: page.title, URL: url})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for url, page := range before {
+		if _, ok := after[url]; !ok {
+			diff.Removed = append(diff.Removed, PageChange{Title: page.title, URL: url})
+		}
+	}
+
+	// A page removed and one added under the same title moved, if the title
+	// is unique on both sides
+	addedBy, removedBy := uniqueByTitle(diff.Added), uniqueByTitle(diff.Removed)
+	moved := map[string]bool{}
+	removed := []PageChange{}
+	for _, p := range diff.Removed {
+		title := strings.ToLower(p.Title)
+		if to, ok := addedBy[title]; ok && removedBy[title].URL == p.URL {
+			diff.Moved = append(diff.Moved, PageChange{Title: to.Title, URL: to.URL, From: p.URL})
+			moved[to.URL] = true
+			continue
+		}
+		removed = append(removed, p)
+	}
+	diff.Removed = removed
+	added := diff.Added[:0]
+	for _, p := range diff.Added {
+		if !moved[p.URL] {
+			added = append(added, p)
+		}
+	}
+	diff.Added = added
+
+	for _, list := range [][]PageChange{diff.Added, diff.Removed, diff.Updated, diff.Moved} {
+		sort.Slice(list, func(i, j int) bool { return list[i].URL < list[j].URL })
+	}
+	return diff, nil
+}
+
+// pageStates reads the named index's pages at state, by page URL.
+func (r *Registry) pageStates(name, state string) (map[string]*pageState, error) {
+	var docs []Doc
+	if state == CurrentState {
+		docs = r.Get(name).cur.Load().docs
+	} else {
+		if _, valid := parseSnapshotID(name, state); !valid || strings.ContainsAny(state, `/\`) {
+			return nil, fmt.Errorf("bad snapshot id %q", state)
+		}
+		var err error
+		if docs, err = readSnapshot(filepath.Join(r.snapshotDir(name), state+".json")); err != nil {
+			return nil, err
+		}
+	}
+	pages := map[string]*pageState{}
+	for _, d := range docs {
+		if d.Source == StarterSource {
+			continue
+		}
+		url := PageURL(d.URL)
+		page := pages[url]
+		if page == nil {
+			page = &pageState{}
+			pages[url] = page
+		}
+		// The page's own chunk carries its title; sections add " — heading"
+		if page.title == "" || d.URL == url {
+			page.title, _, _ = strings.Cut(d.Title, " — ")
+		}
+		hash := d.Hash
+		if hash == "" {
+			hash = contentHash(d.Content)
+		}
+		page.hashes = append(page.hashes, d.URL+"="+hash)
+	}
+	for _, page := range pages {
+		sort.Strings(page.hashes)
+	}
+	return pages, nil
+}
+
+// uniqueByTitle indexes pages by lower-cased title, leaving out titles
+// more than one page has.
+func uniqueByTitle(pages []PageChange) map[string]PageChange {
+	byTitle := map[string]PageChange{}
+	dup := map[string]bool{}
+	for _, p := range pages {
+		t := strings.ToLower(p.Title)
+		if _, seen := byTitle[t]; seen {
+			dup[t] = true
+		}
+		byTitle[t] = p
+	}
+	for t := range dup {
+		delete(byTitle, t)
+	}
+	return byTitle
+}
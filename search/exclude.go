@@ -0,0 +1,188 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ── Excluded pages ────────────────────────────────────────────────────────────
+// "Never show me this page again": a page a user keeps getting but doesn't
+// want (an outdated guide, an unrelated namesake) is hidden from results
+// without deleting it from the index, for that user or for everyone. Pages
+// are matched by canonical URL without the anchor, so every section goes.
+
+// PageSet is a set of canonical page URLs, see PageURL.
+type PageSet map[string]bool
+
+// Has reports whether url's page is in the set.
+func (s PageSet) Has(url string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	canon, _ := CanonicalURL(url)
+	return s[PageURL(canon)]
+}
+
+// Filter returns results without the ones whose page is in the set.
+func (s PageSet) Filter(results []Result) []Result {
+	if len(s) == 0 {
+		return results
+	}
+	kept := make([]Result, 0, len(results))
+	for _, r := range results {
+		if !s.Has(r.URL) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// key identifies the set's contents, for the result cache.
+func (s PageSet) key() string {
+	if len(s) == 0 {
+		return ""
+	}
+	pages := make([]string, 0, len(s))
+	for p := range s {
+		pages = append(pages, p)
+	}
+	sort.Strings(pages)
+	return strings.Join(pages, "\n")
+}
+
+// Exclusions lists the excluded pages, global and per user.
+type Exclusions struct {
+	Global []string            `json:"global"`
+	Users  map[string][]string `json:"users"`
+}
+
+// ExclusionList holds the excluded pages and persists them to a JSON file.
+type ExclusionList struct {
+	mu     sync.RWMutex
+	path   string
+	global PageSet
+	users  map[string]PageSet
+}
+
+// LoadExclusions reads the list at path. A missing or unreadable file
+// starts empty.
+func LoadExclusions(path string) *ExclusionList {
+	x := &ExclusionList{path: path, global: PageSet{}, users: map[string]PageSet{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return x
+	}
+	var ex Exclusions
+	if json.Unmarshal(data, &ex) != nil {
+		return x
+	}
+	for _, u := range ex.Global {
+		x.global[u] = true
+	}
+	for user, urls := range ex.Users {
+		set := PageSet{}
+		for _, u := range urls {
+			set[u] = true
+		}
+		x.users[user] = set
+	}
+	return x
+}
+
+// pageKey is the form pages are stored under.
+func pageKey(url string) string {
+	canon, _ := CanonicalURL(url)
+	return PageURL(canon)
+}
+
+// Add hides url's page from user, or from everyone when user is "".
+// Returns false if it was already hidden.
+func (x *ExclusionList) Add(url, user string) (bool, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	set := x.global
+	if user != "" {
+		if set = x.users[user]; set == nil {
+			set = PageSet{}
+			x.users[user] = set
+		}
+	}
+	page := pageKey(url)
+	if set[page] {
+		return false, nil
+	}
+	set[page] = true
+	return true, x.save()
+}
+
+// Remove shows url's page again to user, or to everyone when user is "".
+// Returns false if it wasn't hidden.
+func (x *ExclusionList) Remove(url, user string) (bool, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	set := x.global
+	if user != "" {
+		set = x.users[user]
+	}
+	page := pageKey(url)
+	if !set[page] {
+		return false, nil
+	}
+	delete(set, page)
+	if user != "" && len(set) == 0 {
+		delete(x.users, user)
+	}
+	return true, x.save()
+}
+
+// For returns the pages hidden from user: the global ones plus their own.
+func (x *ExclusionList) For(user string) PageSet {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	set := make(PageSet, len(x.global)+len(x.users[user]))
+	for p := range x.global {
+		set[p] = true
+	}
+	if user != "" {
+		for p := range x.users[user] {
+			set[p] = true
+		}
+	}
+	return set
+}
+
+// List returns every excluded page, sorted.
+func (x *ExclusionList) List() Exclusions {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.snapshot()
+}
+
+// snapshot is List without locking; caller must hold the lock.
+func (x *ExclusionList) snapshot() Exclusions {
+	sorted := func(s PageSet) []string {
+		out := make([]string, 0, len(s))
+		for p := range s {
+			out = append(out, p)
+		}
+		sort.Strings(out)
+		return out
+	}
+	ex := Exclusions{Global: sorted(x.global), Users: map[string][]string{}}
+	for user, set := range x.users {
+		ex.Users[user] = sorted(set)
+	}
+	return ex
+}
+
+// save writes the list; caller must hold the write lock.
+func (x *ExclusionList) save() error {
+	data, err := json.MarshalIndent(x.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(x.path, data)
+}
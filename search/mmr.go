@@ -0,0 +1,95 @@
+package search
+
+// ── Diversity re-ranking (MMR) ────────────────────────────────────────────────
+// Five near-identical Rigidbody pages make for a redundant answer. Maximal
+// marginal relevance picks each next hit by its relevance minus its
+// similarity to the hits already picked, so the top-k spreads over different
+// pages and sections of the topic.
+
+// mmrPool is how many candidates per requested hit are considered.
+const mmrPool = 3
+
+// diversify reorders ranked (best first) so its first topK entries trade
+// relevance against redundancy; weight is Ranking.Diversity (0 = off).
+func (v *view) diversify(ranked []scoredDoc, topK int, weight float64) []scoredDoc {
+	if weight <= 0 || len(ranked) <= 1 {
+		return ranked
+	}
+	pool := ranked
+	if len(pool) > topK*mmrPool {
+		pool = pool[:topK*mmrPool]
+	}
+	maxScore := pool[0].score
+	if maxScore <= 0 {
+		return ranked
+	}
+	feats := make([]mmrFeatures, len(pool))
+	for i, sd := range pool {
+		feats[i] = v.mmrFeaturesOf(sd.idx)
+	}
+
+	picked := make([]scoredDoc, 0, len(ranked))
+	used := make([]bool, len(pool))
+	maxSim := make([]float64, len(pool)) // similarity to the closest picked hit
+	for len(picked) < topK && len(picked) < len(pool) {
+		best, bestVal := -1, 0.0
+		for i, sd := range pool {
+			if used[i] {
+				continue
+			}
+			val := (1-weight)*sd.score/maxScore - weight*maxSim[i]
+			if best < 0 || val > bestVal {
+				best, bestVal = i, val
+			}
+		}
+		used[best] = true
+		picked = append(picked, pool[best])
+		for i := range pool {
+			if !used[i] {
+				if s := feats[i].similarity(feats[best]); s > maxSim[i] {
+					maxSim[i] = s
+				}
+			}
+		}
+	}
+	// Everything not picked keeps its relevance order behind the diverse head
+	for i, sd := range pool {
+		if !used[i] {
+			picked = append(picked, sd)
+		}
+	}
+	return append(picked, ranked[len(pool):]...)
+}
+
+type mmrFeatures struct {
+	page  string
+	title map[string]bool
+}
+
+func (v *view) mmrFeaturesOf(idx int) mmrFeatures {
+	d := v.docs[idx]
+	f := mmrFeatures{page: PageURL(d.URL), title: map[string]bool{}}
+	for _, tok := range tokenize(d.Title) {
+		f.title[tok] = true
+	}
+	return f
+}
+
+// similarity is 1 for sections of the same page, otherwise the Jaccard
+// overlap of title tokens ("Rigidbody.AddForce" vs "Rigidbody.velocity").
+func (f mmrFeatures) similarity(g mmrFeatures) float64 {
+	if f.page == g.page {
+		return 1
+	}
+	inter := 0
+	for tok := range f.title {
+		if g.title[tok] {
+			inter++
+		}
+	}
+	union := len(f.title) + len(g.title) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// ── Obsolete APIs ─────────────────────────────────────────────────────────────
+// Unity renames APIs between versions (Rigidbody.velocity became
+// Rigidbody.linearVelocity in Unity 6) and keeps the old page, marked
+// obsolete, pointing at the new one. The offline indexer tags such pages
+// with ReplacedByTag and the new name; Renames reads the old → new map back
+// from the index, so it always matches the docs the index holds.
+
+// ReplacedByTag starts the tag of an obsolete API's page, followed by what
+// replaces it: "replaced by Rigidbody.linearVelocity".
+const ReplacedByTag = "replaced by "
+
+// Rename is an obsolete API and the one to use instead.
+type Rename struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+	URL string `json:"url"` // the old API's page
+}
+
+// APIName is the API a Scripting Reference page documents, "Rigidbody.velocity"
+// for ScriptReference/Rigidbody-velocity.html, "" for any other page.
+func APIName(url string) string {
+	i := strings.Index(url, "/ScriptReference/")
+	if i < 0 || !strings.HasSuffix(url, ".html") {
+		return ""
+	}
+	page := strings.TrimSuffix(url[i+len("/ScriptReference/"):], ".html")
+	if page == "" || strings.Contains(page, "/") {
+		return ""
+	}
+	return strings.Replace(page, "-", ".", 1)
+}
+
+// Renames returns every obsolete API in the index with its replacement,
+// sorted by old name.
+func (e *Engine) Renames() []Rename {
+	v := e.cur.Load()
+	if r := v.renames.Load(); r != nil {
+		return *r
+	}
+	var renames []Rename
+	seen := map[string]bool{}
+	for _, d := range v.docs {
+		for _, t := range d.Tags {
+			if !strings.HasPrefix(t, ReplacedByTag) {
+				continue
+			}
+			old := APIName(d.URL)
+			if old != "" && !seen[old] {
+				seen[old] = true
+				renames = append(renames, Rename{Old: old, New: strings.TrimPrefix(t, ReplacedByTag), URL: d.URL})
+			}
+		}
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].Old < renames[j].Old })
+	v.renames.Store(&renames)
+	return renames
+}
+
+// ObsoleteIn returns the obsolete APIs a question mentions, as
+// "rigidbody.velocity" or with class and member anywhere in it.
+func (e *Engine) ObsoleteIn(query string) []Rename {
+	lower := strings.ToLower(query)
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(lower, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		words[w] = true
+	}
+	var found []Rename
+	for _, r := range e.Renames() {
+		old := strings.ToLower(r.Old)
+		dot := strings.LastIndexByte(old, '.')
+		if dot < 0 {
+			if words[old] { // an obsolete class
+				found = append(found, r)
+			}
+			continue
+		}
+		if strings.Contains(lower, old) || words[lastSegment(old[:dot])] && words[old[dot+1:]] {
+			found = append(found, r)
+		}
+	}
+	return found
+}
@@ -0,0 +1,78 @@
+package search
+
+// ── Term proximity ────────────────────────────────────────────────────────────
+// BM25 scores each query term on its own, so a page mentioning "camera" in
+// its intro and "follow" three sections later ties with one explaining how
+// to make the camera follow the player. The best candidates are re-scored by
+// how tightly the query terms cluster in their text.
+
+// proximityWindow is the widest span, in tokens, that still counts as "close".
+const proximityWindow = 8
+
+// rerankProximity adds up to boost to the first pool entries of ranked (best
+// first) by how close together the query tokens appear in each doc, then
+// re-sorts them. Single-term queries and boost 0 leave ranked unchanged.
+func (v *view) rerankProximity(ranked []scoredDoc, tokens []string, pool int, boost float64) []scoredDoc {
+	want := map[string]int{}
+	for _, tok := range tokens {
+		if _, ok := want[tok]; !ok {
+			want[tok] = len(want)
+		}
+	}
+	if boost <= 0 || len(want) < 2 || len(ranked) == 0 {
+		return ranked
+	}
+	if pool > len(ranked) {
+		pool = len(ranked)
+	}
+	head := ranked[:pool]
+	for i := range head {
+		doc, _ := v.hydrate(v.docs[head[i].idx])
+		head[i].score += boost * proximity(tokenize(doc.Title+" "+doc.Content), want)
+	}
+	sortScored(head)
+	return ranked
+}
+
+// proximity rates 0..1 how tightly the wanted tokens cluster in body: the
+// most distinct wanted tokens found within proximityWindow, weighted by how
+// densely they sit. All terms adjacent scores 1; none within reach scores 0.
+func proximity(body []string, want map[string]int) float64 {
+	type hit struct{ pos, term int }
+	var hits []hit
+	for pos, tok := range body {
+		if t, ok := want[tok]; ok {
+			hits = append(hits, hit{pos, t})
+		}
+	}
+	bestN, bestSpan := 1, 0
+	counts := make([]int, len(want))
+	distinct, lo := 0, 0
+	for _, h := range hits {
+		if counts[h.term] == 0 {
+			distinct++
+		}
+		counts[h.term]++
+		for h.pos-hits[lo].pos >= proximityWindow {
+			if counts[hits[lo].term]--; counts[hits[lo].term] == 0 {
+				distinct--
+			}
+			lo++
+		}
+		// Shrink past duplicates at the left edge so the span is as tight as it can be
+		for counts[hits[lo].term] > 1 {
+			counts[hits[lo].term]--
+			lo++
+		}
+		span := h.pos - hits[lo].pos
+		if distinct > bestN || distinct == bestN && distinct > 1 && span < bestSpan {
+			bestN, bestSpan = distinct, span
+		}
+	}
+	if bestN < 2 {
+		return 0
+	}
+	coverage := float64(bestN-1) / float64(len(want)-1)
+	density := float64(bestN-1) / float64(bestSpan)
+	return coverage * density
+}
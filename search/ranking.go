@@ -0,0 +1,213 @@
+package search
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Ranking holds the relevance knobs. Zero values mean "use the default".
+// A Ranking is also a named profile: the chat UI wants conceptual Manual
+// pages, an API lookup wants precise ScriptReference hits.
+type Ranking struct {
+	K1             float64 `json:"k1"`              // BM25 term-frequency saturation
+	B              float64 `json:"b"`               // BM25 length normalization
+	TitleBoost     float64 `json:"title_boost"`     // added per query token found in the title
+	PrefixBoost    float64 `json:"prefix_boost"`    // weight of prefix (partial) matches vs exact
+	SubstringBoost float64 `json:"substring_boost"` // weight of matches inside API names
+	Threshold      float64 `json:"threshold"`       // min normalized score to answer from local docs
+	ManualBoost    float64 `json:"manual_boost"`    // score multiplier for /Manual/ pages
+	ScriptRefBoost float64 `json:"scriptref_boost"` // score multiplier for /ScriptReference/ pages
+	CodeBoost      float64 `json:"code_boost"`      // added per query token found in code samples (code requests only)
+	Diversity      float64 `json:"diversity"`       // MMR weight of redundancy vs relevance, 0 = plain ranking
+	IntentBoost    float64 `json:"intent_boost"`    // extra page-type multiplier matching the query's intent, see ForIntent
+	ProximityBoost float64 `json:"proximity_boost"` // added when query terms appear close together, see proximity.go
+	// Preferred documentation language ("ja", "ko", "zh", "es", "en"): pages in
+	// others are only searched when none in it match. "" searches them all.
+	Language string `json:"language,omitempty"`
+
+	// Set when Diversity or ProximityBoost was given, even as 0: for those
+	// two, 0 is a setting (plain ranking, no proximity boost), not "unset"
+	diversitySet, proximitySet bool
+}
+
+// rankingJSON is Ranking as written in the config. Diversity and
+// ProximityBoost are left out unless set, so a profile read back keeps
+// inheriting them.
+type rankingJSON struct {
+	rankingFields
+	Diversity      *float64 `json:"diversity,omitempty"`
+	ProximityBoost *float64 `json:"proximity_boost,omitempty"`
+}
+
+type rankingFields Ranking
+
+// MarshalJSON writes r as rankingJSON.
+func (r Ranking) MarshalJSON() ([]byte, error) {
+	out := rankingJSON{rankingFields: rankingFields(r)}
+	if r.diversitySet || r.Diversity != 0 {
+		out.Diversity = &r.Diversity
+	}
+	if r.proximitySet || r.ProximityBoost != 0 {
+		out.ProximityBoost = &r.ProximityBoost
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads r, noting which of Diversity and ProximityBoost
+// were given.
+func (r *Ranking) UnmarshalJSON(data []byte) error {
+	var in rankingJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = Ranking(in.rankingFields)
+	if in.Diversity != nil {
+		r.Diversity, r.diversitySet = *in.Diversity, true
+	}
+	if in.ProximityBoost != nil {
+		r.ProximityBoost, r.proximitySet = *in.ProximityBoost, true
+	}
+	return nil
+}
+
+// DefaultRanking is the tuning UnityMind ships with.
+func DefaultRanking() Ranking {
+	return Ranking{K1: 1.5, B: 0.75, TitleBoost: 2.0, PrefixBoost: 0.7, SubstringBoost: 0.5, Threshold: 0.4, ManualBoost: 1, ScriptRefBoost: 1, CodeBoost: 2.5, Diversity: 0.3, IntentBoost: 1.25, ProximityBoost: 1.5}
+}
+
+// BuiltinProfiles are the named profiles available without any config.
+// Their zero fields inherit from the engine's current ranking (see Over).
+func BuiltinProfiles() map[string]Ranking {
+	return map[string]Ranking{
+		"balanced":   {},
+		"conceptual": {ManualBoost: 1.3},
+		"api":        {ScriptRefBoost: 1.5, TitleBoost: 3.0, Threshold: 0.5},
+	}
+}
+
+// Over returns r with every unset (zero) field taken from base. Diversity
+// and ProximityBoost given as 0 stay 0.
+func (r Ranking) Over(base Ranking) Ranking {
+	pick := func(v, b float64) float64 {
+		if v == 0 {
+			return b
+		}
+		return v
+	}
+	out := Ranking{
+		K1:             pick(r.K1, base.K1),
+		B:              pick(r.B, base.B),
+		TitleBoost:     pick(r.TitleBoost, base.TitleBoost),
+		PrefixBoost:    pick(r.PrefixBoost, base.PrefixBoost),
+		SubstringBoost: pick(r.SubstringBoost, base.SubstringBoost),
+		Threshold:      pick(r.Threshold, base.Threshold),
+		ManualBoost:    pick(r.ManualBoost, base.ManualBoost),
+		ScriptRefBoost: pick(r.ScriptRefBoost, base.ScriptRefBoost),
+		CodeBoost:      pick(r.CodeBoost, base.CodeBoost),
+		Diversity:      pick(r.Diversity, base.Diversity),
+		IntentBoost:    pick(r.IntentBoost, base.IntentBoost),
+		ProximityBoost: pick(r.ProximityBoost, base.ProximityBoost),
+		Language:       pickString(r.Language, base.Language),
+		diversitySet:   r.diversitySet || base.diversitySet,
+		proximitySet:   r.proximitySet || base.proximitySet,
+	}
+	if r.diversitySet {
+		out.Diversity = r.Diversity
+	}
+	if r.proximitySet {
+		out.ProximityBoost = r.ProximityBoost
+	}
+	return out
+}
+
+func pickString(v, b string) string {
+	if v == "" {
+		return b
+	}
+	return v
+}
+
+// ForLanguage prefers pages written in lang, see Ranking.Language. An empty
+// lang leaves r unchanged.
+func (r Ranking) ForLanguage(lang string) Ranking {
+	if lang != "" {
+		r.Language = lang
+	}
+	return r
+}
+
+// speaks reports whether a page in lang is in the preferred language.
+// Untagged pages are English.
+func (r Ranking) speaks(lang string) bool {
+	if lang == "" {
+		lang = "en"
+	}
+	return lang == r.Language
+}
+
+// ForIntent leans the page-type boosts toward what the query wants: API
+// lookups and code requests favour ScriptReference, conceptual "what is"
+// questions favour the Manual. Neither flag leaves r unchanged.
+func (r Ranking) ForIntent(api, conceptual bool) Ranking {
+	r = r.withDefaults()
+	switch {
+	case conceptual:
+		r.ManualBoost *= r.IntentBoost
+	case api:
+		r.ScriptRefBoost *= r.IntentBoost
+	}
+	return r
+}
+
+// withDefaults fills unset or out-of-range fields from DefaultRanking.
+func (r Ranking) withDefaults() Ranking {
+	d := DefaultRanking()
+	if r.K1 <= 0 {
+		r.K1 = d.K1
+	}
+	if r.B < 0 || r.B > 1 {
+		r.B = d.B
+	}
+	if r.TitleBoost < 0 {
+		r.TitleBoost = d.TitleBoost
+	}
+	if r.PrefixBoost <= 0 {
+		r.PrefixBoost = d.PrefixBoost
+	}
+	if r.SubstringBoost <= 0 {
+		r.SubstringBoost = d.SubstringBoost
+	}
+	if r.Threshold <= 0 {
+		r.Threshold = d.Threshold
+	}
+	if r.ManualBoost <= 0 {
+		r.ManualBoost = d.ManualBoost
+	}
+	if r.ScriptRefBoost <= 0 {
+		r.ScriptRefBoost = d.ScriptRefBoost
+	}
+	if r.CodeBoost < 0 {
+		r.CodeBoost = d.CodeBoost
+	}
+	if r.Diversity < 0 || r.Diversity >= 1 {
+		r.Diversity = d.Diversity
+	}
+	if r.IntentBoost <= 0 {
+		r.IntentBoost = d.IntentBoost
+	}
+	if r.ProximityBoost < 0 {
+		r.ProximityBoost = d.ProximityBoost
+	}
+	return r
+}
+
+// typeBoost is the multiplier for a doc based on which part of the docs it's from.
+func (r Ranking) typeBoost(url string) float64 {
+	switch {
+	case strings.Contains(url, "/ScriptReference/"):
+		return r.ScriptRefBoost
+	case strings.Contains(url, "/Manual/"):
+		return r.ManualBoost
+	}
+	return 1
+}
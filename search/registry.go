@@ -0,0 +1,197 @@
+package search
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultIndex is the name of the index used when a request doesn't pick one.
+// It keeps the original cache file so existing installs load unchanged.
+const DefaultIndex = "default"
+
+// Registry holds several independent named engines — e.g. "2021.3",
+// "6000.0", "live" — so answers never mix APIs from different Unity versions.
+type Registry struct {
+	mu      sync.RWMutex
+	dir     string
+	engines map[string]*Engine
+	ranking Ranking
+	budget  int64 // per-index page text budget in bytes, see Engine.SetMemoryBudget
+	keep    int   // automatic snapshots kept per index, see snapshots.go
+}
+
+func NewRegistry(cacheDir string) *Registry {
+	return &Registry{
+		dir:     cacheDir,
+		engines: make(map[string]*Engine),
+		ranking: DefaultRanking(),
+		keep:    DefaultSnapshotRetention,
+	}
+}
+
+// Get returns the named engine, or nil if it hasn't been opened.
+// An empty name means DefaultIndex.
+func (r *Registry) Get(name string) *Engine {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.engines[normalizeIndexName(name)]
+}
+
+// Open returns the named engine, creating it and loading its cache file
+// on first use.
+func (r *Registry) Open(name string) *Engine {
+	name = normalizeIndexName(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.engines[name]; ok {
+		return e
+	}
+	e := NewEngine()
+	e.SetRanking(r.ranking)
+	if r.budget > 0 {
+		e.SetMemoryBudget(r.budget, r.spillPath(name)) // on error the index just stays in RAM
+	}
+	path := r.cachePath(name)
+	if legacy := r.legacyCachePath(name); !fileExists(path) && !r.sharesLegacy(name) {
+		path = legacy // from before file names told such names apart
+	}
+	e.LoadCache(path) // missing cache = empty index
+	e.WarmUp()        // so the first question doesn't pay for the load
+	r.engines[name] = e
+	return e
+}
+
+// SetRanking applies the relevance knobs to every open index and to any opened later.
+func (r *Registry) SetRanking(rk Ranking) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ranking = rk.withDefaults()
+	for _, e := range r.engines {
+		e.SetRanking(r.ranking)
+	}
+}
+
+// SetMemoryBudget caps the page text each index keeps in RAM (0 = no limit),
+// spilling the rest to cache/spill. Applies to open indexes and any opened later.
+func (r *Registry) SetMemoryBudget(maxBytes int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budget = maxBytes
+	for name, e := range r.engines {
+		if err := e.SetMemoryBudget(maxBytes, r.spillPath(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names lists the open indexes, default first, then alphabetically.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.engines))
+	for n := range r.engines {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == DefaultIndex || names[j] == DefaultIndex {
+			return names[i] == DefaultIndex
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// CachePath is where the named index is persisted.
+func (r *Registry) CachePath(name string) string {
+	return r.cachePath(normalizeIndexName(name))
+}
+
+// Save persists one index to its cache file.
+func (r *Registry) Save(name string) error {
+	e := r.Get(name)
+	if e == nil {
+		return nil
+	}
+	return e.SaveCache(r.CachePath(name))
+}
+
+// SnapshotPath is where a snapshot of the named index written under
+// snapshot is kept, or the index's default snapshot if snapshot is empty.
+// Named snapshots sit in the index's own folder, so two indexes can use the
+// same name.
+func (r *Registry) SnapshotPath(index, snapshot string) string {
+	index = normalizeIndexName(index)
+	if snapshot == "" {
+		return filepath.Join(r.dir, "snapshots", indexFileName(index)+".json")
+	}
+	return filepath.Join(r.snapshotDir(index), "named", snapshot+".json")
+}
+
+func (r *Registry) spillPath(name string) string {
+	return filepath.Join(r.dir, "spill", indexFileName(name)+".spill")
+}
+
+func (r *Registry) cachePath(name string) string {
+	if name == DefaultIndex {
+		return filepath.Join(r.dir, "docs_index.json")
+	}
+	return filepath.Join(r.dir, "index_"+indexFileName(name)+".json")
+}
+
+// legacyCachePath is where the named index was persisted when file names
+// were only made file-safe, and "Unity", "unity" and "unity!" shared one.
+func (r *Registry) legacyCachePath(name string) string {
+	if name == DefaultIndex {
+		return r.cachePath(name)
+	}
+	return filepath.Join(r.dir, "index_"+fileSafe(name)+".json")
+}
+
+// sharesLegacy reports whether an open index already owns the legacy cache
+// file name would load, so two indexes don't both start from it.
+func (r *Registry) sharesLegacy(name string) bool {
+	for other := range r.engines {
+		if other != name && fileSafe(other) == fileSafe(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeIndexName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return DefaultIndex
+	}
+	return name
+}
+
+// indexFileName is the named index's part of its file names: fileSafe, and
+// for a name fileSafe changes a hash of it, so indexes whose names differ
+// only in case or punctuation ("Unity", "unity"; "2021.3 offline",
+// "2021.3_offline") don't share files. Names that are already file-safe
+// keep the file names they always had.
+func indexFileName(name string) string {
+	if fileSafe(name) == name {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("%s-%08x", fileSafe(name), h.Sum32())
+}
+
+// fileSafe maps an index name like "2021.3 offline" to "2021.3_offline".
+func fileSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+}
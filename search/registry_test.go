@@ -0,0 +1,42 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexFileNamesDontCollide(t *testing.T) {
+	names := []string{"2021.3 offline", "2021.3_offline", "2021.3/offline", "Unity", "unity"}
+	seen := map[string]string{}
+	for _, name := range names {
+		f := indexFileName(name)
+		if other, ok := seen[f]; ok {
+			t.Errorf("%q and %q share the file name %q", other, name, f)
+		}
+		seen[f] = name
+	}
+	if got := indexFileName("2021.3_offline"); got != "2021.3_offline" {
+		t.Errorf("file-safe name became %q, want it unchanged", got)
+	}
+}
+
+func TestRegistryLoadsLegacyCache(t *testing.T) {
+	dir := t.TempDir()
+	old := NewEngine()
+	old.AddResults([]Result{{Title: "Rigidbody", URL: "https://docs.unity3d.com/ScriptReference/Rigidbody.html", Excerpt: "Physics body."}})
+	if err := old.SaveCache(filepath.Join(dir, "index_2021.3_offline.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry(dir)
+	if n := r.Open("2021.3 offline").DocCount(); n != 1 {
+		t.Fatalf("opened with %d docs, want the 1 in its old cache file", n)
+	}
+	if err := r.Save("2021.3 offline"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(r.CachePath("2021.3 offline")); err != nil {
+		t.Errorf("not saved under its own file name: %v", err)
+	}
+}
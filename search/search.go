@@ -1,21 +1,45 @@
 package search
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"math"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Doc is a single indexed Unity documentation page
 type Doc struct {
-	ID      string `json:"id"`
-	Title   string `json:"title"`
-	URL     string `json:"url"`
-	Content string `json:"content"`
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Content string   `json:"content"`
 	Tags    []string `json:"tags"`
+	Source  string   `json:"source,omitempty"`  // "live", "offline", ...
+	Touched int64    `json:"touched,omitempty"` // unix seconds of the last add/refresh
+	Hash    string   `json:"hash,omitempty"`    // content fingerprint, see contentHash
+	Version string   `json:"version,omitempty"` // Unity version folded out of the URL, see CanonicalURL
+	Code    string   `json:"code,omitempty"`    // text of the page's <pre>/code samples
+	Lang    string   `json:"lang,omitempty"`    // documentation language, "" = English, see Ranking.Language
+	Label   string   `json:"label,omitempty"`   // name of the doc source it came from, shown with its links
+	Related []Link   `json:"related,omitempty"` // pages it names as related ("See also")
+	Aliases []string `json:"aliases,omitempty"` // other URLs whose content deduplicated into this doc
+
+	// Where Content and Code live while spilled to disk, see spill.go
+	spilled                 bool
+	spillOff                int64
+	spillContent, spillCode int
 }
 
 // Result is a ranked search hit
@@ -24,82 +48,364 @@ type Result struct {
 	URL     string
 	Excerpt string
 	Score   float64
+	Source  string
+	Code    string   // code samples, set by the indexers
+	Tags    []string // indexed with the page, e.g. the package it documents
+	Version string   // Unity version the page documents, "" if unknown
+	Lang    string   // language the page is written in, "" = English
+	Label   string   // doc source it came from, e.g. "Team wiki"; "" for Unity's docs
+	Related []Link   // pages it names as related, set by the offline indexer
+}
+
+// Link is a page a doc page links to, with the link's text.
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
 }
 
-// Engine is the local search engine (in-memory, zero deps)
+// Engine is the local search engine (in-memory, zero deps). Searches read
+// the current view without locking; see view.go.
 type Engine struct {
-	mu   sync.RWMutex
-	docs []Doc
-	// inverted index: token → []doc indices
-	index map[string][]int
+	mu      sync.Mutex // serializes writers
+	cur     atomic.Pointer[view]
+	ranking atomic.Pointer[Ranking]
+	// memory budget for page text, see spill.go; guarded by mu
+	budget int64
 }
 
 func NewEngine() *Engine {
-	return &Engine{
-		docs:  make([]Doc, 0, 500),
-		index: make(map[string][]int),
-	}
+	e := &Engine{}
+	e.cur.Store(newView())
+	e.SetRanking(DefaultRanking())
+	return e
+}
+
+// SetRanking replaces the relevance knobs; unset fields fall back to defaults.
+func (e *Engine) SetRanking(r Ranking) {
+	r = r.withDefaults()
+	e.ranking.Store(&r)
+}
+
+// Ranking returns the relevance knobs currently in use.
+func (e *Engine) Ranking() Ranking {
+	return *e.ranking.Load()
 }
 
 // DocCount returns how many docs are indexed
 func (e *Engine) DocCount() int {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-	return len(e.docs)
+	return len(e.cur.Load().docs)
 }
 
-// tokenize splits text into lowercase tokens, removes stop words
+// tokenize splits text into lowercase tokens, removes stop words.
+// CamelCase identifiers also yield their sub-words, so "OnCollisionEnter2D"
+// indexes as "oncollisionenter2d", "collision", "enter" and "2d".
 func tokenize(text string) []string {
-	stopWords := map[string]bool{
-		"the": true, "a": true, "an": true, "is": true, "in": true,
-		"to": true, "of": true, "and": true, "or": true, "for": true,
-		"on": true, "with": true, "this": true, "that": true, "it": true,
-		"be": true, "as": true, "at": true, "by": true, "we": true,
-		"how": true, "do": true, "i": true, "you": true, "can": true,
-		"what": true, "from": true, "are": true, "use": true, "used": true,
+	return tokenizeWith(text, stopWords.Load().(map[string]bool))
+}
+
+// tokenizeQuery is tokenize for search input: identifier-heavy queries keep
+// their stop words, see IdentifierHeavy.
+func tokenizeQuery(query string) []string {
+	if IdentifierHeavy(query) {
+		return tokenizeWith(query, nil)
 	}
+	return tokenize(query)
+}
+
+func tokenizeWith(text string, stopWords map[string]bool) []string {
 	var tokens []string
+	emit := func(word string) {
+		if len(word) < 2 {
+			return
+		}
+		tok := strings.ToLower(word)
+		if !stopWords[tok] {
+			tokens = append(tokens, tok)
+		}
+		if parts := camelParts(word); len(parts) > 1 {
+			for _, p := range parts {
+				if sub := strings.ToLower(p); len(sub) >= 2 && !stopWords[sub] {
+					tokens = append(tokens, sub)
+				}
+			}
+		}
+	}
 	var current strings.Builder
-	for _, r := range strings.ToLower(text) {
+	var cjk []rune
+	for _, r := range text {
+		if isCJK(r) {
+			emit(current.String())
+			current.Reset()
+			cjk = append(cjk, r)
+			continue
+		}
+		if len(cjk) > 0 {
+			tokens = append(tokens, cjkBigrams(cjk)...)
+			cjk = cjk[:0]
+		}
 		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			current.WriteRune(r)
+			current.WriteRune(foldAccent(r))
 		} else {
-			if current.Len() >= 2 {
-				tok := current.String()
-				if !stopWords[tok] {
-					tokens = append(tokens, tok)
-				}
-			}
+			emit(current.String())
 			current.Reset()
 		}
 	}
-	if current.Len() >= 2 {
-		tok := current.String()
-		if !stopWords[tok] {
-			tokens = append(tokens, tok)
+	emit(current.String())
+	return append(tokens, cjkBigrams(cjk)...)
+}
+
+// isCJK reports whether r is Chinese or Japanese script, which is written
+// without spaces between words. (Korean is, and is tokenized like English.)
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// cjkBigrams indexes a run of CJK text as its overlapping character pairs,
+// the usual stand-in for a word splitter: "物理演算" → 物理, 理演, 演算.
+func cjkBigrams(run []rune) []string {
+	if len(run) == 1 {
+		return []string{string(run)}
+	}
+	grams := make([]string, 0, len(run))
+	for i := 0; i+1 < len(run); i++ {
+		grams = append(grams, string(run[i:i+2]))
+	}
+	return grams
+}
+
+// accentFolds maps accented Latin letters to their base letter, so Spanish
+// docs match queries typed with or without accents ("animación", "animacion").
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i', 'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u', 'ñ': 'n', 'ç': 'c',
+	'Á': 'A', 'É': 'E', 'Í': 'I', 'Ó': 'O', 'Ú': 'U', 'Ü': 'U', 'Ñ': 'N',
+}
+
+func foldAccent(r rune) rune {
+	if f, ok := accentFolds[r]; ok {
+		return f
+	}
+	return r
+}
+
+// camelParts splits an identifier at case and digit boundaries:
+// "Rigidbody2D" → Rigidbody, 2D; "UIButton" → UI, Button; "GetComponent" → Get, Component.
+// A digit run keeps the capitals that follow it, since "2D"/"3D" are one word in Unity.
+func camelParts(word string) []string {
+	rs := []rune(word)
+	var parts []string
+	start := 0
+	for i := 1; i < len(rs); i++ {
+		prev, cur := rs[i-1], rs[i]
+		split := false
+		switch {
+		case unicode.IsLower(prev) && unicode.IsUpper(cur):
+			split = true // camelCase
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(rs) && unicode.IsLower(rs[i+1]):
+			split = true // UIButton: split before the last capital of a run
+		case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+			split = true // Vector3, Rigidbody2D
+		case unicode.IsDigit(prev) && unicode.IsLower(cur):
+			split = true // 2d stays attached only when capitalised
+		case unicode.IsDigit(prev) && unicode.IsUpper(cur) && i+1 < len(rs) && unicode.IsLower(rs[i+1]):
+			split = true // Physics2DRaycast → Physics, 2D, Raycast
+		}
+		if split {
+			parts = append(parts, string(rs[start:i]))
+			start = i
 		}
 	}
-	return tokens
+	return append(parts, string(rs[start:]))
 }
 
 // AddDoc indexes a single document
 func (e *Engine) AddDoc(doc Doc) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	e.AddDocs([]Doc{doc})
+}
+
+// AddDocs indexes docs as one update: searches see all of them or none.
+// Bulk loads should use it rather than AddDoc in a loop, which copies the
+// view once per doc.
+func (e *Engine) AddDocs(docs []Doc) {
+	if len(docs) == 0 {
+		return
+	}
+	e.update(func(d *view) bool {
+		for _, doc := range docs {
+			d.add(doc)
+		}
+		return true
+	})
+}
+
+// add indexes doc into draft d.
+func (d *view) add(doc Doc) {
+	if doc.Touched == 0 {
+		doc.Touched = time.Now().Unix()
+	}
+	canon, version := CanonicalURL(doc.URL)
+	if doc.ID == doc.URL {
+		doc.ID = canon
+	}
+	doc.URL = canon
+	if version != "" {
+		doc.Version = version
+	}
+	doc.Hash = contentHash(doc.Content)
 	// Deduplicate by URL
-	for i, d := range e.docs {
-		if d.URL == doc.URL {
-			e.docs[i] = doc
-			e.reindexDoc(i, doc)
+	for i, old := range d.docs {
+		if old.URL == doc.URL {
+			full, _ := d.hydrate(old)
+			d.own(ownHash)
+			if k := dedupKey(full); k != "" && d.byHash[k] == i {
+				delete(d.byHash, k)
+			}
+			d.resident += textSize(doc) - textSize(old)
+			d.unindexDoc(i, full)
+			doc.Aliases = old.Aliases
+			d.docs[i] = doc
+			if k := dedupKey(doc); k != "" {
+				d.byHash[k] = i
+			}
+			d.reindexDoc(i, doc)
 			return
 		}
+		if hasAlias(old, doc.URL) {
+			if old.Hash == doc.Hash {
+				d.docs[i].Touched = doc.Touched
+				return
+			}
+			// The page no longer matches the doc it was folded into
+			d.docs[i].Aliases = dropAlias(old.Aliases, doc.URL)
+			break
+		}
+	}
+	// Deduplicate by content: keep the doc we already have, just refresh it
+	// and remember doc's URL, so Page, Pages and RemoveDoc still find it
+	key := dedupKey(doc)
+	if i, ok := d.byHash[key]; ok && key != "" {
+		d.docs[i].Touched = doc.Touched
+		d.docs[i].Tags = mergeTags(d.docs[i].Tags, doc.Tags)
+		d.docs[i].Aliases = append(d.docs[i].Aliases[:len(d.docs[i].Aliases):len(d.docs[i].Aliases)], doc.URL)
+		return
+	}
+	idx := len(d.docs)
+	d.docs = append(d.docs, doc)
+	d.resident += textSize(doc)
+	if key != "" {
+		d.own(ownHash)
+		d.byHash[key] = idx
+	}
+	d.reindexDoc(idx, doc)
+}
+
+// minDedupWords is the least text a doc needs before another with the same
+// text is folded into it; shorter ones ("See also", empty sections) say too
+// little to be the same page.
+const minDedupWords = 30
+
+// dedupKey is what doc (with its text, hydrated if spilled) is
+// deduplicated by: its content hash, scoped to its page if it's a section
+// of one, since sections that read alike on different pages (boilerplate)
+// are still different sections. "" means it isn't deduplicated by content.
+func dedupKey(doc Doc) string {
+	if len(strings.Fields(doc.Content)) < minDedupWords {
+		return ""
+	}
+	if strings.Contains(doc.URL, "#") {
+		return doc.Hash + " " + PageURL(doc.URL)
+	}
+	return doc.Hash
+}
+
+func hasAlias(d Doc, u string) bool {
+	for _, a := range d.Aliases {
+		if a == u {
+			return true
+		}
 	}
-	idx := len(e.docs)
-	e.docs = append(e.docs, doc)
-	e.reindexDoc(idx, doc)
+	return false
 }
 
-func (e *Engine) reindexDoc(idx int, doc Doc) {
+func dropAlias(aliases []string, u string) []string {
+	var kept []string
+	for _, a := range aliases {
+		if a != u {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// contentHash fingerprints a page's text, ignoring case and whitespace
+// differences so re-extracted copies of the same page still match.
+func contentHash(content string) string {
+	h := fnv.New64a()
+	for _, f := range strings.Fields(strings.ToLower(content)) {
+		h.Write([]byte(f))
+		h.Write([]byte{' '})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// TrailSep joins a doc page's breadcrumb trail into one of its tags:
+// "Physics > 2D > Rigidbody 2D".
+const TrailSep = " > "
+
+// Topic is the area of the docs a page with these tags belongs to: the
+// first step of its breadcrumb trail, "" if it has none.
+func Topic(tags []string) string {
+	for _, t := range tags {
+		if i := strings.Index(t, TrailSep); i > 0 {
+			return t[:i]
+		}
+	}
+	return ""
+}
+
+// Trail is the breadcrumb trail of a page with these tags, its first step
+// (Topic) first; nil if it has none.
+func Trail(tags []string) []string {
+	for _, t := range tags {
+		if strings.Contains(t, TrailSep) {
+			return strings.Split(t, TrailSep)
+		}
+	}
+	return nil
+}
+
+func mergeTags(a, b []string) []string {
+	for _, t := range b {
+		found := false
+		for _, have := range a {
+			if have == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, t)
+		}
+	}
+	return a
+}
+
+// reindexDoc adds the postings for doc, stored at idx, to draft d. doc must
+// carry its page text (hydrated if spilled).
+func (d *view) reindexDoc(idx int, doc Doc) {
+	d.own(ownIndex)
+	body := tokenize(doc.Content + " " + doc.Title)
+	tf := make(map[string]int, len(body))
+	for _, tok := range body {
+		tf[tok]++
+	}
+	for len(d.lens) <= idx {
+		d.lens = append(d.lens, 0)
+	}
+	d.lenSum += len(body) - d.lens[idx]
+	d.lens[idx] = len(body)
+
 	combined := doc.Title + " " + doc.Content + " " + strings.Join(doc.Tags, " ")
 	tokens := tokenize(combined)
 	seen := map[string]bool{}
@@ -108,79 +414,374 @@ func (e *Engine) reindexDoc(idx int, doc Doc) {
 			continue
 		}
 		seen[tok] = true
-		e.index[tok] = append(e.index[tok], idx)
+		if _, ok := d.index[tok]; !ok && d.terms.Load() != nil {
+			d.newTerms = append(d.newTerms, tok)
+		}
+		d.index[tok] = append(d.index[tok], idx)
+		d.freq[tok] = append(d.freq[tok], tf[tok])
+	}
+	if doc.Code != "" {
+		d.own(ownCode)
+	}
+	seen = map[string]bool{}
+	for _, tok := range tokenize(doc.Code) {
+		if !seen[tok] {
+			seen[tok] = true
+			d.code[tok] = append(d.code[tok], idx)
+		}
+	}
+	d.addTrigrams(doc.Title)
+	d.addSymbol(doc)
+}
+
+// unindexDoc drops the postings of old, stored at idx, from draft d before
+// the doc is reindexed with new text; otherwise it would keep matching words
+// it no longer contains. Posting slices are shared with older views, so each
+// one touched is copied rather than edited in place.
+func (d *view) unindexDoc(idx int, old Doc) {
+	d.own(ownIndex | ownCode)
+	d.removeSymbol(old)
+	seen := map[string]bool{}
+	for _, tok := range tokenize(old.Title + " " + old.Content + " " + strings.Join(old.Tags, " ")) {
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		postings, freqs := d.index[tok], d.freq[tok]
+		var keptP, keptF []int
+		for j, p := range postings {
+			if p != idx {
+				keptP = append(keptP, p)
+				keptF = append(keptF, freqs[j])
+			}
+		}
+		if len(keptP) == 0 {
+			delete(d.index, tok)
+			delete(d.freq, tok)
+			d.goneTerms = append(d.goneTerms, tok)
+			continue
+		}
+		d.index[tok], d.freq[tok] = keptP, keptF
+	}
+	seen = map[string]bool{}
+	for _, tok := range tokenize(old.Code) {
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		var kept []int
+		for _, p := range d.code[tok] {
+			if p != idx {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(d.code, tok)
+			continue
+		}
+		d.code[tok] = kept
 	}
 }
 
 // AddResults adds multiple search results to the index
 func (e *Engine) AddResults(results []Result) {
-	for _, r := range results {
-		e.AddDoc(Doc{
+	docs := make([]Doc, len(results))
+	for i, r := range results {
+		docs[i] = Doc{
 			ID:      r.URL,
 			Title:   r.Title,
 			URL:     r.URL,
 			Content: r.Excerpt,
-		})
+			Source:  r.Source,
+			Code:    r.Code,
+			Tags:    r.Tags,
+			Version: r.Version,
+			Lang:    r.Lang,
+			Label:   r.Label,
+			Related: r.Related,
+		}
 	}
+	e.AddDocs(docs)
 }
 
-// Search finds the top-k most relevant docs for a query
-func (e *Engine) Search(query string, topK int) []Result {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+// Page returns the indexed sections of the page at url, in index order,
+// with their text; nil if it isn't indexed.
+func (e *Engine) Page(url string) []Result {
+	url, _ = CanonicalURL(url)
+	url = PageURL(url)
+	v := e.cur.Load()
+	var chunks []Result
+	for _, d := range v.docs {
+		at := d.URL
+		if PageURL(at) != url {
+			if at = pageAlias(d, url); at == "" {
+				continue
+			}
+		}
+		full, _ := v.hydrate(d)
+		chunks = append(chunks, Result{Title: full.Title, URL: at, Excerpt: full.Content, Source: full.Source, Code: full.Code, Tags: full.Tags, Version: full.Version, Lang: full.Lang, Label: full.Label, Related: full.Related})
+	}
+	return chunks
+}
 
-	if len(e.docs) == 0 {
-		return nil
+// Pages returns the pages the index holds, see PageURL.
+func (e *Engine) Pages() PageSet {
+	v := e.cur.Load()
+	pages := make(PageSet, len(v.docs))
+	for _, d := range v.docs {
+		pages[PageURL(d.URL)] = true
+		for _, a := range d.Aliases {
+			pages[PageURL(a)] = true
+		}
 	}
+	return pages
+}
 
-	tokens := tokenize(query)
-	if len(tokens) == 0 {
-		return nil
+// pageAlias is the alias of d on the page at url, "" if it has none.
+func pageAlias(d Doc, url string) string {
+	for _, a := range d.Aliases {
+		if PageURL(a) == url {
+			return a
+		}
 	}
+	return ""
+}
 
-	// BM25-lite scoring
-	scores := make(map[int]float64)
-	N := float64(len(e.docs))
-	avgLen := e.avgDocLen()
-	k1 := 1.5
-	b := 0.75
+// RemoveDoc drops the doc with the given URL. Returns false if it wasn't indexed.
+func (e *Engine) RemoveDoc(url string) bool {
+	return e.RemoveDocs([]string{url}) > 0
+}
 
-	for _, tok := range tokens {
-		// Exact match
-		e.scoreToken(tok, tokens, scores, N, avgLen, k1, b, 1.0)
-		// Prefix match (partial)
-		for indexedTok := range e.index {
-			if indexedTok != tok && strings.HasPrefix(indexedTok, tok) && len(tok) >= 3 {
-				e.scoreToken(indexedTok, tokens, scores, N, avgLen, k1, b, 0.7)
+// RemoveDocs drops the docs with the given URLs in one update, rebuilding
+// the index once rather than once per URL. Returns how many were removed.
+func (e *Engine) RemoveDocs(urls []string) int {
+	if len(urls) == 0 {
+		return 0
+	}
+	drop := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		canon, _ := CanonicalURL(u)
+		drop[canon] = true
+	}
+	// A page's sections (page.html#anchor) go with it
+	gone := func(u string) bool { return drop[u] || drop[PageURL(u)] }
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cur := e.cur.Load()
+	// A URL that's only an alias just loses it; a doc whose own URL goes
+	// but has aliases lives on under the first of them
+	var kept []Doc
+	removed := 0
+	for _, d := range cur.docs {
+		aliases := d.Aliases
+		for _, a := range d.Aliases {
+			if gone(a) {
+				aliases = dropAlias(aliases, a)
+				removed++
+			}
+		}
+		if gone(d.URL) {
+			removed++
+			if len(aliases) == 0 {
+				continue
 			}
+			if d.ID == d.URL {
+				d.ID = aliases[0]
+			}
+			d.URL, aliases = aliases[0], aliases[1:]
 		}
+		d.Aliases = aliases
+		kept = append(kept, d)
+	}
+	if removed > 0 {
+		e.rebuild(cur, kept)
 	}
+	return removed
+}
 
-	// Boost score if title contains query tokens
-	for idx, doc := range e.docs {
-		titleLower := strings.ToLower(doc.Title)
-		for _, tok := range tokens {
-			if strings.Contains(titleLower, tok) {
-				scores[idx] += 2.0
-			}
+// Prune drops docs not touched within maxAge. An empty source prunes
+// every doc, otherwise only docs from that source are considered.
+// Returns how many docs were removed.
+func (e *Engine) Prune(maxAge time.Duration, source string) int {
+	cutoff := time.Now().Add(-maxAge).Unix()
+	return e.removeWhere(func(d Doc) bool {
+		return (source == "" || d.Source == source) && d.Touched < cutoff
+	})
+}
+
+// SourceCount is how many docs came from the given source.
+func (e *Engine) SourceCount(source string) int {
+	n := 0
+	for _, d := range e.cur.Load().docs {
+		if d.Source == source {
+			n++
 		}
 	}
+	return n
+}
+
+// DropSource removes every doc from the given source, e.g. the bundled
+// starter docs once a real index is in place. Returns how many were removed.
+func (e *Engine) DropSource(source string) int {
+	return e.removeWhere(func(d Doc) bool { return d.Source == source })
+}
 
-	// Collect and sort
-	type scoredDoc struct {
-		idx   int
-		score float64
+// removeWhere deletes matching docs and rebuilds the inverted index,
+// since postings hold slice positions that shift on removal.
+func (e *Engine) removeWhere(match func(Doc) bool) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cur := e.cur.Load()
+	var kept []Doc
+	for _, d := range cur.docs {
+		if !match(d) {
+			kept = append(kept, d)
+		}
 	}
-	var ranked []scoredDoc
-	for idx, score := range scores {
-		ranked = append(ranked, scoredDoc{idx, score})
+	removed := len(cur.docs) - len(kept)
+	if removed > 0 {
+		e.rebuild(cur, kept)
 	}
-	// Simple insertion sort (small N, low memory)
-	for i := 1; i < len(ranked); i++ {
-		for j := i; j > 0 && ranked[j].score > ranked[j-1].score; j-- {
-			ranked[j], ranked[j-1] = ranked[j-1], ranked[j]
+	return removed
+}
+
+// rebuild publishes a view of just docs, taken from cur, indexed afresh.
+// e.mu must be held.
+func (e *Engine) rebuild(cur *view, docs []Doc) {
+	fresh := newView()
+	fresh.spill = cur.spill
+	for _, d := range docs {
+		idx := len(fresh.docs)
+		fresh.docs = append(fresh.docs, d)
+		fresh.resident += textSize(d)
+		full, _ := cur.hydrate(d) // unreadable text: index what's in memory
+		if k := dedupKey(full); k != "" {
+			fresh.byHash[k] = idx
 		}
+		fresh.reindexDoc(idx, full)
 	}
+	e.publish(fresh)
+}
+
+// Search finds the top-k most relevant docs for a query
+func (e *Engine) Search(query string, topK int) []Result {
+	return e.SearchWith(query, topK, e.Ranking())
+}
+
+// SearchWith is Search using a specific ranking profile instead of the engine's own.
+func (e *Engine) SearchWith(query string, topK int, rk Ranking) []Result {
+	return e.search(query, topK, rk, false, nil)
+}
+
+// SearchCode is SearchWith for code requests ("example of PlayOneShot"):
+// query tokens found in a page's code samples add rk.CodeBoost, so pages
+// that actually show the API in use rank above ones that only describe it.
+func (e *Engine) SearchCode(query string, topK int, rk Ranking) []Result {
+	return e.search(query, topK, rk, true, nil)
+}
+
+// SearchPage returns hits offset..offset+limit-1 of the ranking SearchWith
+// (or SearchCode, with code set) would produce, and whether more follow.
+// Scores stay normalized against the best hit overall, so pages line up.
+// Pages in hide are left out before ranking, so they don't use up hits.
+func (e *Engine) SearchPage(query string, offset, limit int, rk Ranking, code bool, hide PageSet) ([]Result, bool) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		return nil, false
+	}
+	// Past the last doc there's nothing to rank, and offset+limit can't overflow
+	if n := e.DocCount(); offset > n {
+		offset = n
+	}
+	limit = min(limit, e.DocCount()+1)
+	// One extra hit tells us whether there's another page
+	all := e.search(query, offset+limit+1, rk, code, hide)
+	if offset >= len(all) {
+		return nil, false
+	}
+	all = all[offset:]
+	if len(all) > limit {
+		return all[:limit], true
+	}
+	return all, false
+}
+
+func (e *Engine) search(query string, topK int, rk Ranking, wantCode bool, hide PageSet) []Result {
+	rk = rk.withDefaults()
+	// Each view has its own cache, so results can never outlive their docs
+	v := e.cur.Load()
+	if topK <= 0 {
+		return nil
+	}
+	topK = min(topK, len(v.docs))
+	key := queryKey{normalizeQuery(query), topK, rk, wantCode, hide.key()}
+	if results, ok := v.cache.get(key); ok {
+		return results
+	}
+	results := v.score(query, topK, rk, wantCode, hide)
+	v.cache.put(key, results)
+	return results
+}
+
+// score ranks every doc in v against the query.
+func (v *view) score(query string, topK int, rk Ranking, wantCode bool, hide PageSet) []Result {
+
+	// The shards slice their hits to topK in goroutines of their own, where
+	// a bad bound would take the process down rather than the request
+	if len(v.docs) == 0 || topK <= 0 {
+		return nil
+	}
+	topK = min(topK, len(v.docs))
+
+	tokens := tokenizeQuery(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// Expand query tokens into the index terms to score, once for all shards
+	terms := v.expandQuery(tokens, rk)
+	N := float64(len(v.docs))
+	avgLen := v.avgDocLen()
+
+	// Score shards concurrently; each returns its own top-k, merged below
+	shards := v.shardCount()
+	shardK := topK
+	if rk.Diversity > 0 || rk.ProximityBoost > 0 {
+		shardK = topK * mmrPool // candidates for proximity and diversify
+	}
+	rankShards := func(rk Ranking) []scoredDoc {
+		tops := make([][]scoredDoc, shards)
+		per := (len(v.docs) + shards - 1) / shards
+		var wg sync.WaitGroup
+		for s := 0; s < shards; s++ {
+			lo, hi := s*per, (s+1)*per
+			if hi > len(v.docs) {
+				hi = len(v.docs)
+			}
+			wg.Add(1)
+			go func(s, lo, hi int) {
+				defer wg.Done()
+				tops[s] = v.scoreShard(lo, hi, tokens, terms, N, avgLen, rk, wantCode, hide, shardK)
+			}(s, lo, hi)
+		}
+		wg.Wait()
+
+		var ranked []scoredDoc
+		for _, t := range tops {
+			ranked = append(ranked, t...)
+		}
+		return ranked
+	}
+	ranked := rankShards(rk)
+	if len(ranked) == 0 && rk.Language != "" {
+		rk.Language = "" // nothing in the preferred language: any will do
+		ranked = rankShards(rk)
+	}
+	sortScored(ranked)
+	ranked = v.rerankProximity(ranked, tokens, topK*mmrPool, rk.ProximityBoost)
+	ranked = v.diversify(ranked, topK, rk.Diversity)
 
 	// Build results
 	results := make([]Result, 0, topK)
@@ -192,7 +793,7 @@ func (e *Engine) Search(query string, topK int) []Result {
 		if i >= topK {
 			break
 		}
-		doc := e.docs[sd.idx]
+		doc, _ := v.hydrate(v.docs[sd.idx])
 		normalizedScore := 0.0
 		if maxScore > 0 {
 			normalizedScore = sd.score / maxScore
@@ -202,51 +803,370 @@ func (e *Engine) Search(query string, topK int) []Result {
 			URL:     doc.URL,
 			Excerpt: extractExcerpt(doc.Content, tokens, 300),
 			Score:   normalizedScore,
+			Source:  doc.Source,
+			Version: doc.Version,
+			Lang:    doc.Lang,
+			Label:   doc.Label,
+			Tags:    doc.Tags,
+			Related: doc.Related,
 		})
 	}
 	return results
 }
 
-func (e *Engine) scoreToken(tok string, queryTokens []string, scores map[int]float64, N, avgLen, k1, b, boost float64) {
-	postings, ok := e.index[tok]
+// TermCount is an indexed term and how many docs contain it
+type TermCount struct {
+	Term string `json:"term"`
+	Docs int    `json:"docs"`
+}
+
+// SourceStats summarizes the docs that came from one source
+type SourceStats struct {
+	Docs        int   `json:"docs"`
+	LastUpdated int64 `json:"last_updated"` // unix seconds, newest Touched
+}
+
+// Stats describes what the index actually holds
+type Stats struct {
+	Docs        int                    `json:"docs"`
+	Terms       int                    `json:"terms"`
+	Postings    int                    `json:"postings"`
+	MemoryBytes int64                  `json:"memory_bytes"` // rough estimate of docs + inverted index
+	SpilledDocs int                    `json:"spilled_docs"` // docs whose text is on disk, see SetMemoryBudget
+	LastUpdated int64                  `json:"last_updated"`
+	Sources     map[string]SourceStats `json:"sources"`
+	TopTerms    []TermCount            `json:"top_terms"`
+}
+
+// Stats reports doc counts per source, index size and the topN most common terms.
+// Docs without a source (older caches) are counted under "unknown".
+func (e *Engine) Stats(topN int) Stats {
+	v := e.cur.Load()
+	st := Stats{Docs: len(v.docs), Terms: len(v.index), Sources: map[string]SourceStats{}}
+	for _, d := range v.docs {
+		src := d.Source
+		if src == "" {
+			src = "unknown"
+		}
+		ss := st.Sources[src]
+		ss.Docs++
+		if d.Touched > ss.LastUpdated {
+			ss.LastUpdated = d.Touched
+		}
+		st.Sources[src] = ss
+		if d.Touched > st.LastUpdated {
+			st.LastUpdated = d.Touched
+		}
+		st.MemoryBytes += int64(len(d.ID) + len(d.Title) + len(d.URL) + len(d.Content) + len(d.Code) + len(d.Source))
+		if d.spilled {
+			st.SpilledDocs++
+		}
+		for _, t := range d.Tags {
+			st.MemoryBytes += int64(len(t))
+		}
+	}
+
+	top := make([]TermCount, 0, len(v.index))
+	for tok, postings := range v.index {
+		st.Postings += len(postings)
+		// key + slice header + one int per posting, same again for freq
+		st.MemoryBytes += int64(len(tok) + 24 + 8*cap(postings) + 24 + 8*cap(v.freq[tok]))
+		top = append(top, TermCount{Term: tok, Docs: len(postings)})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Docs != top[j].Docs {
+			return top[i].Docs > top[j].Docs
+		}
+		return top[i].Term < top[j].Term
+	})
+	if len(top) > max(topN, 0) {
+		top = top[:max(topN, 0)]
+	}
+	st.TopTerms = top
+	return st
+}
+
+// Suggestions are autocomplete candidates for a partially typed query
+type Suggestions struct {
+	Titles []string `json:"titles"`
+	Terms  []string `json:"terms"`
+}
+
+// Suggest returns up to limit doc titles and indexed terms starting with prefix.
+// Terms are ordered by how many docs contain them, so common API names come first.
+func (e *Engine) Suggest(prefix string, limit int) Suggestions {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	out := Suggestions{Titles: []string{}, Terms: []string{}}
+	if prefix == "" || limit <= 0 {
+		return out
+	}
+
+	v := e.cur.Load()
+
+	// Terms: binary search to the first match, then walk while the prefix holds
+	var matches []string
+	terms := v.sortedTerms()
+	for i := sort.SearchStrings(terms, prefix); i < len(terms) && strings.HasPrefix(terms[i], prefix); i++ {
+		matches = append(matches, terms[i])
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(v.index[matches[i]]) > len(v.index[matches[j]])
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	out.Terms = matches
+
+	// Titles: whole title or any word in it starts with the prefix
+	seen := map[string]bool{}
+	for _, doc := range v.docs {
+		if len(out.Titles) >= limit {
+			break
+		}
+		lower := strings.ToLower(doc.Title)
+		if seen[lower] {
+			continue
+		}
+		hit := strings.HasPrefix(lower, prefix)
+		for _, w := range strings.Fields(lower) {
+			if hit {
+				break
+			}
+			hit = strings.HasPrefix(w, prefix)
+		}
+		if hit {
+			seen[lower] = true
+			out.Titles = append(out.Titles, doc.Title)
+		}
+	}
+	return out
+}
+
+// DidYouMean rewrites query with each unknown word replaced by the closest
+// indexed term (edit distance ≤ 2, most common term wins ties).
+// Returns "" when every word is already indexed or nothing close exists.
+func (e *Engine) DidYouMean(query string) string {
+	v := e.cur.Load()
+
+	words := strings.Fields(query)
+	changed := false
+	for i, w := range words {
+		tok := strings.ToLower(strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }))
+		if len(tok) < 3 || len(tokenize(tok)) == 0 {
+			continue // too short to correct, or a stop word
+		}
+		if _, ok := v.index[tok]; ok {
+			continue
+		}
+		if best := v.closestTerm(tok); best != "" {
+			words[i] = best
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+func (v *view) closestTerm(tok string) string {
+	maxDist := 1
+	if len(tok) >= 6 {
+		maxDist = 2
+	}
+	best, bestDist, bestDF := "", maxDist+1, 0
+	for term, postings := range v.index {
+		if d := len(term) - len(tok); d > maxDist || -d > maxDist {
+			continue
+		}
+		dist := editDistance(tok, term, bestDist)
+		if dist < bestDist || (dist == bestDist && len(postings) > bestDF) {
+			best, bestDist, bestDF = term, dist, len(postings)
+		}
+	}
+	if bestDist > maxDist {
+		return ""
+	}
+	return best
+}
+
+// editDistance is Levenshtein distance with adjacent transpositions counted
+// as one edit ("rigdibody"). Gives up early once every cell in a row exceeds
+// limit, returning limit+1.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+			rowMin = minInt(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ── Sharded scoring ───────────────────────────────────────────────────────────
+// The doc store is split into contiguous ranges scored in parallel, one per
+// CPU. Small indexes stay single-shard: goroutines would cost more than they save.
+
+// minDocsPerShard keeps shards big enough to be worth a goroutine.
+const minDocsPerShard = 1000
+
+type scoredDoc struct {
+	idx   int
+	score float64
+}
+
+type weightedTerm struct {
+	term  string
+	boost float64
+}
+
+func (v *view) shardCount() int {
+	n := runtime.GOMAXPROCS(0)
+	if max := len(v.docs) / minDocsPerShard; n > max {
+		n = max
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// expandQuery lists every index term a query scores against: the tokens
+// themselves, prefix matches and substring matches inside API names.
+func (v *view) expandQuery(tokens []string, rk Ranking) []weightedTerm {
+	var terms []weightedTerm
+	for _, tok := range tokens {
+		// Exact match
+		terms = append(terms, weightedTerm{tok, 1.0})
+		// Prefix match (partial)
+		if len(tok) >= 3 {
+			for _, indexedTok := range v.prefixTerms(tok) {
+				if indexedTok != tok {
+					terms = append(terms, weightedTerm{indexedTok, rk.PrefixBoost})
+				}
+			}
+		}
+		// Substring match inside API names ("trigger" → "ontriggerenter2d")
+		if len(tok) >= 4 {
+			for _, term := range v.substringTerms(tok) {
+				if term != tok && !strings.HasPrefix(term, tok) {
+					terms = append(terms, weightedTerm{term, rk.SubstringBoost})
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// scoreShard scores docs [lo, hi) and returns the shard's best topK.
+func (v *view) scoreShard(lo, hi int, tokens []string, terms []weightedTerm, N, avgLen float64, rk Ranking, wantCode bool, hide PageSet, topK int) []scoredDoc {
+	// BM25-lite scoring
+	scores := make(map[int]float64)
+	for _, t := range terms {
+		v.scoreToken(t.term, scores, lo, hi, N, avgLen, rk.K1, rk.B, t.boost)
+	}
+
+	// Boost score if title contains query tokens
+	for idx := lo; idx < hi; idx++ {
+		titleLower := strings.ToLower(v.docs[idx].Title)
+		for _, tok := range tokens {
+			if strings.Contains(titleLower, tok) {
+				scores[idx] += rk.TitleBoost
+			}
+		}
+	}
+
+	// Code requests: boost pages whose samples use the query tokens
+	if wantCode {
+		for _, tok := range tokens {
+			for _, idx := range v.code[tok] {
+				if idx >= lo && idx < hi {
+					scores[idx] += rk.CodeBoost
+				}
+			}
+		}
+	}
+
+	// Page-type boosts (ScriptReference vs Manual)
+	ranked := make([]scoredDoc, 0, len(scores))
+	for idx, score := range scores {
+		if len(hide) > 0 && hide[PageURL(v.docs[idx].URL)] {
+			continue // excluded by the user, see exclude.go
+		}
+		if rk.Language != "" && !rk.speaks(v.docs[idx].Lang) {
+			continue
+		}
+		ranked = append(ranked, scoredDoc{idx, score * rk.typeBoost(v.docs[idx].URL)})
+	}
+	sortScored(ranked)
+	if len(ranked) > topK {
+		ranked = ranked[:topK]
+	}
+	return ranked
+}
+
+// sortScored orders best first; ties go to the earlier doc so results are stable across shard counts.
+func sortScored(ranked []scoredDoc) {
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].idx < ranked[j].idx
+	})
+}
+
+// scoreToken adds the BM25 contribution of tok for docs in [lo, hi).
+func (v *view) scoreToken(tok string, scores map[int]float64, lo, hi int, N, avgLen, k1, b, boost float64) {
+	postings, ok := v.index[tok]
 	if !ok {
 		return
 	}
 	df := float64(len(postings))
 	idf := math.Log((N-df+0.5)/(df+0.5) + 1)
-	for _, idx := range postings {
-		doc := e.docs[idx]
-		docLen := float64(len(tokenize(doc.Content + " " + doc.Title)))
-		tf := countOccurrences(tok, doc.Content+" "+doc.Title)
-		tfNorm := float64(tf) * (k1 + 1) / (float64(tf) + k1*(1-b+b*docLen/avgLen))
+	freqs := v.freq[tok]
+	for i, idx := range postings {
+		if idx < lo || idx >= hi {
+			continue
+		}
+		docLen := float64(v.lens[idx])
+		tf := float64(freqs[i])
+		tfNorm := tf * (k1 + 1) / (tf + k1*(1-b+b*docLen/avgLen))
 		scores[idx] += idf * tfNorm * boost
 	}
 }
 
-func (e *Engine) avgDocLen() float64 {
-	if len(e.docs) == 0 {
+func (v *view) avgDocLen() float64 {
+	if len(v.docs) == 0 {
 		return 100
 	}
-	total := 0
-	for _, d := range e.docs {
-		total += len(tokenize(d.Content + " " + d.Title))
-	}
-	return float64(total) / float64(len(e.docs))
-}
-
-func countOccurrences(tok, text string) int {
-	count := 0
-	lower := strings.ToLower(text)
-	idx := 0
-	for {
-		i := strings.Index(lower[idx:], tok)
-		if i < 0 {
-			break
-		}
-		count++
-		idx += i + len(tok)
-	}
-	return count
+	return float64(v.lenSum) / float64(len(v.docs))
 }
 
 // extractExcerpt pulls the most relevant snippet from content
@@ -254,17 +1174,18 @@ func extractExcerpt(content string, tokens []string, maxLen int) string {
 	if len(content) == 0 {
 		return ""
 	}
-	lower := strings.ToLower(content)
 	bestPos := 0
 	bestHits := 0
-	// Slide a window to find densest token region
+	// Slide a window to find densest token region. Each window is lowered
+	// on its own so positions stay those of content: lowering can change
+	// the length of a text.
 	windowSize := 200
-	for i := 0; i < len(lower)-windowSize; i += 50 {
+	for i := 0; i < len(content)-windowSize; i += 50 {
 		end := i + windowSize
-		if end > len(lower) {
-			end = len(lower)
+		if end > len(content) {
+			end = len(content)
 		}
-		window := lower[i:end]
+		window := strings.ToLower(content[i:end])
 		hits := 0
 		for _, tok := range tokens {
 			if strings.Contains(window, tok) {
@@ -276,7 +1197,8 @@ func extractExcerpt(content string, tokens []string, maxLen int) string {
 			bestPos = i
 		}
 	}
-	// Extract around best position
+	// Extract around best position, on whole characters: Japanese, Korean
+	// and Chinese take 3 bytes each
 	start := bestPos
 	if start > 50 {
 		start -= 50
@@ -285,14 +1207,29 @@ func extractExcerpt(content string, tokens []string, maxLen int) string {
 	if end > len(content) {
 		end = len(content)
 	}
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end--
+	}
 	excerpt := strings.TrimSpace(content[start:end])
+	prefix, suffix := "", ""
 	if start > 0 {
-		excerpt = "..." + excerpt
+		prefix = "..."
 	}
 	if end < len(content) {
-		excerpt = excerpt + "..."
+		suffix = "..."
+	}
+	// Content is Markdown: reopen a code block the window starts inside of,
+	// and close one it ends inside of
+	if strings.Count(content[:start], "```")%2 == 1 {
+		excerpt = "\n```\n" + excerpt
+	}
+	if strings.Count(excerpt, "```")%2 == 1 {
+		excerpt += "\n```\n"
 	}
-	return excerpt
+	return prefix + excerpt + suffix
 }
 
 // --- Persistence ---
@@ -301,14 +1238,28 @@ type cacheFile struct {
 	Docs []Doc `json:"docs"`
 }
 
+// StarterSource marks docs from the mini-index bundled in the binary. They
+// stand in until real docs are indexed and are never written to the cache.
+const StarterSource = "starter"
+
 func (e *Engine) SaveCache(path string) error {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-	data, err := json.Marshal(cacheFile{Docs: e.docs})
+	v := e.cur.Load()
+	docs := make([]Doc, 0, len(v.docs))
+	for _, d := range v.docs {
+		if d.Source == StarterSource {
+			continue
+		}
+		full, err := v.hydrate(d)
+		if err != nil {
+			return err // never save a cache with pages missing their text
+		}
+		docs = append(docs, full)
+	}
+	data, err := json.Marshal(cacheFile{Docs: docs})
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return writeFileAtomic(path, data)
 }
 
 func (e *Engine) LoadCache(path string) error {
@@ -320,8 +1271,105 @@ func (e *Engine) LoadCache(path string) error {
 	if err := json.Unmarshal(data, &cf); err != nil {
 		return err
 	}
-	for _, doc := range cf.Docs {
-		e.AddDoc(doc)
+	e.AddDocs(cf.Docs)
+	return nil
+}
+
+// --- Snapshots ---
+// A snapshot is the doc list plus a SHA-256 of it, written via temp file +
+// rename so a crash mid-write leaves the previous snapshot intact.
+
+type snapshotFile struct {
+	Checksum string          `json:"checksum"`
+	Docs     json.RawMessage `json:"docs"`
+}
+
+// Snapshot atomically writes the whole index to path with a checksum.
+func (e *Engine) Snapshot(path string) error {
+	v := e.cur.Load()
+	full := make([]Doc, len(v.docs))
+	for i, d := range v.docs {
+		var err error
+		if full[i], err = v.hydrate(d); err != nil {
+			return err
+		}
+	}
+	docs, err := json.Marshal(full)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(docs)
+	data, err := json.Marshal(snapshotFile{Checksum: hex.EncodeToString(sum[:]), Docs: docs})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Restore replaces the index with the snapshot at path. The checksum is
+// verified before anything is touched, so a bad file leaves the index as-is.
+func (e *Engine) Restore(path string) error {
+	docs, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	// Build the replacement off to the side, then swap it in
+	fresh := newView()
+	for _, doc := range docs {
+		fresh.add(doc)
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fresh.spill = e.cur.Load().spill
+	fresh.sortedTerms()
+	e.publish(fresh)
 	return nil
 }
+
+// readSnapshot reads the docs of the snapshot at path, verifying its checksum.
+func readSnapshot(path string) ([]Doc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sf snapshotFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("corrupt snapshot: %w", err)
+	}
+	sum := sha256.Sum256(sf.Docs)
+	if hex.EncodeToString(sum[:]) != sf.Checksum {
+		return nil, fmt.Errorf("snapshot checksum mismatch: %s", path)
+	}
+	var docs []Doc
+	if err := json.Unmarshal(sf.Docs, &docs); err != nil {
+		return nil, fmt.Errorf("corrupt snapshot: %w", err)
+	}
+	return docs, nil
+}
+
+// writeFileAtomic writes to a temp file in the same directory, syncs it,
+// then renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
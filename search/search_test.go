@@ -0,0 +1,60 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRemoveDocsDropsSections(t *testing.T) {
+	const page = "https://docs.unity3d.com/ScriptReference/Rigidbody2D.html"
+	e := NewEngine()
+	e.AddResults([]Result{
+		{Title: "Rigidbody2D", URL: page, Excerpt: "Rigidbody physics component for 2D sprites."},
+		{Title: "Rigidbody2D.AddForce", URL: page + "#AddForce", Excerpt: "Apply a force to the rigidbody2d."},
+		{Title: "Rigidbody2D.velocity", URL: page + "#velocity", Excerpt: "Linear velocity of the rigidbody2d."},
+		{Title: "Rigidbody", URL: "https://docs.unity3d.com/ScriptReference/Rigidbody.html", Excerpt: "Control of an object's position through physics simulation."},
+	})
+
+	if n := e.RemoveDocs([]string{page}); n != 3 {
+		t.Errorf("removed %d docs, want the page and its 2 sections", n)
+	}
+	if n := e.DocCount(); n != 1 {
+		t.Errorf("%d docs left, want 1", n)
+	}
+	for _, r := range e.Search("rigidbody2d velocity force", 10) {
+		if strings.HasPrefix(r.URL, page) {
+			t.Errorf("removed section %s still found", r.URL)
+		}
+	}
+
+	// Removing one section keeps the rest of its page
+	e.AddResults([]Result{
+		{Title: "Rigidbody2D.AddForce", URL: page + "#AddForce", Excerpt: "Apply a force to the rigidbody2d."},
+		{Title: "Rigidbody2D.velocity", URL: page + "#velocity", Excerpt: "Linear velocity of the rigidbody2d."},
+	})
+	if n := e.RemoveDocs([]string{page + "#AddForce"}); n != 1 {
+		t.Errorf("removed %d docs for one section, want 1", n)
+	}
+	if got := e.Page(page); len(got) != 1 || got[0].URL != page+"#velocity" {
+		t.Errorf("page left with %v, want only #velocity", got)
+	}
+}
+
+func TestExcerptKeepsWholeCharacters(t *testing.T) {
+	ja := strings.Repeat("物理演算を使ってオブジェクトを動かします。", 30) + "リジッドボディ に力を加える。" + strings.Repeat("スクリプトから速度を設定できます。", 30)
+	got := extractExcerpt(ja, []string{"リジッドボディ"}, 300)
+	if !utf8.ValidString(got) {
+		t.Errorf("excerpt is not valid UTF-8: %q", got)
+	}
+	if !strings.Contains(got, "リジッドボディ") {
+		t.Errorf("excerpt misses the matched term: %q", got)
+	}
+
+	// "İ" is longer lowered, so positions in the lowered text run ahead
+	tr := strings.Repeat("İstanbul ", 120) + "Rigidbody velocity" + strings.Repeat(" sonra", 100)
+	got = extractExcerpt(tr, []string{"rigidbody"}, 300)
+	if !utf8.ValidString(got) || !strings.Contains(got, "Rigidbody") {
+		t.Errorf("excerpt misses the matched term: %q", got)
+	}
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Automatic snapshots ───────────────────────────────────────────────────────
+// Before a re-index, a prune or a rollback rewrites an index, and once a
+// day, the index is snapshotted to cache/snapshots/<index>/ so a bad run
+// can be undone in one call. Only the newest few are kept per index.
+
+// DefaultSnapshotRetention is how many automatic snapshots each index keeps.
+const DefaultSnapshotRetention = 5
+
+const snapshotTimeFormat = "20060102-150405.000"
+
+// SnapshotInfo describes one automatic snapshot.
+type SnapshotInfo struct {
+	ID      string    `json:"id"` // e.g. "20261015-021500.000-daily"
+	Index   string    `json:"index"`
+	Reason  string    `json:"reason"` // what it was taken before: "reindex", "prune", ...
+	Created time.Time `json:"created"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// SetSnapshotRetention sets how many automatic snapshots each index keeps
+// (0 = DefaultSnapshotRetention). Older ones are deleted at the next snapshot.
+func (r *Registry) SetSnapshotRetention(keep int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if keep <= 0 {
+		keep = DefaultSnapshotRetention
+	}
+	r.keep = keep
+}
+
+// AutoSnapshot snapshots the named index, tagged with why, and drops the
+// oldest snapshots beyond the retention. An empty or unopened index has
+// nothing worth keeping and is skipped (ok=false).
+func (r *Registry) AutoSnapshot(name, reason string) (info SnapshotInfo, ok bool, err error) {
+	name = normalizeIndexName(name)
+	if info, ok, err = r.snapshot(name, reason); ok {
+		r.pruneSnapshots(name, "")
+	}
+	return info, ok, err
+}
+
+// snapshot writes one snapshot of the named index without pruning.
+func (r *Registry) snapshot(name, reason string) (SnapshotInfo, bool, error) {
+	e := r.Get(name)
+	if e == nil || e.DocCount() == 0 || e.DocCount() == e.SourceCount(StarterSource) {
+		return SnapshotInfo{}, false, nil
+	}
+	dir := r.snapshotDir(name)
+	stamp := time.Now().UTC().Format(snapshotTimeFormat)
+	id := stamp + "-" + fileSafe(reason)
+	for n := 2; fileExists(filepath.Join(dir, id+".json")); n++ {
+		id = fmt.Sprintf("%s-%s-%d", stamp, fileSafe(reason), n)
+	}
+	path := filepath.Join(dir, id+".json")
+	if err := e.Snapshot(path); err != nil {
+		return SnapshotInfo{}, false, err
+	}
+	info, _ := parseSnapshotID(name, id)
+	if st, err := os.Stat(path); err == nil {
+		info.Bytes = st.Size()
+	}
+	return info, true, nil
+}
+
+// pruneSnapshots deletes the named index's oldest snapshots beyond the
+// retention, sparing protect.
+func (r *Registry) pruneSnapshots(name, protect string) {
+	r.mu.RLock()
+	keep := r.keep
+	r.mu.RUnlock()
+	list := r.Snapshots(name)
+	for _, old := range list[min(keep, len(list)):] {
+		if old.ID != protect {
+			os.Remove(filepath.Join(r.snapshotDir(name), old.ID+".json"))
+		}
+	}
+}
+
+// Snapshots lists the named index's automatic snapshots, newest first.
+func (r *Registry) Snapshots(name string) []SnapshotInfo {
+	name = normalizeIndexName(name)
+	entries, _ := os.ReadDir(r.snapshotDir(name))
+	list := []SnapshotInfo{}
+	for _, ent := range entries {
+		id, isJSON := strings.CutSuffix(ent.Name(), ".json")
+		if ent.IsDir() || !isJSON {
+			continue
+		}
+		info, ok := parseSnapshotID(name, id)
+		if !ok {
+			continue
+		}
+		if fi, err := ent.Info(); err == nil {
+			info.Bytes = fi.Size()
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Rollback restores the named index from one of its automatic snapshots and
+// saves it. The index is snapshotted first, so the rollback can itself be
+// undone; that snapshot is returned (ok=false if the index was empty). The
+// snapshot rolled back to is verified before anything changes.
+func (r *Registry) Rollback(name, id string) (undo SnapshotInfo, ok bool, err error) {
+	name = normalizeIndexName(name)
+	e := r.Get(name)
+	if e == nil {
+		return SnapshotInfo{}, false, fmt.Errorf("unknown index %q", name)
+	}
+	if _, valid := parseSnapshotID(name, id); !valid || strings.ContainsAny(id, `/\`) {
+		return SnapshotInfo{}, false, fmt.Errorf("bad snapshot id %q", id)
+	}
+	path := filepath.Join(r.snapshotDir(name), id+".json")
+	if !fileExists(path) {
+		return SnapshotInfo{}, false, fmt.Errorf("no snapshot %q for index %q", id, name)
+	}
+	if undo, ok, err = r.snapshot(name, "rollback"); err != nil {
+		return SnapshotInfo{}, false, err
+	}
+	if err := e.Restore(path); err != nil {
+		return undo, ok, err
+	}
+	r.pruneSnapshots(name, id)
+	return undo, ok, r.Save(name)
+}
+
+// NamedSnapshotPath resolves a snapshot name from a request to its file
+// under the cache dir: one of the index's automatic snapshot IDs, a
+// snapshot of the index written under that name (see SnapshotPath), or the
+// index's default snapshot if name is empty. Names that could reach outside
+// the snapshots directory are rejected.
+func (r *Registry) NamedSnapshotPath(index, name string) (string, error) {
+	index, name = normalizeIndexName(index), strings.TrimSpace(name)
+	if name == "" {
+		return r.SnapshotPath(index, ""), nil
+	}
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("bad snapshot name %q", name)
+	}
+	if _, ok := parseSnapshotID(index, name); ok {
+		if path := filepath.Join(r.snapshotDir(index), name+".json"); fileExists(path) {
+			return path, nil
+		}
+	}
+	return r.SnapshotPath(index, name), nil
+}
+
+func (r *Registry) snapshotDir(name string) string {
+	return filepath.Join(r.dir, "snapshots", indexFileName(name))
+}
+
+// parseSnapshotID reads the time and reason back out of an ID.
+func parseSnapshotID(index, id string) (SnapshotInfo, bool) {
+	if len(id) < len(snapshotTimeFormat)+2 {
+		return SnapshotInfo{}, false
+	}
+	created, err := time.Parse(snapshotTimeFormat, id[:len(snapshotTimeFormat)])
+	if err != nil || id[len(snapshotTimeFormat)] != '-' {
+		return SnapshotInfo{}, false
+	}
+	return SnapshotInfo{ID: id, Index: index, Reason: id[len(snapshotTimeFormat)+1:], Created: created}, true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ── Memory budget ─────────────────────────────────────────────────────────────
+// Page text is most of an index's memory: the full offline docs can push the
+// process past 1 GB. Scoring only needs postings, term frequencies and doc
+// lengths, so once the text held in RAM passes the budget, docs' Content and
+// Code move to a scratch file and are read back only for excerpts, saving
+// and reindexing.
+
+// spillFile is an append-only scratch file holding evicted page text. It is
+// truncated when opened: the cache file remains the source of truth.
+type spillFile struct {
+	mu   sync.Mutex // guards size; ReadAt/WriteAt are safe concurrently
+	f    *os.File
+	path string
+	size int64
+}
+
+// minSpillCompact is the dead space a spill file may hold before it's
+// compacted, however little of it is still live.
+const minSpillCompact = 16 << 20
+
+func openSpill(path string) (*spillFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &spillFile{f: f, path: path}, nil
+}
+
+// write appends content and code and returns where they start.
+func (s *spillFile) write(content, code string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	off := s.size
+	if _, err := s.f.WriteAt([]byte(content+code), off); err != nil {
+		return 0, err
+	}
+	s.size += int64(len(content) + len(code))
+	return off, nil
+}
+
+func (s *spillFile) read(off int64, contentLen, codeLen int) (string, string, error) {
+	buf := make([]byte, contentLen+codeLen)
+	if _, err := s.f.ReadAt(buf, off); err != nil {
+		return "", "", err
+	}
+	return string(buf[:contentLen]), string(buf[contentLen:]), nil
+}
+
+// SetMemoryBudget caps the bytes of page text (Content + Code) this engine
+// keeps in RAM; the rest is spilled to spillPath. Zero or less means no limit.
+// Titles, postings and everything scoring needs always stay in memory.
+func (e *Engine) SetMemoryBudget(maxBytes int64, spillPath string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	d := e.cur.Load().clone()
+	e.budget = maxBytes
+	if maxBytes > 0 && d.spill == nil {
+		s, err := openSpill(spillPath)
+		if err != nil {
+			e.budget = 0
+			return err
+		}
+		d.spill = s
+	}
+	e.publish(d)
+	return nil
+}
+
+// textSize is how much RAM a doc's page text takes while resident.
+func textSize(d Doc) int64 {
+	if d.spilled {
+		return 0
+	}
+	return int64(len(d.Content) + len(d.Code))
+}
+
+// enforceBudget spills docs of draft v, oldest first, until resident text is
+// back to three quarters of the budget, so eviction doesn't run on every add.
+func (v *view) enforceBudget(budget int64) {
+	if budget <= 0 || v.spill == nil || v.resident <= budget {
+		return
+	}
+	target := budget / 4 * 3
+	for i := range v.docs {
+		if v.resident <= target {
+			return
+		}
+		d := &v.docs[i]
+		if d.spilled || d.Content == "" && d.Code == "" {
+			continue
+		}
+		off, err := v.spill.write(d.Content, d.Code)
+		if err != nil {
+			return // keep it in memory rather than lose it
+		}
+		v.resident -= textSize(*d)
+		d.spillOff, d.spillContent, d.spillCode = off, len(d.Content), len(d.Code)
+		d.Content, d.Code, d.spilled = "", "", true
+	}
+}
+
+// compactSpill moves draft v's spilled text to a fresh spill file once most
+// of the old one is text of docs since replaced or removed, which would
+// otherwise pile up across re-indexes. The old file is unlinked, not
+// truncated: older views still reading it keep their open handle, and the
+// space is freed once the last of them is gone. If any text can't be moved,
+// v stays on the old file.
+func (v *view) compactSpill() {
+	if v.spill == nil {
+		return
+	}
+	var live int64
+	for _, d := range v.docs {
+		if d.spilled {
+			live += int64(d.spillContent + d.spillCode)
+		}
+	}
+	v.spill.mu.Lock()
+	dead := v.spill.size - live
+	v.spill.mu.Unlock()
+	if dead < minSpillCompact || dead < live {
+		return
+	}
+	old := v.spill
+	os.Remove(old.path)
+	fresh, err := openSpill(old.path)
+	if err != nil {
+		return // keep appending to the old one
+	}
+	offs := make([]int64, len(v.docs))
+	for i, d := range v.docs {
+		if !d.spilled {
+			continue
+		}
+		content, code, err := old.read(d.spillOff, d.spillContent, d.spillCode)
+		if err == nil {
+			offs[i], err = fresh.write(content, code)
+		}
+		if err != nil {
+			// Keep every doc on the old file, whose handle stays open, rather
+			// than lose this one's text
+			log.Printf("[search] Spill compaction stopped at %s: %v", d.URL, err)
+			fresh.f.Close()
+			os.Remove(fresh.path)
+			return
+		}
+	}
+	for i := range v.docs {
+		if v.docs[i].spilled {
+			v.docs[i].spillOff = offs[i]
+		}
+	}
+	v.spill = fresh
+}
+
+// hydrate returns d with its page text read back from the spill file.
+// Docs that were never spilled are returned as-is.
+func (v *view) hydrate(d Doc) (Doc, error) {
+	if !d.spilled {
+		return d, nil
+	}
+	content, code, err := v.spill.read(d.spillOff, d.spillContent, d.spillCode)
+	if err != nil {
+		return d, err
+	}
+	d.Content, d.Code, d.spilled = content, code, false
+	return d, nil
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// ── Stop words ────────────────────────────────────────────────────────────────
+// One list, shared by indexing, query tokenizing and the offline NLU: the
+// union of the lists each used to keep. API verbs on it ("get", "set",
+// "make") still count in identifier-heavy queries, see IdentifierHeavy.
+
+// DefaultStopWords is the list used unless the config replaces it.
+var DefaultStopWords = []string{
+	"the", "a", "an", "is", "in", "to", "of", "and", "or", "for",
+	"on", "with", "this", "that", "it", "be", "as", "at", "by", "we",
+	"how", "do", "i", "you", "can", "what", "from", "are", "use", "used",
+	"my", "me", "get", "make", "create", "want", "need", "help", "using",
+	"does", "would", "should", "could", "will", "please", "just", "also",
+	"way", "some", "give", "show", "tell", "write", "let", "set", "put",
+	"try", "work", "works",
+}
+
+var stopWords atomic.Value // map[string]bool
+
+func init() {
+	SetStopWords(DefaultStopWords)
+}
+
+// SetStopWords replaces the stop-word list; an empty list disables stop-word
+// removal. Docs already indexed keep their tokens until they're reindexed.
+func SetStopWords(words []string) {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			set[w] = true
+		}
+	}
+	stopWords.Store(set)
+}
+
+// IsStopWord reports whether the lowercase token w is on the stop-word list.
+func IsStopWord(w string) bool {
+	return stopWords.Load().(map[string]bool)[w]
+}
+
+// IdentifierHeavy reports whether at least half the words of q look like
+// code identifiers (Animator.SetBool, GetComponent, rb_2d). Such queries are
+// tokenized without stop-word removal, since every word is likely meaningful.
+func IdentifierHeavy(q string) bool {
+	words := strings.Fields(q)
+	if len(words) == 0 {
+		return false
+	}
+	ids := 0
+	for _, w := range words {
+		if looksLikeIdentifier(w) {
+			ids++
+		}
+	}
+	return ids*2 >= len(words)
+}
+
+func looksLikeIdentifier(w string) bool {
+	w = strings.Trim(w, "()?!,;:'\"")
+	rs := []rune(w)
+	for i := 1; i < len(rs); i++ {
+		switch {
+		case unicode.IsLower(rs[i-1]) && unicode.IsUpper(rs[i]):
+			return true // camelCase
+		case rs[i] == '.' || rs[i] == '_':
+			if i+1 < len(rs) && unicode.IsLetter(rs[i+1]) {
+				return true // Type.Member, snake_case
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ── Symbol table ──────────────────────────────────────────────────────────────
+// "AudioSource.PlayOneShot" names exactly one page; ranking it among every
+// page that mentions PlayOneShot can still put a tutorial first. While
+// Scripting Reference pages are indexed, each one is also entered in a
+// symbol table kept apart from the full-text postings: its full API name →
+// the type, member, kind, declaration and URL. Lookups there are exact, and
+// the API catalog (Members) reads it instead of rescanning the pages.
+
+var (
+	// A type's own page says what it is: "class in UnityEngine"
+	reTypeKind = regexp.MustCompile(`\b(class|struct|enum|interface) in\s+[A-Z][A-Za-z0-9.]*`)
+	// Dotted API names in a question: "AudioSource.PlayOneShot()"
+	reSymbolRef = regexp.MustCompile(`\b[A-Z][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)+`)
+)
+
+// symbolOf reads the symbol-table entry of a Scripting Reference page from
+// its text; ok=false for any other doc, including a page's sections.
+func symbolOf(doc Doc) (key string, m Member, ok bool) {
+	name := APIName(doc.URL)
+	if name == "" {
+		return "", Member{}, false
+	}
+	class, member, kind, isMember := apiPage(doc.URL)
+	typeKind := reTypeKind.FindStringSubmatch(doc.Content)
+	switch {
+	case typeKind != nil && (!isMember || kind == "method"):
+		// Its own page: Rendering.CommandBuffer is a type, not a method
+		m = Member{Class: lastSegment(name), Kind: typeKind[1], URL: doc.URL}
+	case isMember:
+		if kind == "method" && messageClasses[class] && isMessageName(member) {
+			kind = "message"
+		}
+		m = Member{Class: class, Name: member, Kind: kind, URL: doc.URL}
+	default:
+		m = Member{Class: lastSegment(name), Kind: "type", URL: doc.URL}
+	}
+	m.Signature, m.Summary = describeMember(doc.Content, doc.Title)
+	return strings.ToLower(name), m, true
+}
+
+// addSymbol enters doc in draft d's symbol table if it documents an API.
+func (d *view) addSymbol(doc Doc) {
+	if key, m, ok := symbolOf(doc); ok {
+		d.own(ownSymbols)
+		d.symbols[key] = m
+	}
+}
+
+// removeSymbol drops old's entry, unless another page has taken it since.
+func (d *view) removeSymbol(old Doc) {
+	if key := strings.ToLower(APIName(old.URL)); key != "" && d.symbols[key].URL == old.URL {
+		d.own(ownSymbols)
+		delete(d.symbols, key)
+	}
+}
+
+// Symbol looks up an API by name, exactly rather than by relevance:
+// "AudioSource.PlayOneShot" (or "AudioSource-clip", "PlayOneShot()") is that
+// member, "CommandBuffer.Blit" matches without its namespace, "AudioSource"
+// is the type and a bare member name is that member of every type that has
+// one. The type comes first, then members by type and name; nil if nothing
+// in the index has that name.
+func (e *Engine) Symbol(name string) []Member {
+	key := SymbolKey(name)
+	if key == "" {
+		return nil
+	}
+	v := e.cur.Load()
+	if m, ok := v.symbols[key]; ok {
+		return []Member{m}
+	}
+	var found []Member
+	for k, m := range v.symbols {
+		if strings.HasSuffix(k, "."+key) || (!strings.Contains(key, ".") && strings.EqualFold(m.Name, key)) {
+			found = append(found, m)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if (found[i].Name == "") != (found[j].Name == "") {
+			return found[i].Name == ""
+		}
+		if found[i].Class != found[j].Class {
+			return found[i].Class < found[j].Class
+		}
+		return found[i].Name < found[j].Name
+	})
+	return found
+}
+
+// SymbolsIn returns the APIs a question names in full, like
+// "AudioSource.PlayOneShot", that the symbol table has exactly one entry
+// for, in the order they appear.
+func (e *Engine) SymbolsIn(text string) []Member {
+	var found []Member
+	for _, ref := range SymbolRefs(text) {
+		if m := e.Symbol(ref); len(m) == 1 {
+			found = append(found, m[0])
+		}
+	}
+	return found
+}
+
+// SymbolRefs returns the dotted API names text mentions, like
+// "AudioSource.PlayOneShot", once each in the order they appear.
+func SymbolRefs(text string) []string {
+	var refs []string
+	seen := map[string]bool{}
+	for _, ref := range reSymbolRef.FindAllString(text, -1) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// SymbolCount is how many APIs the symbol table holds.
+func (e *Engine) SymbolCount() int {
+	return len(e.cur.Load().symbols)
+}
+
+// SymbolKey normalizes an API name for lookup: no namespace prefix,
+// parameters or call parentheses, "-" as ".", lower case.
+func SymbolKey(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	for _, ns := range []string{"UnityEngine.", "UnityEditor."} {
+		name = strings.TrimPrefix(name, ns)
+	}
+	return strings.ToLower(strings.Trim(strings.ReplaceAll(name, "-", "."), ". "))
+}
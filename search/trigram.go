@@ -0,0 +1,90 @@
+package search
+
+import "strings"
+
+// ── Trigram index for identifiers ─────────────────────────────────────────────
+// Prefix matching finds "ontriggerenter2d" from "ontrigger", but not from
+// "trigger" or "enter2d". API names live in doc titles, so title tokens get a
+// trigram index: any 3+ char substring of an API name finds its page.
+
+// minTrigramTerm skips short words — only identifier-like tokens are worth it.
+const minTrigramTerm = 6
+
+// addTrigrams registers the identifier-like tokens of a title in draft v.
+func (v *view) addTrigrams(title string) {
+	for _, tok := range tokenize(title) {
+		if len(tok) < minTrigramTerm || v.triTerms[tok] {
+			continue
+		}
+		v.own(ownTrigram)
+		v.triTerms[tok] = true
+		for _, g := range trigramsOf(tok) {
+			v.trigrams[g] = append(v.trigrams[g], tok)
+		}
+	}
+}
+
+// removeTrigrams drops the tokens in gone, which no doc has any more, from
+// the trigram index of draft v. Candidate lists are shared with older views,
+// so each one touched is copied rather than edited in place.
+func (v *view) removeTrigrams(gone map[string]bool) {
+	for tok := range gone {
+		if !v.triTerms[tok] {
+			continue
+		}
+		v.own(ownTrigram)
+		delete(v.triTerms, tok)
+		for _, g := range trigramsOf(tok) {
+			var kept []string
+			for _, t := range v.trigrams[g] {
+				if t != tok {
+					kept = append(kept, t)
+				}
+			}
+			if len(kept) == 0 {
+				delete(v.trigrams, g)
+			} else {
+				v.trigrams[g] = kept
+			}
+		}
+	}
+}
+
+// substringTerms returns identifier terms that contain tok anywhere.
+func (v *view) substringTerms(tok string) []string {
+	grams := trigramsOf(tok)
+	if len(grams) == 0 {
+		return nil
+	}
+	// Start from the rarest trigram, then verify each candidate directly
+	rarest := v.trigrams[grams[0]]
+	for _, g := range grams[1:] {
+		if cand := v.trigrams[g]; len(cand) < len(rarest) {
+			rarest = cand
+		}
+	}
+	var out []string
+	for _, term := range rarest {
+		if strings.Contains(term, tok) {
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+func trigramsOf(s string) []string {
+	r := []rune(s)
+	if len(r) < 3 {
+		return nil
+	}
+	seen := map[string]bool{}
+	grams := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		g := string(r[i : i+3])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
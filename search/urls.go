@@ -0,0 +1,94 @@
+package search
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// reVersionSegment matches the version prefix Unity uses for pinned docs:
+// https://docs.unity3d.com/2022.3/Documentation/Manual/X.html
+var reVersionSegment = regexp.MustCompile(`^/(\d{4}\.\d+|\d+\.\d+)/Documentation(/.*)$`)
+
+// CanonicalURL folds the many spellings of a Unity doc URL into one, and
+// returns the Unity version that was stripped from it ("" if unversioned).
+//
+//	https://docs.unity3d.com/2022.3/Documentation/Manual/X.html → https://docs.unity3d.com/Manual/X.html, "2022.3"
+//	http://DOCS.unity3d.com/Manual/X.html?foo#bar             → https://docs.unity3d.com/Manual/X.html#bar, ""
+//	https://docs.unity3d.com/Documentation/Manual//./X.html   → https://docs.unity3d.com/Manual/X.html, ""
+//	https://docs.unity3d.com/Manual/                          → https://docs.unity3d.com/Manual/index.html, ""
+//
+// The fragment is kept: it identifies a section (chunk) of the page.
+// Anything that isn't docs.unity3d.com is returned unchanged.
+func CanonicalURL(raw string) (string, string) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !strings.EqualFold(u.Host, "docs.unity3d.com") {
+		return raw, ""
+	}
+	u.Scheme = "https"
+	u.Host = "docs.unity3d.com"
+	u.RawQuery = ""
+	version := ""
+	if m := reVersionSegment.FindStringSubmatch(u.Path); m != nil {
+		version = m[1]
+		u.Path = m[2]
+	}
+	if strings.HasPrefix(u.Path, "/en/") {
+		u.Path = u.Path[len("/en"):]
+	}
+	// The unversioned alias of the latest docs
+	if strings.HasPrefix(u.Path, "/Documentation/") {
+		u.Path = u.Path[len("/Documentation"):]
+	}
+	// A folder is its index page: /Manual, /Manual/ and /Manual/index.html;
+	// a page is the same with a slash after it
+	dir := strings.HasSuffix(u.Path, "/")
+	u.Path = path.Clean("/" + u.Path)
+	u.RawPath = ""
+	if u.Path != "/" && !strings.HasSuffix(u.Path, ".html") &&
+		(dir || u.Path == "/Manual" || u.Path == "/ScriptReference" || strings.HasSuffix(u.Path, "/manual")) {
+		u.Path += "/index.html"
+	}
+	return u.String(), version
+}
+
+// VersionedURL points a canonical docs.unity3d.com URL at a specific Unity
+// version. An empty version returns the URL unchanged (latest docs).
+func VersionedURL(canonical, version string) string {
+	const base = "https://docs.unity3d.com/"
+	if version == "" || !strings.HasPrefix(canonical, base) {
+		return canonical
+	}
+	rest := canonical[len(base):]
+	if !strings.HasPrefix(rest, "Manual/") && !strings.HasPrefix(rest, "ScriptReference/") {
+		return canonical
+	}
+	return base + version + "/Documentation/" + rest
+}
+
+var reDocsVersion = regexp.MustCompile(`^(\d{4}|\d{1,2})\.(\d+)(?:[.abfpx]\S*)?$`)
+
+// DocsVersion returns the docs version for a Unity version as it's written
+// in an editor's About box or a project's ProjectVersion.txt: "2022.3.10f1"
+// and "2022.3" both give "2022.3". "" gives "" (latest docs); ok=false if v
+// isn't a Unity version.
+func DocsVersion(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", true
+	}
+	m := reDocsVersion.FindStringSubmatch(v)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "." + m[2], true
+}
+
+// PageURL strips the section anchor, giving the page a chunk belongs to.
+func PageURL(u string) string {
+	if i := strings.IndexByte(u, '#'); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
@@ -0,0 +1,218 @@
+package search
+
+import (
+	"maps"
+	"sort"
+	"sync/atomic"
+)
+
+// ── Copy-on-write views ───────────────────────────────────────────────────────
+// Live results, offline indexing and crawls add docs while questions are
+// being answered. Instead of one lock both sides contend on, the index is an
+// immutable view: searches load the current view and never block, while a
+// writer copies it into a draft, changes the draft and publishes it with a
+// single atomic store. Writers are serialized by Engine.mu.
+//
+// Copies stay cheap: maps are copied shallowly, and only once a draft writes
+// to them, and posting slices are only
+// ever appended to, so an older view still reads exactly its own prefix of
+// any backing array it shares with a newer one.
+
+// view is one published version of the index. Nothing in it changes after
+// publish except the lazily built term list and its result cache.
+type view struct {
+	docs []Doc
+	// inverted index: token → []doc indices
+	index map[string][]int
+	// freq holds the term frequency of each posting, parallel to index, and
+	// lens each doc's length in tokens, so BM25 never needs the page text
+	freq   map[string][]int
+	lens   []int
+	lenSum int
+	// code is the same for the Code field only, see SearchCode
+	code map[string][]int
+	// byHash maps a content fingerprint to the doc holding it, so the same
+	// page under a different URL (live vs offline, ?query, /en/) is stored
+	// once, with the other URL in its Aliases; see dedupKey
+	byHash map[string]int
+	// trigram → identifier terms containing it, see trigram.go
+	trigrams map[string][]string
+	triTerms map[string]bool
+	// bytes of page text held in RAM and where the rest went, see spill.go
+	resident int64
+	spill    *spillFile
+	// terms is every indexed token in sorted order, for prefix lookups; nil
+	// until first needed. A draft records the tokens it adds in newTerms and
+	// those no doc has any more in goneTerms (dropped from the trigrams
+	// too), and merges them in on publish, so the list survives small updates.
+	terms     atomic.Pointer[[]string]
+	newTerms  []string
+	goneTerms []string
+	// Scripting Reference APIs by lower-cased full name, see symbols.go
+	symbols map[string]Member
+	// obsolete APIs and their replacements, see obsolete.go; nil until
+	// first needed, rebuilt for each view
+	renames atomic.Pointer[[]Rename]
+	// which maps a draft has copied for itself rather than shares with the
+	// view it was cloned from, see own
+	owned int
+	// results of searches against this view; a new view starts empty
+	cache *queryCache
+}
+
+func newView() *view {
+	return &view{
+		docs:     make([]Doc, 0, 500),
+		index:    make(map[string][]int),
+		freq:     make(map[string][]int),
+		code:     make(map[string][]int),
+		byHash:   make(map[string]int),
+		trigrams: make(map[string][]string),
+		triTerms: make(map[string]bool),
+		symbols:  make(map[string]Member),
+		owned:    ownAll,
+		cache:    newQueryCache(),
+	}
+}
+
+// clone returns a draft a writer may change freely. Docs and lens are copied
+// outright because they're updated in place; maps are shared until the
+// draft first writes to one (see own), so a batch only pays for copying
+// the maps it touches.
+func (v *view) clone() *view {
+	d := &view{
+		docs:     append(make([]Doc, 0, len(v.docs)+1), v.docs...),
+		index:    v.index,
+		freq:     v.freq,
+		lens:     append([]int(nil), v.lens...),
+		lenSum:   v.lenSum,
+		code:     v.code,
+		byHash:   v.byHash,
+		trigrams: v.trigrams,
+		triTerms: v.triTerms,
+		symbols:  v.symbols,
+		resident: v.resident,
+		spill:    v.spill,
+		cache:    newQueryCache(),
+	}
+	d.terms.Store(v.terms.Load())
+	return d
+}
+
+// The maps of a view, as bits of view.owned.
+const (
+	ownIndex = 1 << iota // index and freq, which are kept parallel
+	ownCode
+	ownHash
+	ownTrigram // trigrams and triTerms
+	ownSymbols
+	ownAll = ownIndex | ownCode | ownHash | ownTrigram | ownSymbols
+)
+
+// own makes the maps in which of draft d its own, copying those it still
+// shares with the view it was cloned from. Call it before writing to them.
+func (d *view) own(which int) {
+	which &^= d.owned
+	if which&ownIndex != 0 {
+		d.index, d.freq = maps.Clone(d.index), maps.Clone(d.freq)
+	}
+	if which&ownCode != 0 {
+		d.code = maps.Clone(d.code)
+	}
+	if which&ownHash != 0 {
+		d.byHash = maps.Clone(d.byHash)
+	}
+	if which&ownTrigram != 0 {
+		d.trigrams, d.triTerms = maps.Clone(d.trigrams), maps.Clone(d.triTerms)
+	}
+	if which&ownSymbols != 0 {
+		d.symbols = maps.Clone(d.symbols)
+	}
+	d.owned |= which
+}
+
+// update runs change against a draft of the current view and publishes it
+// if change reports that something changed.
+func (e *Engine) update(change func(d *view) bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	d := e.cur.Load().clone()
+	if change(d) {
+		e.publish(d)
+	}
+}
+
+// publish makes d the view searches see. Caller must hold e.mu.
+func (e *Engine) publish(d *view) {
+	d.compactSpill()
+	d.enforceBudget(e.budget)
+	gone := d.goneSet()
+	if t := d.terms.Load(); t != nil && (len(d.newTerms) > 0 || len(gone) > 0) {
+		merged := mergeTerms(*t, d.newTerms)
+		if len(gone) > 0 {
+			merged = dropTerms(merged, gone)
+		}
+		d.terms.Store(&merged)
+	}
+	d.removeTrigrams(gone)
+	d.newTerms, d.goneTerms = nil, nil
+	e.cur.Store(d)
+}
+
+// sortedTerms returns every indexed token in sorted order, building the
+// list on first use.
+func (v *view) sortedTerms() []string {
+	if t := v.terms.Load(); t != nil {
+		return *t
+	}
+	terms := make([]string, 0, len(v.index))
+	for tok := range v.index {
+		terms = append(terms, tok)
+	}
+	sort.Strings(terms)
+	v.terms.Store(&terms)
+	return terms
+}
+
+// goneSet is the tokens of d.goneTerms that no doc has taken up again since.
+func (d *view) goneSet() map[string]bool {
+	gone := make(map[string]bool, len(d.goneTerms))
+	for _, tok := range d.goneTerms {
+		if _, back := d.index[tok]; !back {
+			gone[tok] = true
+		}
+	}
+	return gone
+}
+
+// dropTerms removes the tokens in gone from terms, in place.
+func dropTerms(terms []string, gone map[string]bool) []string {
+	kept := terms[:0]
+	for _, tok := range terms {
+		if !gone[tok] {
+			kept = append(kept, tok)
+		}
+	}
+	return kept
+}
+
+// mergeTerms returns the sorted union of sorted and the new tokens in add.
+func mergeTerms(sorted, add []string) []string {
+	add = append([]string(nil), add...)
+	sort.Strings(add)
+	out := make([]string, 0, len(sorted)+len(add))
+	i, j := 0, 0
+	for i < len(sorted) || j < len(add) {
+		switch {
+		case j == len(add) || i < len(sorted) && sorted[i] < add[j]:
+			out = append(out, sorted[i])
+			i++
+		case i < len(sorted) && sorted[i] == add[j]:
+			j++
+		default:
+			out = append(out, add[j])
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Warm-up ───────────────────────────────────────────────────────────────────
+// Right after a load the first question paid for work every later one reuses:
+// sorting the term list for prefix lookups and touching postings, frequency
+// and excerpt memory for the first time. WarmUp does that up front so the
+// first user question is as fast as the tenth.
+
+// warmUpQueries cover the common shapes of a question: a type, a member,
+// a callback and a code request.
+var warmUpQueries = []string{
+	"Rigidbody AddForce",
+	"GetComponent",
+	"OnTriggerEnter collider",
+	"Instantiate prefab",
+	"coroutine WaitForSeconds example",
+}
+
+// WarmUp builds the sorted term list and scores a few representative
+// queries, bypassing the result cache so real questions aren't served stale
+// hits. Returns how long it took. Safe to call while searches are running.
+func (e *Engine) WarmUp() time.Duration {
+	start := time.Now()
+	v := e.cur.Load()
+	v.sortedTerms()
+	rk := e.Ranking()
+	for _, q := range warmUpQueries {
+		v.score(q, 5, rk, false, nil)
+		v.score(q, 5, rk, true, nil)
+	}
+	return time.Since(start)
+}
+
+// prefixTerms returns every indexed term starting with prefix: a binary
+// search over the sorted terms once they're built, a full scan before that.
+func (v *view) prefixTerms(prefix string) []string {
+	var out []string
+	if t := v.terms.Load(); t != nil {
+		terms := *t
+		for i := sort.SearchStrings(terms, prefix); i < len(terms) && strings.HasPrefix(terms[i], prefix); i++ {
+			out = append(out, terms[i])
+		}
+		return out
+	}
+	for tok := range v.index {
+		if strings.HasPrefix(tok, prefix) {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
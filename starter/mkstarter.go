@@ -0,0 +1,41 @@
+//go:build ignore
+
+// mkstarter compresses pages.json into starter_index.json.gz, the file
+// embedded into the binary. Edit pages.json, then run `go generate ./starter`.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"log"
+	"os"
+)
+
+func main() {
+	data, err := os.ReadFile("pages.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Validate and strip the indentation before compressing
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		log.Fatalf("pages.json: %v", err)
+	}
+	compact, _ := json.Marshal(v)
+
+	out, err := os.Create("starter_index.json.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+	zw, _ := gzip.NewWriterLevel(out, gzip.BestCompression)
+	if _, err := zw.Write(compact); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("starter_index.json.gz: compressed %d bytes of JSON", len(compact))
+}
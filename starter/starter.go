@@ -0,0 +1,58 @@
+// Package starter ships a small, curated slice of the Unity docs inside the
+// binary so a fresh install can answer common questions before the offline
+// docs are indexed or the core pages are fetched.
+package starter
+
+//go:generate go run mkstarter.go
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/json"
+	"io"
+
+	"unitymind/search"
+)
+
+// index is pages.json, gzipped by mkstarter.go.
+//
+//go:embed starter_index.json.gz
+var index []byte
+
+// Docs returns the bundled pages, tagged with search.StarterSource.
+func Docs() ([]search.Doc, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(index))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Docs []search.Doc `json:"docs"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for i := range f.Docs {
+		f.Docs[i].Source = search.StarterSource
+	}
+	return f.Docs, nil
+}
+
+// Load adds the bundled pages to e if it holds no docs yet.
+// Returns how many were added.
+func Load(e *search.Engine) (int, error) {
+	if e.DocCount() > 0 {
+		return 0, nil
+	}
+	docs, err := Docs()
+	if err != nil {
+		return 0, err
+	}
+	e.AddDocs(docs)
+	return len(docs), nil
+}
@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"unitymind/brain"
+)
+
+// ── Server-sent events ────────────────────────────────────────────────────────
+// The web UI (and anything else that reads /api/chat with "stream": true)
+// gets the answer as server-sent events: "answer" events, each with the
+// whole answer so far in {"answer": "..."}, then one "done" event with the
+// full chat response.
+
+// EventSink writes the answer to an HTTP response as "answer" events.
+type EventSink struct {
+	w      http.ResponseWriter
+	format string
+	mu     sync.Mutex
+}
+
+// NewEventSink starts an event stream on w, writing answers in format (see
+// brain.Render).
+func NewEventSink(w http.ResponseWriter, format string) *EventSink {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	return &EventSink{w: w, format: format}
+}
+
+// Update sends the answer so far.
+func (s *EventSink) Update(ctx context.Context, text string) error {
+	text, err := brain.Render(text, s.format)
+	if err != nil {
+		return err
+	}
+	return s.Event("answer", map[string]string{"answer": text})
+}
+
+// Finish sends nothing: the answer comes with the "done" event.
+func (s *EventSink) Finish(ctx context.Context, text string) error { return nil }
+
+// Pace is 10 updates a second: a browser redraws no faster.
+func (s *EventSink) Pace() Pace { return Pace{Every: 100 * time.Millisecond} }
+
+// Event sends v as JSON in an event named name, flushed at once.
+func (s *EventSink) Event(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"unitymind/brain"
+)
+
+// ── Slack and Discord ─────────────────────────────────────────────────────────
+// A bot bridging Slack or Discord to /api/chat passes the URL of its reply
+// and UnityMind edits that message itself as the answer is written: a Slack
+// slash command's response_url, or a Discord webhook message (an
+// interaction's ".../webhooks/<app id>/<token>/messages/@original"). Only
+// those hosts are accepted, so the chat API can't be made to send requests
+// anywhere else.
+
+// hookTimeout bounds one edit of a Slack or Discord message.
+const hookTimeout = 10 * time.Second
+
+var hookClient = &http.Client{Timeout: hookTimeout}
+
+// discordMaxChars is the most a Discord message may hold.
+const discordMaxChars = 2000
+
+// SlackSink writes the answer into the message of a Slack response_url.
+type SlackSink struct{ url string }
+
+// NewSlackSink returns a sink for a Slack response_url.
+func NewSlackSink(responseURL string) (*SlackSink, error) {
+	if err := checkHook(responseURL, "hooks.slack.com"); err != nil {
+		return nil, err
+	}
+	return &SlackSink{url: responseURL}, nil
+}
+
+// Update shows the answer so far.
+func (s *SlackSink) Update(ctx context.Context, text string) error { return s.post(ctx, text) }
+
+// Finish shows the whole answer.
+func (s *SlackSink) Finish(ctx context.Context, text string) error { return s.post(ctx, text) }
+
+// Pace keeps within Slack's five uses of a response_url, the last one for
+// Finish.
+func (s *SlackSink) Pace() Pace { return Pace{Every: 3 * time.Second, Max: 4} }
+
+func (s *SlackSink) post(ctx context.Context, text string) error {
+	text, err := brain.Render(text, "slack")
+	if err != nil {
+		return err
+	}
+	return sendHook(ctx, http.MethodPost, s.url, map[string]interface{}{
+		"text":             text,
+		"response_type":    "in_channel",
+		"replace_original": true,
+	})
+}
+
+// DiscordSink writes the answer into a Discord webhook message.
+type DiscordSink struct{ url string }
+
+// NewDiscordSink returns a sink for the URL of a Discord webhook message.
+func NewDiscordSink(messageURL string) (*DiscordSink, error) {
+	if err := checkHook(messageURL, "discord.com", "discordapp.com"); err != nil {
+		return nil, err
+	}
+	if !strings.Contains(messageURL, "/messages/") {
+		return nil, fmt.Errorf("not a Discord webhook message: %s", messageURL)
+	}
+	return &DiscordSink{url: messageURL}, nil
+}
+
+// Update shows the answer so far.
+func (s *DiscordSink) Update(ctx context.Context, text string) error { return s.edit(ctx, text) }
+
+// Finish shows the whole answer.
+func (s *DiscordSink) Finish(ctx context.Context, text string) error { return s.edit(ctx, text) }
+
+// Pace keeps within Discord's 5 edits per 5 seconds.
+func (s *DiscordSink) Pace() Pace { return Pace{Every: 1200 * time.Millisecond} }
+
+func (s *DiscordSink) edit(ctx context.Context, text string) error {
+	// Discord renders Markdown itself
+	return sendHook(ctx, http.MethodPatch, s.url, map[string]string{"content": brain.Truncate(text, discordMaxChars, false)})
+}
+
+// checkHook accepts an https URL on one of hosts.
+func checkHook(raw string, hosts ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" {
+		return fmt.Errorf("not an https URL: %s", raw)
+	}
+	for _, h := range hosts {
+		if strings.EqualFold(u.Hostname(), h) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not one of %s", u.Hostname(), strings.Join(hosts, ", "))
+}
+
+func sendHook(ctx context.Context, method, u string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, req.URL.Host, resp.Status)
+	}
+	return nil
+}
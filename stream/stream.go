@@ -0,0 +1,81 @@
+// Package stream relays an answer to the front-ends while it is being
+// written. Every front-end shows it the same way: one message, edited as
+// the answer grows and replaced by the whole of it at the end. A Sink is
+// that message (the web UI's event stream, a Slack response_url, a Discord
+// message); a Relay feeds it the answer so far as often as it may be
+// edited.
+package stream
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink is a message being written on a front-end.
+type Sink interface {
+	// Update replaces the message with the answer so far (Markdown).
+	Update(ctx context.Context, text string) error
+	// Finish replaces the message with the whole answer (Markdown).
+	Finish(ctx context.Context, text string) error
+	// Pace is how often the message may be edited.
+	Pace() Pace
+}
+
+// Pace limits the updates a sink gets before Finish.
+type Pace struct {
+	Every time.Duration // least time between two updates
+	Max   int           // most updates, 0 = no limit
+}
+
+// Relay feeds an answer being written to a sink at the sink's pace. An
+// update is sent in the background, so a slow front-end never holds up
+// reading the answer; pieces written meanwhile go out with the next one.
+// After an update fails no more are tried, but Finish still is.
+type Relay struct {
+	ctx  context.Context
+	sink Sink
+
+	mu     sync.Mutex
+	text   strings.Builder
+	last   time.Time
+	sent   int
+	busy   bool
+	failed bool
+	wg     sync.WaitGroup
+}
+
+// NewRelay returns a relay to sink; ctx bounds every update and Finish.
+func NewRelay(ctx context.Context, sink Sink) *Relay {
+	return &Relay{ctx: ctx, sink: sink}
+}
+
+// Write adds the next piece of the answer.
+func (r *Relay) Write(piece string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.text.WriteString(piece)
+	p := r.sink.Pace()
+	if r.busy || r.failed || time.Since(r.last) < p.Every || (p.Max > 0 && r.sent >= p.Max) {
+		return
+	}
+	r.busy, r.last = true, time.Now()
+	r.sent++
+	text := r.text.String()
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := r.sink.Update(r.ctx, text)
+		r.mu.Lock()
+		r.busy = false
+		r.failed = r.failed || err != nil
+		r.mu.Unlock()
+	}()
+}
+
+// Finish waits for an update still being sent, then shows the whole answer.
+func (r *Relay) Finish(answer string) error {
+	r.wg.Wait()
+	return r.sink.Finish(r.ctx, answer)
+}